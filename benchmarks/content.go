@@ -0,0 +1,182 @@
+package benchmarks
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/rancher/fleet/benchmarks/record"
+	"github.com/rancher/fleet/internal/content"
+	"github.com/rancher/fleet/internal/manifest"
+	"github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	gm "github.com/onsi/gomega/gmeasure"
+)
+
+// This experiment measures the volume of content resources written, when a
+// single line in a large bundle changes. Each file is stored once as a blob,
+// so an update only writes the changed blob and a small content resource
+// referencing the blobs. The agents of the benchmark clusters read blobs, so
+// the whole manifest is not stored in the content resource. For comparison,
+// the size of the content resources which would contain the whole gzipped
+// manifest, as written for agents which do not read blobs, is recorded as
+// well.
+//
+// update-1-line-500-file-bundle
+var _ = Context("Benchmarks Content", func() {
+	const (
+		files   = 500
+		updates = 10
+	)
+
+	var (
+		bundle *v1alpha1.Bundle
+	)
+
+	BeforeEach(func() {
+		name = "update-1-line-500-file-bundle"
+		info = fmt.Sprintf("updating one line of a bundle with %d files %d times", files, updates)
+
+		resources := make([]v1alpha1.BundleResource, files)
+		for i := range resources {
+			resources[i] = v1alpha1.BundleResource{
+				Name:    fmt.Sprintf("configmap-%d.yaml", i),
+				Content: configMap(i, 0),
+			}
+		}
+		bundle = &v1alpha1.Bundle{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: workspace,
+				Name:      name,
+				Labels:    map[string]string{GroupLabel: name},
+			},
+			Spec: v1alpha1.BundleSpec{
+				// Only the content resources are of interest, not
+				// the deployment.
+				Paused:    true,
+				Resources: resources,
+				Targets: []v1alpha1.BundleTarget{{
+					ClusterSelector: &metav1.LabelSelector{MatchLabels: map[string]string{BenchmarkLabel: "true"}},
+				}},
+			},
+		}
+	})
+
+	Describe("Updating one line of a bundle with 500 files", Label("update-1-line-500-file-bundle"), func() {
+		It("writes only the changed file", func() {
+			DeferCleanup(func() {
+				_ = k8sClient.Delete(ctx, bundle)
+			})
+
+			Expect(k8sClient.Create(ctx, bundle)).To(Succeed())
+			waitForContent(bundle)
+
+			var written, wholeManifest int
+			experiment.MeasureDuration("TotalDuration", func() {
+				record.MemoryUsage(experiment, "MemDuring")
+
+				for u := 1; u <= updates; u++ {
+					before := contentNames()
+
+					Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(bundle), bundle)).To(Succeed())
+					i := u % files
+					bundle.Spec.Resources[i].Content = configMap(i, u)
+					Expect(k8sClient.Update(ctx, bundle)).To(Succeed())
+					waitForContent(bundle)
+
+					written += writtenSince(before)
+					wholeManifest += gzippedManifestSize(bundle)
+				}
+			}, gm.Style("{{bold}}"))
+
+			experiment.RecordValue("ContentBytesWritten", float64(written)/1024, gm.Precision(0), gm.Units("KB"))
+			experiment.RecordValue("WholeManifestBytes", float64(wholeManifest)/1024, gm.Precision(0), gm.Units("KB"))
+			experiment.RecordValue("WriteVolumeReduction", 100*(1-float64(written)/float64(wholeManifest)), gm.Precision(1), gm.Units("%"))
+		})
+	})
+})
+
+// configMap returns a config map manifest of about 2KB, which differs in one
+// line per revision.
+func configMap(i, revision int) string {
+	return fmt.Sprintf(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: benchmark-content-%d
+data:
+  revision: "%d"
+  padding: |
+%s`, i, revision, strings.Repeat(fmt.Sprintf("    line of configuration for config map %d\n", i), 40))
+}
+
+// waitForContent waits until the content resource of the bundle's current
+// resources exists and checks it references blobs only.
+func waitForContent(bundle *v1alpha1.Bundle) {
+	GinkgoHelper()
+
+	id, err := manifest.New(bundle.Spec.Resources).ID()
+	Expect(err).ToNot(HaveOccurred())
+	c := &v1alpha1.Content{}
+	Eventually(func(g Gomega) {
+		g.Expect(k8sClient.Get(ctx, client.ObjectKey{Name: id}, c)).To(Succeed())
+	}).WithTimeout(ShortTimeout).WithPolling(PollingInterval).Should(Succeed())
+	Expect(c.Content).To(BeEmpty(), "expected only blobs to be written, do all benchmark agents read blobs?")
+}
+
+// contentNames returns the names of all content resources.
+func contentNames() map[string]bool {
+	GinkgoHelper()
+
+	list := &v1alpha1.ContentList{}
+	Expect(k8sClient.List(ctx, list)).To(Succeed())
+	names := make(map[string]bool, len(list.Items))
+	for _, c := range list.Items {
+		names[c.Name] = true
+	}
+	return names
+}
+
+// writtenSince returns the serialized size of the content resources, which
+// were created since the names in before were listed.
+func writtenSince(before map[string]bool) int {
+	GinkgoHelper()
+
+	list := &v1alpha1.ContentList{}
+	Expect(k8sClient.List(ctx, list)).To(Succeed())
+	n := 0
+	for _, c := range list.Items {
+		if before[c.Name] {
+			continue
+		}
+		data, err := json.Marshal(c)
+		Expect(err).ToNot(HaveOccurred())
+		n += len(data)
+	}
+	return n
+}
+
+// gzippedManifestSize returns the size of a content resource, which contains
+// the whole gzipped manifest of the bundle.
+func gzippedManifestSize(bundle *v1alpha1.Bundle) int {
+	GinkgoHelper()
+
+	m := manifest.New(bundle.Spec.Resources)
+	data, err := m.Content()
+	Expect(err).ToNot(HaveOccurred())
+	compressed, err := content.Gzip(data)
+	Expect(err).ToNot(HaveOccurred())
+	id, err := m.ID()
+	Expect(err).ToNot(HaveOccurred())
+
+	c, err := json.Marshal(&v1alpha1.Content{
+		ObjectMeta: metav1.ObjectMeta{Name: id},
+		Content:    compressed,
+	})
+	Expect(err).ToNot(HaveOccurred())
+	return len(c)
+}
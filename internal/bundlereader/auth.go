@@ -86,6 +86,14 @@ func ReadHelmAuthFromSecret(ctx context.Context, c client.Reader, req types.Name
 		return Auth{}, fmt.Errorf("%s is set in the secret, but %s isn't", corev1.BasicAuthPasswordKey, corev1.BasicAuthUsernameKey)
 	}
 
+	// SSH keys are used for charts in git repositories.
+	if key, ok := secret.Data[corev1.SSHAuthPrivateKey]; ok {
+		auth.SSHPrivateKey = key
+	}
+	if knownHosts, ok := secret.Data["known_hosts"]; ok {
+		auth.SSHKnownHosts = knownHosts
+	}
+
 	caBundle, ok := secret.Data["cacerts"]
 	if ok {
 		auth.CABundle = caBundle
@@ -0,0 +1,355 @@
+package bundlereader
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// maxExtendsDepth limits how deep fleet.yaml files can extend each other.
+const maxExtendsDepth = 10
+
+// appendedLists are the lists in fleet.yaml, which are appended to the lists
+// of the extended files, instead of replacing them. The value is true for
+// lists whose entries are identified by name: an entry replaces the
+// inherited entry with the same name.
+var appendedLists = map[string]bool{
+	"targetCustomizations": true,
+	"dependsOn":            true,
+	"imageScans":           true,
+	"outputs":              true,
+	"sources":              true,
+	"downstreamResources":  false,
+	"diff.comparePatches":  false,
+	"helm.valuesFiles":     false,
+	"helm.valuesFrom":      false,
+}
+
+// resolveExtends returns the fleet.yaml data merged with the files it
+// extends. dir is the directory of the fleet.yaml and file its path, if it
+// was read from a file. The data is returned unchanged, if it doesn't extend
+// any files.
+func resolveExtends(dir, file string, data []byte) ([]byte, error) {
+	doc, err := parseFleetYAMLMap(data)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := doc["extends"]; !ok {
+		return data, nil
+	}
+
+	root, err := repositoryRoot(dir)
+	if err != nil {
+		return nil, err
+	}
+	// paths of extended files are rebased onto dir, which must be resolved
+	// like the extended files' paths
+	if dir, err = filepath.Abs(dir); err != nil {
+		return nil, err
+	}
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = resolved
+	}
+	r := &extendsResolver{root: root}
+	if file != "" {
+		if file, err = filepath.Abs(file); err != nil {
+			return nil, err
+		}
+		if resolved, err := filepath.EvalSymlinks(file); err == nil {
+			file = resolved
+		}
+		r.stack = []string{file}
+	}
+
+	merged, err := r.resolve(dir, doc)
+	if err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(merged)
+}
+
+type extendsResolver struct {
+	// root is the repository root, extended files must be inside of it.
+	root string
+	// stack contains the files currently being resolved, to detect cycles.
+	stack []string
+}
+
+// resolve merges the files extended by doc, then doc on top of them.
+func (r *extendsResolver) resolve(dir string, doc map[string]any) (map[string]any, error) {
+	extends, err := extendsOf(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := map[string]any{}
+	for _, e := range extends {
+		path, err := r.path(dir, e)
+		if err != nil {
+			return nil, err
+		}
+		if slices.Contains(r.stack, path) {
+			return nil, fmt.Errorf("extends cycle: %s", strings.Join(append(r.stack, path), " -> "))
+		}
+		if len(r.stack) >= maxExtendsDepth {
+			return nil, fmt.Errorf("extends %q: more than %d levels of extended files", e, maxExtendsDepth)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("extends %q: %w", e, err)
+		}
+		base, err := parseFleetYAMLMap(data)
+		if err != nil {
+			return nil, fmt.Errorf("extends %q: %w", e, err)
+		}
+
+		r.stack = append(r.stack, path)
+		base, err = r.resolve(filepath.Dir(path), base)
+		r.stack = r.stack[:len(r.stack)-1]
+		if err != nil {
+			return nil, err
+		}
+		// only the extends of the top-level file are kept
+		delete(base, "extends")
+		if err := rebasePaths(base, filepath.Dir(path), dir); err != nil {
+			return nil, fmt.Errorf("extends %q: %w", e, err)
+		}
+
+		merged = mergeFleetYAML(merged, base, "")
+	}
+
+	return mergeFleetYAML(merged, doc, ""), nil
+}
+
+// path returns the resolved path of the extended file, which must be inside
+// the repository.
+func (r *extendsResolver) path(dir, extends string) (string, error) {
+	if extends == "" || filepath.IsAbs(extends) {
+		return "", fmt.Errorf("extends %q: must be a relative path", extends)
+	}
+	path, err := filepath.Abs(filepath.Join(dir, extends))
+	if err != nil {
+		return "", err
+	}
+	path, err = filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", fmt.Errorf("extends %q: %w", extends, err)
+	}
+	if !isInside(r.root, path) {
+		return "", fmt.Errorf("extends %q: path is outside of the repository", extends)
+	}
+	return path, nil
+}
+
+// repositoryRoot returns the closest parent of dir containing a .git
+// directory. Without one, the working directory is used if it contains dir,
+// dir itself otherwise.
+func repositoryRoot(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	if dir, err = filepath.EvalSymlinks(dir); err != nil {
+		return "", err
+	}
+
+	for d := dir; ; d = filepath.Dir(d) {
+		if _, err := os.Stat(filepath.Join(d, ".git")); err == nil {
+			return d, nil
+		}
+		if filepath.Dir(d) == d {
+			break
+		}
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	if wd, err = filepath.EvalSymlinks(wd); err == nil && isInside(wd, dir) {
+		return wd, nil
+	}
+	return dir, nil
+}
+
+func isInside(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(os.PathSeparator))
+}
+
+// rebasePaths rewrites the relative paths in doc, which are relative to the
+// directory from, so they are relative to the directory to. These are the
+// helm.valuesFiles, a local helm.chart and kustomize.dir, of the bundle and
+// its targetCustomizations. Overlays are names of directories in the
+// bundle's "overlays/" directory, not paths, and are kept.
+func rebasePaths(doc map[string]any, from, to string) error {
+	if from == to {
+		return nil
+	}
+	options := []any{doc}
+	if targets, ok := doc["targetCustomizations"].([]any); ok {
+		options = append(options, targets...)
+	}
+
+	for _, o := range options {
+		m, ok := o.(map[string]any)
+		if !ok {
+			continue
+		}
+		if helm, ok := m["helm"].(map[string]any); ok {
+			if files, ok := helm["valuesFiles"].([]any); ok {
+				for i, f := range files {
+					if files[i], ok = rebasePath(f, from, to); !ok {
+						return errors.New("helm.valuesFiles must be a list of paths")
+					}
+				}
+			}
+			if chart, ok := helm["chart"].(string); ok && isLocalChart(chart, helm["repo"]) {
+				helm["chart"], _ = rebasePath(chart, from, to)
+			}
+		}
+		if kustomize, ok := m["kustomize"].(map[string]any); ok {
+			if d, ok := kustomize["dir"].(string); ok {
+				kustomize["dir"], _ = rebasePath(d, from, to)
+			}
+		}
+	}
+	return nil
+}
+
+// rebasePath returns the path relative to the directory to, if p is a
+// relative path to a file in the directory from. Absolute paths and paths
+// of the bundle's sources are returned unchanged. False is returned if p
+// is not a string.
+func rebasePath(p any, from, to string) (any, bool) {
+	s, ok := p.(string)
+	if !ok {
+		return p, false
+	}
+	if s == "" || filepath.IsAbs(s) || isSourcePath(s) {
+		return s, true
+	}
+	rel, err := filepath.Rel(to, filepath.Join(from, s))
+	if err != nil {
+		return s, true
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// isLocalChart returns true if chart is a path, not a chart name in a
+// repository or a URL.
+func isLocalChart(chart string, repo any) bool {
+	if r, _ := repo.(string); r != "" {
+		return false
+	}
+	return !strings.Contains(chart, "://") && !strings.Contains(chart, "::")
+}
+
+func parseFleetYAMLMap(data []byte) (map[string]any, error) {
+	doc := map[string]any{}
+	// numbers are kept as they are, so they don't turn into floats
+	if err := yaml.Unmarshal(data, &doc, func(d *json.Decoder) *json.Decoder {
+		d.UseNumber()
+		return d
+	}); err != nil {
+		return nil, fmt.Errorf("reading fleet.yaml: %w", err)
+	}
+	if doc == nil {
+		doc = map[string]any{}
+	}
+	return doc, nil
+}
+
+func extendsOf(doc map[string]any) ([]string, error) {
+	v, ok := doc["extends"]
+	if !ok || v == nil {
+		return nil, nil
+	}
+	list, ok := v.([]any)
+	if !ok {
+		return nil, errors.New("extends must be a list of paths")
+	}
+	extends := make([]string, 0, len(list))
+	for _, e := range list {
+		s, ok := e.(string)
+		if !ok {
+			return nil, errors.New("extends must be a list of paths")
+		}
+		extends = append(extends, s)
+	}
+	return extends, nil
+}
+
+// mergeFleetYAML merges override into base and returns the result. Maps are
+// merged recursively, null values remove the key and lists are replaced,
+// except for appendedLists. path is the dotted path of base in the document.
+func mergeFleetYAML(base, override map[string]any, path string) map[string]any {
+	result := make(map[string]any, len(base)+len(override))
+	for k, v := range base {
+		result[k] = v
+	}
+
+	for k, v := range override {
+		p := k
+		if path != "" {
+			p = path + "." + k
+		}
+
+		switch ov := v.(type) {
+		case nil:
+			delete(result, k)
+		case map[string]any:
+			if bm, ok := result[k].(map[string]any); ok {
+				result[k] = mergeFleetYAML(bm, ov, p)
+			} else {
+				result[k] = ov
+			}
+		case []any:
+			byName, appended := appendedLists[p]
+			if bl, ok := result[k].([]any); ok && appended {
+				result[k] = appendList(bl, ov, byName)
+			} else {
+				result[k] = ov
+			}
+		default:
+			result[k] = v
+		}
+	}
+
+	return result
+}
+
+// appendList appends the entries of custom to base. If byName is true,
+// entries with a name replace the base entry with the same name.
+func appendList(base, custom []any, byName bool) []any {
+	result := slices.Clone(base)
+	for _, c := range custom {
+		name := entryName(c)
+		idx := -1
+		if byName && name != "" {
+			idx = slices.IndexFunc(result, func(b any) bool { return entryName(b) == name })
+		}
+		if idx >= 0 {
+			result[idx] = c
+		} else {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+func entryName(entry any) string {
+	m, ok := entry.(map[string]any)
+	if !ok {
+		return ""
+	}
+	name, _ := m["name"].(string)
+	return name
+}
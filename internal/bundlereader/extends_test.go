@@ -0,0 +1,228 @@
+package bundlereader
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+}
+
+func TestNewBundle_Extends(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".git/HEAD": "ref: refs/heads/main\n",
+		"base/common.yaml": `
+namespace: base
+helm:
+  timeoutSeconds: 1000000
+  values:
+    replicas: 1
+    image:
+      tag: v1
+  valuesFiles:
+  - base-values.yaml
+rolloutStrategy:
+  maxUnavailable: 10%
+diff:
+  comparePatches:
+  - apiVersion: v1
+    kind: ConfigMap
+    name: cm
+    jsonPointers: ["/data"]
+targetCustomizations:
+- name: prod
+  clusterSelector:
+    matchLabels:
+      env: prod
+  helm:
+    values:
+      replicas: 3
+    valuesFiles:
+    - prod-values.yaml
+    - .sources/config/values.yaml
+  kustomize:
+    dir: kustomize/prod
+- name: dev
+  clusterSelector:
+    matchLabels:
+      env: dev
+`,
+		"base/prod-values.yaml": "tier: prod\n",
+		"base/prod.yaml": `
+extends: [common.yaml]
+labels:
+  tier: prod
+`,
+		"apps/a/fleet.yaml": `
+extends: [../../base/prod.yaml]
+helm:
+  values:
+    image:
+      tag: v2
+  valuesFiles:
+  - values.yaml
+rolloutStrategy: null
+targetCustomizations:
+- name: dev
+  clusterSelector:
+    matchLabels:
+      env: test
+- name: edge
+  clusterName: edge-1
+`,
+		"apps/a/cm.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cm\n",
+	})
+
+	bundle, _, err := NewBundle(context.Background(), "a", filepath.Join(root, "apps/a"), "", nil)
+	require.NoError(t, err)
+
+	spec := bundle.Spec
+	assert.Equal(t, "base", spec.TargetNamespace)
+	assert.Equal(t, map[string]string{"tier": "prod"}, bundle.Labels)
+	assert.Equal(t, 1000000, spec.Helm.TimeoutSeconds)
+	assert.Equal(t, map[string]any{"replicas": float64(1), "image": map[string]any{"tag": "v2"}}, spec.Helm.Values.Data)
+	// paths of extended files are relative to them
+	assert.Equal(t, []string{"../../base/base-values.yaml", "values.yaml"}, spec.Helm.ValuesFiles)
+	assert.Nil(t, spec.RolloutStrategy)
+	require.Len(t, spec.Diff.ComparePatches, 1)
+
+	var targets []string
+	for _, target := range spec.Targets {
+		targets = append(targets, target.Name)
+	}
+	assert.Equal(t, []string{"prod", "dev", "edge"}, targets)
+	assert.Equal(t, map[string]string{"env": "test"}, spec.Targets[1].ClusterSelector.MatchLabels)
+	assert.Equal(t, map[string]any{"replicas": float64(3), "tier": "prod"}, spec.Targets[0].Helm.Values.Data)
+	assert.Equal(t, []string{"../../base/prod-values.yaml", ".sources/config/values.yaml"}, spec.Targets[0].Helm.ValuesFiles)
+	assert.Equal(t, "../../base/kustomize/prod", spec.Targets[0].Kustomize.Dir)
+}
+
+func TestRebasePaths(t *testing.T) {
+	doc := map[string]any{
+		"helm": map[string]any{
+			"chart":       "chart",
+			"valuesFiles": []any{"values.yaml", "/abs/values.yaml"},
+		},
+		"targetCustomizations": []any{
+			map[string]any{"helm": map[string]any{"chart": "oci://registry/chart"}},
+			map[string]any{"helm": map[string]any{"chart": "git::https://example.com/charts"}},
+			map[string]any{"helm": map[string]any{"repo": "https://charts.example.com", "chart": "app"}},
+		},
+	}
+	require.NoError(t, rebasePaths(doc, "/repo/base", "/repo/apps/a"))
+	assert.Equal(t, map[string]any{
+		"helm": map[string]any{
+			"chart":       "../../base/chart",
+			"valuesFiles": []any{"../../base/values.yaml", "/abs/values.yaml"},
+		},
+		"targetCustomizations": []any{
+			map[string]any{"helm": map[string]any{"chart": "oci://registry/chart"}},
+			map[string]any{"helm": map[string]any{"chart": "git::https://example.com/charts"}},
+			map[string]any{"helm": map[string]any{"repo": "https://charts.example.com", "chart": "app"}},
+		},
+	}, doc)
+
+	err := rebasePaths(map[string]any{"helm": map[string]any{"valuesFiles": []any{1}}}, "/a", "/b")
+	assert.EqualError(t, err, "helm.valuesFiles must be a list of paths")
+}
+
+func TestNewBundle_ExtendsErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		err   string
+	}{
+		{
+			name: "cycle",
+			files: map[string]string{
+				"a.yaml":            "extends: [b.yaml]\n",
+				"b.yaml":            "extends: [a.yaml]\n",
+				"bundle/fleet.yaml": "extends: [../a.yaml]\n",
+			},
+			err: "extends cycle: ",
+		},
+		{
+			name: "self",
+			files: map[string]string{
+				"bundle/fleet.yaml": "extends: [fleet.yaml]\n",
+			},
+			err: "extends cycle: ",
+		},
+		{
+			name: "outside of repository",
+			files: map[string]string{
+				"bundle/fleet.yaml": "extends: [../../outside.yaml]\n",
+			},
+			err: `extends "../../outside.yaml": path is outside of the repository`,
+		},
+		{
+			name: "absolute path",
+			files: map[string]string{
+				"bundle/fleet.yaml": "extends: [/etc/fleet.yaml]\n",
+			},
+			err: `extends "/etc/fleet.yaml": must be a relative path`,
+		},
+		{
+			name: "missing file",
+			files: map[string]string{
+				"bundle/fleet.yaml": "extends: [missing.yaml]\n",
+			},
+			err: `extends "missing.yaml": `,
+		},
+		{
+			name: "not a list",
+			files: map[string]string{
+				"bundle/fleet.yaml": "extends: base.yaml\n",
+			},
+			err: "extends must be a list of paths",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parent := t.TempDir()
+			writeFiles(t, parent, map[string]string{"outside.yaml": "namespace: outside\n"})
+			root := filepath.Join(parent, "repo")
+			tt.files[".git/HEAD"] = "ref: refs/heads/main\n"
+			tt.files["bundle/cm.yaml"] = "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cm\n"
+			writeFiles(t, root, tt.files)
+
+			_, _, err := NewBundle(context.Background(), "test", filepath.Join(root, "bundle"), "", nil)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
+
+func TestMergeFleetYAML(t *testing.T) {
+	base := map[string]any{
+		"helm":                map[string]any{"repo": "https://charts.example.com", "chart": "app"},
+		"dependsOn":           []any{map[string]any{"name": "db"}},
+		"overrideTargets":     []any{map[string]any{"name": "a"}},
+		"downstreamResources": []any{map[string]any{"kind": "Secret", "name": "s"}},
+	}
+	override := map[string]any{
+		"helm":                map[string]any{"chart": "other"},
+		"dependsOn":           []any{map[string]any{"name": "cache"}},
+		"overrideTargets":     []any{map[string]any{"name": "b"}},
+		"downstreamResources": []any{map[string]any{"kind": "ConfigMap", "name": "s"}},
+	}
+
+	got := mergeFleetYAML(base, override, "")
+	assert.Equal(t, map[string]any{"repo": "https://charts.example.com", "chart": "other"}, got["helm"])
+	assert.Equal(t, []any{map[string]any{"name": "db"}, map[string]any{"name": "cache"}}, got["dependsOn"])
+	assert.Equal(t, []any{map[string]any{"name": "b"}}, got["overrideTargets"])
+	assert.Equal(t, []any{map[string]any{"kind": "Secret", "name": "s"}, map[string]any{"kind": "ConfigMap", "name": "s"}}, got["downstreamResources"])
+}
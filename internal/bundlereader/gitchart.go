@@ -0,0 +1,146 @@
+package bundlereader
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	gogit "github.com/go-git/go-git/v5"
+	"sigs.k8s.io/yaml"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+)
+
+var commitRegexp = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// IsGitRepo returns true if the repo of a HelmOp refers to a git repository
+// instead of a Helm repository, e.g. "git::https://github.com/org/charts",
+// "https://github.com/org/charts.git?ref=main" or "git@github.com:org/charts".
+func IsGitRepo(repo string) bool {
+	if repo == "" {
+		return false
+	}
+	if scheme, _ := splitForcedScheme(repo); scheme == "git" || scheme == "ssh" {
+		return true
+	}
+	if u, err := url.Parse(repo); err == nil && (u.Scheme == "git" || u.Scheme == "ssh") {
+		return true
+	}
+	if _, ok := detectSCPSSH(repo); ok {
+		return true
+	}
+	base, _, _ := strings.Cut(repo, "?")
+	base, _ = splitSubdir(base)
+	return strings.HasSuffix(base, ".git")
+}
+
+// GitChartVersion clones the git repository of a HelmOp and returns the
+// version in the chart's Chart.yaml, with the commit as build metadata, e.g.
+// "1.2.0+<commit>". The chart is located at location.Chart in the
+// repository. If location.Version is set, the chart's version must satisfy
+// it.
+func GitChartVersion(ctx context.Context, location fleet.HelmOptions, auth Auth) (string, error) {
+	si, err := parseSource(gitSource(location.Repo), "")
+	if err != nil {
+		return "", err
+	}
+
+	temp, err := os.MkdirTemp("", "fleet-gitchart")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(temp)
+
+	if err := gitDownload(ctx, temp, si.rawURL, auth); err != nil {
+		return "", err
+	}
+
+	repo, err := gogit.PlainOpen(temp)
+	if err != nil {
+		return "", err
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return "", err
+	}
+
+	dir, err := safeJoinSubDir(temp, path.Join(si.subDir, location.Chart))
+	if err != nil {
+		return "", fmt.Errorf("invalid chart path %q: %w", location.Chart, err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, chartYAML))
+	if err != nil {
+		return "", fmt.Errorf("reading %s of chart %q: %w", chartYAML, location.Chart, err)
+	}
+	var metadata struct {
+		Version string `json:"version"`
+	}
+	if err := yaml.Unmarshal(data, &metadata); err != nil {
+		return "", fmt.Errorf("reading %s of chart %q: %w", chartYAML, location.Chart, err)
+	}
+
+	v, err := semver.NewVersion(metadata.Version)
+	if err != nil {
+		return "", fmt.Errorf("invalid version %q in %s of chart %q: %w", metadata.Version, chartYAML, location.Chart, err)
+	}
+	if location.Version != "" {
+		constraint, err := semver.NewConstraint(location.Version)
+		if err != nil {
+			return "", fmt.Errorf("invalid version constraint %q: %w", location.Version, err)
+		}
+		if !constraint.Check(v) {
+			return "", fmt.Errorf("version %s of chart %q does not satisfy constraint %q", metadata.Version, location.Chart, location.Version)
+		}
+	}
+
+	version, _, _ := strings.Cut(metadata.Version, "+")
+	return version + "+" + head.Hash().String(), nil
+}
+
+// gitChartSource returns the source to download the chart of a HelmOp from
+// its git repository. If the version contains a commit, as returned by
+// GitChartVersion, the commit replaces the ref of the repository.
+func gitChartSource(location fleet.HelmOptions) string {
+	_, src := splitForcedScheme(location.Repo)
+	src, subDir := splitSubdir(src)
+
+	base, query, _ := strings.Cut(src, "?")
+	if _, commit, _ := strings.Cut(location.Version, "+"); commitRegexp.MatchString(commit) {
+		// Keep the other query params unchanged, like extractQueryParams.
+		kept := []string{}
+		for pair := range strings.SplitSeq(query, "&") {
+			rawKey, _, _ := strings.Cut(pair, "=")
+			if key, _ := url.QueryUnescape(rawKey); pair != "" && key != "ref" {
+				kept = append(kept, pair)
+			}
+		}
+		query = strings.Join(append(kept, "ref="+url.QueryEscape(commit)), "&")
+	}
+
+	source := "git::" + base
+	if dir := path.Join(subDir, location.Chart); dir != "." && dir != "" {
+		source += "//" + dir
+	}
+	if query != "" {
+		source += "?" + query
+	}
+	return source
+}
+
+// gitSource forces the git scheme for repo, which is required for HTTPS
+// URLs.
+func gitSource(repo string) string {
+	if scheme, _ := splitForcedScheme(repo); scheme != "" {
+		return repo
+	}
+	if _, ok := detectSCPSSH(repo); ok {
+		return repo
+	}
+	return "git::" + repo
+}
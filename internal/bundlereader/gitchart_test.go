@@ -0,0 +1,108 @@
+package bundlereader
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+)
+
+func TestIsGitRepo(t *testing.T) {
+	tests := map[string]bool{
+		"":                                        false,
+		"https://charts.example.com":              false,
+		"oci://registry.example.com/charts":       false,
+		"https://example.com/charts/index.yaml":   false,
+		"git::https://github.com/org/charts":      true,
+		"https://github.com/org/charts.git":       true,
+		"https://github.com/org/charts.git?ref=a": true,
+		"https://github.com/org/charts.git//sub":  true,
+		"ssh://git@github.com/org/charts":         true,
+		"git@github.com:org/charts":               true,
+	}
+	for repo, want := range tests {
+		assert.Equal(t, want, IsGitRepo(repo), repo)
+	}
+}
+
+func TestGitChartSource(t *testing.T) {
+	commit := strings.Repeat("a", 40)
+	tests := []struct {
+		name     string
+		location fleet.HelmOptions
+		want     string
+	}{
+		{
+			name:     "no commit",
+			location: fleet.HelmOptions{Repo: "https://github.com/org/charts.git?ref=main", Chart: "charts/app"},
+			want:     "git::https://github.com/org/charts.git//charts/app?ref=main",
+		},
+		{
+			name:     "commit replaces ref",
+			location: fleet.HelmOptions{Repo: "git::https://github.com/org/charts?depth=5&ref=main", Chart: "app", Version: "1.2.0+" + commit},
+			want:     "git::https://github.com/org/charts//app?depth=5&ref=" + commit,
+		},
+		{
+			name:     "subdirectory in repo",
+			location: fleet.HelmOptions{Repo: "git::https://github.com/org/charts//charts", Chart: "app", Version: "1.2.0+" + commit},
+			want:     "git::https://github.com/org/charts//charts/app?ref=" + commit,
+		},
+		{
+			name:     "chart at repository root, build metadata without commit",
+			location: fleet.HelmOptions{Repo: "git@github.com:org/charts", Version: "1.2.0+build5"},
+			want:     "git::git@github.com:org/charts",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, gitChartSource(tt.location))
+		})
+	}
+}
+
+func TestGitChartVersion(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := gogit.PlainInit(dir, false)
+	require.NoError(t, err)
+	wt, err := repo.Worktree()
+	require.NoError(t, err)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "charts", "app"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "charts", "app", "Chart.yaml"), []byte("apiVersion: v2\nname: app\nversion: 1.2.0\n"), 0o600))
+	_, err = wt.Add("charts")
+	require.NoError(t, err)
+	commit, err := wt.Commit("add chart", &gogit.CommitOptions{
+		Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+	})
+	require.NoError(t, err)
+
+	location := fleet.HelmOptions{Repo: "git::" + dir, Chart: "charts/app"}
+	version, err := GitChartVersion(context.TODO(), location, Auth{})
+	require.NoError(t, err)
+	assert.Equal(t, "1.2.0+"+commit.String(), version)
+
+	location.Version = "~1.2"
+	_, err = GitChartVersion(context.TODO(), location, Auth{})
+	require.NoError(t, err)
+
+	location.Version = ">= 2.0.0"
+	_, err = GitChartVersion(context.TODO(), location, Auth{})
+	assert.ErrorContains(t, err, `version 1.2.0 of chart "charts/app" does not satisfy constraint ">= 2.0.0"`)
+
+	location = fleet.HelmOptions{Repo: "git::" + dir, Chart: "charts/missing"}
+	_, err = GitChartVersion(context.TODO(), location, Auth{})
+	assert.ErrorContains(t, err, `reading Chart.yaml of chart "charts/missing"`)
+
+	location = fleet.HelmOptions{Repo: "git::" + dir, Chart: "../outside"}
+	_, err = GitChartVersion(context.TODO(), location, Auth{})
+	assert.ErrorContains(t, err, "invalid chart path")
+}
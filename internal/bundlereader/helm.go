@@ -39,13 +39,16 @@ func GetManifestFromHelmChart(ctx context.Context, c client.Reader, bd *fleet.Bu
 		auth.CABundle = bd.Spec.HelmChartOptions.CABundle
 	}
 
-	chartURL, err := ChartURL(ctx, *helm, auth)
-	if err != nil {
+	var chartURL string
+	if IsGitRepo(helm.Repo) {
+		// the version contains the commit resolved by the controller
+		chartURL = gitChartSource(*helm)
+	} else if chartURL, err = ChartURL(ctx, *helm, auth); err != nil {
 		return nil, err
 	}
 
 	resources, err := loadDirectory(ctx,
-		loadOpts{},
+		loadOpts{disableDepsUpdate: helm.DisableDependencyUpdate},
 		directory{
 			prefix:  checksum(helm),
 			base:    temp,
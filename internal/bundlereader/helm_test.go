@@ -18,7 +18,10 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
 	corev1 "k8s.io/api/core/v1"
@@ -506,3 +509,54 @@ func TestGetManifestFromHelmChart(t *testing.T) {
 		}
 	}
 }
+
+func TestGetManifestFromHelmChart_Git(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := gogit.PlainInit(dir, false)
+	assert.NoError(t, err)
+	wt, err := repo.Worktree()
+	assert.NoError(t, err)
+
+	commitChart := func(version string) string {
+		t.Helper()
+		assert.NoError(t, os.MkdirAll(filepath.Join(dir, "charts", "app", "templates"), 0o755))
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "charts", "app", "Chart.yaml"), []byte("apiVersion: v2\nname: app\nversion: "+version+"\n"), 0o600))
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "charts", "app", "templates", "deployment.yaml"), []byte(deployment), 0o600))
+		_, err := wt.Add("charts")
+		assert.NoError(t, err)
+		hash, err := wt.Commit("chart "+version, &gogit.CommitOptions{
+			Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+		})
+		assert.NoError(t, err)
+		return hash.String()
+	}
+	first := commitChart("0.1.0")
+	commitChart("0.2.0")
+
+	bd := &fleet.BundleDeployment{
+		Spec: fleet.BundleDeploymentSpec{
+			Options: fleet.BundleDeploymentOptions{
+				Helm: &fleet.HelmOptions{
+					Repo:                    "git::" + dir,
+					Chart:                   "charts/app",
+					Version:                 "0.1.0+" + first,
+					DisableDependencyUpdate: true,
+				},
+			},
+			HelmChartOptions: &fleet.BundleHelmOptions{},
+		},
+	}
+
+	m, err := bundlereader.GetManifestFromHelmChart(context.TODO(), nil, bd)
+	assert.NoError(t, err)
+
+	found := map[string]string{}
+	for _, r := range m.Resources {
+		// Resources are named .chart/<checksum>/<file>.
+		parts := strings.SplitN(r.Name, "/", 3)
+		assert.Len(t, parts, 3)
+		found[parts[len(parts)-1]] = r.Content
+	}
+	assert.Equal(t, "apiVersion: v2\nname: app\nversion: 0.1.0\n", found["Chart.yaml"])
+	assert.Equal(t, deployment, found["templates/deployment.yaml"])
+}
@@ -49,14 +49,15 @@ func NewBundle(ctx context.Context, name, baseDir, file string, opts *Options) (
 	}
 
 	if file == "-" {
-		b, s, err := loadBundle(ctx, name, baseDir, os.Stdin, opts)
+		b, s, err := loadBundle(ctx, name, baseDir, "", os.Stdin, opts)
 		if err != nil {
 			return b, s, fmt.Errorf("failed to process bundle from STDIN: %w", err)
 		}
 	}
 
 	var (
-		in io.Reader
+		in       io.Reader
+		specFile string
 	)
 
 	if file == "" {
@@ -64,6 +65,7 @@ func NewBundle(ctx context.Context, name, baseDir, file string, opts *Options) (
 			return nil, nil, fmt.Errorf("failed to open existing fleet.yaml in %q: %w", baseDir, err)
 		} else if file != nil {
 			in = file
+			specFile = file.Name()
 			defer file.Close()
 		} else {
 			// Create a new buffer if opening both files resulted in "IsNotExist" errors.
@@ -76,9 +78,10 @@ func NewBundle(ctx context.Context, name, baseDir, file string, opts *Options) (
 		}
 		defer f.Close()
 		in = f
+		specFile = f.Name()
 	}
 
-	b, s, err := loadBundle(ctx, name, baseDir, in, opts)
+	b, s, err := loadBundle(ctx, name, baseDir, specFile, in, opts)
 	if err != nil {
 		return b, s, fmt.Errorf("failed to process bundle: %w", err)
 	}
@@ -108,8 +111,9 @@ func setupIOReader(baseDir string) (*os.File, error) {
 }
 
 // loadBundle creates a bundle and imagescan from a base directory name and a reader (which may represent data from a
-// directory structure or from standard input).
-func loadBundle(ctx context.Context, name, baseDir string, bundleSpecReader io.Reader, opts *Options) (*fleet.Bundle, []*fleet.ImageScan, error) {
+// directory structure or from standard input). specFile is the path of the fleet.yaml, if it was read from a file, and
+// is used to resolve the files it extends.
+func loadBundle(ctx context.Context, name, baseDir, specFile string, bundleSpecReader io.Reader, opts *Options) (*fleet.Bundle, []*fleet.ImageScan, error) {
 	if opts == nil {
 		opts = &Options{}
 	}
@@ -119,6 +123,15 @@ func loadBundle(ctx context.Context, name, baseDir string, bundleSpecReader io.R
 		return nil, nil, err
 	}
 
+	specDir := baseDir
+	if specFile != "" {
+		specDir = filepath.Dir(specFile)
+	}
+	data, err = resolveExtends(specDir, specFile, data)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	bundle, scans, err := bundleFromDir(ctx, name, baseDir, data, opts)
 	if err != nil {
 		return nil, nil, err
@@ -244,6 +257,7 @@ func bundleFromDir(ctx context.Context, name, baseDir string, bundleData []byte,
 				ClusterSelector:      target.ClusterSelector,
 				ClusterGroup:         target.ClusterGroup,
 				ClusterGroupSelector: target.ClusterGroupSelector,
+				ClusterExpression:    target.ClusterExpression,
 			})
 			bundle.Spec.TargetRestrictions = append(bundle.Spec.TargetRestrictions, fleet.BundleTargetRestriction(target))
 		}
@@ -5,6 +5,7 @@ import (
 	"crypto/sha256"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
 	"sort"
@@ -191,6 +192,10 @@ func generateValues(base string, chart *fleet.HelmOptions) (valuesMap *fleet.Gen
 		valuesMap = chart.Values
 	}
 	for _, value := range chart.ValuesFiles {
+		if isSourcePath(value) {
+			// read by the controller, from the bundle's sources
+			continue
+		}
 		valuesByte, err := os.ReadFile(base + "/" + value)
 		if err != nil {
 			return nil, fmt.Errorf("reading values file: %s/%s: %w", base, value, err)
@@ -206,6 +211,12 @@ func generateValues(base string, chart *fleet.HelmOptions) (valuesMap *fleet.Gen
 	return valuesMap, nil
 }
 
+// isSourcePath returns true if name refers to a file of the bundle's
+// sources.
+func isSourcePath(name string) bool {
+	return strings.HasPrefix(path.Clean(name), fleet.BundleSourcesDir+"/")
+}
+
 func mergeGenericMap(first, second *fleet.GenericMap) *fleet.GenericMap {
 	result := &fleet.GenericMap{Data: make(map[string]any)}
 	result.Data = data.MergeMaps(first.Data, second.Data)
@@ -218,6 +229,10 @@ func mergeGenericMap(first, second *fleet.GenericMap) *fleet.GenericMap {
 func addRemoteCharts(ctx context.Context, directories []directory, base string, charts []*fleet.HelmOptions, auth Auth, helmRepoURLRegex string) ([]directory, error) {
 	warnedOnce := false
 	for _, chart := range charts {
+		if isSourcePath(chart.Chart) && chart.Repo == "" {
+			// the chart is added from the bundle's sources by the controller
+			continue
+		}
 		if _, err := os.Stat(filepath.Join(base, chart.Chart)); os.IsNotExist(err) || chart.Repo != "" {
 			shouldAddAuthToRequest, err := shouldAddAuthToRequest(helmRepoURLRegex, chart.Repo, chart.Chart)
 			if err != nil {
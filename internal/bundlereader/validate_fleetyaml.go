@@ -2,14 +2,42 @@ package bundlereader
 
 import (
 	"fmt"
+	"path"
+	"path/filepath"
 	"sort"
 
+	"github.com/Masterminds/semver/v3"
+
 	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
 )
 
 // validateFleetYAML validates the semantic content of a parsed FleetYAML.
 // It returns an error if any field contains invalid values.
 func validateFleetYAML(fy *fleet.FleetYAML) error {
+	for i, e := range fy.Extends {
+		if e == "" || filepath.IsAbs(e) {
+			return fmt.Errorf("extends[%d]: %q must be a relative path", i, e)
+		}
+	}
+
+	if err := validateSources(fy.Sources); err != nil {
+		return err
+	}
+	if err := validatePatches("patches", fy.Patches); err != nil {
+		return err
+	}
+	if err := validateSubstitute("substitute", fy.Substitute); err != nil {
+		return err
+	}
+	for i, tc := range fy.TargetCustomizations {
+		if err := validatePatches(fmt.Sprintf("targetCustomizations[%d].patches", i), tc.Patches); err != nil {
+			return err
+		}
+		if err := validateSubstitute(fmt.Sprintf("targetCustomizations[%d].substitute", i), tc.Substitute); err != nil {
+			return err
+		}
+	}
+
 	// Validate DependsOn entries at the bundle level
 	for i, dep := range fy.DependsOn {
 		if err := validateBundleRef(i, dep); err != nil {
@@ -27,6 +55,12 @@ func validateBundleRef(index int, dep fleet.BundleRef) error {
 		return fmt.Errorf("dependsOn[%d]: must specify either 'name' or 'selector'", index)
 	}
 
+	if dep.Version != "" {
+		if _, err := semver.NewConstraint(dep.Version); err != nil {
+			return fmt.Errorf("dependsOn[%d].version: invalid constraint %q: %w", index, dep.Version, err)
+		}
+	}
+
 	// Validate AcceptedStates against the known valid states
 	for j, state := range dep.AcceptedStates {
 		if !isValidBundleState(state) {
@@ -39,6 +73,75 @@ func validateBundleRef(index int, dep fleet.BundleRef) error {
 	return nil
 }
 
+// validatePatches validates the patch types and target name patterns.
+func validatePatches(field string, patches []fleet.ResourcePatch) error {
+	for i, p := range patches {
+		switch p.Type {
+		case "", fleet.ResourcePatchStrategicMerge, fleet.ResourcePatchJSON6902:
+		default:
+			return fmt.Errorf("%s[%d].type: invalid patch type %q", field, i, p.Type)
+		}
+		if p.Patch == "" {
+			return fmt.Errorf("%s[%d].patch: must not be empty", field, i)
+		}
+		if _, err := path.Match(p.Target.Name, ""); err != nil {
+			return fmt.Errorf("%s[%d].target.name: invalid pattern %q: %w", field, i, p.Target.Name, err)
+		}
+	}
+	return nil
+}
+
+// validateSubstitute validates the references to ConfigMaps and Secrets.
+func validateSubstitute(field string, substitute *fleet.SubstituteOptions) error {
+	if substitute == nil {
+		return nil
+	}
+	for i, from := range substitute.VarsFrom {
+		if from.Kind != "ConfigMap" && from.Kind != "Secret" {
+			return fmt.Errorf("%s.varsFrom[%d].kind: must be ConfigMap or Secret, got %q", field, i, from.Kind)
+		}
+		if from.Name == "" {
+			return fmt.Errorf("%s.varsFrom[%d].name: must not be empty", field, i)
+		}
+	}
+	return nil
+}
+
+// validateSources validates that sources have unique names and exactly one
+// of git, oci and configMap set.
+func validateSources(sources []fleet.BundleSource) error {
+	names := map[string]bool{}
+	for i, source := range sources {
+		if source.Name == "" {
+			return fmt.Errorf("sources[%d].name: must not be empty", i)
+		}
+		if names[source.Name] {
+			return fmt.Errorf("sources[%d].name: duplicate source %q", i, source.Name)
+		}
+		names[source.Name] = true
+
+		set := 0
+		for _, ok := range []bool{source.Git != nil, source.OCI != nil, source.ConfigMap != nil} {
+			if ok {
+				set++
+			}
+		}
+		if set != 1 {
+			return fmt.Errorf("sources[%d]: exactly one of git, oci and configMap must be set", i)
+		}
+		if source.Git != nil && source.Git.Repo == "" {
+			return fmt.Errorf("sources[%d].git.repo: must not be empty", i)
+		}
+		if source.OCI != nil && source.OCI.Reference == "" {
+			return fmt.Errorf("sources[%d].oci.reference: must not be empty", i)
+		}
+		if source.ConfigMap != nil && source.ConfigMap.Name == "" {
+			return fmt.Errorf("sources[%d].configMap.name: must not be empty", i)
+		}
+	}
+	return nil
+}
+
 // isValidBundleState checks if a BundleState is valid by checking against StateRank
 func isValidBundleState(state fleet.BundleState) bool {
 	_, exists := fleet.StateRank[state]
@@ -198,3 +198,141 @@ func TestValidBundleStatesList_SortedByRank(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateFleetYAML_VersionConstraint(t *testing.T) {
+	fy := &fleet.FleetYAML{
+		BundleSpec: fleet.BundleSpec{
+			DependsOn: []fleet.BundleRef{{Name: "cert-manager", Namespace: "platform", Version: ">= 1.14"}},
+		},
+	}
+	if err := validateFleetYAML(fy); err != nil {
+		t.Errorf("validateFleetYAML() unexpected error: %v", err)
+	}
+
+	fy.DependsOn[0].Version = "latest"
+	err := validateFleetYAML(fy)
+	if err == nil || !strings.Contains(err.Error(), `dependsOn[0].version: invalid constraint "latest"`) {
+		t.Errorf("validateFleetYAML() expected invalid constraint error, got: %v", err)
+	}
+}
+
+func TestValidateFleetYAML_Extends(t *testing.T) {
+	fy := &fleet.FleetYAML{Extends: []string{"../../base/fleet.yaml"}}
+	if err := validateFleetYAML(fy); err != nil {
+		t.Errorf("validateFleetYAML() unexpected error: %v", err)
+	}
+
+	fy.Extends = append(fy.Extends, "/base/fleet.yaml")
+	err := validateFleetYAML(fy)
+	if err == nil || !strings.Contains(err.Error(), `extends[1]: "/base/fleet.yaml" must be a relative path`) {
+		t.Errorf("validateFleetYAML() expected relative path error, got: %v", err)
+	}
+}
+
+func TestValidateFleetYAML_Patches(t *testing.T) {
+	fy := &fleet.FleetYAML{
+		BundleSpec: fleet.BundleSpec{
+			BundleDeploymentOptions: fleet.BundleDeploymentOptions{
+				Patches: []fleet.ResourcePatch{{Target: fleet.PatchTarget{Name: "web-*"}, Patch: "metadata: {}"}},
+			},
+		},
+		TargetCustomizations: []fleet.BundleTarget{{
+			Name: "prod",
+			BundleDeploymentOptions: fleet.BundleDeploymentOptions{
+				Patches: []fleet.ResourcePatch{{Type: "merge", Patch: "metadata: {}"}},
+			},
+		}},
+	}
+	err := validateFleetYAML(fy)
+	if err == nil || !strings.Contains(err.Error(), `targetCustomizations[0].patches[0].type: invalid patch type "merge"`) {
+		t.Errorf("validateFleetYAML() expected invalid type error, got: %v", err)
+	}
+
+	fy.TargetCustomizations = nil
+	fy.Patches[0].Target.Name = "web-["
+	err = validateFleetYAML(fy)
+	if err == nil || !strings.Contains(err.Error(), `patches[0].target.name: invalid pattern "web-["`) {
+		t.Errorf("validateFleetYAML() expected invalid pattern error, got: %v", err)
+	}
+}
+
+func TestValidateFleetYAML_Substitute(t *testing.T) {
+	fy := &fleet.FleetYAML{
+		BundleSpec: fleet.BundleSpec{
+			BundleDeploymentOptions: fleet.BundleDeploymentOptions{
+				Substitute: &fleet.SubstituteOptions{VarsFrom: []fleet.SubstituteFrom{{Kind: "ConfigMap", Name: "vars"}}},
+			},
+		},
+	}
+	if err := validateFleetYAML(fy); err != nil {
+		t.Errorf("validateFleetYAML() unexpected error: %v", err)
+	}
+
+	fy.TargetCustomizations = []fleet.BundleTarget{{
+		Name: "prod",
+		BundleDeploymentOptions: fleet.BundleDeploymentOptions{
+			Substitute: &fleet.SubstituteOptions{VarsFrom: []fleet.SubstituteFrom{{Kind: "Vault", Name: "vars"}}},
+		},
+	}}
+	err := validateFleetYAML(fy)
+	if err == nil || !strings.Contains(err.Error(), `targetCustomizations[0].substitute.varsFrom[0].kind: must be ConfigMap or Secret, got "Vault"`) {
+		t.Errorf("validateFleetYAML() expected invalid kind error, got: %v", err)
+	}
+}
+
+func TestValidateFleetYAML_Sources(t *testing.T) {
+	fy := &fleet.FleetYAML{
+		BundleSpec: fleet.BundleSpec{
+			Sources: []fleet.BundleSource{
+				{Name: "shared", Git: &fleet.GitSource{Repo: "https://github.com/example/shared"}},
+				{Name: "values", ConfigMap: &fleet.LocalObjectReference{Name: "values"}},
+			},
+		},
+	}
+	if err := validateFleetYAML(fy); err != nil {
+		t.Errorf("validateFleetYAML() unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		sources []fleet.BundleSource
+		wantErr string
+	}{
+		{
+			name: "duplicate name",
+			sources: []fleet.BundleSource{
+				{Name: "shared", OCI: &fleet.OCISource{Reference: "registry.example.com/shared:1.0"}},
+				{Name: "shared", ConfigMap: &fleet.LocalObjectReference{Name: "shared"}},
+			},
+			wantErr: `sources[1].name: duplicate source "shared"`,
+		},
+		{
+			name:    "no type",
+			sources: []fleet.BundleSource{{Name: "shared"}},
+			wantErr: "sources[0]: exactly one of git, oci and configMap must be set",
+		},
+		{
+			name: "several types",
+			sources: []fleet.BundleSource{{
+				Name:      "shared",
+				Git:       &fleet.GitSource{Repo: "https://github.com/example/shared"},
+				ConfigMap: &fleet.LocalObjectReference{Name: "shared"},
+			}},
+			wantErr: "sources[0]: exactly one of git, oci and configMap must be set",
+		},
+		{
+			name:    "missing repo",
+			sources: []fleet.BundleSource{{Name: "shared", Git: &fleet.GitSource{}}},
+			wantErr: "sources[0].git.repo: must not be empty",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fy := &fleet.FleetYAML{BundleSpec: fleet.BundleSpec{Sources: tt.sources}}
+			err := validateFleetYAML(fy)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validateFleetYAML() expected error %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -4,8 +4,10 @@ import (
 	"context"
 	"time"
 
+	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
@@ -16,6 +18,7 @@ import (
 
 type ClusterStatusRunnable struct {
 	config          *rest.Config
+	localConfig     *rest.Config
 	namespace       string
 	checkinInterval string
 	agentInfo       *register.AgentInfo
@@ -36,6 +39,26 @@ func (cs *ClusterStatusRunnable) Start(ctx context.Context) error {
 
 	setupLog.Info("Starting cluster status ticker", "checkin interval", checkinInterval.String(), "cluster namespace", cs.agentInfo.ClusterNamespace, "cluster name", cs.agentInfo.ClusterName)
 
+	// collect facts from a cache of the downstream cluster, so check-ins
+	// do not list all nodes and CRDs
+	localCache, err := cache.New(cs.localConfig, cache.Options{
+		Scheme:           localScheme,
+		DefaultTransform: cache.TransformStripManagedFields(),
+	})
+	if err != nil {
+		return err
+	}
+	go func() {
+		if err := localCache.Start(ctx); err != nil {
+			setupLog.Error(err, "failed to start cache for cluster facts")
+		}
+	}()
+	dc, err := discovery.NewDiscoveryClientForConfig(cs.localConfig)
+	if err != nil {
+		return err
+	}
+	facts := &clusterstatus.FactCollector{Client: localCache, Discovery: dc}
+
 	// use a separate client for the cluster status ticker, that does not use a cache
 	client, err := client.New(cs.config, client.Options{Scheme: scheme})
 	if err != nil {
@@ -46,6 +69,7 @@ func (cs *ClusterStatusRunnable) Start(ctx context.Context) error {
 		clusterstatus.Ticker(
 			ctx,
 			client,
+			facts,
 			cs.namespace,
 			cs.agentInfo.ClusterNamespace,
 			cs.agentInfo.ClusterName,
@@ -0,0 +1,130 @@
+package clusterstatus
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/discovery"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// FactCollector collects facts about the downstream cluster.
+type FactCollector struct {
+	// Client reads nodes and CRDs of the downstream cluster. It should be
+	// backed by a cache, as facts are collected on every check-in.
+	Client    client.Reader
+	Discovery discovery.ServerVersionInterface
+}
+
+// Collect returns the facts of the downstream cluster.
+func (f *FactCollector) Collect(ctx context.Context) (*fleet.ClusterFacts, error) {
+	v, err := f.Discovery.ServerVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := &corev1.NodeList{}
+	if err := f.Client.List(ctx, nodes); err != nil {
+		return nil, err
+	}
+
+	crds := &metav1.PartialObjectMetadataList{}
+	crds.SetGroupVersionKind(schema.GroupVersionKind{
+		Group:   "apiextensions.k8s.io",
+		Version: "v1",
+		Kind:    "CustomResourceDefinitionList",
+	})
+	if err := f.Client.List(ctx, crds); err != nil {
+		return nil, err
+	}
+
+	facts := &fleet.ClusterFacts{
+		KubernetesVersion: v.GitVersion,
+		NodeCount:         len(nodes.Items),
+		Allocatable:       corev1.ResourceList{},
+	}
+	for _, node := range nodes.Items {
+		facts.Architectures = appendUnique(facts.Architectures, node.Status.NodeInfo.Architecture)
+		facts.OperatingSystems = appendUnique(facts.OperatingSystems, node.Status.NodeInfo.OperatingSystem)
+		for name, q := range node.Status.Allocatable {
+			sum := facts.Allocatable[name]
+			sum.Add(q)
+			facts.Allocatable[name] = sum
+		}
+		if facts.CloudProvider == "" {
+			facts.CloudProvider = cloudProvider(node.Spec.ProviderID)
+		}
+	}
+	// CRDs are named <plural>.<group>
+	for _, crd := range crds.Items {
+		if _, group, ok := strings.Cut(crd.Name, "."); ok {
+			facts.CRDGroups = appendUnique(facts.CRDGroups, group)
+		}
+	}
+	slices.Sort(facts.Architectures)
+	slices.Sort(facts.OperatingSystems)
+	slices.Sort(facts.CRDGroups)
+	facts.Distribution = distribution(v, nodes.Items, facts.CRDGroups)
+
+	return facts, nil
+}
+
+func appendUnique(s []string, v string) []string {
+	if v == "" || slices.Contains(s, v) {
+		return s
+	}
+	return append(s, v)
+}
+
+// cloudProvider returns the scheme of a node's provider ID, e.g. "aws" for
+// "aws:///eu-west-1a/i-0123", unless it only identifies a distribution.
+func cloudProvider(providerID string) string {
+	scheme, _, ok := strings.Cut(providerID, "://")
+	if !ok {
+		return ""
+	}
+	switch scheme {
+	case "k3s", "rke2", "kind":
+		return ""
+	}
+	return scheme
+}
+
+// distribution detects the Kubernetes distribution from the server version,
+// node labels and installed CRDs.
+func distribution(v *version.Info, nodes []corev1.Node, crdGroups []string) string {
+	switch {
+	case strings.Contains(v.GitVersion, "+k3s"):
+		return "k3s"
+	case strings.Contains(v.GitVersion, "+rke2"):
+		return "rke2"
+	case strings.Contains(v.GitVersion, "+k0s"):
+		return "k0s"
+	case strings.Contains(v.GitVersion, "-eks-"):
+		return "eks"
+	case strings.Contains(v.GitVersion, "-gke."):
+		return "gke"
+	case slices.Contains(crdGroups, "config.openshift.io"):
+		return "openshift"
+	}
+	for _, node := range nodes {
+		switch {
+		case node.Labels["kubernetes.azure.com/cluster"] != "":
+			return "aks"
+		case node.Labels["minikube.k8s.io/name"] != "":
+			return "minikube"
+		case node.Labels["microk8s.io/cluster"] != "":
+			return "microk8s"
+		case strings.HasPrefix(node.Spec.ProviderID, "kind://"):
+			return "kind"
+		}
+	}
+	return ""
+}
@@ -0,0 +1,86 @@
+package clusterstatus
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("FactCollector", func() {
+	node := func(name, arch, providerID string, cpu string) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       corev1.NodeSpec{ProviderID: providerID},
+			Status: corev1.NodeStatus{
+				NodeInfo: corev1.NodeSystemInfo{Architecture: arch, OperatingSystem: "linux"},
+				Allocatable: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse(cpu),
+					corev1.ResourcePods:   resource.MustParse("110"),
+					corev1.ResourceMemory: resource.MustParse("4Gi"),
+				},
+			},
+		}
+	}
+	crd := func(name string) *apiextensionsv1.CustomResourceDefinition {
+		return &apiextensionsv1.CustomResourceDefinition{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	}
+
+	collect := func(gitVersion string) *fleet.ClusterFacts {
+		scheme := runtime.NewScheme()
+		utilruntime.Must(corev1.AddToScheme(scheme))
+		utilruntime.Must(apiextensionsv1.AddToScheme(scheme))
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			node("node-1", "amd64", "aws:///eu-west-1a/i-1", "2"),
+			node("node-2", "arm64", "aws:///eu-west-1b/i-2", "1500m"),
+			crd("bundles.fleet.cattle.io"),
+			crd("clusters.fleet.cattle.io"),
+			crd("certificates.cert-manager.io"),
+		).Build()
+		dc := &fakediscovery.FakeDiscovery{
+			Fake:               &clienttesting.Fake{},
+			FakedServerVersion: &version.Info{GitVersion: gitVersion},
+		}
+
+		facts, err := (&FactCollector{Client: c, Discovery: dc}).Collect(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		return facts
+	}
+
+	It("collects facts from nodes and CRDs", func() {
+		facts := collect("v1.30.2+k3s1")
+		Expect(facts.KubernetesVersion).To(Equal("v1.30.2+k3s1"))
+		Expect(facts.Distribution).To(Equal("k3s"))
+		Expect(facts.CloudProvider).To(Equal("aws"))
+		Expect(facts.NodeCount).To(Equal(2))
+		Expect(facts.Architectures).To(Equal([]string{"amd64", "arm64"}))
+		Expect(facts.OperatingSystems).To(Equal([]string{"linux"}))
+		Expect(facts.CRDGroups).To(Equal([]string{"cert-manager.io", "fleet.cattle.io"}))
+		Expect(facts.Allocatable.Cpu().String()).To(Equal("3500m"))
+		Expect(facts.Allocatable.Pods().Value()).To(Equal(int64(220)))
+		Expect(facts.Allocatable.Memory().String()).To(Equal("8Gi"))
+	})
+
+	DescribeTable("detects the distribution",
+		func(gitVersion, distribution string) {
+			Expect(collect(gitVersion).Distribution).To(Equal(distribution))
+		},
+		Entry("rke2", "v1.30.2+rke2r1", "rke2"),
+		Entry("eks", "v1.29.4-eks-036c24b", "eks"),
+		Entry("gke", "v1.29.4-gke.1043002", "gke"),
+		Entry("unknown", "v1.30.2", ""),
+	)
+})
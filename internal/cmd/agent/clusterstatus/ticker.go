@@ -3,6 +3,7 @@ package clusterstatus
 
 import (
 	"context"
+	"encoding/json"
 	"time"
 
 	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
@@ -22,10 +23,14 @@ type handler struct {
 	clusterName      string
 	clusterNamespace string
 	client           client.Client
+	facts            *FactCollector
 	reported         fleet.AgentStatus
+	reportedFacts    *fleet.ClusterFacts
 }
 
-func Ticker(ctx context.Context, client client.Client, agentNamespace string, clusterNamespace string, clusterName string, checkinInterval time.Duration) {
+// Ticker reports the agent status and, if facts is not nil, the facts of the
+// downstream cluster periodically.
+func Ticker(ctx context.Context, client client.Client, facts *FactCollector, agentNamespace string, clusterNamespace string, clusterName string, checkinInterval time.Duration) {
 	logger := log.FromContext(ctx).WithName("clusterstatus").WithValues("cluster", clusterName, "interval", checkinInterval)
 
 	h := handler{
@@ -33,6 +38,7 @@ func Ticker(ctx context.Context, client client.Client, agentNamespace string, cl
 		clusterName:      clusterName,
 		clusterNamespace: clusterNamespace,
 		client:           client,
+		facts:            facts,
 	}
 
 	go func() {
@@ -58,8 +64,9 @@ func Ticker(ctx context.Context, client client.Client, agentNamespace string, cl
 // Update the cluster.fleet.cattle.io status in the upstream cluster with the current cluster status
 func (h *handler) Update(ctx context.Context) error {
 	agentStatus := fleet.AgentStatus{
-		LastSeen:  metav1.Now(),
-		Namespace: h.agentNamespace,
+		LastSeen:     metav1.Now(),
+		Namespace:    h.agentNamespace,
+		ContentBlobs: true,
 	}
 
 	if equality.Semantic.DeepEqual(h.reported, agentStatus) {
@@ -74,17 +81,55 @@ func (h *handler) Update(ctx context.Context) error {
 	}
 
 	// Create a patch with the updated status, we avoid Get as that would
-	// need additional RBAC
-	patch := `[{"op":"add","path":"/status/agent","value":{"lastSeen":"` +
-		agentStatus.LastSeen.Format(time.RFC3339) +
-		`","namespace":"` + agentStatus.Namespace +
-		`"}}]`
-
-	err := h.client.Status().Patch(ctx, cluster, client.RawPatch(types.JSONPatchType, []byte(patch)))
+	// need additional RBAC. A merge patch keeps the credential rotation
+	// fields of the agent status.
+	status := map[string]any{
+		"agent": map[string]any{
+			"lastSeen":     agentStatus.LastSeen.Format(time.RFC3339),
+			"namespace":    agentStatus.Namespace,
+			"contentBlobs": agentStatus.ContentBlobs,
+		},
+	}
+	patch, err := json.Marshal(map[string]any{"status": status})
 	if err != nil {
 		return err
 	}
 
+	err = h.client.Status().Patch(ctx, cluster, client.RawPatch(types.MergePatchType, patch))
+	if err != nil {
+		return err
+	}
 	h.reported = agentStatus
+
+	if h.facts != nil {
+		// missing facts are not fatal, the previously reported facts are kept
+		facts, err := h.facts.Collect(ctx)
+		if err != nil {
+			log.FromContext(ctx).Error(err, "failed to collect cluster facts")
+		} else if err := h.updateFacts(ctx, cluster, facts); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// updateFacts replaces the facts in the cluster status, if they changed. A
+// merge patch would keep map keys, which are no longer reported, e.g. of
+// allocatable resources which were removed from all nodes.
+func (h *handler) updateFacts(ctx context.Context, cluster *fleet.Cluster, facts *fleet.ClusterFacts) error {
+	if h.reportedFacts != nil && equality.Semantic.DeepEqual(h.reportedFacts, facts) {
+		return nil
+	}
+
+	patch, err := json.Marshal([]map[string]any{{"op": "add", "path": "/status/facts", "value": facts}})
+	if err != nil {
+		return err
+	}
+	if err := h.client.Status().Patch(ctx, cluster, client.RawPatch(types.JSONPatchType, patch)); err != nil {
+		return err
+	}
+
+	h.reportedFacts = facts
 	return nil
 }
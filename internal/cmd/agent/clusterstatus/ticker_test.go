@@ -10,9 +10,14 @@ import (
 
 	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	clienttesting "k8s.io/client-go/testing"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -95,7 +100,56 @@ var _ = Describe("ClusterStatus Ticker", func() {
 	})
 
 	It("should patch the cluster status after checkinInterval", func() {
-		Ticker(ctx, clt, agentNamespace, clusterNamespace, clusterName, checkinInterval)
+		Ticker(ctx, clt, nil, agentNamespace, clusterNamespace, clusterName, checkinInterval)
 		<-ctx.Done()
 	})
 })
+
+var _ = Describe("ClusterStatus facts", func() {
+	It("replaces the reported facts, removing resources which are no longer allocatable", func() {
+		ctx := context.Background()
+		scheme := runtime.NewScheme()
+		utilruntime.Must(fleet.AddToScheme(scheme))
+		cluster := &fleet.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-name", Namespace: "cluster-namespace"},
+			Status: fleet.ClusterStatus{
+				Facts: &fleet.ClusterFacts{
+					NodeCount: 2,
+					Allocatable: corev1.ResourceList{
+						corev1.ResourceCPU:                    resource.MustParse("4"),
+						corev1.ResourceName("nvidia.com/gpu"): resource.MustParse("1"),
+					},
+				},
+			},
+		}
+		upstream := fake.NewClientBuilder().WithScheme(scheme).WithObjects(cluster).WithStatusSubresource(cluster).Build()
+
+		localScheme := runtime.NewScheme()
+		utilruntime.Must(corev1.AddToScheme(localScheme))
+		local := fake.NewClientBuilder().WithScheme(localScheme).WithObjects(&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+			Status: corev1.NodeStatus{
+				Allocatable: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")},
+			},
+		}).Build()
+		dc := &fakediscovery.FakeDiscovery{
+			Fake:               &clienttesting.Fake{},
+			FakedServerVersion: &version.Info{GitVersion: "v1.30.2"},
+		}
+
+		h := &handler{
+			agentNamespace:   "cattle-fleet-system",
+			clusterName:      cluster.Name,
+			clusterNamespace: cluster.Namespace,
+			client:           upstream,
+			facts:            &FactCollector{Client: local, Discovery: dc},
+		}
+		Expect(h.Update(ctx)).To(Succeed())
+
+		Expect(upstream.Get(ctx, client.ObjectKeyFromObject(cluster), cluster)).To(Succeed())
+		Expect(cluster.Status.Agent.Namespace).To(Equal("cattle-fleet-system"))
+		Expect(cluster.Status.Facts.NodeCount).To(Equal(1))
+		Expect(cluster.Status.Facts.Allocatable).To(HaveLen(1))
+		Expect(cluster.Status.Facts.Allocatable.Cpu().String()).To(Equal("2"))
+	})
+})
@@ -0,0 +1,42 @@
+package contentcache
+
+import (
+	"context"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// Resync reports queued status updates to the upstream cluster when the
+// agent starts and then periodically, until the queue is empty. On start, it
+// also removes entries of bundle deployments deleted while the agent was
+// disconnected.
+type Resync struct {
+	Store    *Store
+	Upstream client.Client
+	Interval time.Duration
+}
+
+// Start implements manager.Runnable.
+func (r *Resync) Start(ctx context.Context) error {
+	logger := log.FromContext(ctx).WithName("content-cache-resync")
+
+	if err := r.Store.Prune(ctx, r.Upstream); err != nil {
+		logger.Error(err, "Failed to prune content cache")
+	}
+
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+	for {
+		if err := r.Store.FlushStatus(ctx, r.Upstream); err != nil {
+			logger.Error(err, "Failed to report queued bundledeployment status")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,573 @@
+// Package contentcache persists the last applied manifest and options of each
+// bundle deployment on the agent's cluster.
+//
+// Manifests are normally loaded from Content resources or OCI registries of
+// the upstream cluster when a bundle deployment is deployed. The cache allows
+// the agent to deploy again, e.g. after a restart, and to correct drift while
+// the upstream cluster is unreachable. Status updates which could not be sent
+// upstream are queued in the cache until the agent reconnects.
+//
+// Entries are stored in secrets in the agent's namespace, encrypted with a key
+// which never leaves the cluster. The key is stored in a separate namespace,
+// so that read access to the agent's namespace does not grant access to the
+// cached options and manifests; access to the key namespace should be
+// restricted to the agent. The number of entries is bounded, the least
+// recently updated entries are evicted first.
+package contentcache
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/rancher/fleet/internal/content"
+	"github.com/rancher/fleet/internal/manifest"
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+)
+
+const (
+	// KeySecretName is the name of the secret holding the encryption key.
+	KeySecretName = "fleet-agent-content-cache-key"
+	// DefaultKeyNamespace is the default namespace of the key secret. It is
+	// created by the agent if it does not exist.
+	DefaultKeyNamespace = "cattle-fleet-content-cache-key"
+	// CacheLabel marks secrets holding cache entries.
+	CacheLabel = "fleet.cattle.io/content-cache"
+	// BundleDeploymentAnnotation holds the namespace and name of the cached
+	// bundle deployment, the secret name is derived from them.
+	BundleDeploymentAnnotation = "fleet.cattle.io/content-cache-bundledeployment"
+	// UpdatedAnnotation holds the time an entry was last updated, used to
+	// evict the oldest entries first.
+	UpdatedAnnotation = "fleet.cattle.io/content-cache-updated"
+
+	// DefaultMaxEntries is the default number of cached bundle deployments.
+	DefaultMaxEntries = 256
+	// MaxEntrySize is the maximum size of an encrypted entry, which must fit
+	// into a secret.
+	MaxEntrySize = 1000 * 1024
+
+	secretPrefix = "fleet-content-cache-"
+	keyKey       = "key"
+	entryKey     = "entry"
+)
+
+// ErrEntryTooLarge is returned when an entry does not fit into a secret.
+var ErrEntryTooLarge = errors.New("content cache entry too large")
+
+// Entry is the cached state of a bundle deployment.
+type Entry struct {
+	// BundleDeployment is the last deployed bundle deployment, with options
+	// loaded from its options secret and the resulting release in its status.
+	BundleDeployment *fleet.BundleDeployment `json:"bundleDeployment"`
+	// Resources are the resources of the deployed manifest.
+	Resources []fleet.BundleResource `json:"resources"`
+	// PendingStatus is a status which could not be reported upstream yet.
+	PendingStatus *fleet.BundleDeploymentStatus `json:"pendingStatus,omitempty"`
+}
+
+// Manifest returns the cached manifest.
+func (e *Entry) Manifest() *manifest.Manifest {
+	return manifest.New(e.Resources)
+}
+
+// ManifestID returns the manifest ID part of the cached deployment ID.
+func (e *Entry) ManifestID() string {
+	id, _, _ := strings.Cut(e.BundleDeployment.Spec.DeploymentID, ":")
+	return id
+}
+
+// Store reads and writes cache entries using a client for the agent's cluster.
+type Store struct {
+	client       client.Client
+	namespace    string
+	keyNamespace string
+	maxEntries   int
+
+	mu   sync.Mutex
+	aead cipher.AEAD
+	// deployed maps cached bundle deployments to their cached deployment
+	// ID, to avoid reading entries which are up to date.
+	deployed map[string]string
+	// pending contains the bundle deployments with a pending status.
+	pending map[string]bool
+	// listed is true once all entries have been indexed.
+	listed bool
+}
+
+// New returns a store keeping at most maxEntries entries in namespace. The
+// encryption key is kept in keyNamespace, which must differ from namespace.
+func New(c client.Client, namespace, keyNamespace string, maxEntries int) (*Store, error) {
+	if keyNamespace == namespace {
+		return nil, fmt.Errorf("content cache key namespace must differ from the cache namespace %q", namespace)
+	}
+	return &Store{
+		client:       c,
+		namespace:    namespace,
+		keyNamespace: keyNamespace,
+		maxEntries:   maxEntries,
+		deployed:     map[string]string{},
+		pending:      map[string]bool{},
+	}, nil
+}
+
+// Get returns the entry for the named bundle deployment, or nil if there is
+// none.
+func (s *Store) Get(ctx context.Context, namespace, name string) (*Entry, error) {
+	// Load the key first, creating a new key drops unreadable entries.
+	if _, err := s.cipher(ctx); err != nil {
+		return nil, err
+	}
+	secret := &corev1.Secret{}
+	err := s.client.Get(ctx, client.ObjectKey{Namespace: s.namespace, Name: secretName(namespace, name)}, secret)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	entry, err := s.decode(ctx, secret)
+	if err != nil {
+		return nil, err
+	}
+	s.index(entry)
+	return entry, nil
+}
+
+// Has returns true if bd is cached with its current deployment ID.
+func (s *Store) Has(ctx context.Context, bd *fleet.BundleDeployment) (bool, error) {
+	s.mu.Lock()
+	id, ok := s.deployed[key(bd.Namespace, bd.Name)]
+	s.mu.Unlock()
+	if ok {
+		return id == bd.Spec.DeploymentID, nil
+	}
+
+	entry, err := s.Get(ctx, bd.Namespace, bd.Name)
+	if err != nil || entry == nil {
+		return false, err
+	}
+	return entry.BundleDeployment.Spec.DeploymentID == bd.Spec.DeploymentID, nil
+}
+
+// List returns all entries.
+func (s *Store) List(ctx context.Context) ([]*Entry, error) {
+	if _, err := s.cipher(ctx); err != nil {
+		return nil, err
+	}
+	secrets := &corev1.SecretList{}
+	if err := s.client.List(ctx, secrets, client.InNamespace(s.namespace), client.HasLabels{CacheLabel}); err != nil {
+		return nil, err
+	}
+
+	entries := make([]*Entry, 0, len(secrets.Items))
+	for i := range secrets.Items {
+		entry, err := s.decode(ctx, &secrets.Items[i])
+		if err != nil {
+			return nil, fmt.Errorf("failed to read content cache entry %s: %w", secrets.Items[i].Name, err)
+		}
+		s.index(entry)
+		entries = append(entries, entry)
+	}
+
+	s.mu.Lock()
+	s.listed = true
+	s.mu.Unlock()
+	return entries, nil
+}
+
+// Put stores the deployed manifest m of bd, which must have its options
+// loaded and its release set in the status. A pending status of a previous
+// entry is kept.
+func (s *Store) Put(ctx context.Context, bd *fleet.BundleDeployment, m *manifest.Manifest) error {
+	prev, err := s.Get(ctx, bd.Namespace, bd.Name)
+	if err != nil {
+		return err
+	}
+
+	cached := bd.DeepCopy()
+	cached.ManagedFields = nil
+	entry := &Entry{
+		BundleDeployment: cached,
+		Resources:        m.Resources,
+	}
+	if prev != nil {
+		entry.PendingStatus = prev.PendingStatus
+	} else if err := s.evict(ctx); err != nil {
+		return err
+	}
+
+	return s.write(ctx, entry)
+}
+
+// QueueStatus stores the status of bd, so it can be reported upstream once
+// the upstream cluster is reachable. It does nothing if bd is not cached.
+func (s *Store) QueueStatus(ctx context.Context, bd *fleet.BundleDeployment) error {
+	entry, err := s.Get(ctx, bd.Namespace, bd.Name)
+	if err != nil || entry == nil {
+		return err
+	}
+	status := bd.Status.DeepCopy()
+	entry.PendingStatus = status
+	entry.BundleDeployment.Status.Release = status.Release
+	return s.write(ctx, entry)
+}
+
+// ClearStatus removes the pending status of the named bundle deployment, if
+// any.
+func (s *Store) ClearStatus(ctx context.Context, namespace, name string) error {
+	s.mu.Lock()
+	pending := s.pending[key(namespace, name)]
+	s.mu.Unlock()
+	if !pending {
+		return nil
+	}
+
+	entry, err := s.Get(ctx, namespace, name)
+	if err != nil || entry == nil || entry.PendingStatus == nil {
+		return err
+	}
+	entry.PendingStatus = nil
+	return s.write(ctx, entry)
+}
+
+// Delete removes the entry of the named bundle deployment.
+func (s *Store) Delete(ctx context.Context, namespace, name string) error {
+	s.mu.Lock()
+	delete(s.deployed, key(namespace, name))
+	delete(s.pending, key(namespace, name))
+	s.mu.Unlock()
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: s.namespace, Name: secretName(namespace, name)},
+	}
+	return client.IgnoreNotFound(s.client.Delete(ctx, secret))
+}
+
+// FlushStatus reports pending statuses to the upstream cluster. Pending
+// statuses of bundle deployments which were deleted or updated upstream in
+// the meantime are dropped, as the agent reconciles those anyway.
+func (s *Store) FlushStatus(ctx context.Context, upstream client.Client) error {
+	s.mu.Lock()
+	idle := s.listed && len(s.pending) == 0
+	s.mu.Unlock()
+	if idle {
+		return nil
+	}
+
+	entries, err := s.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, entry := range entries {
+		if entry.PendingStatus == nil {
+			continue
+		}
+		cached := entry.BundleDeployment
+
+		bd := &fleet.BundleDeployment{}
+		err := upstream.Get(ctx, client.ObjectKeyFromObject(cached), bd)
+		if apierrors.IsNotFound(err) {
+			errs = append(errs, s.Delete(ctx, cached.Namespace, cached.Name))
+			continue
+		} else if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if bd.Spec.DeploymentID == cached.Spec.DeploymentID {
+			orig := bd.DeepCopy()
+			bd.Status = *entry.PendingStatus
+			err := upstream.Status().Patch(ctx, bd, client.MergeFromWithOptions(orig, client.MergeFromWithOptimisticLock{}))
+			if err != nil && !apierrors.IsConflict(err) && !apierrors.IsNotFound(err) {
+				errs = append(errs, err)
+				continue
+			}
+		}
+
+		errs = append(errs, s.ClearStatus(ctx, cached.Namespace, cached.Name))
+	}
+	return errors.Join(errs...)
+}
+
+// Prune removes entries of bundle deployments which no longer exist in the
+// upstream cluster.
+func (s *Store) Prune(ctx context.Context, upstream client.Reader) error {
+	entries, err := s.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, entry := range entries {
+		cached := entry.BundleDeployment
+		err := upstream.Get(ctx, client.ObjectKeyFromObject(cached), &fleet.BundleDeployment{})
+		if apierrors.IsNotFound(err) {
+			errs = append(errs, s.Delete(ctx, cached.Namespace, cached.Name))
+		} else if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// IsUnreachable returns true if err indicates that the upstream cluster could
+// not be reached, as opposed to a request rejected by its API server.
+func IsUnreachable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if apierrors.IsServiceUnavailable(err) || apierrors.IsTimeout(err) ||
+		apierrors.IsServerTimeout(err) || apierrors.IsTooManyRequests(err) {
+		return true
+	}
+	var status apierrors.APIStatus
+	return !errors.As(err, &status)
+}
+
+// evict deletes the least recently updated entries, to make room for a new
+// one.
+func (s *Store) evict(ctx context.Context) error {
+	if s.maxEntries <= 0 {
+		return nil
+	}
+
+	secrets := &corev1.SecretList{}
+	if err := s.client.List(ctx, secrets, client.InNamespace(s.namespace), client.HasLabels{CacheLabel}); err != nil {
+		return err
+	}
+	if len(secrets.Items) < s.maxEntries {
+		return nil
+	}
+
+	sort.Slice(secrets.Items, func(i, j int) bool {
+		return secrets.Items[i].Annotations[UpdatedAnnotation] < secrets.Items[j].Annotations[UpdatedAnnotation]
+	})
+	for i := 0; i <= len(secrets.Items)-s.maxEntries; i++ {
+		ns, name, _ := strings.Cut(secrets.Items[i].Annotations[BundleDeploymentAnnotation], "/")
+		if err := s.Delete(ctx, ns, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Store) write(ctx context.Context, entry *Entry) error {
+	bd := entry.BundleDeployment
+	data, err := s.encode(ctx, entry)
+	if err != nil {
+		return err
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: s.namespace,
+			Name:      secretName(bd.Namespace, bd.Name),
+			Labels:    map[string]string{CacheLabel: "true"},
+			Annotations: map[string]string{
+				BundleDeploymentAnnotation: bd.Namespace + "/" + bd.Name,
+				UpdatedAnnotation:          time.Now().UTC().Format(time.RFC3339Nano),
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{entryKey: data},
+	}
+
+	err = s.client.Create(ctx, secret)
+	if apierrors.IsAlreadyExists(err) {
+		existing := &corev1.Secret{}
+		if err := s.client.Get(ctx, client.ObjectKeyFromObject(secret), existing); err != nil {
+			return err
+		}
+		existing.Labels = secret.Labels
+		existing.Annotations = secret.Annotations
+		existing.Data = secret.Data
+		err = s.client.Update(ctx, existing)
+	}
+	if err != nil {
+		return err
+	}
+
+	s.index(entry)
+	return nil
+}
+
+// encode serializes, compresses and encrypts entry. The bundle deployment's
+// namespace and name are authenticated, so an entry cannot be swapped with
+// another one.
+func (s *Store) encode(ctx context.Context, entry *Entry) ([]byte, error) {
+	aead, err := s.cipher(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return nil, err
+	}
+	data, err = content.Gzip(data)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(data)+aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	bd := entry.BundleDeployment
+	sealed := aead.Seal(nonce, nonce, data, additionalData(bd.Namespace, bd.Name))
+	if len(sealed) > MaxEntrySize {
+		return nil, fmt.Errorf("%w: %d bytes for bundledeployment %s/%s", ErrEntryTooLarge, len(sealed), bd.Namespace, bd.Name)
+	}
+	return sealed, nil
+}
+
+// decode decrypts the entry stored in secret.
+func (s *Store) decode(ctx context.Context, secret *corev1.Secret) (*Entry, error) {
+	namespace, name, ok := strings.Cut(secret.Annotations[BundleDeploymentAnnotation], "/")
+	if !ok || secretName(namespace, name) != secret.Name {
+		return nil, fmt.Errorf("secret %s has an invalid %s annotation", secret.Name, BundleDeploymentAnnotation)
+	}
+
+	aead, err := s.cipher(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sealed := secret.Data[entryKey]
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("content cache entry is truncated")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	data, err := aead.Open(nil, nonce, ciphertext, additionalData(namespace, name))
+	if err != nil {
+		return nil, err
+	}
+	data, err = content.GUnzip(data)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &Entry{}
+	if err := json.Unmarshal(data, entry); err != nil {
+		return nil, err
+	}
+	if entry.BundleDeployment == nil {
+		return nil, errors.New("content cache entry has no bundledeployment")
+	}
+	return entry, nil
+}
+
+// cipher returns the AEAD used to encrypt entries, creating the key secret
+// on first use.
+func (s *Store) cipher(ctx context.Context) (cipher.AEAD, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.aead != nil {
+		return s.aead, nil
+	}
+
+	key, err := s.key(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load content cache key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	s.aead = aead
+	return aead, nil
+}
+
+func (s *Store) key(ctx context.Context) ([]byte, error) {
+	secret := &corev1.Secret{}
+	err := s.client.Get(ctx, client.ObjectKey{Namespace: s.keyNamespace, Name: KeySecretName}, secret)
+	if err == nil {
+		if len(secret.Data[keyKey]) != 32 {
+			return nil, fmt.Errorf("secret %s/%s does not contain a 32 byte key", s.keyNamespace, KeySecretName)
+		}
+		return secret.Data[keyKey], nil
+	} else if !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: s.keyNamespace}}
+	if err := s.client.Create(ctx, ns); err != nil && !apierrors.IsAlreadyExists(err) {
+		return nil, err
+	}
+
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+	secret = &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: s.keyNamespace, Name: KeySecretName},
+		Type:       corev1.SecretTypeOpaque,
+		Data:       map[string][]byte{keyKey: key},
+	}
+	if err := s.client.Create(ctx, secret); apierrors.IsAlreadyExists(err) {
+		// Another agent instance created the key first.
+		return s.key(ctx)
+	} else if err != nil {
+		return nil, err
+	}
+
+	// Entries encrypted with a previous key, e.g. one stored in the cache
+	// namespace by older agents, can no longer be read.
+	if err := s.client.DeleteAllOf(ctx, &corev1.Secret{}, client.InNamespace(s.namespace), client.HasLabels{CacheLabel}); err != nil {
+		return nil, err
+	}
+	old := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: s.namespace, Name: KeySecretName}}
+	if err := s.client.Delete(ctx, old); err != nil && !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+	clear(s.deployed)
+	clear(s.pending)
+	return key, nil
+}
+
+// index records the deployment ID and pending status of entry.
+func (s *Store) index(entry *Entry) {
+	bd := entry.BundleDeployment
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deployed[key(bd.Namespace, bd.Name)] = bd.Spec.DeploymentID
+	if entry.PendingStatus != nil {
+		s.pending[key(bd.Namespace, bd.Name)] = true
+	} else {
+		delete(s.pending, key(bd.Namespace, bd.Name))
+	}
+}
+
+func key(namespace, name string) string {
+	return namespace + "/" + name
+}
+
+func secretName(namespace, name string) string {
+	h := sha256.Sum256([]byte(namespace + "/" + name))
+	return secretPrefix + hex.EncodeToString(h[:])[:32]
+}
+
+func additionalData(namespace, name string) []byte {
+	return []byte(namespace + "/" + name)
+}
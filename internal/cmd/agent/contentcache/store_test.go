@@ -0,0 +1,310 @@
+package contentcache
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/rancher/fleet/internal/manifest"
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+)
+
+const agentNamespace = "cattle-fleet-system"
+
+func newStore(t *testing.T, c client.Client, maxEntries int) *Store {
+	t.Helper()
+	store, err := New(c, agentNamespace, DefaultKeyNamespace, maxEntries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func newBD(name, deploymentID string) *fleet.BundleDeployment {
+	return &fleet.BundleDeployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "cluster-ns", Name: name},
+		Spec: fleet.BundleDeploymentSpec{
+			DeploymentID: deploymentID,
+			ValuesHash:   "hash",
+			Options: fleet.BundleDeploymentOptions{
+				Helm: &fleet.HelmOptions{Values: &fleet.GenericMap{Data: map[string]any{"password": "secret-value"}}},
+			},
+		},
+		Status: fleet.BundleDeploymentStatus{Release: "default/" + name + ":1"},
+	}
+}
+
+func newManifest() *manifest.Manifest {
+	return manifest.New([]fleet.BundleResource{{Name: "cm.yaml", Content: "kind: ConfigMap\ndata:\n  key: plaintext-content\n"}})
+}
+
+func newScheme(t *testing.T) *runtime.Scheme {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(fleet.AddToScheme(scheme))
+	return scheme
+}
+
+func TestPutGet(t *testing.T) {
+	ctx := context.Background()
+	c := fake.NewClientBuilder().WithScheme(newScheme(t)).Build()
+	store := newStore(t, c, DefaultMaxEntries)
+
+	if entry, err := store.Get(ctx, "cluster-ns", "app"); err != nil || entry != nil {
+		t.Fatalf("expected no entry, got %v, %v", entry, err)
+	}
+
+	if err := store.Put(ctx, newBD("app", "s-1:abc"), newManifest()); err != nil {
+		t.Fatal(err)
+	}
+
+	entry, err := store.Get(ctx, "cluster-ns", "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.ManifestID() != "s-1" {
+		t.Errorf("expected manifest ID s-1, got %q", entry.ManifestID())
+	}
+	if v := entry.BundleDeployment.Spec.Options.Helm.Values.Data["password"]; v != "secret-value" {
+		t.Errorf("expected cached options, got %v", v)
+	}
+	if id, err := entry.Manifest().ID(); err != nil || id != mustID(t, newManifest()) {
+		t.Errorf("expected cached manifest to have the same ID, got %q, %v", id, err)
+	}
+
+	if ok, err := store.Has(ctx, newBD("app", "s-1:abc")); err != nil || !ok {
+		t.Errorf("expected entry for the current deployment ID, got %v, %v", ok, err)
+	}
+	if ok, err := store.Has(ctx, newBD("app", "s-2:abc")); err != nil || ok {
+		t.Errorf("expected no entry for a new deployment ID, got %v, %v", ok, err)
+	}
+
+	// Options and manifests are encrypted at rest.
+	secrets := &corev1.SecretList{}
+	if err := c.List(ctx, secrets, client.InNamespace(agentNamespace), client.HasLabels{CacheLabel}); err != nil {
+		t.Fatal(err)
+	}
+	if len(secrets.Items) != 1 {
+		t.Fatalf("expected 1 secret, got %d", len(secrets.Items))
+	}
+	for _, plaintext := range []string{"secret-value", "plaintext-content"} {
+		if bytes.Contains(secrets.Items[0].Data[entryKey], []byte(plaintext)) {
+			t.Errorf("expected %q to be encrypted", plaintext)
+		}
+	}
+
+	// The key is not readable from the cache namespace.
+	if err := c.Get(ctx, client.ObjectKey{Namespace: agentNamespace, Name: KeySecretName}, &corev1.Secret{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expected no key secret in the cache namespace, got %v", err)
+	}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: DefaultKeyNamespace, Name: KeySecretName}, &corev1.Secret{}); err != nil {
+		t.Errorf("expected key secret in the key namespace, got %v", err)
+	}
+
+	// A new store instance reads the entry with the key from the cluster.
+	entry, err = newStore(t, c, DefaultMaxEntries).Get(ctx, "cluster-ns", "app")
+	if err != nil || entry == nil {
+		t.Fatalf("expected entry to be readable by a new store, got %v, %v", entry, err)
+	}
+}
+
+func TestNewRejectsSharedKeyNamespace(t *testing.T) {
+	c := fake.NewClientBuilder().WithScheme(newScheme(t)).Build()
+	if _, err := New(c, agentNamespace, agentNamespace, DefaultMaxEntries); err == nil {
+		t.Error("expected an error when the key is stored next to the cache")
+	}
+}
+
+func TestNewKeyDropsUnreadableEntries(t *testing.T) {
+	ctx := context.Background()
+	c := fake.NewClientBuilder().WithScheme(newScheme(t)).Build()
+	if err := newStore(t, c, DefaultMaxEntries).Put(ctx, newBD("app", "s-1:abc"), newManifest()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Older agents kept the key in the cache namespace.
+	key := &corev1.Secret{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: DefaultKeyNamespace, Name: KeySecretName}, key); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Delete(ctx, key); err != nil {
+		t.Fatal(err)
+	}
+	key.ObjectMeta = metav1.ObjectMeta{Namespace: agentNamespace, Name: KeySecretName}
+	if err := c.Create(ctx, key); err != nil {
+		t.Fatal(err)
+	}
+
+	store := newStore(t, c, DefaultMaxEntries)
+	if err := store.Put(ctx, newBD("other", "s-1:abc"), newManifest()); err != nil {
+		t.Fatal(err)
+	}
+	if entry, err := store.Get(ctx, "cluster-ns", "app"); err != nil || entry != nil {
+		t.Errorf("expected entry encrypted with the old key to be dropped, got %v, %v", entry, err)
+	}
+	if entry, err := store.Get(ctx, "cluster-ns", "other"); err != nil || entry == nil {
+		t.Errorf("expected new entry, got %v, %v", entry, err)
+	}
+	if err := c.Get(ctx, client.ObjectKeyFromObject(key), &corev1.Secret{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expected old key secret to be deleted, got %v", err)
+	}
+}
+
+func TestSwappedEntryIsRejected(t *testing.T) {
+	ctx := context.Background()
+	c := fake.NewClientBuilder().WithScheme(newScheme(t)).Build()
+	store := newStore(t, c, DefaultMaxEntries)
+
+	for _, name := range []string{"a", "b"} {
+		if err := store.Put(ctx, newBD(name, "s-1:abc"), newManifest()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	a := &corev1.Secret{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: agentNamespace, Name: secretName("cluster-ns", "a")}, a); err != nil {
+		t.Fatal(err)
+	}
+	b := &corev1.Secret{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: agentNamespace, Name: secretName("cluster-ns", "b")}, b); err != nil {
+		t.Fatal(err)
+	}
+	b.Data = a.Data
+	if err := c.Update(ctx, b); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := newStore(t, c, DefaultMaxEntries).Get(ctx, "cluster-ns", "b"); err == nil {
+		t.Error("expected an error reading an entry copied from another bundle deployment")
+	}
+}
+
+func TestEviction(t *testing.T) {
+	ctx := context.Background()
+	c := fake.NewClientBuilder().WithScheme(newScheme(t)).Build()
+	store := newStore(t, c, 2)
+
+	for i := range 3 {
+		if err := store.Put(ctx, newBD(fmt.Sprintf("app-%d", i), "s-1:abc"), newManifest()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := store.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if entry, err := store.Get(ctx, "cluster-ns", "app-0"); err != nil || entry != nil {
+		t.Errorf("expected the oldest entry to be evicted, got %v, %v", entry, err)
+	}
+}
+
+func TestEntryTooLarge(t *testing.T) {
+	ctx := context.Background()
+	c := fake.NewClientBuilder().WithScheme(newScheme(t)).Build()
+	store := newStore(t, c, DefaultMaxEntries)
+
+	// Random content does not compress well.
+	data := make([]byte, MaxEntrySize)
+	if _, err := rand.Read(data); err != nil {
+		t.Fatal(err)
+	}
+	m := manifest.New([]fleet.BundleResource{{Name: "big", Content: fmt.Sprintf("%x", data)}})
+
+	if err := store.Put(ctx, newBD("app", "s-1:abc"), m); !errors.Is(err, ErrEntryTooLarge) {
+		t.Errorf("expected %v, got %v", ErrEntryTooLarge, err)
+	}
+}
+
+func TestFlushStatus(t *testing.T) {
+	ctx := context.Background()
+	scheme := newScheme(t)
+	local := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+	upstreamBD := newBD("app", "s-1:abc")
+	upstreamBD.Status = fleet.BundleDeploymentStatus{}
+	changedBD := newBD("changed", "s-2:abc")
+	changedBD.Status = fleet.BundleDeploymentStatus{}
+	upstream := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(upstreamBD, changedBD).
+		WithStatusSubresource(&fleet.BundleDeployment{}).
+		Build()
+
+	store := newStore(t, local, DefaultMaxEntries)
+	for _, bd := range []*fleet.BundleDeployment{newBD("app", "s-1:abc"), newBD("changed", "s-1:abc"), newBD("deleted", "s-1:abc")} {
+		if err := store.Put(ctx, bd, newManifest()); err != nil {
+			t.Fatal(err)
+		}
+		bd.Status.AppliedDeploymentID = bd.Spec.DeploymentID
+		bd.Status.Ready = true
+		if err := store.QueueStatus(ctx, bd); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := store.FlushStatus(ctx, upstream); err != nil {
+		t.Fatal(err)
+	}
+
+	bd := &fleet.BundleDeployment{}
+	if err := upstream.Get(ctx, client.ObjectKey{Namespace: "cluster-ns", Name: "app"}, bd); err != nil {
+		t.Fatal(err)
+	}
+	if !bd.Status.Ready || bd.Status.AppliedDeploymentID != "s-1:abc" {
+		t.Errorf("expected queued status to be reported, got %+v", bd.Status)
+	}
+
+	if err := upstream.Get(ctx, client.ObjectKey{Namespace: "cluster-ns", Name: "changed"}, bd); err != nil {
+		t.Fatal(err)
+	}
+	if bd.Status.Ready {
+		t.Errorf("expected queued status for a previous deployment ID not to be reported, got %+v", bd.Status)
+	}
+
+	entries, err := store.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("expected the entry of the deleted bundledeployment to be removed, got %d entries", len(entries))
+	}
+	for _, entry := range entries {
+		if entry.PendingStatus != nil {
+			t.Errorf("expected no pending status for %s, got %+v", entry.BundleDeployment.Name, entry.PendingStatus)
+		}
+	}
+}
+
+func TestIsUnreachable(t *testing.T) {
+	if IsUnreachable(nil) || IsUnreachable(context.Canceled) {
+		t.Error("expected nil and canceled errors not to be unreachable")
+	}
+	if !IsUnreachable(errors.New("dial tcp: connection refused")) {
+		t.Error("expected a connection error to be unreachable")
+	}
+}
+
+func mustID(t *testing.T, m *manifest.Manifest) string {
+	t.Helper()
+	id, err := m.ID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
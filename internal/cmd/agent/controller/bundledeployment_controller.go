@@ -8,16 +8,20 @@ import (
 	"strings"
 	"time"
 
+	"github.com/rancher/fleet/internal/cmd/agent/contentcache"
 	"github.com/rancher/fleet/internal/cmd/agent/deployer"
 	"github.com/rancher/fleet/internal/cmd/agent/deployer/cleanup"
 	"github.com/rancher/fleet/internal/cmd/agent/deployer/driftdetect"
 	"github.com/rancher/fleet/internal/cmd/agent/deployer/monitor"
+	"github.com/rancher/fleet/internal/metrics"
 	"github.com/rancher/fleet/internal/namespaces"
+	"github.com/rancher/fleet/internal/tracing"
 	fleetv1 "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
 	"github.com/rancher/fleet/pkg/durations"
 	"github.com/rancher/fleet/pkg/helmvalues"
 
 	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel/attribute"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -51,6 +55,11 @@ type BundleDeploymentReconciler struct {
 	DriftDetect *driftdetect.DriftDetect
 	Cleanup     *cleanup.Cleanup
 
+	// ContentCache stores deployed manifests and options, and status updates
+	// which could not be reported while the upstream cluster is unreachable.
+	// It is optional.
+	ContentCache *contentcache.Store
+
 	// DriftChan is shared with the DriftReconciler. Sending a BundleDeployment
 	// here wakes up the drift controller so it can run drift correction. It is
 	// used to handle the case where CorrectDrift is enabled after the drift has
@@ -136,6 +145,12 @@ func (r *BundleDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Req
 		if err := r.Cleanup.CleanupReleases(ctx, key, nil); err != nil {
 			logger.Error(err, "Failed to clean up missing bundledeployment", "key", key)
 		}
+		metrics.DeleteClusterDeploymentLatency(req.Name, req.Namespace)
+		if r.ContentCache != nil {
+			if err := r.ContentCache.Delete(ctx, req.Namespace, req.Name); err != nil {
+				logger.Error(err, "Failed to delete content cache entry", "key", key)
+			}
+		}
 
 		return ctrl.Result{}, nil
 	} else if err != nil {
@@ -143,6 +158,13 @@ func (r *BundleDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Req
 	}
 	orig := bd.DeepCopy()
 
+	// Join the trace of the change which updated the bundledeployment, if any.
+	ctx, span := tracing.Start(tracing.Extract(ctx, bd), "agent.Reconcile",
+		attribute.String("bundledeployment", key),
+		attribute.String("deploymentID", bd.Spec.DeploymentID),
+	)
+	defer span.End()
+
 	if bd.Spec.Paused {
 		logger.V(1).Info("Bundle paused, clearing drift detection")
 		err := r.DriftDetect.Clear(req.String())
@@ -164,17 +186,7 @@ func (r *BundleDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Req
 
 	// load the bundledeployment options from the secret, if present
 	if bd.Spec.ValuesHash != "" {
-		secret := &corev1.Secret{}
-		if err := r.Reader.Get(ctx, client.ObjectKey{Namespace: bd.Namespace, Name: bd.Name}, secret); err != nil {
-			return ctrl.Result{}, err
-		}
-
-		h := helmvalues.HashOptions(secret.Data[helmvalues.ValuesKey], secret.Data[helmvalues.StagedValuesKey])
-		if h != bd.Spec.ValuesHash {
-			return ctrl.Result{}, fmt.Errorf("retrying, hash mismatch between secret and bundledeployment: actual %s != expected %s", h, bd.Spec.ValuesHash)
-		}
-
-		if err := helmvalues.SetOptions(bd, secret.Data); err != nil {
+		if err := r.loadOptions(ctx, bd); err != nil {
 			return ctrl.Result{}, err
 		}
 	}
@@ -231,10 +243,29 @@ func (r *BundleDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Req
 		return ctrl.Result{}, err
 	}
 
+	// Values from external stores are not part of the deployment ID,
+	// redeploy if they changed since the last deployment.
+	externalValues, err := r.Deployer.ExternalValues(ctx, bd)
+	if err != nil {
+		err = fmt.Errorf("failed to read external values: %w", err)
+		bd.Status = setCondition(bd.Status, err, monitor.Cond(fleetv1.BundleDeploymentConditionDeployed))
+		if statusErr := r.updateStatus(ctx, orig, bd); statusErr != nil {
+			return ctrl.Result{}, fmt.Errorf("%w; failed to update status: %w", err, statusErr)
+		}
+		return ctrl.Result{}, err
+	}
+	if externalValues.Hash != bd.Status.ExternalValuesHash {
+		logger.V(1).Info("External values changed, redeploying")
+		forceDeploy = true
+	}
+
 	var merr []error
 
 	// helm deploy the bundledeployment
-	if status, err := r.Deployer.DeployBundle(ctx, bd, forceDeploy); err != nil {
+	deployCtx, deploySpan := tracing.Start(ctx, "deployer.Deploy")
+	status, err := r.Deployer.DeployBundle(deployCtx, bd, forceDeploy, externalValues)
+	tracing.End(deploySpan, err)
+	if err != nil {
 		if handled, res, err := r.requeueIfNamespaceForbidden(ctx, orig, bd, status, err); handled {
 			return res, err
 		}
@@ -251,6 +282,7 @@ func (r *BundleDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Req
 	} else {
 		logger.V(1).Info("Bundle deployed", "status", status)
 		bd.Status = setCondition(status, nil, monitor.Cond(fleetv1.BundleDeploymentConditionDeployed))
+		bd.Status.ExternalValuesHash = externalValues.Hash
 	}
 
 	// retrieve the resources from the helm history.
@@ -267,7 +299,9 @@ func (r *BundleDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Req
 
 	if monitor.ShouldUpdateStatus(bd) {
 		// update the bundledeployment status and check if we deploy an agent
-		status, err := r.Monitor.UpdateStatus(ctx, bd, resources)
+		monitorCtx, monitorSpan := tracing.Start(ctx, "monitor.UpdateStatus")
+		status, err := r.Monitor.UpdateStatus(monitorCtx, bd, resources)
+		tracing.End(monitorSpan, err)
 		if err != nil {
 			logger.Error(err, "Cannot monitor deployed bundle")
 
@@ -349,9 +383,83 @@ func (r *BundleDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Req
 		merr = append(merr, fmt.Errorf("bundledeployment has been deleted: %w", err))
 	} else if err != nil {
 		merr = append(merr, fmt.Errorf("failed final update to bundledeployment status: %w", err))
+		queueStatus(ctx, r.ContentCache, bd, err)
+	} else {
+		observeDeploymentLatency(orig, bd, time.Now())
+		clearQueuedStatus(ctx, r.ContentCache, bd)
+	}
+
+	if err := errutil.NewAggregate(merr); err != nil {
+		return ctrl.Result{}, err
 	}
 
-	return ctrl.Result{}, errutil.NewAggregate(merr)
+	// read external values again after their refresh interval, to redeploy
+	// if they changed
+	return ctrl.Result{RequeueAfter: externalValues.Refresh}, nil
+}
+
+// loadOptions loads the options of bd from its options secret. If the
+// upstream cluster is unreachable, the options of the cached deployment are
+// used, as long as they have the same hash.
+func (r *BundleDeploymentReconciler) loadOptions(ctx context.Context, bd *fleetv1.BundleDeployment) error {
+	secret := &corev1.Secret{}
+	if err := r.Reader.Get(ctx, client.ObjectKey{Namespace: bd.Namespace, Name: bd.Name}, secret); err != nil {
+		if r.ContentCache == nil || !contentcache.IsUnreachable(err) {
+			return err
+		}
+		entry, cacheErr := r.ContentCache.Get(ctx, bd.Namespace, bd.Name)
+		if cacheErr != nil || entry == nil || entry.BundleDeployment.Spec.ValuesHash != bd.Spec.ValuesHash {
+			return err
+		}
+		log.FromContext(ctx).Info("Failed to load options secret, using cached options", "error", err)
+		bd.Spec.Options = entry.BundleDeployment.Spec.Options
+		bd.Spec.StagedOptions = entry.BundleDeployment.Spec.StagedOptions
+		return nil
+	}
+
+	h := helmvalues.HashOptions(secret.Data[helmvalues.ValuesKey], secret.Data[helmvalues.StagedValuesKey])
+	if h != bd.Spec.ValuesHash {
+		return fmt.Errorf("retrying, hash mismatch between secret and bundledeployment: actual %s != expected %s", h, bd.Spec.ValuesHash)
+	}
+
+	return helmvalues.SetOptions(bd, secret.Data)
+}
+
+// queueStatus stores the status of bd in cache, if it could not be reported
+// because the upstream cluster is unreachable.
+func queueStatus(ctx context.Context, cache *contentcache.Store, bd *fleetv1.BundleDeployment, err error) {
+	if cache == nil || !contentcache.IsUnreachable(err) {
+		return
+	}
+	if err := cache.QueueStatus(ctx, bd); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to queue bundledeployment status")
+	}
+}
+
+// clearQueuedStatus removes a queued status of bd from cache, after a newer
+// status was reported.
+func clearQueuedStatus(ctx context.Context, cache *contentcache.Store, bd *fleetv1.BundleDeployment) {
+	if cache == nil {
+		return
+	}
+	if err := cache.ClearStatus(ctx, bd.Namespace, bd.Name); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to clear queued bundledeployment status")
+	}
+}
+
+// observeDeploymentLatency records the deployment stages reached by bd since
+// its previous status orig.
+func observeDeploymentLatency(orig, bd *fleetv1.BundleDeployment, now time.Time) {
+	if bd.Status.AppliedDeploymentID != bd.Spec.DeploymentID {
+		return
+	}
+	wasApplied := orig.Status.AppliedDeploymentID == bd.Spec.DeploymentID
+	if !wasApplied {
+		metrics.ObserveClusterDeploymentLatency(bd, metrics.StageApplied, now)
+	}
+	if bd.Status.Ready && (!wasApplied || !orig.Status.Ready) {
+		metrics.ObserveClusterDeploymentLatency(bd, metrics.StageReady, now)
+	}
 }
 
 // copyResourcesFromUpstream copies bd's DownstreamResources, from the downstream cluster's namespace on the management
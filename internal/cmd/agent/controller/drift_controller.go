@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/rancher/fleet/internal/cmd/agent/contentcache"
 	"github.com/rancher/fleet/internal/cmd/agent/deployer"
 	"github.com/rancher/fleet/internal/cmd/agent/deployer/driftdetect"
 	"github.com/rancher/fleet/internal/cmd/agent/deployer/monitor"
@@ -35,6 +36,10 @@ type DriftReconciler struct {
 	Monitor     *monitor.Monitor
 	DriftDetect *driftdetect.DriftDetect
 
+	// ContentCache queues status updates while the upstream cluster is
+	// unreachable. It is optional.
+	ContentCache *contentcache.Store
+
 	DriftChan chan event.TypedGenericEvent[*fleetv1.BundleDeployment]
 
 	Workers int
@@ -133,7 +138,10 @@ func (r *DriftReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 			merr = append(merr, fmt.Errorf("bundledeployment has been deleted: %w", err))
 		} else {
 			merr = append(merr, fmt.Errorf("failed final update to bundledeployment status: %w", err))
+			queueStatus(ctx, r.ContentCache, bd, err)
 		}
+	} else {
+		clearQueuedStatus(ctx, r.ContentCache, bd)
 	}
 
 	return ctrl.Result{}, errutil.NewAggregate(merr)
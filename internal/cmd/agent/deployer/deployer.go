@@ -9,12 +9,16 @@ import (
 	"slices"
 	"strings"
 
+	"github.com/Masterminds/semver/v3"
 	"github.com/rancher/fleet/internal/bundlereader"
+	"github.com/rancher/fleet/internal/cmd/agent/contentcache"
 	"github.com/rancher/fleet/internal/cmd/controller/summary"
 	"github.com/rancher/fleet/internal/helmdeployer"
 	"github.com/rancher/fleet/internal/manifest"
 	"github.com/rancher/fleet/internal/ocistorage"
+	"github.com/rancher/fleet/internal/resourcepolicy"
 	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	fleetsummary "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1/summary"
 
 	"github.com/rancher/wrangler/v3/pkg/condition"
 
@@ -45,10 +49,20 @@ var deployErrPattern = regexp.MustCompile(
 
 type NotReadyDependenciesError struct {
 	Pending []string
+	// Reasons explain why the pending dependencies are blocking, in the
+	// same order.
+	Reasons []string
 }
 
 func (e *NotReadyDependenciesError) Error() string {
-	return fmt.Sprintf("dependent bundle(s) are not ready: %v", e.Pending)
+	blocking := make([]string, 0, len(e.Pending))
+	for i, name := range e.Pending {
+		if i < len(e.Reasons) {
+			name += ": " + e.Reasons[i]
+		}
+		blocking = append(blocking, name)
+	}
+	return "dependent bundle(s) are not ready: " + strings.Join(blocking, "; ")
 }
 
 // NamespaceForbiddenError indicates the deployment's service account is not
@@ -73,6 +87,7 @@ type Deployer struct {
 	upstreamClient client.Reader
 	lookup         Lookup
 	helm           *helmdeployer.Helm
+	cache          *contentcache.Store
 }
 
 type Lookup interface {
@@ -88,6 +103,22 @@ func New(localClient client.Client, upstreamClient client.Reader, lookup Lookup,
 	}
 }
 
+// SetContentCache enables caching deployed manifests in cache. Cached
+// manifests are used when they cannot be loaded from the upstream cluster.
+func (d *Deployer) SetContentCache(cache *contentcache.Store) {
+	d.cache = cache
+}
+
+// ExternalValues returns the values bd reads from external stores, like
+// Vault, with their hash and the interval after which they should be read
+// again. They are passed to DeployBundle, to deploy the hashed values.
+func (d *Deployer) ExternalValues(ctx context.Context, bd *fleet.BundleDeployment) (*helmdeployer.ExternalValues, error) {
+	if d.helm == nil {
+		return &helmdeployer.ExternalValues{}, nil
+	}
+	return d.helm.ExternalValues(ctx, bd.Spec.Options)
+}
+
 func (d *Deployer) Resources(name string, releaseID string) (*helmdeployer.Resources, error) {
 	return d.helm.Resources(name, releaseID)
 }
@@ -100,10 +131,12 @@ func (d *Deployer) RemoveExternalChanges(ctx context.Context, bd *fleet.BundleDe
 // mutate bd, instead it returns the modified status
 // If force is true, bd will be upgraded even if its contents have not changed; this is useful for
 // applying changes coming from external resources, such as those referenced through valuesFrom.
+// external are the values returned by ExternalValues, they are read again if nil.
 func (d *Deployer) DeployBundle(
 	ctx context.Context,
 	bd *fleet.BundleDeployment,
 	force bool,
+	external *helmdeployer.ExternalValues,
 ) (fleet.BundleDeploymentStatus, error) {
 	status := bd.Status
 	logger := log.FromContext(ctx).WithName("deploy-bundle").WithValues("deploymentID", bd.Spec.DeploymentID, "appliedDeploymentID", status.AppliedDeploymentID)
@@ -113,7 +146,7 @@ func (d *Deployer) DeployBundle(
 		return status, err
 	}
 
-	releaseID, err := d.helmdeploy(ctx, logger, bd, force)
+	releaseID, err := d.helmdeploy(ctx, logger, bd, force, external)
 
 	if err != nil {
 		// When an error from DeployBundle is returned it causes DeployBundle
@@ -135,6 +168,14 @@ func (d *Deployer) DeployBundle(
 		}
 		return status, err
 	}
+	if releaseID != status.Release || status.ChartVersion == "" {
+		// The chart version is only reported for dependencies with version constraints.
+		if version, err := d.helm.ChartVersion(bd.Name, releaseID); err != nil {
+			logger.V(1).Info("Failed to read chart version of release", "release", releaseID, "error", err)
+		} else {
+			status.ChartVersion = version
+		}
+	}
 	status.Release = releaseID
 	status.AppliedDeploymentID = bd.Spec.DeploymentID
 
@@ -161,15 +202,39 @@ func (d *Deployer) DeployBundle(
 // This loads the manifest and the contents from the upstream cluster.
 // If force is true, checks on whether the bundle deployment exists will be skipped, leading to the bundle deployment
 // being updated even if its deployment ID has not changed.
-func (d *Deployer) helmdeploy(ctx context.Context, logger logr.Logger, bd *fleet.BundleDeployment, force bool) (string, error) {
+func (d *Deployer) helmdeploy(ctx context.Context, logger logr.Logger, bd *fleet.BundleDeployment, force bool, external *helmdeployer.ExternalValues) (string, error) {
 	if !force && bd.Spec.DeploymentID == bd.Status.AppliedDeploymentID {
 		if ok, err := d.helm.EnsureInstalled(bd.Name, bd.Status.Release); err != nil {
 			return "", err
 		} else if ok {
+			d.ensureCached(ctx, logger, bd)
 			return bd.Status.Release, nil
 		}
 	}
 
+	m, err := d.manifest(ctx, logger, bd)
+	if err != nil {
+		return "", err
+	}
+
+	m.Commit = bd.Labels[fleet.CommitLabel]
+	release, err := d.helm.Deploy(ctx, bd.Name, m, bd.Spec.Options, external)
+	if err != nil {
+		return "", err
+	}
+
+	resourceID := helmdeployer.ReleaseToResourceID(release)
+
+	logger.Info("Deployed bundle", "release", resourceID, "DeploymentID", bd.Spec.DeploymentID)
+
+	d.cacheManifest(ctx, logger, bd, m, resourceID)
+
+	return resourceID, nil
+}
+
+// manifest loads the manifest of bd from the upstream cluster, or an OCI
+// registry. If that fails, a cached manifest with the same ID is used.
+func (d *Deployer) manifest(ctx context.Context, logger logr.Logger, bd *fleet.BundleDeployment) (*manifest.Manifest, error) {
 	// manifestID is used for manifest/OCI lookups.
 	// DeploymentID format is "manifestID:optionsHash".
 	// When only options change (e.g., adding comparePatches for drift acceptance),
@@ -180,55 +245,88 @@ func (d *Deployer) helmdeploy(ctx context.Context, logger logr.Logger, bd *fleet
 		manifestID = specManifestID
 	}
 
-	var (
-		m   *manifest.Manifest
-		err error
-	)
+	m, err := d.upstreamManifest(ctx, bd, manifestID)
+	if err == nil || d.cache == nil {
+		return m, err
+	}
+
+	entry, cacheErr := d.cache.Get(ctx, bd.Namespace, bd.Name)
+	if cacheErr != nil || entry == nil || entry.ManifestID() != manifestID {
+		return nil, err
+	}
+	logger.Info("Failed to load manifest, using cached manifest", "manifestID", manifestID, "error", err)
+	return entry.Manifest(), nil
+}
+
+func (d *Deployer) upstreamManifest(ctx context.Context, bd *fleet.BundleDeployment, manifestID string) (*manifest.Manifest, error) {
 	switch {
 	case bd.Spec.OCIContents:
 		oci := ocistorage.NewOCIWrapper()
 		secretID := client.ObjectKey{Name: manifestID, Namespace: bd.Namespace}
 		opts, err := ocistorage.ReadOptsFromSecret(ctx, d.upstreamClient, secretID)
 		if err != nil {
-			return "", err
+			return nil, err
 		}
-		m, err = oci.PullManifest(ctx, opts, manifestID)
+		m, err := oci.PullManifest(ctx, opts, manifestID)
 		if err != nil {
-			return "", err
+			return nil, err
 		}
 		// Verify that the calculated manifestID for the manifest
 		// we just downloaded matches the expected one.
 		// Otherwise, the manifest will be considered incorrect or corrupted.
 		actualID, err := m.ID()
 		if err != nil {
-			return "", err
+			return nil, err
 		}
 		if actualID != manifestID {
-			return "", fmt.Errorf("invalid or corrupt manifest. Expecting id: %q, got %q", manifestID, actualID)
+			return nil, fmt.Errorf("invalid or corrupt manifest. Expecting id: %q, got %q", manifestID, actualID)
 		}
+		return m, nil
 	case bd.Spec.HelmChartOptions != nil:
-		m, err = bundlereader.GetManifestFromHelmChart(ctx, d.upstreamClient, bd)
-		if err != nil {
-			return "", err
+		m, err := bundlereader.GetManifestFromHelmChart(ctx, d.upstreamClient, bd)
+		if err != nil || manifestID == "" {
+			return m, err
 		}
-	default:
-		m, err = d.lookup.Get(ctx, d.upstreamClient, manifestID)
+		// the files of the HelmOp's sources are stored in a content resource
+		sources, err := d.lookup.Get(ctx, d.upstreamClient, manifestID)
 		if err != nil {
-			return "", err
+			return nil, err
 		}
+		return manifest.New(append(m.Resources, sources.Resources...)), nil
+	default:
+		return d.lookup.Get(ctx, d.upstreamClient, manifestID)
 	}
+}
 
-	m.Commit = bd.Labels[fleet.CommitLabel]
-	release, err := d.helm.Deploy(ctx, bd.Name, m, bd.Spec.Options)
+// ensureCached caches the manifest of an already installed bundle
+// deployment, e.g. one installed before the content cache was enabled.
+func (d *Deployer) ensureCached(ctx context.Context, logger logr.Logger, bd *fleet.BundleDeployment) {
+	if d.cache == nil {
+		return
+	}
+	if ok, err := d.cache.Has(ctx, bd); err != nil || ok {
+		return
+	}
+	m, err := d.manifest(ctx, logger, bd)
 	if err != nil {
-		return "", err
+		logger.V(1).Info("Failed to load manifest for the content cache", "error", err)
+		return
 	}
+	d.cacheManifest(ctx, logger, bd, m, bd.Status.Release)
+}
 
-	resourceID := helmdeployer.ReleaseToResourceID(release)
-
-	logger.Info("Deployed bundle", "release", resourceID, "DeploymentID", bd.Spec.DeploymentID)
-
-	return resourceID, nil
+// cacheManifest stores the deployed manifest of bd in the content cache.
+// Failing to do so does not fail the deployment.
+func (d *Deployer) cacheManifest(ctx context.Context, logger logr.Logger, bd *fleet.BundleDeployment, m *manifest.Manifest, releaseID string) {
+	if d.cache == nil {
+		return
+	}
+	cached := bd.DeepCopy()
+	cached.Status.Release = releaseID
+	cached.Status.AppliedDeploymentID = bd.Spec.DeploymentID
+	if err := d.cache.Put(ctx, cached, m); err != nil {
+		logger.Error(err, "Failed to cache deployed manifest")
+	}
 }
 
 // setNamespaceLabelsAndAnnotations updates the namespace for the release, applying all labels and annotations to that namespace as configured in the bundle spec.
@@ -382,6 +480,13 @@ func deployErrToStatus(err error, status fleet.BundleDeploymentStatus) (bool, fl
 		return false, status
 	}
 
+	// Resources violating the resource rules of a Policy are reported
+	// individually. Redeploying cannot fix them, only a new deployment can.
+	var violationErr *resourcepolicy.ViolationError
+	if errors.As(err, &violationErr) {
+		return true, violationsToStatus(violationErr, status)
+	}
+
 	msg := err.Error()
 
 	// The following error conditions are turned into a status
@@ -422,6 +527,47 @@ func deployErrToStatus(err error, status fleet.BundleDeploymentStatus) (bool, fl
 	return false, status
 }
 
+// violationsMaxLength limits the number of resources listed in the status,
+// like the monitor does for non-ready resources.
+const violationsMaxLength = 10
+
+// violationsToStatus blocks the bundle deployment and lists every violating
+// resource, with the messages of the rules it violates, in NonReadyStatus.
+func violationsToStatus(err *resourcepolicy.ViolationError, status fleet.BundleDeploymentStatus) fleet.BundleDeploymentStatus {
+	status.Ready = false
+	status.NonModified = true
+	status.IncompleteState = false
+	status.NonReadyStatus = nil
+
+	index := map[fleet.ResourceKey]int{}
+	for _, v := range err.Violations {
+		key := fleet.ResourceKey{Kind: v.Kind, APIVersion: v.APIVersion, Namespace: v.Namespace, Name: v.Name}
+		i, ok := index[key]
+		if !ok {
+			if len(status.NonReadyStatus) == violationsMaxLength {
+				status.IncompleteState = true
+				continue
+			}
+			i = len(status.NonReadyStatus)
+			index[key] = i
+			status.NonReadyStatus = append(status.NonReadyStatus, fleet.NonReadyStatus{
+				Kind:       v.Kind,
+				APIVersion: v.APIVersion,
+				Namespace:  v.Namespace,
+				Name:       v.Name,
+				Summary:    fleetsummary.Summary{State: "policy-violation", Error: true},
+			})
+		}
+		msg := fmt.Sprintf("violates rule %q: %s", v.Rule, v.Message)
+		status.NonReadyStatus[i].Summary.Message = append(status.NonReadyStatus[i].Summary.Message, msg)
+	}
+
+	condition.Cond(fleet.BundleDeploymentConditionReady).SetError(&status, "", fmt.Errorf("not ready: %w", err))
+	condition.Cond(fleet.BundleDeploymentConditionInstalled).SetError(&status, "", fmt.Errorf("not installed: %w", err))
+
+	return status
+}
+
 // forbiddenToStatus records a namespace permission error as a status condition,
 // mirroring deployErrToStatus. Such errors occur when the deployment's service
 // account is not allowed to mutate the target namespace. The condition surfaces
@@ -445,14 +591,15 @@ func forbiddenToStatus(err error, status fleet.BundleDeploymentStatus) (bool, fl
 }
 
 func (d *Deployer) checkDependency(ctx context.Context, bd *fleet.BundleDeployment) error {
-	var depBundleList []string
+	var depBundleList, reasons []string
 	bundleNamespace := bd.Labels[fleet.BundleNamespaceLabel]
 	for _, depend := range bd.Spec.DependsOn {
 		// skip empty BundleRef definitions. Possible if there is a typo in the yaml
 		if depend.Name != "" || depend.Selector != nil {
 			ls := &metav1.LabelSelector{}
 			if depend.Selector != nil {
-				ls = depend.Selector
+				// AddLabelToSelector modifies the selector, don't change the spec
+				ls = depend.Selector.DeepCopy()
 			}
 
 			// depend.Name is just a shortcut for matchLabels: {bundle-name: name}
@@ -461,6 +608,13 @@ func (d *Deployer) checkDependency(ctx context.Context, bd *fleet.BundleDeployme
 				ls = metav1.AddLabelToSelector(ls, fleet.BundleNamespaceLabel, bundleNamespace)
 			}
 
+			// the controller only allows other namespaces if permitted by Policy
+			namespace := bundleNamespace
+			if depend.Namespace != "" {
+				ls = metav1.AddLabelToSelector(ls, fleet.BundleNamespaceLabel, depend.Namespace)
+				namespace = depend.Namespace
+			}
+
 			selector, err := metav1.LabelSelectorAsSelector(ls)
 			if err != nil {
 				return err
@@ -473,25 +627,71 @@ func (d *Deployer) checkDependency(ctx context.Context, bd *fleet.BundleDeployme
 			}
 
 			if len(bds.Items) == 0 {
-				return fmt.Errorf("list bundledeployments: no bundles matching labels %s in namespace %s", selector.String(), bundleNamespace)
+				return fmt.Errorf("list bundledeployments: no bundles matching labels %s in namespace %s", selector.String(), namespace)
 			}
 
 			for _, depBundle := range bds.Items {
-				if !isDependencyReady(depBundle, depend.AcceptedStates) {
+				if reason := dependencyBlocker(bd, depBundle, depend); reason != "" {
 					depBundleList = append(depBundleList, depBundle.Name)
+					reasons = append(reasons, reason)
 				}
-
 			}
 		}
 	}
 
 	if len(depBundleList) != 0 {
-		return &NotReadyDependenciesError{Pending: depBundleList}
+		return &NotReadyDependenciesError{Pending: depBundleList, Reasons: reasons}
 	}
 
 	return nil
 }
 
+// dependencyBlocker returns why the dependency's BundleDeployment blocks the
+// deployment of the dependent bundle, or an empty string if it doesn't.
+func dependencyBlocker(bd *fleet.BundleDeployment, depBundle fleet.BundleDeployment, depend fleet.BundleRef) string {
+	if state := summary.GetDeploymentState(&depBundle); !isStateAccepted(state, depend.AcceptedStates) {
+		return fmt.Sprintf("state is %s", state)
+	}
+	if !outputsReported(depBundle) {
+		return "outputs are not reported yet"
+	}
+	if !outputsRendered(bd, depBundle) {
+		return "outputs are not rendered yet"
+	}
+	if depend.Version == "" && depend.Commit == "" {
+		return ""
+	}
+
+	// version and commit are checked against what is deployed, not what is
+	// about to be deployed
+	if depBundle.Status.AppliedDeploymentID != depBundle.Spec.DeploymentID {
+		return "not deployed yet"
+	}
+	if depend.Version != "" {
+		constraint, err := semver.NewConstraint(depend.Version)
+		if err != nil {
+			return fmt.Sprintf("invalid version constraint %q: %v", depend.Version, err)
+		}
+		if depBundle.Status.ChartVersion == "" {
+			return "chart version is not reported yet"
+		}
+		version, err := semver.NewVersion(depBundle.Status.ChartVersion)
+		if err != nil {
+			return fmt.Sprintf("chart version %q is not a semantic version", depBundle.Status.ChartVersion)
+		}
+		if !constraint.Check(version) {
+			return fmt.Sprintf("chart version %s does not satisfy %q", depBundle.Status.ChartVersion, depend.Version)
+		}
+	}
+	if depend.Commit != "" {
+		commit := depBundle.Labels[fleet.CommitLabel]
+		if commit == "" || !strings.HasPrefix(commit, depend.Commit) {
+			return fmt.Sprintf("deployed commit %q does not match %q", commit, depend.Commit)
+		}
+	}
+	return ""
+}
+
 // isStateAccepted checks if currentState is in acceptedStates.
 // If acceptedStates is empty or nil, only Ready is accepted (default behavior).
 func isStateAccepted(currentState fleet.BundleState, acceptedStates []fleet.BundleState) bool {
@@ -501,9 +701,26 @@ func isStateAccepted(currentState fleet.BundleState, acceptedStates []fleet.Bund
 	return slices.Contains(acceptedStates, currentState)
 }
 
-// isDependencyReady checks if a BundleDeployment dependency is in an acceptable state.
-// acceptedStates is a list of states that are considered acceptable for this dependency.
-// If acceptedStates is empty or nil, only the "Ready" state is accepted (default behavior).
-func isDependencyReady(depBundle fleet.BundleDeployment, acceptedStates []fleet.BundleState) bool {
-	return isStateAccepted(summary.GetDeploymentState(&depBundle), acceptedStates)
+// outputsReported checks if all outputs declared by a dependency have been
+// reported, so dependent bundles are not deployed with missing values.
+func outputsReported(depBundle fleet.BundleDeployment) bool {
+	for _, o := range depBundle.Spec.Options.Outputs {
+		if _, ok := depBundle.Status.Outputs[o.Name]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// outputsRendered checks if the options of bd were rendered with the
+// outputs reported by the dependency, so the dependent bundle is not
+// deployed with values rendered before the outputs were available.
+func outputsRendered(bd *fleet.BundleDeployment, depBundle fleet.BundleDeployment) bool {
+	rendered := bd.Spec.Options.DependencyOutputs[depBundle.Labels[fleet.BundleLabel]]
+	for _, o := range depBundle.Spec.Options.Outputs {
+		if v, ok := rendered[o.Name]; !ok || v != depBundle.Status.Outputs[o.Name] {
+			return false
+		}
+	}
+	return true
 }
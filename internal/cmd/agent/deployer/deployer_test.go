@@ -4,9 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"reflect"
 	"testing"
 
+	"github.com/rancher/fleet/internal/resourcepolicy"
 	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	fleetsummary "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1/summary"
+
+	"github.com/rancher/wrangler/v3/pkg/condition"
 
 	"github.com/go-logr/logr"
 
@@ -504,6 +509,103 @@ func TestIsStateAccepted(t *testing.T) {
 	}
 }
 
+func TestOutputsReported(t *testing.T) {
+	bd := fleet.BundleDeployment{}
+	if !outputsReported(bd) {
+		t.Error("expected dependency without outputs to be reported")
+	}
+
+	bd.Spec.Options.Outputs = []fleet.BundleOutput{{Name: "ip"}, {Name: "port"}}
+	bd.Status.Outputs = map[string]string{"ip": "10.0.0.1"}
+	if outputsReported(bd) {
+		t.Error("expected dependency with a missing output not to be reported")
+	}
+
+	bd.Status.Outputs["port"] = "443"
+	if !outputsReported(bd) {
+		t.Error("expected dependency with all outputs to be reported")
+	}
+}
+
+func TestOutputsRendered(t *testing.T) {
+	dep := fleet.BundleDeployment{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{fleet.BundleLabel: "infra"}}}
+	bd := &fleet.BundleDeployment{}
+	if !outputsRendered(bd, dep) {
+		t.Error("expected dependency without outputs to be rendered")
+	}
+
+	dep.Spec.Options.Outputs = []fleet.BundleOutput{{Name: "ip"}}
+	dep.Status.Outputs = map[string]string{"ip": "10.0.0.1"}
+	if outputsRendered(bd, dep) {
+		t.Error("expected options rendered before the outputs were reported not to be rendered")
+	}
+
+	bd.Spec.Options.DependencyOutputs = map[string]map[string]string{"infra": {"ip": "10.0.0.2"}}
+	if outputsRendered(bd, dep) {
+		t.Error("expected options rendered with a previous output not to be rendered")
+	}
+
+	bd.Spec.Options.DependencyOutputs["infra"]["ip"] = "10.0.0.1"
+	if !outputsRendered(bd, dep) {
+		t.Error("expected options rendered with the reported outputs to be rendered")
+	}
+	if got := dependencyBlocker(&fleet.BundleDeployment{}, dep, fleet.BundleRef{AcceptedStates: []fleet.BundleState{fleet.NotReady}}); got != "outputs are not rendered yet" {
+		t.Errorf("dependencyBlocker() = %q, want outputs are not rendered yet", got)
+	}
+}
+
+func TestDependencyBlocker(t *testing.T) {
+	ready := func(chartVersion, commit string) fleet.BundleDeployment {
+		return fleet.BundleDeployment{
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{fleet.CommitLabel: commit}},
+			Spec:       fleet.BundleDeploymentSpec{DeploymentID: "id", StagedDeploymentID: "id"},
+			Status: fleet.BundleDeploymentStatus{
+				AppliedDeploymentID: "id",
+				Ready:               true,
+				NonModified:         true,
+				ChartVersion:        chartVersion,
+			},
+		}
+	}
+	notReady := ready("1.14.0", "")
+	notReady.Status.Ready = false
+
+	tests := []struct {
+		name   string
+		dep    fleet.BundleDeployment
+		ref    fleet.BundleRef
+		reason string
+	}{
+		{"ready", ready("1.14.0", "abc"), fleet.BundleRef{Name: "dep"}, ""},
+		{"not ready", notReady, fleet.BundleRef{Name: "dep"}, "state is NotReady"},
+		{"version satisfied", ready("1.14.2", ""), fleet.BundleRef{Version: ">= 1.14"}, ""},
+		{"version too old", ready("1.13.0", ""), fleet.BundleRef{Version: ">= 1.14"}, `chart version 1.13.0 does not satisfy ">= 1.14"`},
+		{"version not reported", ready("", ""), fleet.BundleRef{Version: ">= 1.14"}, "chart version is not reported yet"},
+		{"invalid constraint", ready("1.14.0", ""), fleet.BundleRef{Version: "latest"}, `invalid version constraint "latest": improper constraint: "latest"`},
+		{"commit prefix", ready("", "abcdef123"), fleet.BundleRef{Commit: "abcdef"}, ""},
+		{"commit mismatch", ready("", "abcdef123"), fleet.BundleRef{Commit: "123"}, `deployed commit "abcdef123" does not match "123"`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := dependencyBlocker(&fleet.BundleDeployment{}, tc.dep, tc.ref); got != tc.reason {
+				t.Errorf("dependencyBlocker() = %q, want %q", got, tc.reason)
+			}
+		})
+	}
+}
+
+func TestNotReadyDependenciesError(t *testing.T) {
+	err := &NotReadyDependenciesError{
+		Pending: []string{"cert-manager", "db"},
+		Reasons: []string{`chart version 1.13.0 does not satisfy ">= 1.14"`, "state is NotReady"},
+	}
+	want := `dependent bundle(s) are not ready: cert-manager: chart version 1.13.0 does not satisfy ">= 1.14"; db: state is NotReady`
+	if err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
+
 func TestDeployErrToStatus(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -537,3 +639,45 @@ func TestDeployErrToStatus(t *testing.T) {
 		})
 	}
 }
+
+func TestDeployErrToStatus_PolicyViolations(t *testing.T) {
+	violationErr := &resourcepolicy.ViolationError{Violations: []resourcepolicy.Violation{
+		{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "ns", Name: "web", Rule: "no-privileged", Message: "privileged containers are not allowed"},
+		{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "ns", Name: "web", Rule: "team-label", Message: "team label is required"},
+		{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole", Name: "admin", Rule: "namespaced", Message: "cluster-scoped resources are not allowed"},
+	}}
+	err := fmt.Errorf("error while running post render on files: %w", violationErr)
+
+	status := fleet.BundleDeploymentStatus{Ready: true, NonReadyStatus: []fleet.NonReadyStatus{{Kind: "Pod", Name: "old"}}}
+	handled, got := deployErrToStatus(err, status)
+	if !handled {
+		t.Fatal("expected policy violations to be turned into a status")
+	}
+	if got.Ready {
+		t.Error("expected status to be not ready")
+	}
+
+	want := []fleet.NonReadyStatus{
+		{
+			APIVersion: "apps/v1", Kind: "Deployment", Namespace: "ns", Name: "web",
+			Summary: fleetsummary.Summary{State: "policy-violation", Error: true, Message: []string{
+				`violates rule "no-privileged": privileged containers are not allowed`,
+				`violates rule "team-label": team label is required`,
+			}},
+		},
+		{
+			APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole", Name: "admin",
+			Summary: fleetsummary.Summary{State: "policy-violation", Error: true, Message: []string{
+				`violates rule "namespaced": cluster-scoped resources are not allowed`,
+			}},
+		},
+	}
+	if !reflect.DeepEqual(got.NonReadyStatus, want) {
+		t.Errorf("expected non-ready status %+v, got %+v", want, got.NonReadyStatus)
+	}
+
+	installed := fleet.BundleDeployment{Status: got}
+	if !condition.Cond(fleet.BundleDeploymentConditionInstalled).IsFalse(&installed) {
+		t.Error("expected Installed condition to be false, so the monitor does not overwrite the status")
+	}
+}
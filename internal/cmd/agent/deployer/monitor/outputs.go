@@ -0,0 +1,102 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/jsonpath"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+)
+
+// readOutputs evaluates the outputs against the live resources. Outputs
+// which can't be read, e.g. because the resource or the field doesn't exist
+// yet, are left out, so consumers don't deploy with an empty value.
+// Only objs, the resources of the bundle's release, in defaultNamespace can
+// be read, except for secrets.
+func readOutputs(ctx context.Context, c client.Client, outputs []fleet.BundleOutput, objs []runtime.Object, defaultNamespace string) map[string]string {
+	logger := log.FromContext(ctx)
+
+	var result map[string]string
+	for _, o := range outputs {
+		value, err := readOutput(ctx, c, o, objs, defaultNamespace)
+		if err != nil {
+			logger.V(1).Info("Cannot read output", "output", o.Name, "error", err.Error())
+			continue
+		}
+		if result == nil {
+			result = map[string]string{}
+		}
+		result[o.Name] = value
+	}
+	return result
+}
+
+func readOutput(ctx context.Context, c client.Client, o fleet.BundleOutput, objs []runtime.Object, defaultNamespace string) (string, error) {
+	jp := jsonpath.New(o.Name)
+	if err := jp.Parse(o.JSONPath); err != nil {
+		return "", fmt.Errorf("invalid jsonPath %q: %w", o.JSONPath, err)
+	}
+
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(o.APIVersion)
+	obj.SetKind(o.Kind)
+
+	gvk := obj.GroupVersionKind()
+	if gvk.GroupKind() == (schema.GroupKind{Kind: "Secret"}) {
+		return "", errors.New("secrets can't be used as outputs")
+	}
+	if !isNamespaced(c.RESTMapper(), gvk) {
+		return "", fmt.Errorf("cluster-scoped %s can't be used as outputs", o.Kind)
+	}
+	ns := o.Namespace
+	if ns == "" {
+		ns = defaultNamespace
+	}
+	if ns != defaultNamespace {
+		return "", fmt.Errorf("resource must be in namespace %s", defaultNamespace)
+	}
+	if !inRelease(objs, gvk.GroupKind(), ns, o.ResourceName, defaultNamespace) {
+		return "", fmt.Errorf("%s %s/%s is not a resource of the bundle", o.Kind, ns, o.ResourceName)
+	}
+
+	if err := c.Get(ctx, client.ObjectKey{Namespace: ns, Name: o.ResourceName}, obj); err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := jp.Execute(&buf, obj.Object); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// inRelease returns true if objs contains the namespaced resource. Objects
+// without a namespace are deployed to defaultNamespace.
+func inRelease(objs []runtime.Object, gk schema.GroupKind, namespace, name, defaultNamespace string) bool {
+	for _, obj := range objs {
+		if obj.GetObjectKind().GroupVersionKind().GroupKind() != gk {
+			continue
+		}
+		m, err := meta.Accessor(obj)
+		if err != nil {
+			continue
+		}
+		ns := m.GetNamespace()
+		if ns == "" {
+			ns = defaultNamespace
+		}
+		if ns == namespace && m.GetName() == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package monitor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+)
+
+func Test_readOutputs(t *testing.T) {
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "app", Name: "lb"},
+		Status: corev1.ServiceStatus{LoadBalancer: corev1.LoadBalancerStatus{
+			Ingress: []corev1.LoadBalancerIngress{{IP: "10.0.0.1"}},
+		}},
+	}
+	other := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "app", Name: "other"}}
+	foreign := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "lb"}}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "app", Name: "creds"},
+		Data:       map[string][]byte{"password": []byte("s3cr3t")},
+	}
+	c := fake.NewClientBuilder().WithObjects(svc, other, foreign, secret).Build()
+
+	// the resources of the bundle's release
+	objs := []runtime.Object{
+		&corev1.Service{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Service"},
+			ObjectMeta: metav1.ObjectMeta{Name: "lb"},
+		},
+		&corev1.Secret{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+			ObjectMeta: metav1.ObjectMeta{Namespace: "app", Name: "creds"},
+		},
+	}
+
+	outputs := []fleet.BundleOutput{
+		{Name: "ip", APIVersion: "v1", Kind: "Service", ResourceName: "lb", JSONPath: "{.status.loadBalancer.ingress[0].ip}"},
+		{Name: "explicit", APIVersion: "v1", Kind: "Service", Namespace: "app", ResourceName: "lb", JSONPath: "{.metadata.name}"},
+		{Name: "missing-field", APIVersion: "v1", Kind: "Service", ResourceName: "lb", JSONPath: "{.spec.notthere}"},
+		{Name: "invalid", APIVersion: "v1", Kind: "Service", ResourceName: "lb", JSONPath: "{.status"},
+		{Name: "not-in-release", APIVersion: "v1", Kind: "Service", ResourceName: "other", JSONPath: "{.metadata.name}"},
+		{Name: "other-namespace", APIVersion: "v1", Kind: "Service", Namespace: "kube-system", ResourceName: "lb", JSONPath: "{.metadata.name}"},
+		{Name: "secret", APIVersion: "v1", Kind: "Secret", ResourceName: "creds", JSONPath: "{.data.password}"},
+	}
+
+	got := readOutputs(context.Background(), c, outputs, objs, "app")
+	assert.Equal(t, map[string]string{
+		"ip":       "10.0.0.1",
+		"explicit": "lb",
+	}, got)
+
+	assert.Nil(t, readOutputs(context.Background(), c, nil, objs, "app"))
+}
@@ -85,7 +85,7 @@ func ShouldUpdateStatus(bd *fleet.BundleDeployment) bool {
 }
 
 // UpdateStatus sets the status of the bundledeployment based on the resources from the helm release history and the live state.
-// In the status it updates: Ready, NonReadyStatus, IncompleteState, NonReadyStatus, NonModified, ModifiedStatus, Resources and ResourceCounts fields.
+// In the status it updates: Ready, NonReadyStatus, IncompleteState, NonReadyStatus, NonModified, ModifiedStatus, Resources, ResourceCounts and Outputs fields.
 // Additionally it sets the Ready condition either from the NonReadyStatus or the NonModified status field.
 func (m *Monitor) UpdateStatus(ctx context.Context, bd *fleet.BundleDeployment, resources *helmdeployer.Resources) (fleet.BundleDeploymentStatus, error) {
 	logger := log.FromContext(ctx).WithName("update-status")
@@ -196,6 +196,7 @@ func (m *Monitor) updateFromPreviousDeployment(
 	}
 
 	updateFromResources(&bd.Status, allResources, nonReadyResources, modifiedResources)
+	bd.Status.Outputs = readOutputs(ctx, m.client, bd.Spec.Options.Outputs, resources.Objects, ns)
 	return nil
 }
 
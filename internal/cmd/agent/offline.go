@@ -0,0 +1,156 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"helm.sh/helm/v4/pkg/cli"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/rancher/fleet/internal/cmd/agent/contentcache"
+	"github.com/rancher/fleet/internal/cmd/agent/deployer/desiredset"
+	"github.com/rancher/fleet/internal/cmd/agent/deployer/monitor"
+	"github.com/rancher/fleet/internal/helmdeployer"
+	"github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/durations"
+)
+
+// startOffline deploys from the content cache while the agent cannot reach
+// the upstream cluster, e.g. when it restarts during a network outage. After
+// durations.OfflineModeDelay, it periodically re-installs missing releases of
+// cached bundle deployments and corrects drift if enabled. Status updates are
+// queued in the cache and reported once the agent is connected again.
+//
+// The returned function stops offline mode and waits for it to finish. It
+// must be called before the bundle deployment controllers are started.
+func startOffline(ctx context.Context, localConfig *rest.Config, systemNamespace, agentScope string) func() {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(durations.OfflineModeDelay):
+		}
+
+		if err := runOffline(ctx, localConfig, systemNamespace, agentScope); err != nil && !errors.Is(err, context.Canceled) {
+			setupLog.Error(err, "failed to run in offline mode")
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+func runOffline(ctx context.Context, localConfig *rest.Config, systemNamespace, agentScope string) error {
+	cache, err := newContentCache(localConfig, systemNamespace)
+	if err != nil || cache == nil {
+		return err
+	}
+
+	localClient, err := client.New(localConfig, client.Options{Scheme: localScheme})
+	if err != nil {
+		return err
+	}
+	helmDeployer := helmdeployer.New(
+		systemNamespace,
+		defaultNamespace,
+		defaultNamespace,
+		agentScope,
+	)
+	if err := helmDeployer.Setup(ctx, localClient, cli.New().RESTClientGetter()); err != nil {
+		return err
+	}
+	ds, err := desiredset.New(localConfig)
+	if err != nil {
+		return err
+	}
+	mon := monitor.New(localClient, ds, helmDeployer, defaultNamespace, agentScope)
+
+	setupLog.Info("upstream cluster unreachable, deploying from content cache")
+	ticker := time.NewTicker(durations.OfflineResync)
+	defer ticker.Stop()
+	for {
+		entries, err := cache.List(ctx)
+		if err != nil {
+			setupLog.Error(err, "failed to list content cache")
+		}
+		for _, entry := range entries {
+			if err := deployOffline(ctx, cache, helmDeployer, mon, entry); err != nil {
+				setupLog.Error(err, "failed to deploy from content cache", "bundledeployment", entry.BundleDeployment.Namespace+"/"+entry.BundleDeployment.Name)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// deployOffline makes sure the release of a cached bundle deployment is
+// installed, corrects drift if enabled, and queues the resulting status.
+func deployOffline(ctx context.Context, cache *contentcache.Store, helm *helmdeployer.Helm, mon *monitor.Monitor, entry *contentcache.Entry) error {
+	bd := entry.BundleDeployment
+	if bd.Spec.Paused || bd.Spec.OffSchedule {
+		return nil
+	}
+	if entry.PendingStatus != nil {
+		bd.Status = *entry.PendingStatus
+	}
+	orig := bd.Status.DeepCopy()
+	logger := log.FromContext(ctx).WithValues("bundledeployment", bd.Namespace+"/"+bd.Name)
+
+	installed, err := helm.EnsureInstalled(bd.Name, bd.Status.Release)
+	if err != nil {
+		return err
+	}
+	if !installed {
+		m := entry.Manifest()
+		m.Commit = bd.Labels[v1alpha1.CommitLabel]
+		release, err := helm.Deploy(ctx, bd.Name, m, bd.Spec.Options, nil)
+		if err != nil {
+			return err
+		}
+		bd.Status.Release = helmdeployer.ReleaseToResourceID(release)
+		bd.Status.AppliedDeploymentID = bd.Spec.DeploymentID
+		logger.Info("Re-installed release from content cache", "release", bd.Status.Release)
+	}
+
+	resources, err := helm.Resources(bd.Name, bd.Status.Release)
+	if err != nil {
+		return err
+	}
+	if monitor.ShouldUpdateStatus(bd) {
+		status, err := mon.UpdateStatus(ctx, bd, resources)
+		if err != nil {
+			return err
+		}
+		bd.Status = status
+	}
+
+	if len(bd.Status.ModifiedStatus) > 0 && bd.Spec.CorrectDrift != nil && bd.Spec.CorrectDrift.Enabled {
+		release, err := helm.RemoveExternalChanges(ctx, bd)
+		if err != nil {
+			return err
+		}
+		bd.Status.Release = release
+		logger.Info("Corrected drift from content cache", "release", release)
+	}
+
+	if equality.Semantic.DeepEqual(orig, &bd.Status) {
+		return nil
+	}
+	return cache.QueueStatus(ctx, bd)
+}
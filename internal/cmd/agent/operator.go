@@ -5,7 +5,9 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strconv"
 
+	"github.com/rancher/fleet/internal/cmd/agent/contentcache"
 	"github.com/rancher/fleet/internal/cmd/agent/controller"
 	"github.com/rancher/fleet/internal/cmd/agent/deployer"
 	"github.com/rancher/fleet/internal/cmd/agent/deployer/cleanup"
@@ -17,7 +19,9 @@ import (
 	"github.com/rancher/fleet/internal/config"
 	"github.com/rancher/fleet/internal/helmdeployer"
 	"github.com/rancher/fleet/internal/manifest"
+	"github.com/rancher/fleet/internal/metrics"
 	"github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/durations"
 
 	"helm.sh/helm/v4/pkg/cli"
 
@@ -69,6 +73,10 @@ func start(
 	if err != nil {
 		return fmt.Errorf("failed to get client config: %w", err)
 	}
+	// the token can be replaced by credential rotation, without restarting
+	// the upstream clients
+	credential := register.NewCredential(upstreamConfig.BearerToken)
+	upstreamConfig = credential.Wrap(upstreamConfig)
 	agentConfig, err := getAgentConfig(ctx, systemNamespace, localConfig)
 	if err != nil {
 		return fmt.Errorf("failed to get agent config: %w", err)
@@ -93,6 +101,8 @@ func start(
 		probeAddr = d
 	}
 
+	metrics.RegisterAgentMetrics()
+
 	setupLog.Info("Starting controller", "metricsAddr", metricsAddr, "probeAddr", probeAddr, "systemNamespace", systemNamespace)
 	mgr, err := ctrl.NewManager(upstreamConfig, ctrl.Options{
 		Scheme:                 scheme,
@@ -147,6 +157,8 @@ func start(
 		Monitor:     reconciler.Monitor,
 		DriftDetect: reconciler.DriftDetect,
 
+		ContentCache: reconciler.ContentCache,
+
 		DriftChan: driftChan,
 
 		Workers: workersOpts.Drift,
@@ -159,6 +171,7 @@ func start(
 	clusterStatus := &ClusterStatusRunnable{
 		agentInfo:       agentInfo,
 		config:          upstreamConfig,
+		localConfig:     localConfig,
 		checkinInterval: checkinInterval,
 		namespace:       systemNamespace,
 	}
@@ -167,6 +180,27 @@ func start(
 		return err
 	}
 
+	// use separate clients for credential rotation, that do not use a cache
+	localClient, err := client.New(localConfig, client.Options{Scheme: localScheme})
+	if err != nil {
+		return err
+	}
+	upstreamClient, err := client.New(upstreamConfig, client.Options{Scheme: scheme})
+	if err != nil {
+		return err
+	}
+	rotator := &register.Rotator{
+		Namespace:  systemNamespace,
+		AgentInfo:  agentInfo,
+		Credential: credential,
+		Local:      localClient,
+		Upstream:   upstreamClient,
+	}
+	if err := mgr.Add(rotator); err != nil {
+		setupLog.Error(err, "unable to add credential rotation")
+		return err
+	}
+
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		return err
@@ -237,6 +271,22 @@ func newReconciler(
 		helmDeployer,
 	)
 
+	contentCache, err := newContentCache(localConfig, systemNamespace)
+	if err != nil {
+		setupLog.Error(err, "unable to set up content cache")
+		return nil, err
+	}
+	if contentCache != nil {
+		deployer.SetContentCache(contentCache)
+		if err := mgr.Add(&contentcache.Resync{
+			Store:    contentCache,
+			Upstream: upstreamClient,
+			Interval: durations.ContentCacheResync,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
 	// Build the monitor to update the bundle deployment's status, calculates modified/non-modified
 	localDynamic, err := dynamic.NewForConfig(localConfig)
 	if err != nil {
@@ -288,6 +338,8 @@ func newReconciler(
 		DriftDetect: driftdetect,
 		Cleanup:     cleanup,
 
+		ContentCache: contentCache,
+
 		DriftChan: driftChan,
 
 		DefaultNamespace: defaultNamespace,
@@ -298,6 +350,34 @@ func newReconciler(
 	}, nil
 }
 
+// newContentCache returns the content cache, unless it is disabled by
+// setting CONTENT_CACHE_MAX_ENTRIES to 0. The encryption key is kept in
+// CONTENT_CACHE_KEY_NAMESPACE, outside of the agent's namespace. The cache
+// uses its own client, so secrets are not cached in memory.
+func newContentCache(localConfig *rest.Config, systemNamespace string) (*contentcache.Store, error) {
+	maxEntries := contentcache.DefaultMaxEntries
+	if d := os.Getenv("CONTENT_CACHE_MAX_ENTRIES"); d != "" {
+		n, err := strconv.Atoi(d)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse CONTENT_CACHE_MAX_ENTRIES: %w", err)
+		}
+		maxEntries = n
+	}
+	if maxEntries == 0 {
+		return nil, nil
+	}
+
+	c, err := client.New(localConfig, client.Options{Scheme: localScheme})
+	if err != nil {
+		return nil, err
+	}
+	keyNamespace := contentcache.DefaultKeyNamespace
+	if ns := os.Getenv("CONTENT_CACHE_KEY_NAMESPACE"); ns != "" {
+		keyNamespace = ns
+	}
+	return contentcache.New(c, systemNamespace, keyNamespace, maxEntries)
+}
+
 // newCluster returns a new cluster client, see controller-runtime/pkg/manager/manager.go
 // This client is for the local cluster, not the upstream cluster. The upstream
 // cluster client is used by the manager to watch for changes to the
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/rancher/fleet/internal/config"
@@ -44,6 +45,9 @@ type AgentInfo struct {
 	ClusterNamespace string
 	ClusterName      string
 	ClientConfig     clientcmd.ClientConfig
+	// CredentialGeneration is the generation of the rotated credential in
+	// ClientConfig, zero if the credential is from the registration.
+	CredentialGeneration int64
 }
 
 // Register creates a fleet-agent secret with the upstream kubeconfig, by
@@ -102,12 +106,16 @@ func tryRegister(ctx context.Context, namespace string, cfg *rest.Config) (*Agen
 		return nil, err
 	}
 
+	// a missing or invalid generation is treated as the credential from the registration
+	generation, _ := strconv.ParseInt(string(secret.Data[CredentialGeneration]), 10, 64)
+
 	// delete the fleet-agent-bootstrap cred
 	_ = k8s.Core().V1().Secret().Delete(namespace, config.AgentBootstrapConfigName, nil)
 	return &AgentInfo{
-		ClusterNamespace: string(secret.Data[ClusterNamespace]),
-		ClusterName:      string(secret.Data[ClusterName]),
-		ClientConfig:     clientConfig,
+		ClusterNamespace:     string(secret.Data[ClusterNamespace]),
+		ClusterName:          string(secret.Data[ClusterName]),
+		ClientConfig:         clientConfig,
+		CredentialGeneration: generation,
 	}, nil
 }
 
@@ -0,0 +1,173 @@
+package register
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/rancher/fleet/internal/registration"
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/durations"
+
+	"github.com/rancher/wrangler/v3/pkg/ticker"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// CredentialGeneration is the generation of the rotated credential in the
+// fleet-agent secret.
+const CredentialGeneration = "credentialGeneration"
+
+// Credential holds the bearer token used for the upstream cluster. The token
+// can be replaced while clients created from a wrapped config are in use.
+type Credential struct {
+	mu    sync.RWMutex
+	token string
+}
+
+func NewCredential(token string) *Credential {
+	return &Credential{token: token}
+}
+
+func (c *Credential) Token() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.token
+}
+
+func (c *Credential) Set(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.token = token
+}
+
+// Wrap returns a copy of cfg, which authenticates requests with the current
+// token of the credential.
+func (c *Credential) Wrap(cfg *rest.Config) *rest.Config {
+	cfg = rest.CopyConfig(cfg)
+	cfg.BearerToken = ""
+	cfg.BearerTokenFile = ""
+	cfg.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return &bearerRoundTripper{credential: c, rt: rt}
+	})
+	return cfg
+}
+
+type bearerRoundTripper struct {
+	credential *Credential
+	rt         http.RoundTripper
+}
+
+func (b *bearerRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	token := b.credential.Token()
+	if token == "" {
+		return b.rt.RoundTrip(req)
+	}
+	req = utilnet.CloneRequest(req)
+	req.Header.Set("Authorization", "Bearer "+token)
+	return b.rt.RoundTrip(req)
+}
+
+// Rotator switches the agent to the credentials, which the fleet-controller
+// issues by credential rotation. The new credential is tested and stored in
+// the fleet-agent secret, before it replaces the token of the running agent.
+// Deployments are not interrupted, as the upstream clients are kept.
+type Rotator struct {
+	// Namespace is the system namespace of the agent, e.g. cattle-fleet-system
+	Namespace  string
+	AgentInfo  *AgentInfo
+	Credential *Credential
+	// Local is a client for the downstream cluster
+	Local client.Client
+	// Upstream is a client for the upstream cluster, which uses Credential
+	Upstream client.Client
+
+	// test checks a kubeconfig can access the upstream cluster
+	test func([]byte) error
+}
+
+// Start checks for rotated credentials periodically.
+func (r *Rotator) Start(ctx context.Context) error {
+	logger := log.FromContext(ctx).WithName("credential-rotation")
+	go func() {
+		for range ticker.Context(ctx, durations.AgentCredentialCheckInterval) {
+			if err := r.Rotate(ctx); err != nil {
+				logger.Error(err, "failed to rotate agent credential")
+			}
+		}
+	}()
+	return nil
+}
+
+// Rotate switches to the credential in the fleet-agent-credential secret, if
+// its generation is newer than the credential in use.
+func (r *Rotator) Rotate(ctx context.Context) error {
+	ns, _, err := r.AgentInfo.ClientConfig.Namespace()
+	if err != nil {
+		return err
+	}
+
+	issued := &corev1.Secret{}
+	err = r.Upstream.Get(ctx, types.NamespacedName{Namespace: ns, Name: registration.AgentCredentialSecretName}, issued)
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	generation, err := strconv.ParseInt(string(issued.Data[registration.AgentCredentialGenerationKey]), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid credential generation in %s/%s: %w", ns, registration.AgentCredentialSecretName, err)
+	}
+	token := string(issued.Data[Token])
+	if generation <= r.AgentInfo.CredentialGeneration || token == "" || token == r.Credential.Token() {
+		return nil
+	}
+
+	kubeconfig, err := updateClientConfig(r.AgentInfo.ClientConfig, token, ns)
+	if err != nil {
+		return err
+	}
+	test := r.test
+	if test == nil {
+		test = testClientConfig
+	}
+	if err := test(kubeconfig); err != nil {
+		return fmt.Errorf("credential generation %d cannot list bundledeployments on management cluster: %w", generation, err)
+	}
+
+	// Store the new credential first, so a restarted agent uses it.
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		secret := &corev1.Secret{}
+		if err := r.Local.Get(ctx, types.NamespacedName{Namespace: r.Namespace, Name: CredName}, secret); err != nil {
+			return err
+		}
+		secret.Data[Kubeconfig] = kubeconfig
+		secret.Data[CredentialGeneration] = []byte(strconv.FormatInt(generation, 10))
+		return r.Local.Update(ctx, secret)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update '%s' secret: %w", CredName, err)
+	}
+
+	r.Credential.Set(token)
+	r.AgentInfo.CredentialGeneration = generation
+	log.FromContext(ctx).Info("Switched to rotated agent credential", "generation", generation)
+
+	// Report the generation, so the fleet-controller revokes previous
+	// credentials.
+	cluster := &fleet.Cluster{}
+	cluster.Namespace = r.AgentInfo.ClusterNamespace
+	cluster.Name = r.AgentInfo.ClusterName
+	patch := fmt.Sprintf(`{"status":{"agent":{"activeCredentialGeneration":%d}}}`, generation)
+	return r.Upstream.Status().Patch(ctx, cluster, client.RawPatch(types.MergePatchType, []byte(patch)))
+}
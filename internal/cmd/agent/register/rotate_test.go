@@ -0,0 +1,128 @@
+package register
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rancher/fleet/internal/registration"
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestCredentialWrap(t *testing.T) {
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("Authorization")
+	}))
+	defer srv.Close()
+
+	credential := NewCredential("old")
+	cfg := credential.Wrap(&rest.Config{Host: srv.URL, BearerToken: "old"})
+	rt, err := rest.TransportFor(cfg)
+	require.NoError(t, err)
+	httpClient := &http.Client{Transport: rt}
+
+	get := func() {
+		t.Helper()
+		resp, err := httpClient.Get(srv.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	get()
+	assert.Equal(t, "Bearer old", got)
+
+	// the same client uses the replaced token
+	credential.Set("new")
+	get()
+	assert.Equal(t, "Bearer new", got)
+}
+
+func TestRotate(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(corev1.AddToScheme(scheme))
+	utilruntime.Must(fleet.AddToScheme(scheme))
+
+	cluster := &fleet.Cluster{ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: "prod"}}
+	issued := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "cluster-ns", Name: registration.AgentCredentialSecretName},
+		Data: map[string][]byte{
+			Token: []byte("new"),
+			registration.AgentCredentialGenerationKey: []byte("2"),
+		},
+	}
+	upstream := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(cluster, issued).WithStatusSubresource(cluster).Build()
+	local := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "cattle-fleet-system", Name: CredName},
+		Data: map[string][]byte{
+			Kubeconfig:  []byte("old"),
+			ClusterName: []byte("prod"),
+		},
+	}).Build()
+
+	clientConfig := clientcmd.NewDefaultClientConfig(clientcmdapi.Config{
+		Clusters:       map[string]*clientcmdapi.Cluster{"cluster": {Server: "https://upstream"}},
+		AuthInfos:      map[string]*clientcmdapi.AuthInfo{"user": {Token: "old"}},
+		Contexts:       map[string]*clientcmdapi.Context{"default": {Cluster: "cluster", AuthInfo: "user", Namespace: "cluster-ns"}},
+		CurrentContext: "default",
+	}, &clientcmd.ConfigOverrides{})
+
+	var testErr error
+	r := &Rotator{
+		Namespace: "cattle-fleet-system",
+		AgentInfo: &AgentInfo{
+			ClusterNamespace:     "fleet-default",
+			ClusterName:          "prod",
+			ClientConfig:         clientConfig,
+			CredentialGeneration: 1,
+		},
+		Credential: NewCredential("old"),
+		Local:      local,
+		Upstream:   upstream,
+		test:       func([]byte) error { return testErr },
+	}
+	ctx := context.Background()
+
+	// a credential, which cannot access the upstream cluster, is not used
+	testErr = errors.New("unauthorized")
+	require.ErrorContains(t, r.Rotate(ctx), "credential generation 2 cannot list bundledeployments")
+	assert.Equal(t, "old", r.Credential.Token())
+
+	testErr = nil
+	require.NoError(t, r.Rotate(ctx))
+	assert.Equal(t, "new", r.Credential.Token())
+	assert.Equal(t, int64(2), r.AgentInfo.CredentialGeneration)
+
+	secret := &corev1.Secret{}
+	require.NoError(t, local.Get(ctx, types.NamespacedName{Namespace: "cattle-fleet-system", Name: CredName}, secret))
+	assert.Equal(t, "2", string(secret.Data[CredentialGeneration]))
+	assert.Equal(t, "prod", string(secret.Data[ClusterName]))
+	kubeconfig, err := clientcmd.Load(secret.Data[Kubeconfig])
+	require.NoError(t, err)
+	assert.Equal(t, "new", kubeconfig.AuthInfos["user"].Token)
+	assert.Equal(t, "cluster-ns", kubeconfig.Contexts["default"].Namespace)
+
+	require.NoError(t, upstream.Get(ctx, client.ObjectKeyFromObject(cluster), cluster))
+	assert.Equal(t, int64(2), cluster.Status.Agent.ActiveCredentialGeneration)
+
+	// the same generation is not applied twice
+	r.test = func([]byte) error { return errors.New("not called") }
+	require.NoError(t, r.Rotate(ctx))
+}
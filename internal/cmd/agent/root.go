@@ -22,6 +22,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	command "github.com/rancher/fleet/internal/cmd"
+	"github.com/rancher/fleet/internal/tracing"
 	"github.com/rancher/fleet/pkg/version"
 )
 
@@ -89,6 +90,12 @@ func (a *FleetAgent) Run(cmd *cobra.Command, args []string) error {
 
 	ctx := log.IntoContext(cmd.Context(), ctrl.Log)
 
+	shutdownTracing, err := tracing.Setup(ctx, "fleet-agent")
+	if err != nil {
+		return fmt.Errorf("failed to set up tracing: %w", err)
+	}
+	defer func() { _ = shutdownTracing(context.WithoutCancel(ctx)) }()
+
 	localConfig := ctrl.GetConfigOrDie()
 	localClient, err := kubernetes.NewForConfig(localConfig)
 	if err != nil {
@@ -117,7 +124,11 @@ func (a *FleetAgent) Run(cmd *cobra.Command, args []string) error {
 			Namespace: a.Namespace,
 		}
 
+		// Keep cached bundle deployments deployed if registration fails
+		// because the upstream cluster is unreachable.
+		stopOffline := startOffline(ctx, localConfig, a.Namespace, a.AgentScope)
 		agentInfo, err := r.RegisterAgent(ctx, localConfig)
+		stopOffline()
 		if err != nil {
 			setupLog.Error(err, "failed to register with upstream cluster")
 			return
@@ -0,0 +1,255 @@
+// Package airgap reads and writes signed archives used to deploy bundles to
+// clusters which cannot reach the upstream Fleet cluster.
+//
+// An archive is a tar file with two entries: a gzipped tar payload and an
+// ed25519 signature of that payload. The payload contains an index, the
+// exported bundles, their bundle deployments with resolved options and the
+// manifest of each bundle deployment, as written by manifest.ToTarGZ.
+package airgap
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/yaml"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/rancher/fleet/internal/manifest"
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+)
+
+const (
+	payloadFile   = "payload.tar.gz"
+	signatureFile = "payload.sig"
+
+	indexFile            = "index.yaml"
+	bundlesDir           = "bundles"
+	bundleDeploymentsDir = "bundledeployments"
+	manifestsDir         = "manifests"
+
+	// maxEntrySize limits the size of a single entry read from an archive.
+	maxEntrySize = 1 << 30
+)
+
+// ErrInvalidSignature is returned when the payload of an archive does not
+// match its signature.
+var ErrInvalidSignature = errors.New("invalid archive signature")
+
+// Index describes where an archive was exported from.
+type Index struct {
+	// Cluster is the name of the upstream cluster resource the bundle
+	// deployments were exported for.
+	Cluster string `json:"cluster"`
+	// ClusterNamespace is the namespace of the upstream cluster resource.
+	ClusterNamespace string `json:"clusterNamespace"`
+	// Created is the time the archive was exported.
+	Created metav1.Time `json:"created"`
+}
+
+// Deployment is a bundle deployment, with its options resolved from the
+// options secret, and its manifest.
+type Deployment struct {
+	BundleDeployment *fleet.BundleDeployment
+	Manifest         *manifest.Manifest
+}
+
+// Archive is the content of an air-gap archive.
+type Archive struct {
+	Index       Index
+	Bundles     []*fleet.Bundle
+	Deployments []Deployment
+}
+
+// Write writes a signed archive to w.
+func Write(w io.Writer, a *Archive, key ed25519.PrivateKey) error {
+	payload, err := a.payload()
+	if err != nil {
+		return err
+	}
+
+	tw := tar.NewWriter(w)
+	if err := writeFile(tw, payloadFile, payload); err != nil {
+		return err
+	}
+	if err := writeFile(tw, signatureFile, ed25519.Sign(key, payload)); err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// Read reads an archive from r, verifying its signature with key before
+// parsing the payload.
+func Read(r io.Reader, key ed25519.PublicKey) (*Archive, error) {
+	files, err := readFiles(tar.NewReader(r))
+	if err != nil {
+		return nil, err
+	}
+
+	payload, ok := files[payloadFile]
+	if !ok {
+		return nil, fmt.Errorf("archive is missing %s", payloadFile)
+	}
+	sig, ok := files[signatureFile]
+	if !ok {
+		return nil, fmt.Errorf("archive is missing %s", signatureFile)
+	}
+	if !ed25519.Verify(key, payload, sig) {
+		return nil, ErrInvalidSignature
+	}
+
+	return parsePayload(payload)
+}
+
+func (a *Archive) payload() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	gz := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gz)
+
+	if err := writeYAML(tw, indexFile, a.Index); err != nil {
+		return nil, err
+	}
+	for _, b := range a.Bundles {
+		if err := writeYAML(tw, path.Join(bundlesDir, b.Namespace, b.Name+".yaml"), b); err != nil {
+			return nil, err
+		}
+	}
+	for _, d := range a.Deployments {
+		bd := d.BundleDeployment
+		if err := writeYAML(tw, path.Join(bundleDeploymentsDir, bd.Namespace, bd.Name+".yaml"), bd); err != nil {
+			return nil, err
+		}
+
+		r, err := d.Manifest.ToTarGZ()
+		if err != nil {
+			return nil, fmt.Errorf("failed to write manifest of bundle deployment %s/%s: %w", bd.Namespace, bd.Name, err)
+		}
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		if err := writeFile(tw, path.Join(manifestsDir, bd.Namespace, bd.Name+".tar.gz"), data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func parsePayload(payload []byte) (*Archive, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	files, err := readFiles(tar.NewReader(gz))
+	if err != nil {
+		return nil, err
+	}
+
+	a := &Archive{}
+	index, ok := files[indexFile]
+	if !ok {
+		return nil, fmt.Errorf("archive payload is missing %s", indexFile)
+	}
+	if err := yaml.Unmarshal(index, &a.Index); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", indexFile, err)
+	}
+
+	manifests := map[string]*manifest.Manifest{}
+	for name, data := range files {
+		switch {
+		case strings.HasPrefix(name, bundlesDir+"/"):
+			b := &fleet.Bundle{}
+			if err := yaml.Unmarshal(data, b); err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", name, err)
+			}
+			a.Bundles = append(a.Bundles, b)
+		case strings.HasPrefix(name, bundleDeploymentsDir+"/"):
+			bd := &fleet.BundleDeployment{}
+			if err := yaml.Unmarshal(data, bd); err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", name, err)
+			}
+			a.Deployments = append(a.Deployments, Deployment{BundleDeployment: bd})
+		case strings.HasPrefix(name, manifestsDir+"/"):
+			m, err := manifest.FromTarGZ(bytes.NewReader(data))
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", name, err)
+			}
+			manifests[strings.TrimPrefix(name, manifestsDir+"/")] = m
+		}
+	}
+
+	for i, d := range a.Deployments {
+		bd := d.BundleDeployment
+		m, ok := manifests[path.Join(bd.Namespace, bd.Name+".tar.gz")]
+		if !ok {
+			return nil, fmt.Errorf("archive payload is missing the manifest of bundle deployment %s/%s", bd.Namespace, bd.Name)
+		}
+		a.Deployments[i].Manifest = m
+	}
+
+	return a, nil
+}
+
+func readFiles(tr *tar.Reader) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return files, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if h.Typeflag != tar.TypeReg {
+			continue
+		}
+		if h.Size > maxEntrySize {
+			return nil, fmt.Errorf("archive entry %s exceeds the maximum size of %d bytes", h.Name, maxEntrySize)
+		}
+
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		files[h.Name] = data
+	}
+}
+
+func writeYAML(tw *tar.Writer, name string, obj any) error {
+	data, err := yaml.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	return writeFile(tw, name, data)
+}
+
+func writeFile(tw *tar.Writer, name string, data []byte) error {
+	if err := tw.WriteHeader(&tar.Header{
+		Name:     name,
+		Mode:     0644,
+		Typeflag: tar.TypeReg,
+		ModTime:  time.Unix(0, 0),
+		Size:     int64(len(data)),
+	}); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
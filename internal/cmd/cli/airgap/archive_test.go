@@ -0,0 +1,148 @@
+package airgap_test
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/rancher/fleet/internal/cmd/cli/airgap"
+	"github.com/rancher/fleet/internal/manifest"
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+)
+
+func newArchive() *airgap.Archive {
+	return &airgap.Archive{
+		Index: airgap.Index{Cluster: "downstream", ClusterNamespace: "fleet-default"},
+		Bundles: []*fleet.Bundle{{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: "app"},
+		}},
+		Deployments: []airgap.Deployment{{
+			BundleDeployment: &fleet.BundleDeployment{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "cluster-fleet-default-downstream-1", Name: "app"},
+				Spec: fleet.BundleDeploymentSpec{
+					DeploymentID: "s-123:abc",
+					Options: fleet.BundleDeploymentOptions{
+						Helm: &fleet.HelmOptions{Values: &fleet.GenericMap{Data: map[string]any{"replicas": float64(2)}}},
+					},
+				},
+			},
+			Manifest: manifest.New([]fleet.BundleResource{
+				{Name: "cm.yaml", Content: "apiVersion: v1\nkind: ConfigMap\n"},
+				{Name: "binary", Content: "/w==", Encoding: "base64"},
+			}),
+		}},
+	}
+}
+
+func TestWriteRead(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := airgap.Write(buf, newArchive(), priv); err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := airgap.Read(bytes.NewReader(buf.Bytes()), pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if a.Index.Cluster != "downstream" || a.Index.ClusterNamespace != "fleet-default" {
+		t.Errorf("unexpected index %+v", a.Index)
+	}
+	if len(a.Bundles) != 1 || a.Bundles[0].Name != "app" {
+		t.Fatalf("unexpected bundles %v", a.Bundles)
+	}
+	if len(a.Deployments) != 1 {
+		t.Fatalf("expected 1 deployment, got %d", len(a.Deployments))
+	}
+
+	d := a.Deployments[0]
+	if d.BundleDeployment.Spec.DeploymentID != "s-123:abc" {
+		t.Errorf("unexpected deployment ID %q", d.BundleDeployment.Spec.DeploymentID)
+	}
+	if v := d.BundleDeployment.Spec.Options.Helm.Values.Data["replicas"]; v != float64(2) {
+		t.Errorf("expected resolved values to be kept, got %v", v)
+	}
+
+	resources := map[string]fleet.BundleResource{}
+	for _, r := range d.Manifest.Resources {
+		resources[r.Name] = r
+	}
+	if r := resources["cm.yaml"]; r.Content != "apiVersion: v1\nkind: ConfigMap\n" || r.Encoding != "" {
+		t.Errorf("unexpected resource %+v", r)
+	}
+	if r := resources["binary"]; r.Content != "/w==" || r.Encoding != "base64" {
+		t.Errorf("expected binary resource to be base64 encoded, got %+v", r)
+	}
+}
+
+func TestReadRejectsInvalidSignature(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := airgap.Write(buf, newArchive(), priv); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := airgap.Read(bytes.NewReader(buf.Bytes()), otherPub); !errors.Is(err, airgap.ErrInvalidSignature) {
+		t.Errorf("expected %v with another key, got %v", airgap.ErrInvalidSignature, err)
+	}
+
+	// Flip a byte of the payload, which is the first entry of the archive
+	// and starts after its 512 byte tar header.
+	tampered := bytes.Clone(buf.Bytes())
+	tampered[600] ^= 0xff
+	pub := priv.Public().(ed25519.PublicKey)
+	if _, err := airgap.Read(bytes.NewReader(tampered), pub); !errors.Is(err, airgap.ErrInvalidSignature) {
+		t.Errorf("expected %v for a tampered payload, got %v", airgap.ErrInvalidSignature, err)
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	privDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsedPriv, err := airgap.ParsePrivateKey(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsedPub, err := airgap.ParsePublicKey(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !parsedPriv.Equal(priv) || !parsedPub.Equal(pub) {
+		t.Error("parsed keys do not match the generated keys")
+	}
+
+	if _, err := airgap.ParsePublicKey([]byte("not a key")); err == nil {
+		t.Error("expected an error for invalid PEM data")
+	}
+}
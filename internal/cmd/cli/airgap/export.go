@@ -0,0 +1,145 @@
+package airgap
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/rancher/fleet/internal/bundlereader"
+	"github.com/rancher/fleet/internal/manifest"
+	"github.com/rancher/fleet/internal/ocistorage"
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/helmvalues"
+)
+
+// ExportOptions selects the bundles to export.
+type ExportOptions struct {
+	// Namespace is the namespace of the bundles and of the cluster.
+	Namespace string
+	// Cluster is the name of the cluster to export bundle deployments for.
+	Cluster string
+	// Bundles restricts the export to the named bundles, if not empty.
+	Bundles []string
+	// Selector restricts the export to bundles matching it.
+	Selector labels.Selector
+}
+
+// Export collects the selected bundles, their bundle deployments for the
+// cluster and the manifests of those deployments from the upstream cluster.
+// Bundles which do not target the cluster are skipped.
+func Export(ctx context.Context, c client.Reader, opts ExportOptions) (*Archive, error) {
+	cluster := &fleet.Cluster{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: opts.Namespace, Name: opts.Cluster}, cluster); err != nil {
+		return nil, err
+	}
+	if cluster.Status.Namespace == "" {
+		return nil, fmt.Errorf("cluster %s/%s has no namespace assigned yet", opts.Namespace, opts.Cluster)
+	}
+
+	listOpts := []client.ListOption{client.InNamespace(opts.Namespace)}
+	if opts.Selector != nil {
+		listOpts = append(listOpts, client.MatchingLabelsSelector{Selector: opts.Selector})
+	}
+	bundles := &fleet.BundleList{}
+	if err := c.List(ctx, bundles, listOpts...); err != nil {
+		return nil, err
+	}
+
+	a := &Archive{
+		Index: Index{
+			Cluster:          cluster.Name,
+			ClusterNamespace: cluster.Namespace,
+			Created:          metav1.NewTime(time.Now().UTC()),
+		},
+	}
+	lookup := manifest.NewLookup()
+	for _, b := range bundles.Items {
+		if len(opts.Bundles) > 0 && !slices.Contains(opts.Bundles, b.Name) {
+			continue
+		}
+
+		bds := &fleet.BundleDeploymentList{}
+		if err := c.List(ctx, bds, client.InNamespace(cluster.Status.Namespace), client.MatchingLabels{
+			fleet.BundleLabel:          b.Name,
+			fleet.BundleNamespaceLabel: b.Namespace,
+		}); err != nil {
+			return nil, err
+		}
+		if len(bds.Items) == 0 {
+			continue
+		}
+
+		b := b.DeepCopy()
+		b.ManagedFields = nil
+		b.Status = fleet.BundleStatus{}
+		a.Bundles = append(a.Bundles, b)
+
+		for _, bd := range bds.Items {
+			d, err := exportDeployment(ctx, c, lookup, bd.DeepCopy())
+			if err != nil {
+				return nil, fmt.Errorf("failed to export bundle deployment %s/%s: %w", bd.Namespace, bd.Name, err)
+			}
+			a.Deployments = append(a.Deployments, d)
+		}
+	}
+
+	return a, nil
+}
+
+// exportDeployment loads the options and the manifest of bd, the same way the
+// agent does before deploying it.
+func exportDeployment(ctx context.Context, c client.Reader, lookup *manifest.Lookup, bd *fleet.BundleDeployment) (Deployment, error) {
+	if bd.Spec.ValuesHash != "" {
+		secret := &corev1.Secret{}
+		if err := c.Get(ctx, client.ObjectKey{Namespace: bd.Namespace, Name: bd.Name}, secret); err != nil {
+			return Deployment{}, err
+		}
+		h := helmvalues.HashOptions(secret.Data[helmvalues.ValuesKey], secret.Data[helmvalues.StagedValuesKey])
+		if h != bd.Spec.ValuesHash {
+			return Deployment{}, fmt.Errorf("hash mismatch between secret and bundledeployment: actual %s != expected %s", h, bd.Spec.ValuesHash)
+		}
+		if err := helmvalues.SetOptions(bd, secret.Data); err != nil {
+			return Deployment{}, err
+		}
+	}
+
+	manifestID, _, _ := strings.Cut(bd.Spec.DeploymentID, ":")
+
+	var (
+		m   *manifest.Manifest
+		err error
+	)
+	switch {
+	case bd.Spec.OCIContents:
+		opts, err := ocistorage.ReadOptsFromSecret(ctx, c, client.ObjectKey{Name: manifestID, Namespace: bd.Namespace})
+		if err != nil {
+			return Deployment{}, err
+		}
+		m, err = ocistorage.NewOCIWrapper().PullManifest(ctx, opts, manifestID)
+		if err != nil {
+			return Deployment{}, err
+		}
+	case bd.Spec.HelmChartOptions != nil:
+		m, err = bundlereader.GetManifestFromHelmChart(ctx, c, bd)
+		if err != nil {
+			return Deployment{}, err
+		}
+	default:
+		m, err = lookup.Get(ctx, c, manifestID)
+		if err != nil {
+			return Deployment{}, err
+		}
+	}
+
+	bd.ManagedFields = nil
+	bd.Status = fleet.BundleDeploymentStatus{}
+
+	return Deployment{BundleDeployment: bd, Manifest: m}, nil
+}
@@ -0,0 +1,109 @@
+package airgap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	"github.com/rancher/wrangler/v3/pkg/condition"
+)
+
+const (
+	// StatusConfigMap is the name of the config map, in the agent
+	// namespace of the disconnected cluster, which holds the status of the
+	// last import.
+	StatusConfigMap = "fleet-import-status"
+	// StatusKey is the config map key holding the status report.
+	StatusKey = "status.yaml"
+)
+
+// StatusReport records the outcome of an import on a disconnected cluster,
+// so it can be imported upstream later.
+type StatusReport struct {
+	Index    Index              `json:"index"`
+	Imported metav1.Time        `json:"imported"`
+	Items    []DeploymentStatus `json:"items,omitempty"`
+}
+
+// DeploymentStatus is the status of an imported bundle deployment.
+type DeploymentStatus struct {
+	Namespace string                       `json:"namespace"`
+	Name      string                       `json:"name"`
+	Status    fleet.BundleDeploymentStatus `json:"status"`
+}
+
+// SaveStatus stores the report in the status config map in namespace.
+func SaveStatus(ctx context.Context, c client.Client, namespace string, report *StatusReport) error {
+	data, err := yaml.Marshal(report)
+	if err != nil {
+		return err
+	}
+
+	cm := &corev1.ConfigMap{}
+	err = c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: StatusConfigMap}, cm)
+	if apierrors.IsNotFound(err) {
+		cm = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: StatusConfigMap},
+			Data:       map[string]string{StatusKey: string(data)},
+		}
+		return c.Create(ctx, cm)
+	} else if err != nil {
+		return err
+	}
+
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+	cm.Data[StatusKey] = string(data)
+	return c.Update(ctx, cm)
+}
+
+// ApplyStatus updates the status of the upstream bundle deployments listed in
+// report. The bundle and cluster controllers then aggregate it, as they would
+// for a status reported by an agent.
+// The report is not signed, so it is only applied to bundle deployments of the
+// cluster in its index, which are still at the deployment ID the status was
+// computed for, and only if the status is consistent with its Ready condition.
+func ApplyStatus(ctx context.Context, c client.Client, report *StatusReport) error {
+	for _, item := range report.Items {
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			bd := &fleet.BundleDeployment{}
+			if err := c.Get(ctx, client.ObjectKey{Namespace: item.Namespace, Name: item.Name}, bd); err != nil {
+				return err
+			}
+			if err := checkStatus(report.Index, bd, item.Status); err != nil {
+				return err
+			}
+			bd.Status = item.Status
+			return c.Status().Update(ctx, bd)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update status of bundle deployment %s/%s: %w", item.Namespace, item.Name, err)
+		}
+	}
+	return nil
+}
+
+// checkStatus returns an error if status must not be applied to bd.
+func checkStatus(index Index, bd *fleet.BundleDeployment, status fleet.BundleDeploymentStatus) error {
+	if bd.Labels[fleet.ClusterLabel] != index.Cluster || bd.Labels[fleet.ClusterNamespaceLabel] != index.ClusterNamespace {
+		return fmt.Errorf("bundle deployment does not belong to cluster %s/%s", index.ClusterNamespace, index.Cluster)
+	}
+	if status.AppliedDeploymentID != "" && status.AppliedDeploymentID != bd.Spec.DeploymentID {
+		return fmt.Errorf("status is for deployment %s, but the bundle deployment is at %s, export and import it again",
+			status.AppliedDeploymentID, bd.Spec.DeploymentID)
+	}
+	if status.Ready && status.NonModified && !condition.Cond(fleet.BundleDeploymentConditionReady).IsTrue(&status) {
+		return errors.New("status is ready, but its Ready condition is not true")
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package airgap_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/rancher/fleet/internal/cmd/cli/airgap"
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	"github.com/rancher/wrangler/v3/pkg/genericcondition"
+)
+
+func TestApplyStatus(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(fleet.AddToScheme(scheme))
+
+	bd := &fleet.BundleDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "cluster-fleet-default-downstream-1",
+			Name:      "app",
+			Labels: map[string]string{
+				fleet.ClusterLabel:          "downstream",
+				fleet.ClusterNamespaceLabel: "fleet-default",
+			},
+		},
+		Spec: fleet.BundleDeploymentSpec{DeploymentID: "s-123:abc"},
+	}
+	index := airgap.Index{Cluster: "downstream", ClusterNamespace: "fleet-default"}
+	ready := fleet.BundleDeploymentStatus{
+		AppliedDeploymentID: "s-123:abc",
+		Ready:               true,
+		NonModified:         true,
+		Conditions: []genericcondition.GenericCondition{
+			{Type: string(fleet.BundleDeploymentConditionReady), Status: corev1.ConditionTrue},
+		},
+	}
+
+	cases := []struct {
+		name   string
+		index  airgap.Index
+		status fleet.BundleDeploymentStatus
+		err    string
+	}{
+		{
+			name:   "ready status is applied",
+			index:  index,
+			status: ready,
+		},
+		{
+			name:   "other cluster",
+			index:  airgap.Index{Cluster: "other", ClusterNamespace: "fleet-default"},
+			status: ready,
+			err:    "does not belong to cluster fleet-default/other",
+		},
+		{
+			name:   "outdated deployment",
+			index:  index,
+			status: fleet.BundleDeploymentStatus{AppliedDeploymentID: "s-122:abc"},
+			err:    "status is for deployment s-122:abc",
+		},
+		{
+			name:   "ready without condition",
+			index:  index,
+			status: fleet.BundleDeploymentStatus{AppliedDeploymentID: "s-123:abc", Ready: true, NonModified: true},
+			err:    "Ready condition is not true",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(bd.DeepCopy()).WithStatusSubresource(&fleet.BundleDeployment{}).Build()
+			report := &airgap.StatusReport{
+				Index: c.index,
+				Items: []airgap.DeploymentStatus{{Namespace: bd.Namespace, Name: bd.Name, Status: c.status}},
+			}
+
+			err := airgap.ApplyStatus(context.TODO(), cl, report)
+			if c.err != "" {
+				if err == nil || !strings.Contains(err.Error(), c.err) {
+					t.Fatalf("expected error containing %q, got %v", c.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := &fleet.BundleDeployment{}
+			if err := cl.Get(context.TODO(), client.ObjectKeyFromObject(bd), got); err != nil {
+				t.Fatal(err)
+			}
+			if !got.Status.Ready || got.Status.AppliedDeploymentID != "s-123:abc" {
+				t.Errorf("expected status to be applied, got %+v", got.Status)
+			}
+		})
+	}
+}
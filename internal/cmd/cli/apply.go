@@ -2,6 +2,7 @@ package cli
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"flag"
 	"fmt"
@@ -10,6 +11,7 @@ import (
 	gogit "github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/spf13/cobra"
+	"go.opentelemetry.io/otel/attribute"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
@@ -19,6 +21,7 @@ import (
 	"github.com/rancher/fleet/internal/cmd/cli/apply"
 	"github.com/rancher/fleet/internal/cmd/cli/writer"
 	ssh "github.com/rancher/fleet/internal/ssh"
+	"github.com/rancher/fleet/internal/tracing"
 	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
 
 	corev1 "k8s.io/api/core/v1"
@@ -71,10 +74,12 @@ type Apply struct {
 	CorrectDriftForce            bool              `usage:"Use --force when correcting drift. Resources can be deleted and recreated" name:"correct-drift-force"`
 	CorrectDriftKeepFailHistory  bool              `usage:"Keep helm history for failed rollbacks" name:"correct-drift-keep-fail-history"`
 	OCIRegistrySecret            string            `usage:"OCI storage registry secret name" name:"oci-registry-secret"`
+	OCIStorageThreshold          int               `usage:"Only store bundles larger than this size in bytes in the OCI registry, smaller bundles are stored in Content resources. 0 stores all bundles in the OCI registry. Does not apply to HelmOp bundles, which store no resources" name:"oci-storage-threshold" env:"FLEET_OCI_STORAGE_THRESHOLD"`
 	DrivenScan                   bool              `usage:"Use driven scan. Bundles are defined by the user" name:"driven-scan"`
 	DrivenScanSeparator          string            `usage:"Separator to use for bundle folder and options file" name:"driven-scan-sep" default:":"`
 	BundleCreationMaxConcurrency int               `usage:"Maximum number of concurrent bundle creation routines" name:"bundle-creation-max-concurrency" default:"4" env:"FLEET_BUNDLE_CREATION_MAX_CONCURRENCY"`
 	ImagescanEnabled             bool              `usage:"Enable imagescan. If disabled, found imagescans will lead to errors" name:"imagescan-enabled"`
+	ChangeDetectedAt             string            `usage:"Time at which the applied change was detected, in RFC3339 format. Used to measure deployment latency" name:"change-detected-at"`
 }
 
 func (r *Apply) PersistentPre(_ *cobra.Command, _ []string) error {
@@ -86,9 +91,22 @@ func (r *Apply) PersistentPre(_ *cobra.Command, _ []string) error {
 }
 
 func (a *Apply) Run(cmd *cobra.Command, args []string) error {
+	shutdown, err := tracing.Setup(cmd.Context(), "fleet-apply")
+	if err != nil {
+		return fmt.Errorf("failed to set up tracing: %w", err)
+	}
+	defer func() {
+		if err := shutdown(context.Background()); err != nil {
+			log.Log.Error(err, "failed to flush traces")
+		}
+	}()
+
+	// Continue the trace of the gitops controller which created the job, if any.
+	ctx, span := tracing.Start(tracing.FromEnv(cmd.Context()), "fleet.apply", attribute.StringSlice("args", args))
+	cmd.SetContext(ctx)
+
 	// Apply retries on conflict errors.
 	// We could have race conditions updating the Bundle in high load situations
-	var err error
 	retries, err := apply.GetOnConflictRetries()
 	if err != nil {
 		log.Log.Error(err, "failed parsing env variable, using defaults", "name", apply.FleetApplyConflictRetriesEnv)
@@ -99,6 +117,7 @@ func (a *Apply) Run(cmd *cobra.Command, args []string) error {
 			break
 		}
 	}
+	tracing.End(span, err)
 
 	return err
 }
@@ -136,8 +155,10 @@ func (a *Apply) run(cmd *cobra.Command, args []string) error {
 		DrivenScan:                   a.DrivenScan,
 		DrivenScanSeparator:          a.DrivenScanSeparator,
 		OCIRegistrySecret:            a.OCIRegistrySecret,
+		OCIStorageThreshold:          a.OCIStorageThreshold,
 		BundleCreationMaxConcurrency: a.BundleCreationMaxConcurrency,
 		ImagescanEnabled:             a.ImagescanEnabled,
+		ChangeDetectedAt:             a.ChangeDetectedAt,
 	}
 
 	if err := a.addAuthToOpts(&opts, os.ReadFile, a.HelmBasicHTTP, a.HelmInsecureSkipTLS); err != nil {
@@ -21,11 +21,13 @@ import (
 	"github.com/rancher/fleet/internal/manifest"
 	"github.com/rancher/fleet/internal/names"
 	"github.com/rancher/fleet/internal/ocistorage"
+	"github.com/rancher/fleet/internal/tracing"
 	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
 	fleetevent "github.com/rancher/fleet/pkg/event"
 	"github.com/rancher/fleet/pkg/helmvalues"
 
 	"github.com/rancher/wrangler/v3/pkg/yaml"
+	"go.opentelemetry.io/otel/attribute"
 	"golang.org/x/sync/errgroup"
 	k8syaml "sigs.k8s.io/yaml"
 
@@ -50,6 +52,7 @@ const (
 	JobNameEnvVar                       = "JOB_NAME"
 	FleetApplyConflictRetriesEnv        = "FLEET_APPLY_CONFLICT_RETRIES"
 	BundleCreationMaxConcurrencyEnv     = "FLEET_BUNDLE_CREATION_MAX_CONCURRENCY"
+	OCIStorageThresholdEnv              = "FLEET_OCI_STORAGE_THRESHOLD"
 	defaultApplyConflictRetries         = 1
 	defaultBundleCreationMaxConcurrency = 4
 )
@@ -89,11 +92,13 @@ type Options struct {
 	CorrectDriftKeepFailHistory  bool
 	OCIRegistry                  OCIRegistrySpec
 	OCIRegistrySecret            string
+	OCIStorageThreshold          int
 	DrivenScan                   bool
 	DrivenScanSeparator          string
 	JobNameEnvVar                string
 	BundleCreationMaxConcurrency int
 	ImagescanEnabled             bool
+	ChangeDetectedAt             string
 }
 
 type bundleWithOpts struct {
@@ -448,6 +453,12 @@ func newBundle(ctx context.Context, name, baseDir string, opts Options) (*fleet.
 		}
 	}
 	bundle.Namespace = opts.Namespace
+	if opts.ChangeDetectedAt != "" {
+		if bundle.Annotations == nil {
+			bundle.Annotations = map[string]string{}
+		}
+		bundle.Annotations[fleet.ChangeDetectedAtAnnotation] = opts.ChangeDetectedAt
+	}
 	return bundle, scans, nil
 }
 
@@ -479,6 +490,10 @@ func writeBundle(ctx context.Context, c client.Client, r record.EventRecorder, b
 		return printToOutput(opts.Output, bundle, scans)
 	}
 
+	ctx, span := tracing.Start(ctx, "fleet.apply.writeBundle", attribute.String("bundle", bundle.Name))
+	defer span.End()
+	tracing.Inject(ctx, bundle)
+
 	// We need to exit early if the bundle is being deleted
 	tmp := &fleet.Bundle{}
 	if err := c.Get(ctx, client.ObjectKey{Name: bundle.Name, Namespace: bundle.Namespace}, tmp); err == nil {
@@ -508,6 +523,11 @@ func writeBundle(ctx context.Context, c client.Client, r record.EventRecorder, b
 	if err != nil {
 		return err
 	}
+	if useOCIRegistry && opts.OCIStorageThreshold > 0 {
+		if useOCIRegistry, err = exceedsThreshold(bundle, opts.OCIStorageThreshold); err != nil {
+			return err
+		}
+	}
 	if useOCIRegistry {
 		if bundle, err = saveOCIBundle(ctx, c, r, bundle, ociOpts); err != nil {
 			return err
@@ -579,14 +599,33 @@ func shouldStoreInOCIRegistry(ctx context.Context, c client.Reader, ociSecretKey
 	return true, nil
 }
 
+// exceedsThreshold returns true if the serialized bundle, including its
+// resources, is larger than threshold bytes.
+func exceedsThreshold(bundle *fleet.Bundle, threshold int) (bool, error) {
+	data, err := json.Marshal(bundle)
+	if err != nil {
+		return false, err
+	}
+	return len(data) > threshold, nil
+}
+
+// ociWriter pushes bundle manifests to an OCI registry.
+type ociWriter interface {
+	PushManifest(ctx context.Context, opts ocistorage.OCIOpts, id string, m *manifest.Manifest) error
+}
+
+// newOCIWriter returns the writer used to push manifests to the OCI registry.
+var newOCIWriter = func() ociWriter {
+	return ocistorage.NewOCIWrapper()
+}
+
 func pushOCIManifest(ctx context.Context, bundle *fleet.Bundle, opts ocistorage.OCIOpts) (string, error) {
 	manifest := manifest.FromBundle(bundle)
 	manifestID, err := manifest.ID()
 	if err != nil {
 		return "", err
 	}
-	oci := ocistorage.NewOCIWrapper()
-	err = oci.PushManifest(ctx, opts, manifestID, manifest)
+	err = newOCIWriter().PushManifest(ctx, opts, manifestID, manifest)
 	if err != nil {
 		return "", err
 	}
@@ -941,6 +980,10 @@ func GetBundleCreationMaxConcurrency() (int, error) {
 	return getIntEnvVar(BundleCreationMaxConcurrencyEnv, defaultBundleCreationMaxConcurrency)
 }
 
+func GetOCIStorageThreshold() (int, error) {
+	return getIntEnvVar(OCIStorageThresholdEnv, 0)
+}
+
 type k8sWithNS struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata"`
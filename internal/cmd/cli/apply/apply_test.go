@@ -1,12 +1,20 @@
 package apply
 
 import (
+	"context"
+	"strings"
 	"testing"
 
+	"github.com/rancher/fleet/internal/config"
+	"github.com/rancher/fleet/internal/manifest"
 	"github.com/rancher/fleet/internal/ocistorage"
 	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 func Test_getKindNS(t *testing.T) {
@@ -117,3 +125,78 @@ func Test_newOCISecret_usesInsecureSkipTLSKey(t *testing.T) {
 		t.Fatal("did not expect legacy insecure key in generated secret")
 	}
 }
+
+type fakeOCIWriter struct {
+	pushed []string
+}
+
+func (f *fakeOCIWriter) PushManifest(_ context.Context, _ ocistorage.OCIOpts, id string, _ *manifest.Manifest) error {
+	f.pushed = append(f.pushed, id)
+	return nil
+}
+
+func Test_exceedsThreshold(t *testing.T) {
+	bundle := &fleet.Bundle{
+		ObjectMeta: metav1.ObjectMeta{Name: "bundle", Namespace: "fleet-local"},
+		Spec:       fleet.BundleSpec{Resources: []fleet.BundleResource{{Name: "cm.yaml", Content: strings.Repeat("a", 1024)}}},
+	}
+	if ok, err := exceedsThreshold(bundle, 4096); err != nil || ok {
+		t.Errorf("expected bundle below the threshold, got %v, %v", ok, err)
+	}
+	if ok, err := exceedsThreshold(bundle, 1024); err != nil || !ok {
+		t.Errorf("expected serialized bundle to exceed the size of its resources, got %v, %v", ok, err)
+	}
+}
+
+func Test_writeBundle_ociStorageThreshold(t *testing.T) {
+	writer := &fakeOCIWriter{}
+	orig := newOCIWriter
+	newOCIWriter = func() ociWriter { return writer }
+	t.Cleanup(func() { newOCIWriter = orig })
+
+	scheme := runtime.NewScheme()
+	utilruntime.Must(corev1.AddToScheme(scheme))
+	utilruntime.Must(fleet.AddToScheme(scheme))
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: config.DefaultOCIStorageSecretName, Namespace: "fleet-local"},
+		Type:       fleet.SecretTypeOCIStorage,
+		Data:       map[string][]byte{ocistorage.OCISecretReference: []byte("registry.example.com/fleet")},
+	}).Build()
+
+	newBundle := func(name, content string) *fleet.Bundle {
+		return &fleet.Bundle{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "fleet-local"},
+			Spec: fleet.BundleSpec{
+				Resources: []fleet.BundleResource{{Name: "cm.yaml", Content: content}},
+			},
+		}
+	}
+
+	opts := Options{OCIStorageThreshold: 1024}
+	if err := writeBundle(context.Background(), c, nil, newBundle("small", "kind: ConfigMap"), nil, opts); err != nil {
+		t.Fatal(err)
+	}
+	bundle := &fleet.Bundle{}
+	if err := c.Get(context.Background(), types.NamespacedName{Namespace: "fleet-local", Name: "small"}, bundle); err != nil {
+		t.Fatal(err)
+	}
+	if bundle.Spec.ContentsID != "" || len(bundle.Spec.Resources) != 1 || len(writer.pushed) != 0 {
+		t.Errorf("expected resources to be kept in the bundle, got contentsID %q, %d resources and pushed %v", bundle.Spec.ContentsID, len(bundle.Spec.Resources), writer.pushed)
+	}
+
+	if err := writeBundle(context.Background(), c, nil, newBundle("large", strings.Repeat("a", 2048)), nil, opts); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Get(context.Background(), types.NamespacedName{Namespace: "fleet-local", Name: "large"}, bundle); err != nil {
+		t.Fatal(err)
+	}
+	if len(writer.pushed) != 1 || bundle.Spec.ContentsID != writer.pushed[0] {
+		t.Fatalf("expected large bundle to reference the pushed OCI artifact, got contentsID %q and pushed %v", bundle.Spec.ContentsID, writer.pushed)
+	}
+	if len(bundle.Spec.Resources) != 0 {
+		t.Errorf("expected resources to be removed from the bundle, got %d", len(bundle.Spec.Resources))
+	}
+	if err := c.Get(context.Background(), types.NamespacedName{Namespace: "fleet-local", Name: bundle.Spec.ContentsID}, &corev1.Secret{}); err != nil {
+		t.Errorf("expected OCI secret for the artifact, got %v", err)
+	}
+}
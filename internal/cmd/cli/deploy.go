@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"errors"
 	"flag"
+	"fmt"
 	"os"
 	"reflect"
 
@@ -13,7 +14,6 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 
 	command "github.com/rancher/fleet/internal/cmd"
-	"github.com/rancher/fleet/internal/content"
 	"github.com/rancher/fleet/internal/helmdeployer"
 	"github.com/rancher/fleet/internal/manifest"
 	"github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
@@ -46,7 +46,7 @@ func NewDeploy() *cobra.Command {
 }
 
 type Deploy struct {
-	InputFile   string `usage:"Location of the YAML file containing the content and the bundledeployment resource" short:"i"`
+	InputFile   string `usage:"Location of the YAML file containing the content and the bundledeployment resource, and the blob content resources referenced by the content" short:"i"`
 	DryRun      bool   `usage:"Print the resources that would be deployed, but do not actually deploy them" short:"d"`
 	Namespace   string `usage:"Set the default namespace. Deploy helm chart into this namespace." short:"n"`
 	KubeVersion string `usage:"For dry runs, sets the Kubernetes version to assume when validating Chart Kubernetes version constraints."`
@@ -69,6 +69,7 @@ func (d *Deploy) Run(cmd *cobra.Command, args []string) error {
 	}
 
 	c := &v1alpha1.Content{}
+	blobs := map[string]*v1alpha1.Content{}
 	bd := &v1alpha1.BundleDeployment{}
 	objs, err := wyaml.ToObjects(bytes.NewBuffer(b))
 	if err != nil {
@@ -83,10 +84,16 @@ func (d *Deploy) Run(cmd *cobra.Command, args []string) error {
 			if err != nil {
 				return err
 			}
-			err = runtime.DefaultUnstructuredConverter.FromUnstructured(un, c)
+			content := &v1alpha1.Content{}
+			err = runtime.DefaultUnstructuredConverter.FromUnstructured(un, content)
 			if err != nil {
 				return err
 			}
+			if manifest.IsBlobID(content.Name) {
+				blobs[content.SHA256Sum] = content
+			} else {
+				c = content
+			}
 		case "BundleDeployment":
 			un, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
 			if err != nil {
@@ -109,11 +116,14 @@ func (d *Deploy) Run(cmd *cobra.Command, args []string) error {
 		return errors.New("failed to read bundledeployment resource from file")
 	}
 
-	data, err := content.GUnzip(c.Content)
-	if err != nil {
-		return err
+	getBlob := func(shasum string) (string, error) {
+		blob, ok := blobs[shasum]
+		if !ok {
+			return "", fmt.Errorf("blob %s is missing from the input file", manifest.ToBlobID(shasum))
+		}
+		return manifest.BlobData(blob)
 	}
-	manifest, err := manifest.FromJSON(data, c.SHA256Sum)
+	manifest, err := manifest.FromContent(c, getBlob)
 	if err != nil {
 		return err
 	}
@@ -156,7 +166,7 @@ func (d *Deploy) Run(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	rel, err := deployer.Deploy(ctx, bd.Name, manifest, bd.Spec.Options)
+	rel, err := deployer.Deploy(ctx, bd.Name, manifest, bd.Spec.Options, nil)
 	if err != nil {
 		return err
 	}
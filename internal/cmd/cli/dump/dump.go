@@ -20,6 +20,7 @@ import (
 	"github.com/go-logr/logr"
 
 	"github.com/rancher/fleet/internal/config"
+	"github.com/rancher/fleet/internal/manifest"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
@@ -172,6 +173,25 @@ func addContentsToArchive(
 
 	logger.V(1).Info("Fetching ...", "resource", rID.String())
 
+	addContent := func(i *unstructured.Unstructured) error {
+		if metadataOnly {
+			// Only strip the actual content (manifests), keep sha256sum and status as metadata
+			i.Object["content"] = nil
+		}
+
+		g, err := yaml.Marshal(i)
+		if err != nil {
+			return fmt.Errorf("failed to marshal content: %w", err)
+		}
+
+		fileName := "contents_" + i.GetName()
+		return addFileToArchive(g, fileName, w)
+	}
+
+	// Blobs referenced by the filtered contents, which are added after
+	// listing.
+	blobIDs := map[string]bool{}
+
 	lo := metav1.ListOptions{Limit: opt.FetchLimit}
 	for {
 		list, err := dynamic.Resource(rID).List(ctx, lo)
@@ -185,18 +205,16 @@ func addContentsToArchive(
 				continue
 			}
 
-			if metadataOnly {
-				// Only strip the actual content (manifests), keep sha256sum and status as metadata
-				i.Object["content"] = nil
-			}
-
-			g, err := yaml.Marshal(&i)
-			if err != nil {
-				return fmt.Errorf("failed to marshal content: %w", err)
+			if contentIDMap != nil {
+				blobs, _, _ := unstructured.NestedSlice(i.Object, "blobs")
+				for _, b := range blobs {
+					if shasum, ok := b.(map[string]any)["sha256sum"].(string); ok {
+						blobIDs[manifest.ToBlobID(shasum)] = true
+					}
+				}
 			}
 
-			fileName := "contents_" + i.GetName()
-			if err := addFileToArchive(g, fileName, w); err != nil {
+			if err := addContent(&i); err != nil {
 				return err
 			}
 		}
@@ -208,6 +226,19 @@ func addContentsToArchive(
 		lo.Continue = c
 	}
 
+	for id := range blobIDs {
+		i, err := dynamic.Resource(rID).Get(ctx, id, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			logger.Info("Content blob not found", "name", id)
+			continue
+		} else if err != nil {
+			return fmt.Errorf("failed to get content blob %s: %w", id, err)
+		}
+		if err := addContent(i); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -0,0 +1,197 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	command "github.com/rancher/fleet/internal/cmd"
+	"github.com/rancher/fleet/internal/cmd/controller/target"
+	"github.com/rancher/fleet/internal/cmd/controller/target/matcher"
+	"github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/yaml"
+)
+
+// NewExplain returns a subcommand to explain the targeting decisions for a bundle and a cluster
+func NewExplain() *cobra.Command {
+	cmd := command.Command(&Explain{}, cobra.Command{
+		Use:   "explain [flags] BUNDLE --cluster NAME",
+		Short: "Explain why a bundle is or is not deployed to a cluster",
+		Args:  cobra.MaximumNArgs(1),
+	})
+	cmd.SetOut(os.Stdout)
+
+	fs := flag.NewFlagSet("", flag.ExitOnError)
+	zopts.BindFlags(fs)
+	ctrl.RegisterFlags(fs)
+	cmd.Flags().AddGoFlagSet(fs)
+	return cmd
+}
+
+type Explain struct {
+	BundleFile       string `usage:"Location of a Bundle resource yaml, used instead of the bundle from the cluster" short:"b"`
+	Cluster          string `usage:"Name of the cluster" short:"c"`
+	ClusterNamespace string `usage:"Namespace of the cluster, defaults to the bundle namespace"`
+	Namespace        string `usage:"Namespace of the bundle" short:"n" default:"fleet-local"`
+	Output           string `usage:"Output format, either text or yaml" short:"o" default:"text"`
+}
+
+func (e *Explain) Run(cmd *cobra.Command, args []string) error {
+	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&zopts)))
+	ctx := log.IntoContext(cmd.Context(), ctrl.Log)
+
+	if e.Cluster == "" || (len(args) == 0 && e.BundleFile == "") {
+		return cmd.Help()
+	}
+	if e.Output != "text" && e.Output != "yaml" {
+		return fmt.Errorf("unsupported output format %q", e.Output)
+	}
+
+	cfg := ctrl.GetConfigOrDie()
+	c, err := client.New(cfg, client.Options{Scheme: scheme})
+	if err != nil {
+		return err
+	}
+
+	bundle := &v1alpha1.Bundle{}
+	if e.BundleFile != "" {
+		b, err := os.ReadFile(e.BundleFile)
+		if err != nil {
+			return err
+		}
+		if err := yaml.Unmarshal(b, bundle); err != nil {
+			return err
+		}
+		if bundle.Name == "" {
+			return errors.New("failed to read bundle from file, bundle has no name")
+		}
+		if bundle.Namespace == "" {
+			bundle.Namespace = e.Namespace
+		}
+	} else if err := c.Get(ctx, client.ObjectKey{Namespace: e.Namespace, Name: args[0]}, bundle); err != nil {
+		return err
+	}
+
+	clusterNamespace := e.ClusterNamespace
+	if clusterNamespace == "" {
+		clusterNamespace = bundle.Namespace
+	}
+	cluster := &v1alpha1.Cluster{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: clusterNamespace, Name: e.Cluster}, cluster); err != nil {
+		return err
+	}
+
+	explanation, err := target.New(c, c).Explain(ctx, bundle, cluster)
+	if err != nil {
+		return err
+	}
+
+	if e.Output == "yaml" {
+		b, err := yaml.Marshal(explanation)
+		if err != nil {
+			return err
+		}
+		_, err = cmd.OutOrStdout().Write(b)
+		return err
+	}
+
+	writeExplanation(cmd.OutOrStdout(), explanation)
+	return nil
+}
+
+// writeExplanation prints a human readable explanation.
+func writeExplanation(w io.Writer, e *target.Explanation) {
+	fmt.Fprintf(w, "Bundle:  %s\n", e.Bundle)
+	fmt.Fprintf(w, "Cluster: %s\n", e.Cluster)
+	if len(e.ClusterGroups) > 0 {
+		fmt.Fprintf(w, "Cluster groups: %s\n", strings.Join(e.ClusterGroups, ", "))
+	} else {
+		fmt.Fprintln(w, "Cluster groups: <none>")
+	}
+
+	fmt.Fprintf(w, "\nScope: %s\n", mark(e.InScope))
+	for _, m := range e.NamespaceMappings {
+		if m.Invalid != "" {
+			fmt.Fprintf(w, "  BundleNamespaceMapping %s: invalid: %s\n", m.Name, m.Invalid)
+			continue
+		}
+		fmt.Fprintf(w, "  BundleNamespaceMapping %s: bundle selector %s, namespace selector %s\n", m.Name, mark(m.BundleMatched), mark(m.NamespaceMatched))
+	}
+
+	writeTargets(w, "GitRepo targets", e.GitTargets)
+	writeTargets(w, "Target restrictions", e.Restrictions)
+	writeTargets(w, "Targets", e.Targets)
+	writeTargets(w, fmt.Sprintf("Target customizations (%s)", e.CustomizationMode), e.Customizations)
+
+	if len(e.Schedules) > 0 {
+		fmt.Fprintln(w, "\nSchedules:")
+		for _, s := range e.Schedules {
+			fmt.Fprintf(w, "  %s %s (active: %t)\n", mark(s.Matched), s.Name, s.Active)
+		}
+	}
+
+	fmt.Fprintln(w)
+	if e.MatchedTarget != "" {
+		fmt.Fprintf(w, "Selected target: %s\n", e.MatchedTarget)
+	}
+	if len(e.SelectedCustomizations) > 0 {
+		fmt.Fprintf(w, "Selected customizations: %s\n", strings.Join(e.SelectedCustomizations, ", "))
+	}
+	if e.BundleDeployment != "" {
+		fmt.Fprintf(w, "BundleDeployment: %s (%s)\n", e.BundleDeployment, e.BundleDeploymentState)
+	}
+
+	if len(e.Blockers) == 0 {
+		fmt.Fprintln(w, "Result: bundle is deployed to the cluster")
+		return
+	}
+	fmt.Fprintln(w, "Result: blocked")
+	for _, b := range e.Blockers {
+		fmt.Fprintf(w, "  - %s\n", b)
+	}
+}
+
+func writeTargets(w io.Writer, title string, targets []matcher.TargetExplanation) {
+	if len(targets) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\n%s:\n", title)
+	for _, t := range targets {
+		name := t.Name
+		if name == "" {
+			name = "<unnamed>"
+		}
+		fmt.Fprintf(w, "  %s %s", mark(t.Matched), name)
+		if t.Matched && t.ClusterGroup != "" {
+			fmt.Fprintf(w, " (via cluster group %s)", t.ClusterGroup)
+		}
+		if t.DoNotDeploy {
+			fmt.Fprint(w, " [doNotDeploy]")
+		}
+		fmt.Fprintln(w)
+		for _, c := range t.Criteria {
+			fmt.Fprintf(w, "      %s %s", mark(c.Matched), c.Criterion)
+			if c.Reason != "" {
+				fmt.Fprintf(w, ": %s", c.Reason)
+			}
+			fmt.Fprintln(w)
+		}
+	}
+}
+
+func mark(ok bool) string {
+	if ok {
+		return "[match]"
+	}
+	return "[no match]"
+}
@@ -0,0 +1,43 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/rancher/fleet/internal/cmd/controller/target"
+	"github.com/rancher/fleet/internal/cmd/controller/target/matcher"
+)
+
+func Test_writeExplanation(t *testing.T) {
+	e := &target.Explanation{
+		Bundle:  "fleet-default/app",
+		Cluster: "fleet-default/c1",
+		InScope: true,
+		BundleExplanation: matcher.BundleExplanation{
+			Targets: []matcher.TargetExplanation{{
+				Name: "prod",
+				MatchResult: matcher.MatchResult{Criteria: []matcher.CriterionResult{
+					{Criterion: "clusterSelector=env=prod", Reason: "cluster labels do not satisfy env=prod"},
+				}},
+			}},
+		},
+		Blockers: []string{"cluster does not match any target"},
+	}
+
+	var b bytes.Buffer
+	writeExplanation(&b, e)
+	out := b.String()
+
+	for _, want := range []string{
+		"Cluster groups: <none>",
+		"Scope: [match]",
+		"  [no match] prod\n",
+		"      [no match] clusterSelector=env=prod: cluster labels do not satisfy env=prod\n",
+		"Result: blocked\n  - cluster does not match any target\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+}
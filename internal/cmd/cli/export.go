@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"k8s.io/apimachinery/pkg/labels"
+
+	command "github.com/rancher/fleet/internal/cmd"
+	"github.com/rancher/fleet/internal/cmd/cli/airgap"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+// NewExport returns a subcommand to export bundles for a disconnected cluster into a signed archive
+func NewExport() *cobra.Command {
+	cmd := command.Command(&Export{}, cobra.Command{
+		Use:   "export [flags] --cluster NAME --private-key FILE -o ARCHIVE [BUNDLE...]",
+		Short: "Export bundles, their bundledeployments and manifests for a disconnected cluster into a signed archive",
+		Long: `Export bundles, their bundledeployments and manifests for a disconnected cluster into a signed archive.
+
+The archive is signed with an ed25519 private key in PEM format, which can be created with:
+
+  openssl genpkey -algorithm ed25519 -out fleet-export.key
+  openssl pkey -in fleet-export.key -pubout -out fleet-export.pub
+
+Use 'fleet import' on the disconnected cluster to verify and deploy the archive.`,
+	})
+	cmd.SetOut(os.Stdout)
+
+	fs := flag.NewFlagSet("", flag.ExitOnError)
+	zopts.BindFlags(fs)
+	ctrl.RegisterFlags(fs)
+	cmd.Flags().AddGoFlagSet(fs)
+	return cmd
+}
+
+type Export struct {
+	Cluster    string `usage:"Name of the cluster to export bundledeployments for" short:"c"`
+	Namespace  string `usage:"Namespace of the bundles and the cluster" short:"n" default:"fleet-local"`
+	Selector   string `usage:"Only export bundles matching this label selector" short:"l"`
+	PrivateKey string `usage:"Location of the PEM encoded ed25519 private key used to sign the archive" short:"k"`
+	Output     string `usage:"Location of the archive, or - for stdout" short:"o"`
+}
+
+func (e *Export) Run(cmd *cobra.Command, args []string) error {
+	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&zopts)))
+	ctx := log.IntoContext(cmd.Context(), ctrl.Log)
+
+	if e.Cluster == "" || e.PrivateKey == "" || e.Output == "" {
+		return cmd.Help()
+	}
+
+	data, err := os.ReadFile(e.PrivateKey)
+	if err != nil {
+		return err
+	}
+	key, err := airgap.ParsePrivateKey(data)
+	if err != nil {
+		return err
+	}
+
+	opts := airgap.ExportOptions{
+		Namespace: e.Namespace,
+		Cluster:   e.Cluster,
+		Bundles:   args,
+	}
+	if e.Selector != "" {
+		opts.Selector, err = labels.Parse(e.Selector)
+		if err != nil {
+			return fmt.Errorf("invalid selector %q: %w", e.Selector, err)
+		}
+	}
+
+	cfg := ctrl.GetConfigOrDie()
+	c, err := client.New(cfg, client.Options{Scheme: scheme})
+	if err != nil {
+		return err
+	}
+
+	archive, err := airgap.Export(ctx, c, opts)
+	if err != nil {
+		return err
+	}
+	if len(archive.Deployments) == 0 {
+		return errors.New("no bundledeployments found for the selected bundles and cluster")
+	}
+
+	if e.Output == "-" {
+		return airgap.Write(cmd.OutOrStdout(), archive, key)
+	}
+
+	f, err := os.Create(e.Output)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := airgap.Write(f, archive, key); err != nil {
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,307 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"helm.sh/helm/v4/pkg/cli"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	command "github.com/rancher/fleet/internal/cmd"
+	"github.com/rancher/fleet/internal/cmd/agent/deployer/desiredset"
+	"github.com/rancher/fleet/internal/cmd/agent/deployer/monitor"
+	"github.com/rancher/fleet/internal/cmd/cli/airgap"
+	"github.com/rancher/fleet/internal/helmdeployer"
+	"github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/durations"
+
+	"github.com/rancher/wrangler/v3/pkg/condition"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/yaml"
+)
+
+// NewImport returns a subcommand to deploy an archive created by fleet export to a disconnected cluster.
+func NewImport() *cobra.Command {
+	cmd := command.Command(&Import{}, cobra.Command{
+		Use:   "import [flags] --public-key FILE ARCHIVE",
+		Short: "Verify and deploy an archive created by 'fleet export' to a disconnected cluster",
+		Long: `Verify and deploy an archive created by 'fleet export' to a disconnected cluster.
+
+Each bundledeployment in the archive is deployed as a Helm release, like 'fleet deploy' does. Dependencies between
+bundles are not checked. The readiness of the deployed resources is monitored like the agent does, until all of them
+are ready or the ready timeout expires.
+
+The outcome is stored in the fleet-import-status config map in the agent namespace and optionally written to a file.
+Use 'fleet import-status' on the upstream cluster to update the status of the bundledeployments from it.`,
+		Args: cobra.ExactArgs(1),
+	})
+	cmd.SetOut(os.Stdout)
+
+	fs := flag.NewFlagSet("", flag.ExitOnError)
+	zopts.BindFlags(fs)
+	ctrl.RegisterFlags(fs)
+	cmd.Flags().AddGoFlagSet(fs)
+	return cmd
+}
+
+type Import struct {
+	PublicKey      string `usage:"Location of the PEM encoded ed25519 public key used to verify the archive" short:"k"`
+	Namespace      string `usage:"Set the default namespace. Deploy helm charts into this namespace." short:"n"`
+	AgentNamespace string `usage:"Set the agent namespace, the import status is stored there" short:"a" default:"cattle-fleet-system"`
+	AgentScope     string `usage:"Set the agent scope, so a fleet agent using the same scope can later take over the helm releases"`
+	StatusOutput   string `usage:"Also write the import status to this file, or - for stdout"`
+	ReadyTimeout   string `usage:"Wait up to this duration for the deployed resources to become ready, as a duration like 30s or 5m" default:"2m"`
+}
+
+func (i *Import) Run(cmd *cobra.Command, args []string) error {
+	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&zopts)))
+	ctx := log.IntoContext(cmd.Context(), ctrl.Log)
+
+	if i.PublicKey == "" {
+		return cmd.Help()
+	}
+
+	readyTimeout, err := time.ParseDuration(i.ReadyTimeout)
+	if err != nil {
+		return fmt.Errorf("invalid ready timeout: %w", err)
+	}
+
+	data, err := os.ReadFile(i.PublicKey)
+	if err != nil {
+		return err
+	}
+	key, err := airgap.ParsePublicKey(data)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	archive, err := airgap.Read(f, key)
+	if err != nil {
+		return err
+	}
+
+	cfg := ctrl.GetConfigOrDie()
+	c, err := client.New(cfg, client.Options{Scheme: scheme})
+	if err != nil {
+		return err
+	}
+
+	namespace := defaultNamespace
+	if i.Namespace != "" {
+		namespace = i.Namespace
+	}
+
+	deployer := helmdeployer.New(
+		i.AgentNamespace,
+		namespace,
+		defaultNamespace,
+		i.AgentScope,
+	)
+
+	if kubeconfig := flag.Lookup("kubeconfig").Value.String(); kubeconfig != "" {
+		// set KUBECONFIG env var so helm can find it
+		os.Setenv("KUBECONFIG", kubeconfig)
+	}
+
+	if err := deployer.Setup(ctx, c, cli.New().RESTClientGetter()); err != nil {
+		return err
+	}
+
+	ds, err := desiredset.New(cfg)
+	if err != nil {
+		return err
+	}
+	// The monitor derives the label prefix from its namespace, which must
+	// match the label prefix of the deployer.
+	mon := monitor.New(c, ds, deployer, defaultNamespace, i.AgentScope)
+
+	report := importArchive(ctx, deployer, mon, archive, readyTimeout)
+	for _, item := range report.Items {
+		if msg := condition.Cond(v1alpha1.BundleDeploymentConditionDeployed).GetMessage(&item.Status); msg != "" {
+			cmd.PrintErrf("failed to deploy %s/%s: %s\n", item.Namespace, item.Name, msg)
+		} else if msg := condition.Cond(v1alpha1.BundleDeploymentConditionReady).GetMessage(&item.Status); msg != "" {
+			cmd.PrintErrf("deployed %s/%s as %s, not ready: %s\n", item.Namespace, item.Name, item.Status.Release, msg)
+		} else {
+			cmd.PrintErrf("deployed %s/%s as %s\n", item.Namespace, item.Name, item.Status.Release)
+		}
+	}
+
+	if err := airgap.SaveStatus(ctx, c, i.AgentNamespace, report); err != nil {
+		return fmt.Errorf("failed to save import status: %w", err)
+	}
+	if i.StatusOutput != "" {
+		if err := writeImportStatus(cmd, i.StatusOutput, report); err != nil {
+			return err
+		}
+	}
+
+	if failed := countFailed(report); failed > 0 {
+		return fmt.Errorf("failed to deploy %d of %d bundledeployments", failed, len(report.Items))
+	}
+	return nil
+}
+
+// importArchive deploys the bundle deployments of the archive and returns
+// their status, as the agent would report it. After deploying, the status is
+// computed by mon until all bundle deployments are ready or readyTimeout
+// expires.
+func importArchive(ctx context.Context, deployer *helmdeployer.Helm, mon *monitor.Monitor, archive *airgap.Archive, readyTimeout time.Duration) *airgap.StatusReport {
+	report := &airgap.StatusReport{
+		Index:    archive.Index,
+		Imported: metav1.NewTime(time.Now().UTC()),
+	}
+
+	bds := make([]*v1alpha1.BundleDeployment, 0, len(archive.Deployments))
+	var deployed []*v1alpha1.BundleDeployment
+	for _, d := range archive.Deployments {
+		bd := d.BundleDeployment.DeepCopy()
+		bd.Status = v1alpha1.BundleDeploymentStatus{}
+		d.Manifest.Commit = bd.Labels[v1alpha1.CommitLabel]
+
+		rel, err := deployer.Deploy(ctx, bd.Name, d.Manifest, bd.Spec.Options, nil)
+		if err == nil {
+			bd.Status.AppliedDeploymentID = bd.Spec.DeploymentID
+			bd.Status.Release = helmdeployer.ReleaseToResourceID(rel)
+			deployed = append(deployed, bd)
+		}
+		condition.Cond(v1alpha1.BundleDeploymentConditionDeployed).SetError(&bd.Status, "", err)
+		bds = append(bds, bd)
+	}
+
+	deadline := time.Now().Add(readyTimeout)
+	for {
+		ready := true
+		for _, bd := range deployed {
+			bd.Status = monitorStatus(ctx, deployer, mon, bd)
+			ready = ready && condition.Cond(v1alpha1.BundleDeploymentConditionReady).IsTrue(&bd.Status)
+		}
+		if ready || !time.Now().Before(deadline) || !sleep(ctx, durations.DefaultRequeueAfter) {
+			break
+		}
+	}
+
+	for _, bd := range bds {
+		report.Items = append(report.Items, airgap.DeploymentStatus{
+			Namespace: bd.Namespace,
+			Name:      bd.Name,
+			Status:    bd.Status,
+		})
+	}
+	return report
+}
+
+// sleep waits for d and returns false if ctx is done first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
+
+// monitorStatus computes the status of the deployed bd from its resources,
+// like the agent does. Errors are recorded in the Ready condition.
+func monitorStatus(ctx context.Context, deployer *helmdeployer.Helm, mon *monitor.Monitor, bd *v1alpha1.BundleDeployment) v1alpha1.BundleDeploymentStatus {
+	resources, err := deployer.Resources(bd.Name, bd.Status.Release)
+	if err == nil {
+		var status v1alpha1.BundleDeploymentStatus
+		status, err = mon.UpdateStatus(ctx, bd, resources)
+		if err == nil {
+			return status
+		}
+	}
+
+	status := *bd.Status.DeepCopy()
+	status.Ready = false
+	condition.Cond(v1alpha1.BundleDeploymentConditionReady).SetError(&status, "", fmt.Errorf("failed to monitor resources: %w", err))
+	return status
+}
+
+func writeImportStatus(cmd *cobra.Command, output string, report *airgap.StatusReport) error {
+	b, err := yaml.Marshal(report)
+	if err != nil {
+		return err
+	}
+	if output == "-" {
+		_, err = cmd.OutOrStdout().Write(b)
+		return err
+	}
+	return os.WriteFile(output, b, 0600)
+}
+
+func countFailed(report *airgap.StatusReport) int {
+	n := 0
+	for _, item := range report.Items {
+		if item.Status.AppliedDeploymentID == "" {
+			n++
+		}
+	}
+	return n
+}
+
+// NewImportStatus returns a subcommand to update upstream bundledeployments from the status recorded by fleet import.
+func NewImportStatus() *cobra.Command {
+	cmd := command.Command(&ImportStatus{}, cobra.Command{
+		Use:   "import-status [flags] FILE",
+		Short: "Update the status of upstream bundledeployments from the status recorded by 'fleet import'",
+		Long: `Update the status of upstream bundledeployments from the status recorded by 'fleet import'.
+
+On the disconnected cluster, the status is stored in the fleet-import-status config map in the agent namespace:
+
+  kubectl get configmap -n cattle-fleet-system fleet-import-status -o jsonpath='{.data.status\.yaml}' > status.yaml
+
+The bundle and cluster status is then updated from the bundledeployments, as for clusters with a fleet agent.`,
+		Args: cobra.ExactArgs(1),
+	})
+	cmd.SetOut(os.Stdout)
+
+	fs := flag.NewFlagSet("", flag.ExitOnError)
+	zopts.BindFlags(fs)
+	ctrl.RegisterFlags(fs)
+	cmd.Flags().AddGoFlagSet(fs)
+	return cmd
+}
+
+type ImportStatus struct {
+}
+
+func (i *ImportStatus) Run(cmd *cobra.Command, args []string) error {
+	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&zopts)))
+	ctx := log.IntoContext(cmd.Context(), ctrl.Log)
+
+	b, err := os.ReadFile(args[0])
+	if err != nil {
+		return err
+	}
+	report := &airgap.StatusReport{}
+	if err := yaml.Unmarshal(b, report); err != nil {
+		return fmt.Errorf("failed to read import status: %w", err)
+	}
+
+	cfg := ctrl.GetConfigOrDie()
+	c, err := client.New(cfg, client.Options{Scheme: scheme})
+	if err != nil {
+		return err
+	}
+
+	if err := airgap.ApplyStatus(ctx, c, report); err != nil {
+		return err
+	}
+	cmd.Printf("updated the status of %d bundledeployments for cluster %s/%s\n", len(report.Items), report.Index.ClusterNamespace, report.Index.Cluster)
+	return nil
+}
@@ -67,9 +67,13 @@ func Match(ctx context.Context, opts *Options) error {
 	}
 
 	if opts.Target == "" {
-		m := bm.Match(opts.ClusterName, map[string]map[string]string{
-			opts.ClusterGroup: opts.ClusterGroupLabels,
-		}, opts.ClusterLabels)
+		m := bm.Match(&matcher.Cluster{
+			Name:   opts.ClusterName,
+			Labels: opts.ClusterLabels,
+			Groups: map[string]map[string]string{
+				opts.ClusterGroup: opts.ClusterGroupLabels,
+			},
+		})
 		return printMatch(ctx, bundle, m, opts.Output)
 	}
 
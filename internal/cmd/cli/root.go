@@ -28,13 +28,19 @@ func App() *cobra.Command {
 		newMigrateCmd(),
 
 		NewTarget(),
+		NewExplain(),
 		NewDeploy(),
+		NewExport(),
+		NewImport(),
+		NewImportStatus(),
+		NewSync(),
 		gitcloner.NewCmd(gitcloner.New()),
 
 		NewMonitor(),
 		NewAnalyze(),
 		NewDump(),
 		NewBundleDiff(),
+		NewValidate(),
 	)
 
 	return root
@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	command "github.com/rancher/fleet/internal/cmd"
+	"github.com/rancher/fleet/pkg/webhook"
+)
+
+// NewSync returns a subcommand to trigger a sync of a GitRepo or HelmOp via the sync endpoint of the gitjob controller
+func NewSync() *cobra.Command {
+	cmd := command.Command(&Sync{}, cobra.Command{
+		Use:   "sync [flags] [NAME]",
+		Short: "Trigger a sync of a GitRepo or HelmOp and optionally wait for the result",
+		Long: `Trigger a sync of a GitRepo or HelmOp and optionally wait for the result.
+
+The request is sent to the sync endpoint of the gitjob controller's webhook
+server, e.g. https://fleet.example.com/sync, and authenticated with the token
+from the "fleet-sync-token" secret. A secret in the controller namespace
+authorizes syncs in all namespaces, a secret in a namespace authorizes syncs
+in that namespace.
+
+Instead of a name, --repo and --branch select all GitRepos using that
+repository and branch.
+
+With --wait, the command waits until the bundle deployments are ready or
+failed and prints the result per cluster as JSON. It fails if a deployment
+failed or the timeout expired.`,
+		Args: cobra.MaximumNArgs(1),
+	})
+	cmd.SetOut(os.Stdout)
+	return cmd
+}
+
+type Sync struct {
+	URL       string `usage:"URL of the sync endpoint" env:"FLEET_SYNC_URL"`
+	Token     string `usage:"Token to authenticate the request" env:"FLEET_SYNC_TOKEN"`
+	Namespace string `usage:"Namespace of the GitRepo or HelmOp" short:"n" default:"fleet-local"`
+	Kind      string `usage:"Kind of the resource to sync, either GitRepo or HelmOp" default:"GitRepo"`
+	Repo      string `usage:"Sync all GitRepos using this repository URL, instead of a GitRepo by name"`
+	Branch    string `usage:"Branch of the repository, used with --repo"`
+	Commit    string `usage:"Commit SHA to deploy. If empty, the current commit is redeployed"`
+	Wait      bool   `usage:"Wait until the bundle deployments are ready or failed"`
+	Timeout   string `usage:"Timeout for waiting, as a duration like 30s or 5m" default:"5m"`
+}
+
+func (s *Sync) Run(cmd *cobra.Command, args []string) error {
+	if s.URL == "" || s.Token == "" {
+		return errors.New("the sync endpoint URL and token are required")
+	}
+	timeout, err := time.ParseDuration(s.Timeout)
+	if err != nil {
+		return fmt.Errorf("invalid timeout %q: %w", s.Timeout, err)
+	}
+	req := webhook.SyncRequest{
+		Kind:   s.Kind,
+		Repo:   s.Repo,
+		Branch: s.Branch,
+		Commit: s.Commit,
+		Wait:   s.Wait,
+	}
+	if s.Wait {
+		req.Timeout = timeout.String()
+	}
+	switch {
+	case len(args) == 1:
+		req.Namespace = s.Namespace
+		req.Name = args[0]
+	case s.Repo != "":
+		// Only restrict the namespace if it was given explicitly.
+		if cmd.Flags().Changed("namespace") {
+			req.Namespace = s.Namespace
+		}
+	default:
+		return cmd.Help()
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	httpReq, err := http.NewRequestWithContext(cmd.Context(), http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+s.Token)
+
+	client := &http.Client{Timeout: timeout + time.Minute}
+	httpResp, err := client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+
+	data, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return err
+	}
+	if httpResp.StatusCode != http.StatusOK {
+		return fmt.Errorf("sync request failed with %s: %s", httpResp.Status, strings.TrimSpace(string(data)))
+	}
+
+	var resp webhook.SyncResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	out, err := json.MarshalIndent(resp, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), string(out))
+
+	if !s.Wait {
+		return nil
+	}
+	switch {
+	case resp.TimedOut:
+		return fmt.Errorf("timed out after %s waiting for %s", timeout, strings.Join(resp.Triggered, ", "))
+	case resp.Failed:
+		return fmt.Errorf("sync of %s failed", strings.Join(resp.Triggered, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rancher/fleet/pkg/webhook"
+)
+
+func TestSync(t *testing.T) {
+	var got webhook.SyncRequest
+	resp := webhook.SyncResponse{Triggered: []string{"fleet-default/app"}, Failed: true, Clusters: []webhook.ClusterResult{
+		{Namespace: "fleet-default", Cluster: "c1", Bundle: "app", State: "ErrApplied", Message: "boom"},
+	}}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer srv.Close()
+
+	var out bytes.Buffer
+	cmd := NewSync()
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"--url", srv.URL, "--token", "secret", "-n", "fleet-default", "--commit", "abc", "--wait", "--timeout", "1m", "app"})
+	err := cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "sync of fleet-default/app failed") {
+		t.Errorf("expected failed sync error, got %v", err)
+	}
+
+	want := webhook.SyncRequest{Kind: "GitRepo", Namespace: "fleet-default", Name: "app", Commit: "abc", Wait: true, Timeout: "1m0s"}
+	if got != want {
+		t.Errorf("expected request %+v, got %+v", want, got)
+	}
+	if !strings.Contains(out.String(), `"message": "boom"`) {
+		t.Errorf("expected per-cluster results in output, got:\n%s", out.String())
+	}
+
+	cmd = NewSync()
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"--url", srv.URL, "--token", "wrong", "app"})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("expected unauthorized error, got %v", err)
+	}
+}
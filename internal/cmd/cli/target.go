@@ -1,8 +1,11 @@
 package cli
 
 import (
+	"context"
 	"errors"
 	"flag"
+	"fmt"
+	"io"
 	"os"
 	"reflect"
 
@@ -10,6 +13,8 @@ import (
 
 	command "github.com/rancher/fleet/internal/cmd"
 	"github.com/rancher/fleet/internal/cmd/controller/target"
+	"github.com/rancher/fleet/internal/cmd/controller/target/matcher"
+	"github.com/rancher/fleet/internal/config"
 	"github.com/rancher/fleet/internal/content"
 	"github.com/rancher/fleet/internal/manifest"
 	"github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
@@ -57,6 +62,7 @@ func NewTarget() *cobra.Command {
 type Target struct {
 	BundleFile    string `usage:"Location of the Bundle resource yaml" short:"b"`
 	DumpInputList bool   `usage:"Dump the live resources, which impact targeting, like clusters, as YAML" short:"l"`
+	Expressions   bool   `usage:"Print the result of each target's clusterExpression for every cluster in the namespace" short:"e"`
 
 	Namespace string `usage:"Override the namespace of the bundle. Targeting searches this namespace for clusters." short:"n"`
 }
@@ -100,6 +106,9 @@ func (t *Target) Run(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	// the placement strategy reads the agent check-in interval from the
+	// config, which is not loaded by the CLI
+	config.Set(config.DefaultConfig())
 	builder := target.New(client, client)
 	matchedTargets, _, err := builder.Targets(ctx, bundle, manifestID)
 	if err != nil {
@@ -121,6 +130,12 @@ func (t *Target) Run(cmd *cobra.Command, args []string) error {
 		cmd.PrintErrln(string(b))
 	}
 
+	if t.Expressions {
+		if err := printExpressions(ctx, cmd.ErrOrStderr(), client, bundle); err != nil {
+			return err
+		}
+	}
+
 	// output manifest/content resource
 	data, err := manifest.Content()
 	if err != nil {
@@ -190,3 +205,50 @@ func stageAllTargets(bundle *v1alpha1.Bundle, matchedTargets []*target.Target) e
 	bundle.Spec.RolloutStrategy.MaxNew = &count
 	return target.UpdatePartitions(&bundle.Status, matchedTargets)
 }
+
+// printExpressions evaluates the cluster expressions of the bundle's targets
+// and target restrictions against the clusters in the bundle namespace.
+func printExpressions(ctx context.Context, w io.Writer, c client.Client, bundle *v1alpha1.Bundle) error {
+	type expression struct{ kind, name, expr string }
+	var exprs []expression
+	for _, t := range bundle.Spec.Targets {
+		if t.ClusterExpression != "" {
+			exprs = append(exprs, expression{"target", t.Name, t.ClusterExpression})
+		}
+	}
+	for _, t := range bundle.Spec.TargetRestrictions {
+		if t.ClusterExpression != "" {
+			exprs = append(exprs, expression{"restriction", t.Name, t.ClusterExpression})
+		}
+	}
+	if len(exprs) == 0 {
+		return nil
+	}
+
+	clusters := &v1alpha1.ClusterList{}
+	if err := c.List(ctx, clusters, client.InNamespace(bundle.Namespace)); err != nil {
+		return err
+	}
+	for _, e := range exprs {
+		m, err := matcher.NewClusterMatcher("", "", nil, nil, e.expr)
+		if err != nil {
+			return fmt.Errorf("%s %q: %w", e.kind, e.name, err)
+		}
+		fmt.Fprintf(w, "# %s %q: %s\n", e.kind, e.name, e.expr)
+		for i := range clusters.Items {
+			cluster := &clusters.Items[i]
+			cgs, err := target.ClusterGroupsForCluster(ctx, c, cluster)
+			if err != nil {
+				return err
+			}
+			r := m.ExplainGroups(matcher.NewCluster(cluster, target.ClusterGroupsToLabelMap(cgs)))
+			if r.Matched {
+				fmt.Fprintf(w, "#   %s: matched\n", cluster.Name)
+			} else {
+				fmt.Fprintf(w, "#   %s: not matched, %s\n", cluster.Name, r.Criteria[0].Reason)
+			}
+		}
+	}
+
+	return nil
+}
@@ -1,12 +1,15 @@
 package cli
 
 import (
+	"bytes"
+	"context"
 	"strconv"
 	"testing"
 
 	"github.com/rancher/fleet/internal/cmd/controller/target"
 	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 // newTargetsWithoutDeployments creates targets that share a single bundle
@@ -108,3 +111,31 @@ func Test_stageAllTargets_preservesExistingRolloutStrategy(t *testing.T) {
 		t.Error("existing rollout strategy fields were overwritten")
 	}
 }
+
+func Test_printExpressions(t *testing.T) {
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&fleet.Cluster{ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: "prod", Labels: map[string]string{"env": "prod"}}},
+		&fleet.Cluster{ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: "dev"}},
+	).Build()
+	bundle := &fleet.Bundle{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: "bundle-1"},
+		Spec: fleet.BundleSpec{
+			Targets: []fleet.BundleTarget{
+				{Name: "selector", ClusterSelector: &metav1.LabelSelector{}},
+				{Name: "prod", ClusterExpression: `labels.env == "prod"`},
+			},
+		},
+	}
+
+	var out bytes.Buffer
+	if err := printExpressions(context.Background(), &out, c, bundle); err != nil {
+		t.Fatal(err)
+	}
+	want := `# target "prod": labels.env == "prod"
+#   dev: not matched, expression failed to evaluate: no such key: env
+#   prod: matched
+`
+	if out.String() != want {
+		t.Errorf("expected output\n%s\ngot\n%s", want, out.String())
+	}
+}
@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	command "github.com/rancher/fleet/internal/cmd"
+	"github.com/rancher/fleet/internal/cmd/cli/validate"
+)
+
+// NewValidate returns a subcommand to validate the rendered manifests of a bundle
+func NewValidate() *cobra.Command {
+	return command.Command(&Validate{}, cobra.Command{
+		Use:   "validate [flags] [BASE_DIR]",
+		Short: "Render a bundle for one or more targets and validate the manifests against Kubernetes schemas, offline",
+		Args:  cobra.MaximumNArgs(1),
+	})
+}
+
+type Validate struct {
+	BundleInputArgs
+	TargetsFile          string            `usage:"YAML file with a list of target descriptions (name, target, clusterName, clusterGroup, clusterLabels, clusterGroupLabels)"`
+	Name                 string            `usage:"Cluster name to match against" short:"N"`
+	Group                string            `usage:"Cluster group to match against" short:"g"`
+	Label                map[string]string `usage:"Cluster labels to match against" short:"l"`
+	GroupLabel           map[string]string `usage:"Cluster group labels to match against" short:"L"`
+	Target               string            `usage:"Explicit target to match" short:"t"`
+	KubeVersion          string            `usage:"Kubernetes version to validate against, APIs removed in this version are reported" default:"v1.36.0"`
+	CRDs                 []string          `usage:"CRD file or directory containing CRDs, can be repeated"`
+	IgnoreMissingSchemas bool              `usage:"Skip resources for which no schema is known, instead of reporting them"`
+	Policies             []string          `usage:"Policy file or directory containing Policy resources, whose resource rules are checked, can be repeated"`
+	JSON                 bool              `usage:"Print findings as JSON"`
+}
+
+func (v *Validate) Run(cmd *cobra.Command, args []string) error {
+	baseDir := "."
+	if len(args) > 0 {
+		baseDir = args[0]
+	}
+
+	opts := validate.Options{
+		BaseDir:              baseDir,
+		BundleSpec:           v.File,
+		BundleFile:           v.BundleFile,
+		KubeVersion:          v.KubeVersion,
+		CRDs:                 v.CRDs,
+		IgnoreMissingSchemas: v.IgnoreMissingSchemas,
+		Policies:             v.Policies,
+	}
+
+	if v.TargetsFile != "" {
+		targets, err := validate.ReadTargetDescriptions(v.TargetsFile)
+		if err != nil {
+			return err
+		}
+		opts.Targets = targets
+	}
+	if v.Name != "" || v.Group != "" || len(v.Label) > 0 || len(v.GroupLabel) > 0 || v.Target != "" {
+		opts.Targets = append(opts.Targets, validate.TargetDescription{
+			Target:             v.Target,
+			ClusterName:        v.Name,
+			ClusterGroup:       v.Group,
+			ClusterLabels:      v.Label,
+			ClusterGroupLabels: v.GroupLabel,
+		})
+	}
+
+	findings, err := validate.Validate(cmd.Context(), opts)
+	if err != nil {
+		return err
+	}
+	validate.SortFindings(findings)
+
+	if v.JSON {
+		if findings == nil {
+			findings = []validate.Finding{}
+		}
+		b, err := json.MarshalIndent(findings, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), string(b))
+	} else {
+		for _, f := range findings {
+			fmt.Fprintln(cmd.OutOrStdout(), f.String())
+		}
+	}
+
+	if len(findings) > 0 {
+		return fmt.Errorf("validation failed with %d error(s)", len(findings))
+	}
+	return nil
+}
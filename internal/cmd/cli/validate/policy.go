@@ -0,0 +1,74 @@
+package validate
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+// ReadPolicies loads Policy objects from a YAML file or from all YAML files
+// in a directory, recursively. Other documents are ignored.
+func ReadPolicies(path string) ([]fleet.Policy, error) {
+	var policies []fleet.Policy
+	err := filepath.WalkDir(path, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if p != path && !isYAML(p) {
+			return nil
+		}
+
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		found, err := decodePolicies(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", p, err)
+		}
+		policies = append(policies, found...)
+		return nil
+	})
+	return policies, err
+}
+
+func decodePolicies(data []byte) ([]fleet.Policy, error) {
+	var policies []fleet.Policy
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(data)))
+	for {
+		doc, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return policies, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		tm := &unstructured.Unstructured{}
+		if err := yaml.Unmarshal(doc, &tm.Object); err != nil || tm.Object == nil {
+			continue
+		}
+		if tm.GetKind() != "Policy" || tm.GroupVersionKind().Group != fleet.SchemeGroupVersion.Group {
+			continue
+		}
+
+		policy := fleet.Policy{}
+		if err := yaml.UnmarshalStrict(doc, &policy); err != nil {
+			return nil, fmt.Errorf("failed to decode Policy %s: %w", tm.GetName(), err)
+		}
+		policies = append(policies, policy)
+	}
+}
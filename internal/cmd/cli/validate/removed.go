@@ -0,0 +1,86 @@
+package validate
+
+import (
+	"fmt"
+
+	"github.com/Masterminds/semver/v3"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// removal describes an API which is no longer served by Kubernetes, see
+// https://kubernetes.io/docs/reference/using-api/deprecation-guide/
+type removal struct {
+	groupVersion string
+	// kind is empty if all kinds of the group version were removed.
+	kind        string
+	removedIn   string
+	replacement string
+}
+
+func (r removal) String() string {
+	return fmt.Sprintf("%s is no longer served since Kubernetes v%s, migrate to %s", r.groupVersion, r.removedIn, r.replacement)
+}
+
+var removals = []removal{
+	{groupVersion: "apps/v1beta1", removedIn: "1.16", replacement: "apps/v1"},
+	{groupVersion: "apps/v1beta2", removedIn: "1.16", replacement: "apps/v1"},
+	{groupVersion: "extensions/v1beta1", kind: "Ingress", removedIn: "1.22", replacement: "networking.k8s.io/v1"},
+	{groupVersion: "extensions/v1beta1", kind: "NetworkPolicy", removedIn: "1.16", replacement: "networking.k8s.io/v1"},
+	{groupVersion: "extensions/v1beta1", kind: "PodSecurityPolicy", removedIn: "1.16", replacement: "policy/v1beta1"},
+	{groupVersion: "extensions/v1beta1", removedIn: "1.16", replacement: "apps/v1"},
+	{groupVersion: "admissionregistration.k8s.io/v1beta1", removedIn: "1.22", replacement: "admissionregistration.k8s.io/v1"},
+	{groupVersion: "apiextensions.k8s.io/v1beta1", removedIn: "1.22", replacement: "apiextensions.k8s.io/v1"},
+	{groupVersion: "apiregistration.k8s.io/v1beta1", removedIn: "1.22", replacement: "apiregistration.k8s.io/v1"},
+	{groupVersion: "authentication.k8s.io/v1beta1", removedIn: "1.22", replacement: "authentication.k8s.io/v1"},
+	{groupVersion: "authorization.k8s.io/v1beta1", removedIn: "1.22", replacement: "authorization.k8s.io/v1"},
+	{groupVersion: "certificates.k8s.io/v1beta1", removedIn: "1.22", replacement: "certificates.k8s.io/v1"},
+	{groupVersion: "coordination.k8s.io/v1beta1", removedIn: "1.22", replacement: "coordination.k8s.io/v1"},
+	{groupVersion: "networking.k8s.io/v1beta1", removedIn: "1.22", replacement: "networking.k8s.io/v1"},
+	{groupVersion: "rbac.authorization.k8s.io/v1beta1", removedIn: "1.22", replacement: "rbac.authorization.k8s.io/v1"},
+	{groupVersion: "scheduling.k8s.io/v1beta1", removedIn: "1.22", replacement: "scheduling.k8s.io/v1"},
+	{groupVersion: "storage.k8s.io/v1beta1", kind: "CSIStorageCapacity", removedIn: "1.27", replacement: "storage.k8s.io/v1"},
+	{groupVersion: "storage.k8s.io/v1beta1", removedIn: "1.22", replacement: "storage.k8s.io/v1"},
+	{groupVersion: "batch/v1beta1", removedIn: "1.25", replacement: "batch/v1"},
+	{groupVersion: "discovery.k8s.io/v1beta1", removedIn: "1.25", replacement: "discovery.k8s.io/v1"},
+	{groupVersion: "events.k8s.io/v1beta1", removedIn: "1.25", replacement: "events.k8s.io/v1"},
+	{groupVersion: "autoscaling/v2beta1", removedIn: "1.25", replacement: "autoscaling/v2"},
+	{groupVersion: "policy/v1beta1", kind: "PodSecurityPolicy", removedIn: "1.25", replacement: "Pod Security Admission"},
+	{groupVersion: "policy/v1beta1", removedIn: "1.25", replacement: "policy/v1"},
+	{groupVersion: "node.k8s.io/v1beta1", removedIn: "1.25", replacement: "node.k8s.io/v1"},
+	{groupVersion: "autoscaling/v2beta2", removedIn: "1.26", replacement: "autoscaling/v2"},
+	{groupVersion: "flowcontrol.apiserver.k8s.io/v1beta1", removedIn: "1.26", replacement: "flowcontrol.apiserver.k8s.io/v1"},
+	{groupVersion: "flowcontrol.apiserver.k8s.io/v1beta2", removedIn: "1.29", replacement: "flowcontrol.apiserver.k8s.io/v1"},
+	{groupVersion: "flowcontrol.apiserver.k8s.io/v1beta3", removedIn: "1.32", replacement: "flowcontrol.apiserver.k8s.io/v1"},
+}
+
+// removedAPI returns the removal matching gvk, if the API is no longer served
+// by the given Kubernetes version. Entries for a specific kind take
+// precedence over entries for the whole group version.
+func removedAPI(gvk schema.GroupVersionKind, kubeVersion *semver.Version) *removal {
+	gv := gvk.GroupVersion().String()
+
+	var match *removal
+	for i := range removals {
+		r := &removals[i]
+		if r.groupVersion != gv {
+			continue
+		}
+		if r.kind == gvk.Kind {
+			match = r
+			break
+		}
+		if r.kind == "" && match == nil {
+			match = r
+		}
+	}
+	if match == nil {
+		return nil
+	}
+
+	removedIn := semver.MustParse(match.removedIn)
+	if kubeVersion.LessThan(removedIn) {
+		return nil
+	}
+	return match
+}
@@ -0,0 +1,61 @@
+package validate
+
+import (
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestRemovedAPI(t *testing.T) {
+	tests := []struct {
+		name        string
+		gvk         schema.GroupVersionKind
+		kubeVersion string
+		removedIn   string
+	}{
+		{
+			name:        "served before removal",
+			gvk:         schema.GroupVersionKind{Group: "batch", Version: "v1beta1", Kind: "CronJob"},
+			kubeVersion: "v1.24.3",
+		},
+		{
+			name:        "removed",
+			gvk:         schema.GroupVersionKind{Group: "batch", Version: "v1beta1", Kind: "CronJob"},
+			kubeVersion: "v1.25.0",
+			removedIn:   "1.25",
+		},
+		{
+			name:        "kind specific entry takes precedence",
+			gvk:         schema.GroupVersionKind{Group: "extensions", Version: "v1beta1", Kind: "Ingress"},
+			kubeVersion: "v1.20.0",
+		},
+		{
+			name:        "group version entry applies to other kinds",
+			gvk:         schema.GroupVersionKind{Group: "extensions", Version: "v1beta1", Kind: "Deployment"},
+			kubeVersion: "v1.20.0",
+			removedIn:   "1.16",
+		},
+		{
+			name:        "current API",
+			gvk:         schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+			kubeVersion: "v1.36.0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := removedAPI(tt.gvk, semver.MustParse(tt.kubeVersion))
+			if tt.removedIn == "" {
+				if r != nil {
+					t.Errorf("expected API to be served, got %s", r)
+				}
+				return
+			}
+			if r == nil || r.removedIn != tt.removedIn {
+				t.Errorf("expected API to be removed in %s, got %v", tt.removedIn, r)
+			}
+		})
+	}
+}
@@ -0,0 +1,268 @@
+package validate
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+
+	"github.com/rancher/fleet/internal/resourcepolicy"
+
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apiextensions-apiserver/pkg/apiserver/validation"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/kube-openapi/pkg/common"
+	"k8s.io/kube-openapi/pkg/validation/spec"
+	"k8s.io/kube-openapi/pkg/validation/strfmt"
+	"k8s.io/kube-openapi/pkg/validation/validate"
+	"k8s.io/kubernetes/pkg/generated/openapi"
+	"sigs.k8s.io/yaml"
+)
+
+// Schemas validates objects against the OpenAPI schemas of the built-in
+// Kubernetes API types, which are compiled into the binary, and against the
+// OpenAPI schemas of CustomResourceDefinitions added to it. No cluster access
+// is needed.
+type Schemas struct {
+	kubeVersion *semver.Version
+	builtin     runtime.Decoder
+	// definitions contains the OpenAPI schemas of the built-in types, by
+	// their model name.
+	definitions map[string]common.OpenAPIDefinition
+	crds        map[schema.GroupVersionKind]validation.SchemaValidator
+	// clusterScoped records the scope of custom resources.
+	clusterScoped map[schema.GroupKind]bool
+}
+
+// NewSchemas returns a schema set for the given Kubernetes version, e.g.
+// "v1.30.0". APIs removed in or before that version are reported as errors.
+func NewSchemas(kubeVersion string) (*Schemas, error) {
+	v, err := semver.NewVersion(kubeVersion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid kubernetes version %q: %w", kubeVersion, err)
+	}
+
+	return &Schemas{
+		kubeVersion:   v,
+		builtin:       serializer.NewCodecFactory(clientgoscheme.Scheme, serializer.EnableStrict).UniversalDeserializer(),
+		definitions:   openapi.GetOpenAPIDefinitions(definitionRef),
+		crds:          map[schema.GroupVersionKind]validation.SchemaValidator{},
+		clusterScoped: map[schema.GroupKind]bool{},
+	}, nil
+}
+
+// AddCRDPath loads CustomResourceDefinitions from a YAML file or from all
+// YAML files in a directory, recursively.
+func (s *Schemas) AddCRDPath(path string) error {
+	return filepath.WalkDir(path, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if p != path && !isYAML(p) {
+			return nil
+		}
+
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		if err := s.AddCRDs(data); err != nil {
+			return fmt.Errorf("%s: %w", p, err)
+		}
+		return nil
+	})
+}
+
+// AddCRDs registers the schemas of all CustomResourceDefinitions found in a
+// multi-document YAML stream. Other documents are ignored.
+func (s *Schemas) AddCRDs(data []byte) error {
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(data)))
+	for {
+		doc, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		tm := &unstructured.Unstructured{}
+		if err := yaml.Unmarshal(doc, &tm.Object); err != nil || tm.Object == nil {
+			continue
+		}
+		if tm.GetKind() != "CustomResourceDefinition" || tm.GroupVersionKind().Group != apiextensionsv1.GroupName {
+			continue
+		}
+
+		crd := &apiextensionsv1.CustomResourceDefinition{}
+		if err := yaml.Unmarshal(doc, crd); err != nil {
+			return fmt.Errorf("failed to decode CRD %s: %w", tm.GetName(), err)
+		}
+		if err := s.AddCRD(crd); err != nil {
+			return err
+		}
+	}
+}
+
+// AddCRD registers the schema of every version of the given CRD.
+func (s *Schemas) AddCRD(crd *apiextensionsv1.CustomResourceDefinition) error {
+	gk := schema.GroupKind{Group: crd.Spec.Group, Kind: crd.Spec.Names.Kind}
+	s.clusterScoped[gk] = crd.Spec.Scope == apiextensionsv1.ClusterScoped
+
+	for _, version := range crd.Spec.Versions {
+		if version.Schema == nil || version.Schema.OpenAPIV3Schema == nil {
+			continue
+		}
+
+		internal := &apiextensions.JSONSchemaProps{}
+		if err := apiextensionsv1.Convert_v1_JSONSchemaProps_To_apiextensions_JSONSchemaProps(version.Schema.OpenAPIV3Schema, internal, nil); err != nil {
+			return fmt.Errorf("failed to convert schema of CRD %s, version %s: %w", crd.Name, version.Name, err)
+		}
+		validator, _, err := validation.NewSchemaValidator(internal)
+		if err != nil {
+			return fmt.Errorf("invalid schema in CRD %s, version %s: %w", crd.Name, version.Name, err)
+		}
+
+		gvk := schema.GroupVersionKind{Group: crd.Spec.Group, Version: version.Name, Kind: crd.Spec.Names.Kind}
+		s.crds[gvk] = validator
+	}
+
+	return nil
+}
+
+// IsClusterScoped returns true if the kind is a cluster-scoped built-in kind
+// or defined by a cluster-scoped CRD.
+func (s *Schemas) IsClusterScoped(gk schema.GroupKind) bool {
+	if scoped, ok := s.clusterScoped[gk]; ok {
+		return scoped
+	}
+	return resourcepolicy.IsBuiltinClusterScoped(gk)
+}
+
+// ErrNoSchema is returned by Validate if neither a built-in type nor a CRD
+// is known for an object's kind.
+var ErrNoSchema = errors.New("no schema found")
+
+// Validate checks a single object. It returns a list of problems, which is
+// empty if the object is valid, or ErrNoSchema.
+func (s *Schemas) Validate(obj *unstructured.Unstructured) ([]string, error) {
+	gvk := obj.GroupVersionKind()
+
+	if removed := removedAPI(gvk, s.kubeVersion); removed != nil {
+		return []string{removed.String()}, nil
+	}
+
+	if validator, ok := s.crds[gvk]; ok {
+		var problems []string
+		for _, e := range validation.ValidateCustomResource(nil, obj.UnstructuredContent(), validator) {
+			problems = append(problems, e.Error())
+		}
+		return problems, nil
+	}
+
+	if clientgoscheme.Scheme.Recognizes(gvk) {
+		return s.validateBuiltin(obj)
+	}
+
+	return nil, ErrNoSchema
+}
+
+// validateBuiltin checks an object of a built-in kind against its OpenAPI
+// schema, e.g. for missing required fields and wrong value types. The
+// schemas allow unknown fields, which are reported by strictly decoding the
+// object into its API type instead.
+func (s *Schemas) validateBuiltin(obj *unstructured.Unstructured) ([]string, error) {
+	var problems []string
+	typed, err := clientgoscheme.Scheme.New(obj.GroupVersionKind())
+	if err != nil {
+		return nil, err
+	}
+	if model, ok := typed.(interface{ OpenAPIModelName() string }); ok {
+		if def, ok := s.definitions[model.OpenAPIModelName()]; ok {
+			result := s.schemaValidator(&def.Schema, "").Validate(obj.UnstructuredContent())
+			for _, e := range result.Errors {
+				problems = append(problems, e.Error())
+			}
+		}
+	}
+
+	data, err := obj.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	gvk := obj.GroupVersionKind()
+	if _, _, err := s.builtin.Decode(data, &gvk, nil); err != nil {
+		// Wrong value types are already reported by the schema.
+		if _, ok := runtime.AsStrictDecodingError(err); ok || len(problems) == 0 {
+			problems = append(problems, strictErrors(err)...)
+		}
+	}
+	return problems, nil
+}
+
+const definitionPrefix = "#/definitions/"
+
+func definitionRef(name string) spec.Ref {
+	return spec.MustCreateRef(definitionPrefix + name)
+}
+
+// schemaValidator returns a validator for schema, which resolves the
+// references to the definitions of other built-in types, as the OpenAPI
+// validator does not support references.
+func (s *Schemas) schemaValidator(schema *spec.Schema, path string) *validate.SchemaValidator {
+	for ref := schema.Ref.String(); ref != ""; ref = schema.Ref.String() {
+		def, ok := s.definitions[strings.TrimPrefix(ref, definitionPrefix)]
+		if !ok {
+			schema = &spec.Schema{}
+			break
+		}
+		schema = &def.Schema
+	}
+
+	return validate.NewSchemaValidator(schema, nil, path, strfmt.Default, func(o *validate.SchemaValidatorOptions) {
+		o.NewValidatorForField = func(_ string, schema *spec.Schema, _ any, path string, _ strfmt.Registry, _ ...validate.Option) validate.ValueValidator {
+			return s.schemaValidator(schema, path)
+		}
+		o.NewValidatorForIndex = func(_ int, schema *spec.Schema, _ any, path string, _ strfmt.Registry, _ ...validate.Option) validate.ValueValidator {
+			return s.schemaValidator(schema, path)
+		}
+	})
+}
+
+// strictErrors splits the aggregated error returned by a strict decoder into
+// one message per field.
+func strictErrors(err error) []string {
+	strictErr, ok := runtime.AsStrictDecodingError(err)
+	if !ok {
+		return []string{err.Error()}
+	}
+
+	var problems []string
+	for _, e := range strictErr.Errors() {
+		problems = append(problems, e.Error())
+	}
+	return problems
+}
+
+func isYAML(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
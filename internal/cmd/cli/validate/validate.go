@@ -0,0 +1,429 @@
+// Package validate renders a bundle for a set of target descriptions and
+// validates the resulting manifests against Kubernetes schemas.
+//
+// It works offline: built-in kinds are checked against their OpenAPI schemas
+// and API types compiled into the fleet CLI, custom resources against the
+// OpenAPI schemas of CRDs, which are passed in or part of the rendered bundle
+// itself. The resource
+// rules of Policy objects are evaluated the same way the agent does.
+package validate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/rancher/fleet/internal/bundlereader"
+	"github.com/rancher/fleet/internal/cmd/controller/options"
+	"github.com/rancher/fleet/internal/cmd/controller/policyrestrictions"
+	"github.com/rancher/fleet/internal/cmd/controller/target/matcher"
+	"github.com/rancher/fleet/internal/content"
+	"github.com/rancher/fleet/internal/helmdeployer"
+	"github.com/rancher/fleet/internal/manifest"
+	"github.com/rancher/fleet/internal/resourcepolicy"
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+)
+
+// DefaultKubeVersion matches the version of the API types compiled into the
+// CLI.
+const DefaultKubeVersion = "v1.36.0"
+
+// TargetDescription describes a hypothetical cluster the bundle is rendered
+// for. Either Target names a target of the bundle explicitly, or the cluster
+// properties are matched against the bundle's targets.
+type TargetDescription struct {
+	// Name is used in the report. Defaults to the cluster or target name.
+	Name               string            `json:"name,omitempty"`
+	Target             string            `json:"target,omitempty"`
+	ClusterName        string            `json:"clusterName,omitempty"`
+	ClusterGroup       string            `json:"clusterGroup,omitempty"`
+	ClusterLabels      map[string]string `json:"clusterLabels,omitempty"`
+	ClusterGroupLabels map[string]string `json:"clusterGroupLabels,omitempty"`
+}
+
+func (t TargetDescription) displayName() string {
+	switch {
+	case t.Name != "":
+		return t.Name
+	case t.Target != "":
+		return t.Target
+	case t.ClusterName != "":
+		return t.ClusterName
+	case t.ClusterGroup != "":
+		return "group " + t.ClusterGroup
+	}
+	return "default"
+}
+
+// ReadTargetDescriptions reads a YAML list of target descriptions.
+func ReadTargetDescriptions(path string) ([]TargetDescription, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var targets []TargetDescription
+	if err := yaml.UnmarshalStrict(data, &targets); err != nil {
+		return nil, fmt.Errorf("failed to read target descriptions from %s: %w", path, err)
+	}
+	return targets, nil
+}
+
+type Options struct {
+	BaseDir    string
+	BundleSpec string
+	BundleFile string
+	// Targets to render the bundle for. If empty, the bundle is rendered
+	// once without any target customization.
+	Targets     []TargetDescription
+	KubeVersion string
+	// CRDs are files or directories containing CustomResourceDefinitions.
+	CRDs []string
+	// IgnoreMissingSchemas skips resources of unknown kinds, instead of
+	// reporting them.
+	IgnoreMissingSchemas bool
+	// Policies are files or directories containing Policy objects, whose
+	// resource rules are checked.
+	Policies []string
+}
+
+// Finding is a validation error for a single resource.
+type Finding struct {
+	Target string `json:"target"`
+	// Source is the template which produced the resource, relative to the
+	// bundle's chart, e.g. "templates/deployment.yaml".
+	Source   string `json:"source,omitempty"`
+	Resource string `json:"resource,omitempty"`
+	Message  string `json:"message"`
+}
+
+func (f Finding) String() string {
+	var b strings.Builder
+	if f.Source != "" {
+		b.WriteString(f.Source)
+		b.WriteString(": ")
+	}
+	fmt.Fprintf(&b, "target %s: ", f.Target)
+	if f.Resource != "" {
+		b.WriteString(f.Resource)
+		b.WriteString(": ")
+	}
+	b.WriteString(f.Message)
+	return b.String()
+}
+
+// Validate renders the bundle for every target description and returns all
+// findings. An error is only returned if the bundle could not be processed.
+func Validate(ctx context.Context, opts Options) ([]Finding, error) {
+	kubeVersion := opts.KubeVersion
+	if kubeVersion == "" {
+		kubeVersion = DefaultKubeVersion
+	}
+	schemas, err := NewSchemas(kubeVersion)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range opts.CRDs {
+		if err := schemas.AddCRDPath(path); err != nil {
+			return nil, err
+		}
+	}
+
+	var policies []fleet.Policy
+	for _, path := range opts.Policies {
+		p, err := ReadPolicies(path)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, p...)
+	}
+	rules, err := resourcepolicy.New(policyrestrictions.Aggregate(policies).ResourceRules)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle, err := readBundle(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	bm, err := matcher.New(bundle)
+	if err != nil {
+		return nil, err
+	}
+
+	sources := sourceIndex(bundle.Spec.Resources)
+
+	targets := opts.Targets
+	if len(targets) == 0 {
+		targets = []TargetDescription{{}}
+	}
+
+	var findings []Finding
+	for _, t := range targets {
+		name := t.displayName()
+
+		bdOpts := bundle.Spec.BundleDeploymentOptions
+		if t.Target != "" || t.ClusterName != "" || t.ClusterGroup != "" || len(t.ClusterLabels) > 0 || len(t.ClusterGroupLabels) > 0 {
+			match := matchTarget(bm, t)
+			if match == nil {
+				findings = append(findings, Finding{Target: name, Message: "bundle does not match target"})
+				continue
+			}
+			bdOpts = options.Merge(bundle.Spec.BundleDeploymentOptions, match.BundleDeploymentOptions)
+		}
+		// Resource rules are reported as findings below, instead of failing
+		// the rendering like they do on the agent.
+		bdOpts.ResourceRules = nil
+
+		rel, err := helmdeployer.Template(ctx, bundle.Name, manifest.New(bundle.Spec.Resources), bdOpts, kubeVersion)
+		if err != nil {
+			findings = append(findings, Finding{Target: name, Message: fmt.Sprintf("failed to render: %v", err)})
+			continue
+		}
+
+		docs := splitManifest(rel.Manifest)
+		for _, hook := range rel.Hooks {
+			docs = append(docs, document{source: hook.Path, data: hook.Manifest})
+		}
+
+		findings = append(findings, validateDocuments(schemas, rules, name, docs, sources, opts.IgnoreMissingSchemas)...)
+	}
+
+	return findings, nil
+}
+
+func readBundle(ctx context.Context, opts Options) (*fleet.Bundle, error) {
+	if opts.BundleFile != "" {
+		data, err := os.ReadFile(opts.BundleFile)
+		if err != nil {
+			return nil, err
+		}
+		bundle := &fleet.Bundle{}
+		if err := yaml.Unmarshal(data, bundle); err != nil {
+			return nil, err
+		}
+		return bundle, nil
+	}
+
+	baseDir := opts.BaseDir
+	if baseDir == "" {
+		baseDir = "."
+	}
+	abs, err := filepath.Abs(baseDir)
+	if err != nil {
+		return nil, err
+	}
+	name := strings.ToLower(filepath.Base(abs))
+	bundle, _, err := bundlereader.NewBundle(ctx, name, baseDir, opts.BundleSpec, nil)
+	return bundle, err
+}
+
+func matchTarget(bm *matcher.BundleMatch, t TargetDescription) *fleet.BundleTarget {
+	if t.Target != "" {
+		return bm.MatchForTarget(t.Target)
+	}
+	return bm.Match(&matcher.Cluster{
+		Name:   t.ClusterName,
+		Labels: t.ClusterLabels,
+		Groups: map[string]map[string]string{
+			t.ClusterGroup: t.ClusterGroupLabels,
+		},
+	})
+}
+
+// sourceIndex maps "kind/name" of the objects in plain YAML resources to the
+// resource's file name. Fleet's post renderer discards the "# Source:"
+// comments Helm adds, so this is used to find the origin of an object again.
+// Templated resources, which do not parse as YAML, are skipped.
+func sourceIndex(resources []fleet.BundleResource) map[string]string {
+	index := map[string]string{}
+	for _, r := range resources {
+		if !isYAML(r.Name) {
+			continue
+		}
+		data, err := content.Decode(r.Content, r.Encoding)
+		if err != nil {
+			continue
+		}
+		for _, part := range separatorRegexp.Split(string(data), -1) {
+			objs, err := document{data: part}.objects()
+			if err != nil {
+				continue
+			}
+			for _, o := range objs {
+				key := o.obj.GetKind() + "/" + o.obj.GetName()
+				if _, ok := index[key]; !ok {
+					index[key] = r.Name
+				}
+			}
+		}
+	}
+	return index
+}
+
+// validateDocuments validates the rendered documents of one target. CRDs,
+// which are part of the documents, are added to the schemas first, so custom
+// resources deployed together with their definition can be checked.
+func validateDocuments(schemas *Schemas, rules *resourcepolicy.Evaluator, target string, docs []document, sources map[string]string, ignoreMissing bool) []Finding {
+	var (
+		findings []Finding
+		objs     []object
+	)
+	for _, doc := range docs {
+		parsed, err := doc.objects()
+		if err != nil {
+			findings = append(findings, Finding{Target: target, Source: doc.source, Message: err.Error()})
+			continue
+		}
+		for _, o := range parsed {
+			if src, ok := sources[o.obj.GetKind()+"/"+o.obj.GetName()]; ok && strings.HasPrefix(o.source, postRenderSourcePrefix) {
+				o.source = src
+			}
+			objs = append(objs, o)
+		}
+	}
+
+	for _, o := range objs {
+		if o.obj.GetKind() != "CustomResourceDefinition" {
+			continue
+		}
+		data, err := yaml.Marshal(o.obj.Object)
+		if err == nil {
+			err = schemas.AddCRDs(data)
+		}
+		if err != nil {
+			findings = append(findings, Finding{Target: target, Source: o.source, Resource: resourceName(o.obj), Message: err.Error()})
+		}
+	}
+
+	for _, o := range objs {
+		problems, err := schemas.Validate(o.obj)
+		if errors.Is(err, ErrNoSchema) {
+			if ignoreMissing {
+				continue
+			}
+			err = fmt.Errorf("%w for %s", err, o.obj.GroupVersionKind())
+		}
+		if err != nil {
+			problems = []string{err.Error()}
+		}
+		for _, p := range problems {
+			findings = append(findings, Finding{Target: target, Source: o.source, Resource: resourceName(o.obj), Message: p})
+		}
+	}
+
+	for _, o := range objs {
+		violations, err := rules.Evaluate(o.obj, schemas.IsClusterScoped(o.obj.GroupVersionKind().GroupKind()))
+		if err != nil {
+			findings = append(findings, Finding{Target: target, Source: o.source, Resource: resourceName(o.obj), Message: err.Error()})
+			continue
+		}
+		for _, v := range violations {
+			findings = append(findings, Finding{
+				Target:   target,
+				Source:   o.source,
+				Resource: resourceName(o.obj),
+				Message:  fmt.Sprintf("violates policy rule %q: %s", v.Rule, v.Message),
+			})
+		}
+	}
+
+	return findings
+}
+
+func resourceName(obj *unstructured.Unstructured) string {
+	name := obj.GetName()
+	if ns := obj.GetNamespace(); ns != "" {
+		name = ns + "/" + name
+	}
+	return obj.GetKind() + " " + name
+}
+
+type document struct {
+	source string
+	data   string
+}
+
+type object struct {
+	source string
+	obj    *unstructured.Unstructured
+}
+
+// postRenderSourcePrefix is the source Helm reports for documents returned
+// by a post renderer.
+const postRenderSourcePrefix = "generated-by-postrender"
+
+var (
+	sourceRegexp    = regexp.MustCompile(`(?m)^# Source: (.+)$`)
+	separatorRegexp = regexp.MustCompile(`(?m)^---\s*$`)
+)
+
+// splitManifest splits a Helm release manifest into its documents, keeping
+// track of the template each document was rendered from.
+func splitManifest(manifest string) []document {
+	var docs []document
+	for _, part := range separatorRegexp.Split(manifest, -1) {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		doc := document{data: part}
+		if m := sourceRegexp.FindStringSubmatch(part); m != nil {
+			doc.source = m[1]
+		}
+		docs = append(docs, doc)
+	}
+	return docs
+}
+
+// objects decodes a document, flattening lists.
+func (d document) objects() ([]object, error) {
+	obj := &unstructured.Unstructured{}
+	if err := yaml.Unmarshal([]byte(d.data), &obj.Object); err != nil {
+		return nil, fmt.Errorf("invalid YAML: %w", err)
+	}
+	if len(obj.Object) == 0 {
+		return nil, nil
+	}
+	if obj.GetKind() == "" || obj.GetAPIVersion() == "" {
+		return nil, errors.New("document is missing apiVersion or kind")
+	}
+
+	if !obj.IsList() {
+		return []object{{source: d.source, obj: obj}}, nil
+	}
+
+	var result []object
+	err := obj.EachListItem(func(item runtime.Object) error {
+		u, ok := item.(*unstructured.Unstructured)
+		if !ok {
+			return fmt.Errorf("unexpected list item %T", item)
+		}
+		result = append(result, object{source: d.source, obj: u})
+		return nil
+	})
+	return result, err
+}
+
+// SortFindings orders findings by target, source and resource, for stable
+// output.
+func SortFindings(findings []Finding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.Target != b.Target {
+			return a.Target < b.Target
+		}
+		if a.Source != b.Source {
+			return a.Source < b.Source
+		}
+		return a.Resource < b.Resource
+	})
+}
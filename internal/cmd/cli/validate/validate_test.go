@@ -0,0 +1,372 @@
+package validate
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const deployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+      - name: web
+        image: nginx
+`
+
+const invalidDeployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: broken
+spec:
+  replicas: "two"
+  selector:
+    matchLabels:
+      app: broken
+  template:
+    spec:
+      containers:
+      - name: web
+        imagee: nginx
+`
+
+const crd = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+spec:
+  group: example.com
+  names:
+    kind: Widget
+    plural: widgets
+  scope: Namespaced
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            required: [size]
+            properties:
+              size:
+                type: integer
+                maximum: 10
+`
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func messages(findings []Finding) string {
+	var s []string
+	for _, f := range findings {
+		s = append(s, f.String())
+	}
+	return strings.Join(s, "\n")
+}
+
+func TestValidate_ValidBundle(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"deployment.yaml": deployment})
+
+	findings, err := Validate(context.Background(), Options{BaseDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 0 {
+		t.Errorf("expected no findings, got:\n%s", messages(findings))
+	}
+}
+
+func TestValidate_InvalidFields(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"deployment.yaml": deployment, "broken.yaml": invalidDeployment})
+
+	findings, err := Validate(context.Background(), Options{BaseDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) == 0 {
+		t.Fatal("expected findings for invalid deployment")
+	}
+	for _, f := range findings {
+		if f.Resource != "Deployment broken" {
+			t.Errorf("unexpected finding for resource %q: %s", f.Resource, f)
+		}
+		if f.Source != "broken.yaml" {
+			t.Errorf("expected source to point to broken.yaml, got %q", f.Source)
+		}
+		if f.Target != "default" {
+			t.Errorf("expected default target, got %q", f.Target)
+		}
+	}
+}
+
+func TestValidate_BuiltinSchemas(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"deployment.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: "two"
+  template:
+    spec:
+      containers:
+      - image: nginx
+        resources:
+          limits:
+            cpu: 1
+`})
+
+	findings, err := Validate(context.Background(), Options{BaseDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := messages(findings)
+	for _, want := range []string{
+		`Deployment web: spec.replicas in body must be of type integer: "string"`,
+		"Deployment web: spec.selector in body is required",
+		"Deployment web: spec.template.spec.containers[0].name in body is required",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected finding %q, got:\n%s", want, got)
+		}
+	}
+	if len(findings) != 3 {
+		t.Errorf("expected 3 findings, got:\n%s", got)
+	}
+
+	// Unknown fields are reported, if the values have the right types.
+	dir = writeFiles(t, map[string]string{"configmap.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+unknown: true
+`})
+	findings, err = Validate(context.Background(), Options{BaseDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 1 || !strings.Contains(findings[0].Message, `unknown field "unknown"`) {
+		t.Errorf("expected unknown field finding, got:\n%s", messages(findings))
+	}
+}
+
+func TestValidate_CRDs(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"widget.yaml": `apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: w
+spec:
+  size: 20
+`,
+	})
+	crdDir := writeFiles(t, map[string]string{"crds/widget.yaml": crd})
+
+	findings, err := Validate(context.Background(), Options{BaseDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 1 || !strings.Contains(findings[0].Message, "no schema found") {
+		t.Errorf("expected missing schema finding, got:\n%s", messages(findings))
+	}
+
+	findings, err = Validate(context.Background(), Options{BaseDir: dir, IgnoreMissingSchemas: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 0 {
+		t.Errorf("expected missing schema to be ignored, got:\n%s", messages(findings))
+	}
+
+	findings, err = Validate(context.Background(), Options{BaseDir: dir, CRDs: []string{crdDir}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 1 || !strings.Contains(findings[0].Message, "spec.size") {
+		t.Errorf("expected spec.size finding, got:\n%s", messages(findings))
+	}
+}
+
+func TestValidate_CRDInBundle(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"crd.yaml": crd,
+		"widget.yaml": `apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: w
+spec: {}
+`,
+	})
+
+	findings, err := Validate(context.Background(), Options{BaseDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 1 || !strings.Contains(findings[0].Message, "spec.size") {
+		t.Errorf("expected required spec.size finding, got:\n%s", messages(findings))
+	}
+}
+
+func TestValidate_Targets(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"fleet.yaml": `targetCustomizations:
+- name: old
+  clusterSelector:
+    matchLabels:
+      env: old
+  kustomize:
+    dir: overlays/old
+- name: new
+  clusterSelector:
+    matchLabels:
+      env: new
+`,
+		"kustomization.yaml": "resources:\n- deployment.yaml\n",
+		"deployment.yaml":    deployment,
+		"overlays/old/kustomization.yaml": `resources:
+- cronjob.yaml
+`,
+		"overlays/old/cronjob.yaml": `apiVersion: batch/v1beta1
+kind: CronJob
+metadata:
+  name: job
+spec:
+  schedule: "* * * * *"
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          restartPolicy: Never
+          containers:
+          - name: job
+            image: busybox
+`,
+	})
+
+	findings, err := Validate(context.Background(), Options{
+		BaseDir:     dir,
+		KubeVersion: "v1.30.0",
+		Targets: []TargetDescription{
+			{Name: "prod", ClusterLabels: map[string]string{"env": "new"}},
+			{ClusterName: "legacy", ClusterLabels: map[string]string{"env": "old"}},
+			{Target: "missing"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	SortFindings(findings)
+	if len(findings) != 2 {
+		t.Fatalf("expected two findings, got:\n%s", messages(findings))
+	}
+	if findings[0].Target != "legacy" || findings[0].Resource != "CronJob job" || !strings.Contains(findings[0].Message, "v1.25") {
+		t.Errorf("expected removed API finding for legacy target, got %s", findings[0])
+	}
+	if findings[1].Target != "missing" || findings[1].Message != "bundle does not match target" {
+		t.Errorf("expected no match finding, got %s", findings[1])
+	}
+}
+
+func TestReadTargetDescriptions(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"targets.yaml": `- name: a
+  clusterLabels:
+    env: dev
+- clusterGroup: prod
+`,
+		"invalid.yaml": "- clusterLabel: {}\n",
+	})
+
+	targets, err := ReadTargetDescriptions(filepath.Join(dir, "targets.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(targets) != 2 || targets[0].ClusterLabels["env"] != "dev" || targets[1].displayName() != "group prod" {
+		t.Errorf("unexpected targets: %+v", targets)
+	}
+
+	if _, err := ReadTargetDescriptions(filepath.Join(dir, "invalid.yaml")); err == nil {
+		t.Error("expected error for unknown field")
+	}
+}
+
+func TestValidate_Policies(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"deployment.yaml": deployment,
+		"clusterrole.yaml": `apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: admin
+  labels:
+    team: a
+`,
+	})
+	policyDir := writeFiles(t, map[string]string{"policies/policy.yaml": `apiVersion: fleet.cattle.io/v1alpha1
+kind: Policy
+metadata:
+  name: tenant
+resourceRules:
+- name: team-label
+  expression: "'team' in object.metadata.labels"
+  message: resources must have a team label
+- name: namespaced
+  expression: "!clusterScoped"
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: ignored
+`})
+
+	findings, err := Validate(context.Background(), Options{BaseDir: dir, Policies: []string{policyDir}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	SortFindings(findings)
+	want := `clusterrole.yaml: target default: ClusterRole admin: violates policy rule "namespaced": expression "!clusterScoped" evaluated to false
+deployment.yaml: target default: Deployment web: violates policy rule "team-label": resources must have a team label`
+	if got := messages(findings); got != want {
+		t.Errorf("expected findings:\n%s\ngot:\n%s", want, got)
+	}
+
+	invalid := writeFiles(t, map[string]string{"policy.yaml": `apiVersion: fleet.cattle.io/v1alpha1
+kind: Policy
+metadata:
+  name: broken
+resourceRules:
+- name: kind
+  expression: object.kind
+`})
+	if _, err := Validate(context.Background(), Options{BaseDir: dir, Policies: []string{invalid}}); err == nil || !strings.Contains(err.Error(), "must evaluate to bool") {
+		t.Errorf("expected error for invalid rule, got %v", err)
+	}
+}
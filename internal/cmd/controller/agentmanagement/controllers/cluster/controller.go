@@ -4,8 +4,10 @@ package cluster
 import (
 	"context"
 	"fmt"
+	"sync/atomic"
 
 	"github.com/rancher/fleet/internal/cmd/controller/agentmanagement/controllers/manageagent"
+	"github.com/rancher/fleet/internal/config"
 	"github.com/rancher/fleet/internal/names"
 	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
 	fleetcontrollers "github.com/rancher/fleet/pkg/generated/controllers/fleet.cattle.io/v1alpha1"
@@ -30,6 +32,9 @@ type handler struct {
 	namespaces           corecontrollers.NamespaceController
 	gitRepos             fleetcontrollers.GitRepoCache
 	clusterRegistrations fleetcontrollers.ClusterRegistrationController
+
+	// factLabels is the last seen value of the ClusterFactLabels config.
+	factLabels atomic.Bool
 }
 
 func Register(ctx context.Context,
@@ -52,6 +57,8 @@ func Register(ctx context.Context,
 	}
 
 	clusters.OnChange(ctx, "managed-cluster-trigger", h.ensureNSDeleted)
+	clusters.OnChange(ctx, "cluster-fact-labels", h.OnClusterFacts)
+	config.OnChange(ctx, h.onFactLabelsConfig)
 	fleetcontrollers.RegisterClusterStatusHandler(ctx,
 		clusters,
 		"Processed",
@@ -0,0 +1,103 @@
+package cluster
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+
+	"github.com/rancher/fleet/internal/config"
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+var invalidLabelValueChars = regexp.MustCompile(`[^A-Za-z0-9_.-]`)
+
+// OnClusterFacts copies the facts reported by the agent to the cluster
+// labels, if enabled by the config. Existing selectors can then target
+// clusters by e.g. "facts.fleet.cattle.io/distribution: k3s". If disabled,
+// the fact labels are removed.
+func (h *handler) OnClusterFacts(key string, cluster *fleet.Cluster) (*fleet.Cluster, error) {
+	if cluster == nil || cluster.DeletionTimestamp != nil {
+		return cluster, nil
+	}
+
+	labels := map[string]string{}
+	for k, v := range cluster.Labels {
+		if !strings.HasPrefix(k, fleet.FactsLabelPrefix) {
+			labels[k] = v
+		}
+	}
+	if config.Get().ClusterFactLabels {
+		maps.Copy(labels, factLabels(cluster.Status.Facts))
+	}
+	if maps.Equal(labels, cluster.Labels) {
+		return cluster, nil
+	}
+
+	log.Log.V(1).Info(fmt.Sprintf("Updating fact labels of cluster %s/%s", cluster.Namespace, cluster.Name))
+	cluster = cluster.DeepCopy()
+	cluster.Labels = labels
+	return h.clusters.Update(cluster)
+}
+
+// onFactLabelsConfig enqueues all clusters when ClusterFactLabels changes, so
+// that their fact labels are added or removed.
+func (h *handler) onFactLabelsConfig(cfg *config.Config) error {
+	if h.factLabels.Swap(cfg.ClusterFactLabels) == cfg.ClusterFactLabels {
+		return nil
+	}
+	clusters, err := h.clusterCache.List("", labels.Everything())
+	if err != nil {
+		return err
+	}
+	for _, cluster := range clusters {
+		h.clusters.Enqueue(cluster.Namespace, cluster.Name)
+	}
+	return nil
+}
+
+// factLabels returns the labels for the facts, prefixed with
+// facts.fleet.cattle.io/. Values are sanitized to be valid label values.
+func factLabels(facts *fleet.ClusterFacts) map[string]string {
+	labels := map[string]string{}
+	if facts == nil {
+		return labels
+	}
+
+	set := func(name, value string) {
+		if value = labelValue(value); value != "" {
+			labels[fleet.FactsLabelPrefix+name] = value
+		}
+	}
+	set("kubernetes-version", facts.KubernetesVersion)
+	if v, err := semver.NewVersion(facts.KubernetesVersion); err == nil {
+		set("kubernetes-minor-version", fmt.Sprintf("%d.%d", v.Major(), v.Minor()))
+	}
+	set("distribution", facts.Distribution)
+	set("cloud-provider", facts.CloudProvider)
+	set("node-count", strconv.Itoa(facts.NodeCount))
+	for _, arch := range facts.Architectures {
+		set("arch-"+labelValue(arch), "true")
+	}
+	for _, os := range facts.OperatingSystems {
+		set("os-"+labelValue(os), "true")
+	}
+
+	return labels
+}
+
+// labelValue replaces characters, which are not allowed in label values,
+// e.g. "v1.30.2+k3s1" becomes "v1.30.2-k3s1".
+func labelValue(s string) string {
+	s = invalidLabelValueChars.ReplaceAllString(s, "-")
+	if len(s) > 63 {
+		s = s[:63]
+	}
+	return strings.Trim(s, "-_.")
+}
@@ -0,0 +1,134 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/rancher/wrangler/v3/pkg/generic/fake"
+	"go.uber.org/mock/gomock"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/rancher/fleet/internal/config"
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+)
+
+func TestFactLabels(t *testing.T) {
+	got := factLabels(&fleet.ClusterFacts{
+		KubernetesVersion: "v1.30.2+k3s1",
+		Distribution:      "k3s",
+		CloudProvider:     "aws",
+		NodeCount:         3,
+		Architectures:     []string{"amd64", "arm64"},
+		OperatingSystems:  []string{"linux"},
+		CRDGroups:         []string{"cert-manager.io"},
+	})
+	want := map[string]string{
+		"facts.fleet.cattle.io/kubernetes-version":       "v1.30.2-k3s1",
+		"facts.fleet.cattle.io/kubernetes-minor-version": "1.30",
+		"facts.fleet.cattle.io/distribution":             "k3s",
+		"facts.fleet.cattle.io/cloud-provider":           "aws",
+		"facts.fleet.cattle.io/node-count":               "3",
+		"facts.fleet.cattle.io/arch-amd64":               "true",
+		"facts.fleet.cattle.io/arch-arm64":               "true",
+		"facts.fleet.cattle.io/os-linux":                 "true",
+	}
+	if len(got) != len(want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("expected label %s=%q, got %q", k, v, got[k])
+		}
+	}
+}
+
+func TestOnClusterFacts(t *testing.T) {
+	cluster := &fleet.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "fleet-default",
+			Name:      "prod",
+			Labels: map[string]string{
+				"env":                                "prod",
+				"facts.fleet.cattle.io/distribution": "rke2",
+				"facts.fleet.cattle.io/arch-arm64":   "true",
+			},
+		},
+		Status: fleet.ClusterStatus{
+			Facts: &fleet.ClusterFacts{Distribution: "k3s", Architectures: []string{"amd64"}},
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	clusters := fake.NewMockControllerInterface[*fleet.Cluster, *fleet.ClusterList](ctrl)
+	h := &handler{clusters: clusters}
+
+	// fact labels are removed, unless enabled
+	config.Set(&config.Config{})
+	clusters.EXPECT().Update(gomock.Any()).DoAndReturn(func(c *fleet.Cluster) (*fleet.Cluster, error) {
+		if len(c.Labels) != 1 || c.Labels["env"] != "prod" {
+			t.Errorf("expected only the env label, got %v", c.Labels)
+		}
+		return c, nil
+	})
+	if _, err := h.OnClusterFacts("", cluster); err != nil {
+		t.Fatal(err)
+	}
+
+	config.Set(&config.Config{ClusterFactLabels: true})
+	defer config.Set(&config.Config{})
+	var updated *fleet.Cluster
+	clusters.EXPECT().Update(gomock.Any()).DoAndReturn(func(c *fleet.Cluster) (*fleet.Cluster, error) {
+		updated = c
+		return c, nil
+	})
+	if _, err := h.OnClusterFacts("", cluster); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"env":                                "prod",
+		"facts.fleet.cattle.io/distribution": "k3s",
+		"facts.fleet.cattle.io/node-count":   "0",
+		"facts.fleet.cattle.io/arch-amd64":   "true",
+	}
+	if len(updated.Labels) != len(want) {
+		t.Errorf("expected labels %v, got %v", want, updated.Labels)
+	}
+	for k, v := range want {
+		if updated.Labels[k] != v {
+			t.Errorf("expected label %s=%q, got %q", k, v, updated.Labels[k])
+		}
+	}
+
+	// no update if the labels match the facts
+	if _, err := h.OnClusterFacts("", updated); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOnFactLabelsConfig(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	clusters := fake.NewMockControllerInterface[*fleet.Cluster, *fleet.ClusterList](ctrl)
+	clusterCache := fake.NewMockCacheInterface[*fleet.Cluster](ctrl)
+	h := &handler{clusters: clusters, clusterCache: clusterCache}
+
+	// nothing to do, if the setting does not change
+	if err := h.onFactLabelsConfig(&config.Config{}); err != nil {
+		t.Fatal(err)
+	}
+
+	clusterCache.EXPECT().List("", gomock.Any()).Return([]*fleet.Cluster{
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: "a"}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-local", Name: "local"}},
+	}, nil).Times(2)
+	clusters.EXPECT().Enqueue("fleet-default", "a").Times(2)
+	clusters.EXPECT().Enqueue("fleet-local", "local").Times(2)
+	if err := h.onFactLabelsConfig(&config.Config{ClusterFactLabels: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.onFactLabelsConfig(&config.Config{ClusterFactLabels: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.onFactLabelsConfig(&config.Config{}); err != nil {
+		t.Fatal(err)
+	}
+}
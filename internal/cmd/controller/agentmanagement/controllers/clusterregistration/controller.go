@@ -46,6 +46,7 @@ type handler struct {
 	clusterRegistration         fleetcontrollers.ClusterRegistrationController
 	clusterCache                fleetcontrollers.ClusterCache
 	clusters                    fleetcontrollers.ClusterClient
+	clusterController           fleetcontrollers.ClusterController
 	serviceAccountCache         corecontrollers.ServiceAccountCache
 	secretsCache                corecontrollers.SecretCache
 	secrets                     corecontrollers.SecretController
@@ -69,6 +70,7 @@ func Register(ctx context.Context,
 		clusterRegistration:         clusterRegistration,
 		clusterCache:                clusters.Cache(),
 		clusters:                    clusters,
+		clusterController:           clusters,
 		serviceAccountCache:         serviceAccount.Cache(),
 		secrets:                     secret,
 		secretsCache:                secret.Cache(),
@@ -91,6 +93,7 @@ func Register(ctx context.Context,
 
 	secret.OnChange(ctx, "registration-expire", h.OnSecretChange)
 	clusters.OnChange(ctx, "cluster-to-clusterregistration", h.OnCluster)
+	clusters.OnChange(ctx, "agent-credential-rotation", h.OnClusterRotateCredential)
 	clusters.Cache().AddIndexer(clusterByClientID, func(obj *fleet.Cluster) ([]string, error) {
 		return []string{
 			fmt.Sprintf("%s/%s", obj.Namespace, obj.Spec.ClientID),
@@ -543,12 +546,14 @@ func sanitizeClusterLabels(request *fleet.ClusterRegistration) map[string]string
 // management.cattle.io/cluster-display-name is consumed by clusterName
 // selectors); letting an agent set them would allow a registrant to spoof
 // another cluster's identity and attract its BundleDeployments. The debugging
-// label fleet.cattle.io/created-by-agent-pod is explicitly allowed.
+// label fleet.cattle.io/created-by-agent-pod is explicitly allowed. Fact labels
+// are reserved, as they are copied from the facts the agent reports.
 func isReservedLabel(key string) bool {
 	if key == fleet.CreatedByAgentPodLabel {
 		return false
 	}
-	return strings.HasPrefix(key, fleet.ManagementLabelPrefix) || strings.HasPrefix(key, fleet.FleetLabelPrefix)
+	return strings.HasPrefix(key, fleet.ManagementLabelPrefix) || strings.HasPrefix(key, fleet.FleetLabelPrefix) ||
+		strings.HasPrefix(key, fleet.FactsLabelPrefix)
 }
 
 func (h *handler) authorizeCluster(sa *v1.ServiceAccount, cluster *fleet.Cluster, req *fleet.ClusterRegistration) (*v1.Secret, error) {
@@ -0,0 +1,234 @@
+package clusterregistration
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/rancher/fleet/internal/config"
+	"github.com/rancher/fleet/internal/names"
+	"github.com/rancher/fleet/internal/registration"
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/durations"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const credentialGenerationLabel = "fleet.cattle.io/agent-credential-generation"
+
+// OnClusterRotateCredential periodically issues a new service account token
+// to the agent of the cluster. The token is published in the
+// fleet-agent-credential secret in the cluster namespace, which the agent
+// reads with its current credential. Once the agent reports it switched to the
+// new token, the previous tokens of the service account are revoked after a
+// grace period.
+func (h *handler) OnClusterRotateCredential(key string, cluster *fleet.Cluster) (*fleet.Cluster, error) {
+	interval := config.Get().AgentCredentialRotationInterval.Duration
+	if cluster == nil || cluster.DeletionTimestamp != nil || cluster.Status.Namespace == "" || interval <= 0 {
+		return cluster, nil
+	}
+
+	request, err := h.grantedClusterRegistration(cluster)
+	if err != nil || request == nil {
+		return cluster, err
+	}
+	saName := names.SafeConcatName(request.Name, string(request.UID))
+	sa, err := h.serviceAccountCache.Get(cluster.Status.Namespace, saName)
+	if apierrors.IsNotFound(err) {
+		return cluster, nil
+	} else if err != nil {
+		return cluster, err
+	}
+
+	agent := cluster.Status.Agent
+	switch agent.CredentialRotation {
+	case fleet.CredentialRotationIssued:
+		if agent.ActiveCredentialGeneration < agent.CredentialGeneration {
+			// wait for the agent to switch to the new credential
+			return cluster, nil
+		}
+		return h.updateCredentialRotation(cluster, func(status *fleet.AgentStatus) {
+			status.CredentialRotation = fleet.CredentialRotationActive
+		})
+	case fleet.CredentialRotationActive:
+		gracePeriod := config.Get().AgentCredentialGracePeriod.Duration
+		if gracePeriod <= 0 {
+			gracePeriod = durations.AgentCredentialGracePeriod
+		}
+		revokeAt := agent.LastCredentialRotation.Add(gracePeriod)
+		if wait := time.Until(revokeAt); wait > 0 {
+			h.clusterController.EnqueueAfter(cluster.Namespace, cluster.Name, wait)
+			return cluster, nil
+		}
+		if err := h.revokeCredentials(sa, agent.CredentialGeneration); err != nil {
+			return cluster, err
+		}
+		return h.updateCredentialRotation(cluster, func(status *fleet.AgentStatus) {
+			status.CredentialRotation = fleet.CredentialRotationCompleted
+		})
+	}
+
+	// The initial credential was issued when the registration was granted.
+	issued := request.CreationTimestamp
+	if agent.LastCredentialRotation != nil && agent.LastCredentialRotation.After(issued.Time) {
+		issued = *agent.LastCredentialRotation
+	}
+	if wait := time.Until(issued.Add(interval)); wait > 0 {
+		h.clusterController.EnqueueAfter(cluster.Namespace, cluster.Name, wait)
+		return cluster, nil
+	}
+
+	generation := agent.CredentialGeneration + 1
+	token, err := h.issueCredential(sa, cluster, generation)
+	if err != nil {
+		return cluster, err
+	}
+	if token == nil {
+		// token controller has not populated the secret yet
+		h.clusterController.EnqueueAfter(cluster.Namespace, cluster.Name, durations.ServiceTokenSleep)
+		return cluster, nil
+	}
+
+	log.Log.Info(fmt.Sprintf("Issued credential generation %d to agent of cluster '%s/%s'", generation, cluster.Namespace, cluster.Name))
+	return h.updateCredentialRotation(cluster, func(status *fleet.AgentStatus) {
+		now := metav1.Now()
+		status.CredentialGeneration = generation
+		status.CredentialRotation = fleet.CredentialRotationIssued
+		status.LastCredentialRotation = &now
+	})
+}
+
+// grantedClusterRegistration returns the newest granted cluster registration
+// of the cluster, its service account is used by the agent.
+func (h *handler) grantedClusterRegistration(cluster *fleet.Cluster) (*fleet.ClusterRegistration, error) {
+	crs, err := h.clusterRegistration.Cache().GetByIndex(clusterRegistrationByClientID,
+		fmt.Sprintf("%s/%s", cluster.Namespace, cluster.Spec.ClientID))
+	if err != nil {
+		return nil, err
+	}
+	var request *fleet.ClusterRegistration
+	for _, cr := range crs {
+		if !cr.Status.Granted || cr.Status.ClusterName != cluster.Name {
+			continue
+		}
+		if request == nil || request.CreationTimestamp.Before(&cr.CreationTimestamp) {
+			request = cr
+		}
+	}
+	return request, nil
+}
+
+// issueCredential creates a token secret for the generation and copies the
+// token to the agent credential secret. It returns nil, if the token is not
+// populated yet.
+func (h *handler) issueCredential(sa *v1.ServiceAccount, cluster *fleet.Cluster, generation int64) (*v1.Secret, error) {
+	name := names.SafeConcatName(sa.Name, "token", strconv.FormatInt(generation, 10))
+	token, err := h.secretsCache.Get(sa.Namespace, name)
+	if apierrors.IsNotFound(err) {
+		token, err = h.secrets.Create(&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: sa.Namespace,
+				Labels: map[string]string{
+					fleet.ClusterAnnotation:   cluster.Name,
+					fleet.ManagedLabel:        "true",
+					credentialGenerationLabel: strconv.FormatInt(generation, 10),
+				},
+				Annotations: map[string]string{
+					v1.ServiceAccountNameKey: sa.Name,
+				},
+				OwnerReferences: []metav1.OwnerReference{
+					{
+						APIVersion: "v1",
+						Kind:       "ServiceAccount",
+						Name:       sa.Name,
+						UID:        sa.UID,
+					},
+				},
+			},
+			Type: v1.SecretTypeServiceAccountToken,
+		})
+		if apierrors.IsAlreadyExists(err) {
+			token, err = h.secrets.Get(sa.Namespace, name, metav1.GetOptions{})
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token secret %s/%s: %w", sa.Namespace, name, err)
+	}
+	if len(token.Data[v1.ServiceAccountTokenKey]) == 0 {
+		return nil, nil
+	}
+
+	data := map[string][]byte{
+		"token": token.Data[v1.ServiceAccountTokenKey],
+		registration.AgentCredentialGenerationKey: []byte(strconv.FormatInt(generation, 10)),
+	}
+	secret, err := h.secretsCache.Get(sa.Namespace, registration.AgentCredentialSecretName)
+	if apierrors.IsNotFound(err) {
+		_, err = h.secrets.Create(&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      registration.AgentCredentialSecretName,
+				Namespace: sa.Namespace,
+				Labels: map[string]string{
+					fleet.ClusterAnnotation: cluster.Name,
+					fleet.ManagedLabel:      "true",
+				},
+			},
+			Type: AgentCredentialSecretType,
+			Data: data,
+		})
+	} else if err == nil {
+		secret = secret.DeepCopy()
+		secret.Data = data
+		_, err = h.secrets.Update(secret)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to write agent credential secret %s/%s: %w", sa.Namespace, registration.AgentCredentialSecretName, err)
+	}
+	return token, nil
+}
+
+// revokeCredentials deletes the token secrets of the service account, which
+// are older than generation. This includes the token, which was issued when
+// the registration was granted.
+func (h *handler) revokeCredentials(sa *v1.ServiceAccount, generation int64) error {
+	var revoke []string
+	if len(sa.Secrets) != 0 {
+		revoke = append(revoke, sa.Secrets[0].Name)
+	} else {
+		revoke = append(revoke, sa.Name+"-token")
+	}
+
+	secrets, err := h.secretsCache.List(sa.Namespace, labels.SelectorFromSet(labels.Set{fleet.ManagedLabel: "true"}))
+	if err != nil {
+		return err
+	}
+	for _, secret := range secrets {
+		if secret.Annotations[v1.ServiceAccountNameKey] != sa.Name {
+			continue
+		}
+		g, err := strconv.ParseInt(secret.Labels[credentialGenerationLabel], 10, 64)
+		if err != nil || g >= generation {
+			continue
+		}
+		revoke = append(revoke, secret.Name)
+	}
+
+	for _, name := range revoke {
+		log.Log.Info(fmt.Sprintf("Revoking agent credential %s/%s", sa.Namespace, name))
+		if err := h.secrets.Delete(sa.Namespace, name, nil); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *handler) updateCredentialRotation(cluster *fleet.Cluster, update func(*fleet.AgentStatus)) (*fleet.Cluster, error) {
+	cluster = cluster.DeepCopy()
+	update(&cluster.Status.Agent)
+	return h.clusters.UpdateStatus(cluster)
+}
@@ -0,0 +1,192 @@
+package clusterregistration
+
+import (
+	"time"
+
+	"github.com/rancher/wrangler/v3/pkg/generic/fake"
+	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/rancher/fleet/internal/config"
+	"github.com/rancher/fleet/internal/registration"
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Agent credential rotation", func() {
+	var (
+		cluster *fleet.Cluster
+		request *fleet.ClusterRegistration
+		sa      *corev1.ServiceAccount
+
+		saCache                  *fake.MockCacheInterface[*corev1.ServiceAccount]
+		secretCache              *fake.MockCacheInterface[*corev1.Secret]
+		secretController         *fake.MockControllerInterface[*corev1.Secret, *corev1.SecretList]
+		clusterController        *fake.MockControllerInterface[*fleet.Cluster, *fleet.ClusterList]
+		clusterRegistrationCache *fake.MockCacheInterface[*fleet.ClusterRegistration]
+		h                        *handler
+		notFound                 = apierrors.NewNotFound(schema.GroupResource{}, "")
+		updated                  *fleet.Cluster
+	)
+
+	BeforeEach(func() {
+		config.Set(&config.Config{AgentCredentialRotationInterval: metav1.Duration{Duration: time.Hour}})
+		DeferCleanup(func() { config.Set(&config.Config{}) })
+
+		ctrl := gomock.NewController(GinkgoT())
+		saCache = fake.NewMockCacheInterface[*corev1.ServiceAccount](ctrl)
+		secretCache = fake.NewMockCacheInterface[*corev1.Secret](ctrl)
+		secretController = fake.NewMockControllerInterface[*corev1.Secret, *corev1.SecretList](ctrl)
+		clusterController = fake.NewMockControllerInterface[*fleet.Cluster, *fleet.ClusterList](ctrl)
+		clusterRegistrationController := fake.NewMockControllerInterface[*fleet.ClusterRegistration, *fleet.ClusterRegistrationList](ctrl)
+		clusterRegistrationCache = fake.NewMockCacheInterface[*fleet.ClusterRegistration](ctrl)
+		clusterRegistrationController.EXPECT().Cache().Return(clusterRegistrationCache).AnyTimes()
+
+		h = &handler{
+			clusterRegistration: clusterRegistrationController,
+			clusters:            clusterController,
+			clusterController:   clusterController,
+			secretsCache:        secretCache,
+			secrets:             secretController,
+			serviceAccountCache: saCache,
+		}
+
+		cluster = &fleet.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: "prod"},
+			Spec:       fleet.ClusterSpec{ClientID: "client-id"},
+			Status:     fleet.ClusterStatus{Namespace: "cluster-ns"},
+		}
+		request = &fleet.ClusterRegistration{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:         "fleet-default",
+				Name:              "request-1",
+				UID:               "uid",
+				CreationTimestamp: metav1.NewTime(time.Now().Add(-2 * time.Hour)),
+			},
+			Spec:   fleet.ClusterRegistrationSpec{ClientID: "client-id"},
+			Status: fleet.ClusterRegistrationStatus{Granted: true, ClusterName: "prod"},
+		}
+		sa = &corev1.ServiceAccount{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "cluster-ns", Name: "request-1-uid", UID: "sa-uid"},
+		}
+		updated = nil
+	})
+
+	expectServiceAccount := func() {
+		clusterRegistrationCache.EXPECT().GetByIndex(clusterRegistrationByClientID, "fleet-default/client-id").
+			Return([]*fleet.ClusterRegistration{request}, nil)
+		saCache.EXPECT().Get("cluster-ns", "request-1-uid").Return(sa, nil)
+	}
+	expectStatusUpdate := func() {
+		clusterController.EXPECT().UpdateStatus(gomock.Any()).DoAndReturn(func(c *fleet.Cluster) (*fleet.Cluster, error) {
+			updated = c
+			return c, nil
+		})
+	}
+
+	It("does nothing if rotation is disabled", func() {
+		config.Set(&config.Config{})
+		_, err := h.OnClusterRotateCredential("", cluster)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("waits until the credential is due", func() {
+		request.CreationTimestamp = metav1.NewTime(time.Now().Add(-30 * time.Minute))
+		expectServiceAccount()
+		clusterController.EXPECT().EnqueueAfter("fleet-default", "prod", gomock.Any())
+
+		_, err := h.OnClusterRotateCredential("", cluster)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("issues a new credential", func() {
+		cluster.Status.Agent.CredentialGeneration = 1
+		cluster.Status.Agent.CredentialRotation = fleet.CredentialRotationCompleted
+		expectServiceAccount()
+		secretCache.EXPECT().Get("cluster-ns", "request-1-uid-token-2").Return(nil, notFound)
+		secretController.EXPECT().Create(gomock.Any()).DoAndReturn(func(s *corev1.Secret) (*corev1.Secret, error) {
+			Expect(s.Type).To(Equal(corev1.SecretTypeServiceAccountToken))
+			Expect(s.Annotations).To(HaveKeyWithValue(corev1.ServiceAccountNameKey, "request-1-uid"))
+			Expect(s.Labels).To(HaveKeyWithValue(credentialGenerationLabel, "2"))
+			s = s.DeepCopy()
+			s.Data = map[string][]byte{corev1.ServiceAccountTokenKey: []byte("token-2")}
+			return s, nil
+		})
+		secretCache.EXPECT().Get("cluster-ns", registration.AgentCredentialSecretName).Return(&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "cluster-ns", Name: registration.AgentCredentialSecretName},
+		}, nil)
+		secretController.EXPECT().Update(gomock.Any()).DoAndReturn(func(s *corev1.Secret) (*corev1.Secret, error) {
+			Expect(string(s.Data["token"])).To(Equal("token-2"))
+			Expect(string(s.Data[registration.AgentCredentialGenerationKey])).To(Equal("2"))
+			return s, nil
+		})
+		expectStatusUpdate()
+
+		_, err := h.OnClusterRotateCredential("", cluster)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(updated.Status.Agent.CredentialGeneration).To(Equal(int64(2)))
+		Expect(updated.Status.Agent.CredentialRotation).To(Equal(fleet.CredentialRotationIssued))
+		Expect(updated.Status.Agent.LastCredentialRotation.Time).To(BeTemporally("~", time.Now(), time.Minute))
+	})
+
+	It("waits for the token to be populated", func() {
+		expectServiceAccount()
+		secretCache.EXPECT().Get("cluster-ns", "request-1-uid-token-1").Return(&corev1.Secret{}, nil)
+		clusterController.EXPECT().EnqueueAfter("fleet-default", "prod", gomock.Any())
+
+		_, err := h.OnClusterRotateCredential("", cluster)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("marks the credential active once the agent uses it", func() {
+		now := metav1.Now()
+		cluster.Status.Agent.CredentialGeneration = 1
+		cluster.Status.Agent.CredentialRotation = fleet.CredentialRotationIssued
+		cluster.Status.Agent.LastCredentialRotation = &now
+		expectServiceAccount()
+
+		// agent still uses the previous credential
+		_, err := h.OnClusterRotateCredential("", cluster)
+		Expect(err).ToNot(HaveOccurred())
+
+		cluster.Status.Agent.ActiveCredentialGeneration = 1
+		expectServiceAccount()
+		expectStatusUpdate()
+		_, err = h.OnClusterRotateCredential("", cluster)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(updated.Status.Agent.CredentialRotation).To(Equal(fleet.CredentialRotationActive))
+	})
+
+	It("revokes previous credentials after the grace period", func() {
+		rotated := metav1.NewTime(time.Now().Add(-2 * time.Hour))
+		cluster.Status.Agent.CredentialGeneration = 2
+		cluster.Status.Agent.ActiveCredentialGeneration = 2
+		cluster.Status.Agent.CredentialRotation = fleet.CredentialRotationActive
+		cluster.Status.Agent.LastCredentialRotation = &rotated
+		expectServiceAccount()
+		tokenSecret := func(name, generation string) *corev1.Secret {
+			return &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+				Namespace:   "cluster-ns",
+				Name:        name,
+				Labels:      map[string]string{credentialGenerationLabel: generation},
+				Annotations: map[string]string{corev1.ServiceAccountNameKey: "request-1-uid"},
+			}}
+		}
+		secretCache.EXPECT().List("cluster-ns", gomock.Any()).Return([]*corev1.Secret{
+			tokenSecret("request-1-uid-token-1", "1"),
+			tokenSecret("request-1-uid-token-2", "2"),
+		}, nil)
+		secretController.EXPECT().Delete("cluster-ns", "request-1-uid-token", nil).Return(notFound)
+		secretController.EXPECT().Delete("cluster-ns", "request-1-uid-token-1", nil).Return(nil)
+		expectStatusUpdate()
+
+		_, err := h.OnClusterRotateCredential("", cluster)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(updated.Status.Agent.CredentialRotation).To(Equal(fleet.CredentialRotationCompleted))
+	})
+})
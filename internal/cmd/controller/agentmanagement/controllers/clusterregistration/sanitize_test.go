@@ -76,6 +76,7 @@ var _ = Describe("isReservedLabel", func() {
 		Entry("management.cattle.io display name is reserved", "management.cattle.io/cluster-display-name", true),
 		Entry("any management.cattle.io key is reserved", "management.cattle.io/foo", true),
 		Entry("any fleet.cattle.io key is reserved", "fleet.cattle.io/cluster", true),
+		Entry("fact labels are reserved", "facts.fleet.cattle.io/distribution", true),
 		Entry("created-by-agent-pod is allow-listed", "fleet.cattle.io/created-by-agent-pod", false),
 		Entry("plain operational label is not reserved", "env", false),
 		Entry("unrelated vendor label is not reserved", "example.com/team", false),
@@ -29,6 +29,7 @@ import (
 	"github.com/rancher/fleet/internal/config"
 	"github.com/rancher/fleet/internal/metrics"
 	"github.com/rancher/fleet/internal/ssh"
+	"github.com/rancher/fleet/internal/tracing"
 	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
 	"github.com/rancher/fleet/pkg/git"
 	"github.com/rancher/fleet/pkg/version"
@@ -87,6 +88,12 @@ func (g *GitOperator) Run(cmd *cobra.Command, args []string) error {
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(zopts)))
 	ctx := clog.IntoContext(cmd.Context(), ctrl.Log.WithName("gitjob-reconciler"))
 
+	shutdownTracing, err := tracing.Setup(ctx, "fleet-gitjob")
+	if err != nil {
+		return fmt.Errorf("failed to set up tracing: %w", err)
+	}
+	defer func() { _ = shutdownTracing(context.WithoutCancel(ctx)) }()
+
 	namespace := g.Namespace
 
 	leaderOpts, err := command.NewLeaderElectionOptions()
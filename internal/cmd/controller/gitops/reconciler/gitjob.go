@@ -15,13 +15,16 @@ import (
 	fleetapply "github.com/rancher/fleet/internal/cmd/cli/apply"
 	"github.com/rancher/fleet/internal/config"
 	fleetgithub "github.com/rancher/fleet/internal/github"
+	"github.com/rancher/fleet/internal/metrics"
 	"github.com/rancher/fleet/internal/names"
 	"github.com/rancher/fleet/internal/ocistorage"
 	ssh "github.com/rancher/fleet/internal/ssh"
+	"github.com/rancher/fleet/internal/tracing"
 	v1alpha1 "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
 	"github.com/rancher/fleet/pkg/cert"
 	fleetgit "github.com/rancher/fleet/pkg/git"
 	"github.com/rancher/fleet/pkg/sharding"
+	"go.opentelemetry.io/otel/attribute"
 
 	appsv1 "k8s.io/api/apps/v1"
 	batchv1 "k8s.io/api/batch/v1"
@@ -55,7 +58,10 @@ type helmSecretOptions struct {
 	BasicHTTP       bool
 }
 
-func (r *GitJobReconciler) createJobAndResources(ctx context.Context, gitrepo *v1alpha1.GitRepo, logger logr.Logger) error {
+func (r *GitJobReconciler) createJobAndResources(ctx context.Context, gitrepo *v1alpha1.GitRepo, logger logr.Logger) (err error) {
+	ctx, span := tracing.Start(ctx, "gitops.createJob", attribute.String("commit", gitrepo.Status.Commit))
+	defer func() { tracing.End(span, err) }()
+
 	logger.V(1).Info("Creating Git job resources")
 
 	if err := r.createJobRBAC(ctx, gitrepo); err != nil {
@@ -383,6 +389,7 @@ func (r *GitJobReconciler) newGitJob(ctx context.Context, obj *v1alpha1.GitRepo)
 			},
 		)
 		job.Spec.Template.Spec.Containers[i].Env = append(job.Spec.Template.Spec.Containers[i].Env, proxyEnvVars()...)
+		job.Spec.Template.Spec.Containers[i].Env = append(job.Spec.Template.Spec.Containers[i].Env, tracing.EnvVars(ctx)...)
 	}
 
 	return job, nil
@@ -753,6 +760,10 @@ func argsAndEnvs(
 		"--target-namespace", gitrepo.Spec.TargetNamespace,
 	)
 
+	if !gitrepo.Status.CommitDetectedTime.IsZero() {
+		args = append(args, "--change-detected-at", metrics.FormatChangeDetectedAt(gitrepo.Status.CommitDetectedTime.Time))
+	}
+
 	if enableImagescan {
 		args = append(args, "--imagescan-enabled")
 	}
@@ -861,6 +872,13 @@ func argsAndEnvs(
 			})
 	} else {
 		args = append(args, "--oci-registry-secret", gitrepo.Spec.OCIRegistrySecret)
+		if threshold := readIntEnvVar(logger, fleetapply.GetOCIStorageThreshold, fleetapply.OCIStorageThresholdEnv); threshold > 0 {
+			env = append(env,
+				corev1.EnvVar{
+					Name:  fleetapply.OCIStorageThresholdEnv,
+					Value: strconv.Itoa(threshold),
+				})
+		}
 	}
 
 	if len(gitrepo.Spec.Bundles) > 0 {
@@ -16,13 +16,16 @@ import (
 
 	"github.com/go-logr/logr"
 	"github.com/reugn/go-quartz/quartz"
+	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/rancher/fleet/internal/cmd/controller/finalize"
 	"github.com/rancher/fleet/internal/cmd/controller/imagescan"
+	"github.com/rancher/fleet/internal/cmd/controller/policyrestrictions"
 	ctrlquartz "github.com/rancher/fleet/internal/cmd/controller/quartz"
 	"github.com/rancher/fleet/internal/cmd/controller/reconciler"
 	"github.com/rancher/fleet/internal/config"
 	"github.com/rancher/fleet/internal/metrics"
+	"github.com/rancher/fleet/internal/tracing"
 	v1alpha1 "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
 	"github.com/rancher/fleet/pkg/durations"
 	"github.com/rancher/fleet/pkg/sharding"
@@ -187,6 +190,13 @@ func (r *GitJobReconciler) SetupWithManager(mgr ctrl.Manager) error {
 // creates a job to clone the repository if a new commit is found. In case of
 // an error, the output of the job is stored in the status.
 func (r *GitJobReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	ctx, span := tracing.Start(ctx, "gitops.Reconcile", attribute.String("gitrepo", req.String()))
+	res, err := r.reconcile(ctx, req)
+	tracing.End(span, err)
+	return res, err
+}
+
+func (r *GitJobReconciler) reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	logger := log.FromContext(ctx).WithName("gitjob")
 	gitrepo := &v1alpha1.GitRepo{}
 
@@ -218,7 +228,11 @@ func (r *GitJobReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 			err,
 		)
 
-		return ctrl.Result{}, updateErrorStatus(ctx, r.Client, req.NamespacedName, *oldStatus, err)
+		statusErr := updateErrorStatus(ctx, r.Client, req.NamespacedName, *oldStatus, err)
+		if statusErr == err && errors.Is(err, policyrestrictions.ErrQuotaExceeded) {
+			return ctrl.Result{RequeueAfter: durations.QuotaRecheckInterval}, nil
+		}
+		return ctrl.Result{}, statusErr
 	}
 	// Persist any spec defaults injected by AuthorizeAndAssignDefaults (e.g. defaultServiceAccount).
 	// The spec update bumps the generation and triggers a fresh reconcile.
@@ -241,6 +255,20 @@ func (r *GitJobReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		return ctrl.Result{}, err
 	}
 
+	if err := validateTargets(gitrepo.Spec.Targets); err != nil {
+		r.Recorder.Eventf(
+			gitrepo,
+			nil,
+			corev1.EventTypeWarning,
+			"FailedValidatingTargets",
+			"ValidateTargets",
+			"%v",
+			err,
+		)
+
+		return ctrl.Result{}, updateErrorStatus(ctx, r.Client, req.NamespacedName, *oldStatus, err)
+	}
+
 	// Migration: Remove the obsolete created-by-display-name label if it exists
 	if err := r.removeDisplayNameLabel(ctx, req.NamespacedName); err != nil {
 		logger.V(1).Error(err, "Failed to remove display name label")
@@ -604,6 +632,7 @@ func (r *GitJobReconciler) handleDelete(ctx context.Context, logger logr.Logger,
 	}
 
 	metrics.GitRepoCollector.Delete(gitrepo.Name, gitrepo.Namespace)
+	metrics.DeleteDeploymentLatency("GitRepo", gitrepo.Name, gitrepo.Namespace)
 
 	// we don't have pending Bundles nor ImageScans, we can remove the finalizer
 	nsName := types.NamespacedName{Name: gitrepo.Name, Namespace: gitrepo.Namespace}
@@ -1231,6 +1260,7 @@ func setStatusFromGitjob(ctx context.Context, c client.Client, gitRepo *v1alpha1
 		}
 	}
 
+	jobWasCurrent := gitRepo.Status.GitJobStatus == status.CurrentStatus.String()
 	gitRepo.Status.GitJobStatus = result.Status.String()
 
 	for _, con := range result.Conditions {
@@ -1253,6 +1283,16 @@ func setStatusFromGitjob(ctx context.Context, c client.Client, gitRepo *v1alpha1
 	case status.CurrentStatus:
 		if strings.Contains(result.Message, "Job Completed") {
 			gitRepo.Status.Commit = job.Annotations["commit"]
+			if !jobWasCurrent && job.Status.CompletionTime != nil {
+				metrics.ObserveDeploymentLatency(
+					"GitRepo",
+					gitRepo.Name,
+					gitRepo.Namespace,
+					metrics.StageGitJobFinished,
+					gitRepo.Status.CommitDetectedTime.Time,
+					job.Status.CompletionTime.Time,
+				)
+			}
 		}
 		kstatus.SetActive(gitRepo)
 	case status.InProgressStatus:
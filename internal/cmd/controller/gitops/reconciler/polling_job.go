@@ -126,6 +126,10 @@ func (j *gitPollingJob) pollGitRepo(ctx context.Context) error {
 			return fmt.Errorf("could not get GitRepo to update its status: %w", err)
 		}
 
+		// A webhook may already have announced this commit, keep its detection time.
+		if commit != t.Status.PollingCommit && commit != t.Status.Commit && commit != t.Status.WebhookCommit {
+			t.Status.CommitDetectedTime = metav1.Time{Time: pollingTimestamp}
+		}
 		t.Status.LastPollingTime = metav1.Time{Time: pollingTimestamp}
 		t.Status.PollingCommit = commit
 
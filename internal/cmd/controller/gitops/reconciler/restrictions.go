@@ -9,6 +9,7 @@ import (
 	"sort"
 
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/rancher/fleet/internal/cmd/controller/labelselectors"
 	"github.com/rancher/fleet/internal/cmd/controller/policyrestrictions"
@@ -47,6 +48,20 @@ func AuthorizeAndAssignDefaults(ctx context.Context, c client.Client, gitrepo *f
 	grr := aggregate(restrictions.Items)
 	pol := policyrestrictions.Aggregate(policies.Items)
 
+	if pol.Quota.MaxGitRepos != nil {
+		gitrepos := &fleet.GitRepoList{}
+		if err := c.List(ctx, gitrepos, client.InNamespace(gitrepo.Namespace)); err != nil {
+			return err
+		}
+		objs := make([]metav1.Object, 0, len(gitrepos.Items))
+		for i := range gitrepos.Items {
+			objs = append(objs, &gitrepos.Items[i])
+		}
+		if err := policyrestrictions.IsWithinCount("GitRepos", gitrepo, objs, pol.Quota.MaxGitRepos); err != nil {
+			return err
+		}
+	}
+
 	// Merge defaults: GitRepoRestriction wins over Policy for first-non-empty.
 	defaultSA := firstNonEmpty(grr.DefaultServiceAccount, pol.GitDefaultServiceAccount)
 	defaultClientSecret := firstNonEmpty(grr.DefaultClientSecretName, pol.GitDefaultClientSecretName)
@@ -5,10 +5,13 @@ import (
 	"errors"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
 	crclient "sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/rancher/fleet/internal/cmd/controller/gitops/reconciler"
@@ -24,6 +27,7 @@ func TestAuthorizeAndAssignDefaults(t *testing.T) {
 		inputGr             fleet.GitRepo
 		restrictions        *fleet.GitRepoRestrictionList
 		policies            *fleet.PolicyList
+		gitrepos            *fleet.GitRepoList
 		restrictionsListErr error
 		expectedGr          fleet.GitRepo
 		expectedErr         string
@@ -396,6 +400,53 @@ func TestAuthorizeAndAssignDefaults(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:    "deny GitRepo exceeding Policy maxGitRepos",
+			inputGr: fleet.GitRepo{ObjectMeta: metav1.ObjectMeta{Name: "new"}},
+			policies: &fleet.PolicyList{Items: []fleet.Policy{
+				{Quota: &fleet.PolicyQuota{MaxGitRepos: ptr.To(int32(1))}},
+			}},
+			gitrepos: &fleet.GitRepoList{Items: []fleet.GitRepo{
+				{ObjectMeta: metav1.ObjectMeta{Name: "old", CreationTimestamp: metav1.NewTime(time.Unix(1, 0))}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "new", CreationTimestamp: metav1.NewTime(time.Unix(2, 0))}},
+			}},
+			expectedGr:  fleet.GitRepo{ObjectMeta: metav1.ObjectMeta{Name: "new"}},
+			expectedErr: "quota exceeded: Policy allows at most 1 GitRepos in the namespace, found 2",
+		},
+		{
+			name: "deny GitRepo exceeding Policy maxGitRepos, which is not listed yet",
+			inputGr: fleet.GitRepo{ObjectMeta: metav1.ObjectMeta{
+				Name:              "new",
+				CreationTimestamp: metav1.NewTime(time.Unix(2, 0)),
+			}},
+			policies: &fleet.PolicyList{Items: []fleet.Policy{
+				{Quota: &fleet.PolicyQuota{MaxGitRepos: ptr.To(int32(1))}},
+			}},
+			gitrepos: &fleet.GitRepoList{Items: []fleet.GitRepo{
+				{ObjectMeta: metav1.ObjectMeta{Name: "old", CreationTimestamp: metav1.NewTime(time.Unix(1, 0))}},
+			}},
+			expectedGr: fleet.GitRepo{ObjectMeta: metav1.ObjectMeta{
+				Name:              "new",
+				CreationTimestamp: metav1.NewTime(time.Unix(2, 0)),
+			}},
+			expectedErr: "quota exceeded: Policy allows at most 1 GitRepos in the namespace, found 2",
+		},
+		{
+			name:    "pass GitRepo within Policy maxGitRepos, ignoring deleted GitRepos",
+			inputGr: fleet.GitRepo{ObjectMeta: metav1.ObjectMeta{Name: "new"}},
+			policies: &fleet.PolicyList{Items: []fleet.Policy{
+				{Quota: &fleet.PolicyQuota{MaxGitRepos: ptr.To(int32(1))}},
+			}},
+			gitrepos: &fleet.GitRepoList{Items: []fleet.GitRepo{
+				{ObjectMeta: metav1.ObjectMeta{
+					Name:              "old",
+					CreationTimestamp: metav1.NewTime(time.Unix(1, 0)),
+					DeletionTimestamp: &metav1.Time{Time: time.Unix(3, 0)},
+				}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "new", CreationTimestamp: metav1.NewTime(time.Unix(2, 0))}},
+			}},
+			expectedGr: fleet.GitRepo{ObjectMeta: metav1.ObjectMeta{Name: "new"}},
+		},
 	}
 
 	for _, c := range cases {
@@ -413,6 +464,9 @@ func TestAuthorizeAndAssignDefaults(t *testing.T) {
 						}
 						return c.restrictionsListErr
 					}
+					if gl, ok := obj.(*fleet.GitRepoList); ok && c.gitrepos != nil {
+						gl.Items = c.gitrepos.Items
+					}
 					if pl, ok := obj.(*fleet.PolicyList); ok && c.policies != nil {
 						pl.Items = c.policies.Items
 					}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/rancher/fleet/internal/cmd/controller/status"
 	"github.com/rancher/fleet/internal/cmd/controller/summary"
+	"github.com/rancher/fleet/internal/metrics"
 	"github.com/rancher/fleet/internal/resourcestatus"
 	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
 	"github.com/rancher/fleet/pkg/durations"
@@ -15,6 +16,7 @@ import (
 	"github.com/rancher/wrangler/v3/pkg/genericcondition"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/util/workqueue"
@@ -145,6 +147,8 @@ func (r *StatusReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		return ctrl.Result{}, err
 	}
 
+	setSyncStatus(bdList, gitrepo, time.Now())
+
 	if err := r.updateStatus(ctx, orig, gitrepo); err != nil {
 		logger.Error(err, "Reconcile failed update to git repo status", "status", gitrepo.Status)
 		return ctrl.Result{RequeueAfter: durations.GitRepoStatusDelay}, nil
@@ -184,6 +188,35 @@ func setStatus(list *fleet.BundleDeploymentList, gitrepo *fleet.GitRepo) error {
 	return nil
 }
 
+// setSyncStatus records when all bundle deployments of the gitrepo became
+// ready with the last detected commit, and how long it took since that commit
+// was detected.
+func setSyncStatus(list *fleet.BundleDeploymentList, gitrepo *fleet.GitRepo, now time.Time) {
+	detected := gitrepo.Status.CommitDetectedTime
+	if detected.IsZero() || !gitrepo.Status.LastSyncTime.Before(&detected) {
+		return
+	}
+
+	// A newer commit may have been detected, which is not deployed yet.
+	commit := gitrepo.Status.Commit
+	if commit == "" || getNextCommit(gitrepo.Status) != commit ||
+		(gitrepo.Status.WebhookCommit != "" && gitrepo.Status.WebhookCommit != commit) {
+		return
+	}
+	if gitrepo.Status.GitJobStatus != "Current" || len(list.Items) == 0 {
+		return
+	}
+	for _, bd := range list.Items {
+		if bd.Labels[fleet.CommitLabel] != commit || summary.GetDeploymentState(&bd) != fleet.Ready {
+			return
+		}
+	}
+
+	gitrepo.Status.LastSyncTime = metav1.Time{Time: now}
+	gitrepo.Status.LastSyncDuration = &metav1.Duration{Duration: max(now.Sub(detected.Time), 0)}
+	metrics.ObserveDeploymentLatency("GitRepo", gitrepo.Name, gitrepo.Namespace, metrics.StageReady, detected.Time, now)
+}
+
 // setReadyStatusFromBundle fetches all bundles from a given gitrepo, checks the ready status conditions
 // from the bundles and applies one on the gitrepo if it isn't ready. The purpose is to make
 // rendering issues visible in the gitrepo status. Those issues need to be made explicitly visible
@@ -0,0 +1,127 @@
+package reconciler
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+)
+
+func TestSetSyncStatus(t *testing.T) {
+	detected := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	now := detected.Add(90 * time.Second)
+
+	bd := func(commit string, ready bool) fleet.BundleDeployment {
+		return fleet.BundleDeployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{fleet.CommitLabel: commit},
+			},
+			Spec: fleet.BundleDeploymentSpec{
+				DeploymentID:       "id",
+				StagedDeploymentID: "id",
+			},
+			Status: fleet.BundleDeploymentStatus{
+				AppliedDeploymentID: "id",
+				Ready:               ready,
+				NonModified:         true,
+			},
+		}
+	}
+
+	cases := []struct {
+		name       string
+		status     fleet.GitRepoStatus
+		bds        []fleet.BundleDeployment
+		expectSync bool
+	}{
+		{
+			name: "all bundle deployments ready with the detected commit",
+			status: fleet.GitRepoStatus{
+				Commit:             "abc",
+				GitJobStatus:       "Current",
+				CommitDetectedTime: metav1.Time{Time: detected},
+			},
+			bds:        []fleet.BundleDeployment{bd("abc", true), bd("abc", true)},
+			expectSync: true,
+		},
+		{
+			name: "bundle deployment not ready",
+			status: fleet.GitRepoStatus{
+				Commit:             "abc",
+				GitJobStatus:       "Current",
+				CommitDetectedTime: metav1.Time{Time: detected},
+			},
+			bds: []fleet.BundleDeployment{bd("abc", true), bd("abc", false)},
+		},
+		{
+			name: "bundle deployment with a previous commit",
+			status: fleet.GitRepoStatus{
+				Commit:             "abc",
+				GitJobStatus:       "Current",
+				CommitDetectedTime: metav1.Time{Time: detected},
+			},
+			bds: []fleet.BundleDeployment{bd("abc", true), bd("old", true)},
+		},
+		{
+			name: "newer commit detected by polling",
+			status: fleet.GitRepoStatus{
+				Commit:             "abc",
+				PollingCommit:      "def",
+				GitJobStatus:       "Current",
+				CommitDetectedTime: metav1.Time{Time: detected},
+			},
+			bds: []fleet.BundleDeployment{bd("abc", true)},
+		},
+		{
+			name: "git job still running",
+			status: fleet.GitRepoStatus{
+				Commit:             "abc",
+				GitJobStatus:       "InProgress",
+				CommitDetectedTime: metav1.Time{Time: detected},
+			},
+			bds: []fleet.BundleDeployment{bd("abc", true)},
+		},
+		{
+			name: "already synced",
+			status: fleet.GitRepoStatus{
+				Commit:             "abc",
+				GitJobStatus:       "Current",
+				CommitDetectedTime: metav1.Time{Time: detected},
+				LastSyncTime:       metav1.Time{Time: detected.Add(time.Minute)},
+			},
+			bds: []fleet.BundleDeployment{bd("abc", true)},
+		},
+		{
+			name: "no detection time",
+			status: fleet.GitRepoStatus{
+				Commit:       "abc",
+				GitJobStatus: "Current",
+			},
+			bds: []fleet.BundleDeployment{bd("abc", true)},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			gitrepo := &fleet.GitRepo{Status: c.status}
+			lastSyncTime := c.status.LastSyncTime
+
+			setSyncStatus(&fleet.BundleDeploymentList{Items: c.bds}, gitrepo, now)
+
+			if !c.expectSync {
+				if !gitrepo.Status.LastSyncTime.Equal(&lastSyncTime) || gitrepo.Status.LastSyncDuration != nil {
+					t.Errorf("expected sync status to be unchanged, got %v and %v", gitrepo.Status.LastSyncTime, gitrepo.Status.LastSyncDuration)
+				}
+				return
+			}
+			if !gitrepo.Status.LastSyncTime.Time.Equal(now) {
+				t.Errorf("expected last sync time %v, got %v", now, gitrepo.Status.LastSyncTime)
+			}
+			if gitrepo.Status.LastSyncDuration == nil || gitrepo.Status.LastSyncDuration.Duration != 90*time.Second {
+				t.Errorf("expected last sync duration of 90s, got %v", gitrepo.Status.LastSyncDuration)
+			}
+		})
+	}
+}
@@ -2,7 +2,9 @@ package reconciler
 
 import (
 	"encoding/json"
+	"fmt"
 
+	"github.com/rancher/fleet/internal/cmd/controller/target/matcher"
 	"github.com/rancher/fleet/internal/names"
 	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
 
@@ -23,6 +25,7 @@ func newTargetsConfigMap(repo *fleet.GitRepo) (*corev1.ConfigMap, error) {
 			ClusterSelector:      target.ClusterSelector,
 			ClusterGroup:         target.ClusterGroup,
 			ClusterGroupSelector: target.ClusterGroupSelector,
+			ClusterExpression:    target.ClusterExpression,
 		})
 		spec.TargetRestrictions = append(spec.TargetRestrictions, fleet.BundleTargetRestriction(target))
 	}
@@ -54,3 +57,14 @@ func targetsOrDefault(targets []fleet.GitTarget) []fleet.GitTarget {
 	}
 	return targets
 }
+
+// validateTargets returns an error if the cluster expression of a target
+// does not compile, so it is reported before a bundle is created.
+func validateTargets(targets []fleet.GitTarget) error {
+	for _, target := range targets {
+		if err := matcher.ValidateExpression(target.ClusterExpression); err != nil {
+			return fmt.Errorf("target %q: %w", target.Name, err)
+		}
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package helmops
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"strconv"
@@ -21,6 +22,7 @@ import (
 	"github.com/rancher/fleet/internal/cmd/controller/helmops/reconciler"
 	fcreconciler "github.com/rancher/fleet/internal/cmd/controller/reconciler"
 	"github.com/rancher/fleet/internal/metrics"
+	"github.com/rancher/fleet/internal/tracing"
 	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
 	"github.com/rancher/fleet/pkg/version"
 )
@@ -72,6 +74,12 @@ func (g *HelmOperator) Run(cmd *cobra.Command, args []string) error {
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(zopts)))
 	ctx := clog.IntoContext(cmd.Context(), ctrl.Log.WithName("helmop-reconciler"))
 
+	shutdownTracing, err := tracing.Setup(ctx, "fleet-helmops")
+	if err != nil {
+		return fmt.Errorf("failed to set up tracing: %w", err)
+	}
+	defer func() { _ = shutdownTracing(context.WithoutCancel(ctx)) }()
+
 	namespace := g.Namespace
 
 	leaderOpts, err := command.NewLeaderElectionOptions()
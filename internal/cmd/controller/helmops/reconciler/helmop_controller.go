@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"path"
 	"strings"
 	"time"
 
@@ -33,7 +34,9 @@ import (
 	"github.com/rancher/fleet/internal/bundlereader"
 	fleetutil "github.com/rancher/fleet/internal/cmd/controller/errorutil"
 	"github.com/rancher/fleet/internal/cmd/controller/finalize"
+	"github.com/rancher/fleet/internal/cmd/controller/policyrestrictions"
 	ctrlquartz "github.com/rancher/fleet/internal/cmd/controller/quartz"
+	"github.com/rancher/fleet/internal/cmd/controller/target/matcher"
 	"github.com/rancher/fleet/internal/metrics"
 	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
 	"github.com/rancher/fleet/pkg/cert"
@@ -107,6 +110,7 @@ func (r *HelmOpReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 
 	if !helmop.GetDeletionTimestamp().IsZero() {
 		metrics.HelmCollector.Delete(helmop.Name, helmop.Namespace)
+		metrics.DeleteDeploymentLatency("HelmOp", helmop.Name, helmop.Namespace)
 
 		if err := purgeBundlesFn(); err != nil {
 			return ctrl.Result{}, err
@@ -144,7 +148,11 @@ func (r *HelmOpReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 			"%v",
 			err,
 		)
-		return ctrl.Result{}, updateErrorStatusHelm(ctx, r.Client, req.NamespacedName, helmop, err)
+		statusErr := updateErrorStatusHelm(ctx, r.Client, req.NamespacedName, helmop, err)
+		if statusErr == err && errors.Is(err, policyrestrictions.ErrQuotaExceeded) {
+			return ctrl.Result{RequeueAfter: durations.QuotaRecheckInterval}, nil
+		}
+		return ctrl.Result{}, statusErr
 	}
 
 	// Reconciling
@@ -204,6 +212,8 @@ func (r *HelmOpReconciler) createUpdateBundle(ctx context.Context, helmop *fleet
 		return nil, err
 	}
 
+	metav1.SetMetaDataAnnotation(&bundle.ObjectMeta, fleet.ChangeDetectedAtAnnotation, changeDetectedAt(b, bundle, time.Now()))
+
 	updated := bundle.DeepCopy()
 	_, err = controllerutil.CreateOrUpdate(ctx, r.Client, bundle, func() error {
 		bundle.Spec = updated.Spec
@@ -215,6 +225,20 @@ func (r *HelmOpReconciler) createUpdateBundle(ctx context.Context, helmop *fleet
 	return bundle, err
 }
 
+// changeDetectedAt returns the time at which the chart deployed by the new
+// bundle was detected. It keeps the time stored in the old bundle, unless the
+// chart, its repository or its version changed.
+func changeDetectedAt(oldBundle *fleet.Bundle, newBundle *fleet.Bundle, now time.Time) string {
+	detected, ok := oldBundle.Annotations[fleet.ChangeDetectedAtAnnotation]
+	if ok && oldBundle.Spec.Helm != nil && newBundle.Spec.Helm != nil &&
+		oldBundle.Spec.Helm.Chart == newBundle.Spec.Helm.Chart &&
+		oldBundle.Spec.Helm.Repo == newBundle.Spec.Helm.Repo &&
+		oldBundle.Spec.Helm.Version == newBundle.Spec.Helm.Version {
+		return detected
+	}
+	return metrics.FormatChangeDetectedAt(now)
+}
+
 // Calculates the bundle representation of the given HelmOp resource
 func (r *HelmOpReconciler) calculateBundle(helmop *fleet.HelmOp) *fleet.Bundle {
 	spec := helmop.Spec.BundleSpec
@@ -256,6 +280,11 @@ func (r *HelmOpReconciler) calculateBundle(helmop *fleet.HelmOp) *fleet.Bundle {
 	bundle.Labels = labels.Merge(bundle.Labels, map[string]string{
 		fleet.HelmOpLabel: helmop.Name,
 	})
+	// the bundle is handled by the same shard as the HelmOp, like bundles
+	// created from a GitRepo
+	if shardID, ok := helmop.Labels[sharding.ShardingRefLabel]; ok {
+		bundle.Labels[sharding.ShardingRefLabel] = shardID
+	}
 
 	// Setting the Resources to nil, the agent will download the helm chart
 	bundle.Spec.Resources = nil
@@ -431,6 +460,11 @@ func usesPolling(helmop fleet.HelmOp) bool {
 		return false
 	}
 
+	// Charts in git repositories change with new commits, regardless of their version.
+	if bundlereader.IsGitRepo(helmop.Spec.Helm.Repo) {
+		return true
+	}
+
 	// we only need to poll if the version is set to a constraint on versions, which may resolve to
 	// different available versions as the contents of the Helm repository evolves over time.
 	_, err := semver.StrictNewVersion(helmop.Spec.Helm.Version)
@@ -541,6 +575,7 @@ func helmChartSpecChanged(o *fleet.HelmOptions, n *fleet.HelmOptions, statusVers
 }
 
 // getChartVersion fetches the latest chart version from the Helm registry referenced by helmop, and returns it.
+// For git repositories, the version contains the commit of the chart as build metadata.
 // If this fails, it returns an empty version along with an error.
 // caBundle is an optional pre-resolved Rancher CA bundle. When nil and no CA bundle is set in auth,
 // getChartVersion resolves the bundle itself via GetRancherCABundle.
@@ -570,7 +605,13 @@ func getChartVersion(ctx context.Context, c client.Client, helmop fleet.HelmOp,
 		}
 	}
 
-	version, err := bundlereader.ChartVersion(ctx, *helmop.Spec.Helm, auth)
+	var version string
+	var err error
+	if bundlereader.IsGitRepo(helmop.Spec.Helm.Repo) {
+		version, err = bundlereader.GitChartVersion(ctx, *helmop.Spec.Helm, auth)
+	} else {
+		version, err = bundlereader.ChartVersion(ctx, *helmop.Spec.Helm, auth)
+	}
 	if err != nil {
 		return "", fmt.Errorf("could not get a chart version: %w", err)
 	}
@@ -587,7 +628,9 @@ func jobKey(h fleet.HelmOp) *quartz.JobKey {
 // as per https://helm.sh/docs/helm/helm_install/ :
 // * tarball URL in Chart, empty Repo, empty Version
 // * OCI reference in the Repo field, empty Chart, optional Version
+// * git repository in the Repo field, optional chart path in Chart, optional Version constraint
 // * non-empty Repo URL, non-empty Chart name, optional Version
+// It also checks that the cluster expressions of the targets compile.
 func validate(h fleet.HelmOp) error {
 	if h.Spec.Helm == nil {
 		return errors.New("helm options are empty in the HelmOp's spec")
@@ -610,6 +653,15 @@ func validate(h fleet.HelmOp) error {
 		if len(h.Spec.Helm.Chart) > 0 {
 			return fail("OCI repository with a non-empty chart field")
 		}
+	case bundlereader.IsGitRepo(h.Spec.Helm.Repo):
+		if chart := path.Clean(h.Spec.Helm.Chart); path.IsAbs(chart) || chart == ".." || strings.HasPrefix(chart, "../") {
+			return fail("git repository with a chart path outside of the repository")
+		}
+		if len(h.Spec.Helm.Version) > 0 {
+			if _, err := semver.NewConstraint(h.Spec.Helm.Version); err != nil {
+				return fail(fmt.Sprintf("git repository with an invalid version constraint: %v", err))
+			}
+		}
 	default: // Expecting full reference: chart + repo + optional version
 		if len(h.Spec.Helm.Chart) == 0 {
 			return fail("non-OCI repository with an empty chart field")
@@ -620,5 +672,11 @@ func validate(h fleet.HelmOp) error {
 		}
 	}
 
+	for _, target := range h.Spec.Targets {
+		if err := matcher.ValidateExpression(target.ClusterExpression); err != nil {
+			return fmt.Errorf("target %q: %w", target.Name, err)
+		}
+	}
+
 	return nil
 }
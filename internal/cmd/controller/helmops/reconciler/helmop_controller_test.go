@@ -234,6 +234,47 @@ func TestReconcile_Validate(t *testing.T) {
 			},
 			err: "non-OCI repository with an empty chart field",
 		},
+		{
+			name: "error if git repo with chart path outside of the repository",
+			helmop: fleet.HelmOp{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "helmop",
+					Namespace: "default",
+				},
+				Spec: fleet.HelmOpSpec{
+					BundleSpec: fleet.BundleSpec{
+						BundleDeploymentOptions: fleet.BundleDeploymentOptions{
+							Helm: &fleet.HelmOptions{
+								Chart: "../charts/app",
+								Repo:  "git::https://foo/bar/baz?ref=main",
+							},
+						},
+					},
+				},
+			},
+			err: "git repository with a chart path outside of the repository",
+		},
+		{
+			name: "error if git repo with invalid version constraint",
+			helmop: fleet.HelmOp{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "helmop",
+					Namespace: "default",
+				},
+				Spec: fleet.HelmOpSpec{
+					BundleSpec: fleet.BundleSpec{
+						BundleDeploymentOptions: fleet.BundleDeploymentOptions{
+							Helm: &fleet.HelmOptions{
+								Chart:   "charts/app",
+								Repo:    "https://foo/bar/baz.git",
+								Version: "not a constraint",
+							},
+						},
+					},
+				},
+			},
+			err: "git repository with an invalid version constraint",
+		},
 		{
 			name: "error if non-tarball chart with empty repo",
 			helmop: fleet.HelmOp{
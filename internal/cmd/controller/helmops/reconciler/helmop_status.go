@@ -4,9 +4,11 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"time"
 
 	"github.com/rancher/fleet/internal/cmd/controller/status"
 	"github.com/rancher/fleet/internal/cmd/controller/summary"
+	"github.com/rancher/fleet/internal/metrics"
 	"github.com/rancher/fleet/internal/resourcestatus"
 	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
 	"github.com/rancher/fleet/pkg/durations"
@@ -105,6 +107,10 @@ func (r *HelmOpStatusReconciler) Reconcile(ctx context.Context, req ctrl.Request
 			logger.Error(err, "Reconcile failed update to HelmOp status", "status", helmop.Status)
 			return ctrl.Result{RequeueAfter: durations.HelmOpStatusDelay}, nil
 		}
+
+		if !summary.IsReady(orig.Status.Summary) && summary.IsReady(helmop.Status.Summary) {
+			observeReadyLatency(bdList, helmop, time.Now())
+		}
 	}
 
 	return ctrl.Result{}, nil
@@ -131,3 +137,18 @@ func setStatusHelm(list *fleet.BundleDeploymentList, helmop *fleet.HelmOp) error
 
 	return nil
 }
+
+// observeReadyLatency records the time since the change deployed by the
+// bundle deployments of helmop was detected, once all of them are ready.
+func observeReadyLatency(list *fleet.BundleDeploymentList, helmop *fleet.HelmOp, now time.Time) {
+	var detected time.Time
+	for _, bd := range list.Items {
+		if summary.GetDeploymentState(&bd) != fleet.Ready {
+			return
+		}
+		if t, ok := metrics.ChangeDetectedAt(&bd); ok && t.After(detected) {
+			detected = t
+		}
+	}
+	metrics.ObserveDeploymentLatency("HelmOp", helmop.Name, helmop.Namespace, metrics.StageReady, detected, now)
+}
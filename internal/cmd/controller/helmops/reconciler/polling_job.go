@@ -14,6 +14,7 @@ import (
 	"github.com/reugn/go-quartz/quartz"
 	"golang.org/x/sync/semaphore"
 
+	"github.com/rancher/fleet/internal/bundlereader"
 	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
 
 	"github.com/rancher/wrangler/v3/pkg/condition"
@@ -110,7 +111,8 @@ func (j *helmPollingJob) pollHelm(ctx context.Context) error {
 
 	// In case the version constraint has changed before the job was updated or deleted, this prevents an unwanted
 	// update caused by a race between the scheduler and the reconciler.
-	if _, err := semver.StrictNewVersion(h.Spec.Helm.Version); err == nil {
+	// Charts in git repositories are polled for new commits, regardless of their version.
+	if _, err := semver.StrictNewVersion(h.Spec.Helm.Version); err == nil && !bundlereader.IsGitRepo(h.Spec.Helm.Repo) {
 		return nil
 	}
 
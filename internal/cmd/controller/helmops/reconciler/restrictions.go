@@ -8,6 +8,7 @@ import (
 	"fmt"
 
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/rancher/fleet/internal/cmd/controller/policyrestrictions"
 	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
@@ -33,6 +34,20 @@ func AuthorizeAndAssignDefaults(ctx context.Context, c client.Client, helmop *fl
 
 	pol := policyrestrictions.Aggregate(policies.Items)
 
+	if pol.Quota.MaxHelmOps != nil {
+		helmops := &fleet.HelmOpList{}
+		if err := c.List(ctx, helmops, client.InNamespace(helmop.Namespace)); err != nil {
+			return err
+		}
+		objs := make([]metav1.Object, 0, len(helmops.Items))
+		for i := range helmops.Items {
+			objs = append(objs, &helmops.Items[i])
+		}
+		if err := policyrestrictions.IsWithinCount("HelmOps", helmop, objs, pol.Quota.MaxHelmOps); err != nil {
+			return err
+		}
+	}
+
 	// Apply HelmOp-specific defaults before running the top-level checks.
 	if helmop.Spec.ServiceAccount == "" {
 		helmop.Spec.ServiceAccount = pol.HelmDefaultServiceAccount
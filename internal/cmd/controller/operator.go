@@ -11,12 +11,15 @@ import (
 
 	"github.com/rancher/fleet/internal/cmd"
 	"github.com/rancher/fleet/internal/cmd/controller/reconciler"
+	"github.com/rancher/fleet/internal/cmd/controller/sources"
 	"github.com/rancher/fleet/internal/cmd/controller/target"
 	"github.com/rancher/fleet/internal/config"
 	"github.com/rancher/fleet/internal/experimental"
 	"github.com/rancher/fleet/internal/manifest"
 	"github.com/rancher/fleet/internal/metrics"
+	"github.com/rancher/fleet/internal/ocistorage"
 	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/durations"
 
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
@@ -24,6 +27,7 @@ import (
 	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
@@ -49,6 +53,7 @@ func start(
 	bindAddresses BindAddresses,
 	disableMetrics bool,
 	shardID string,
+	autoShardIDs []string,
 ) error {
 	setupLog.Info("listening for changes on local cluster",
 		"disableMetrics", disableMetrics,
@@ -117,6 +122,7 @@ func start(
 	if shardID != "" {
 		shardIDSuffix = "-" + shardID
 	}
+	sourcesChan := make(chan event.TypedGenericEvent[*fleet.Bundle], 1024)
 	if err = (&reconciler.BundleReconciler{
 		Client:    mgr.GetClient(),
 		Scheme:    mgr.GetScheme(),
@@ -126,14 +132,65 @@ func start(
 		Builder: builder,
 		Store:   store,
 		Query:   builder,
+		Sources: sources.NewFetcher(mgr.GetClient(), sourcesChan),
+		OCI:     ocistorage.NewOCIWrapper(),
 		ShardID: shardID,
 
+		SourcesChan: sourcesChan,
+
 		Workers: workersOpts.Bundle,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Bundle")
 		return err
 	}
 
+	if err = (&reconciler.SecretDistributionReconciler{
+		Client:  mgr.GetClient(),
+		Scheme:  mgr.GetScheme(),
+		ShardID: shardID,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "SecretDistribution")
+		return err
+	}
+
+	// Policy objects are not sharded, only the unsharded controller reports
+	// their usage.
+	if shardID == "" {
+		if err = (&reconciler.PolicyReconciler{
+			Client: mgr.GetClient(),
+			Scheme: mgr.GetScheme(),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "Policy")
+			return err
+		}
+	}
+
+	// Shards are assigned and their load is reported by the unsharded
+	// controller.
+	if shardID == "" {
+		if len(autoShardIDs) > 0 {
+			setupLog.Info("assigning unlabeled resources to shards", "shardIDs", autoShardIDs)
+			if err = (&reconciler.ShardAssignmentReconciler{
+				Client: mgr.GetClient(),
+				Scheme: mgr.GetScheme(),
+
+				ShardIDs: autoShardIDs,
+			}).SetupWithManager(mgr); err != nil {
+				setupLog.Error(err, "unable to create controller", "controller", "ShardAssignment")
+				return err
+			}
+		}
+
+		if err := mgr.Add(&reconciler.ShardStatusReporter{
+			Client:    mgr.GetClient(),
+			Namespace: systemNamespace,
+			ShardIDs:  autoShardIDs,
+			Interval:  durations.ShardStatusInterval,
+		}); err != nil {
+			return err
+		}
+	}
+
 	sched, err := quartz.NewStdScheduler()
 	if err != nil {
 		return fmt.Errorf("failed to create scheduler: %w", err)
@@ -207,11 +264,23 @@ func start(
 		return err
 	}
 
+	// Add an indexer for the blobs referenced by Contents, to count their
+	// references
+	if err := AddContentBlobIndexer(ctx, mgr); err != nil {
+		return err
+	}
+
 	// Add an indexer for Bundle DownstreamResources (secrets and configmaps)
 	if err := AddBundleDownstreamResourceIndexer(ctx, mgr); err != nil {
 		return err
 	}
 
+	// Add an indexer for the dependencies of Bundles, to find the bundles
+	// using a bundle's outputs
+	if err := AddBundleDependencyIndexer(ctx, mgr); err != nil {
+		return err
+	}
+
 	if err = (&reconciler.ContentReconciler{
 		Client:  mgr.GetClient(),
 		Scheme:  mgr.GetScheme(),
@@ -273,7 +342,40 @@ func AddContentNameLabelIndexer(ctx context.Context, mgr manager.Manager) error
 	)
 }
 
-// AddBundleDownstreamResourceIndexer indexes Bundles by their DownstreamResources (secrets and configmaps).
+// AddContentBlobIndexer indexes Contents by the blobs they reference.
+func AddContentBlobIndexer(ctx context.Context, mgr manager.Manager) error {
+	return mgr.GetFieldIndexer().IndexField(
+		ctx,
+		&fleet.Content{},
+		config.ContentBlobIndex,
+		func(obj client.Object) []string {
+			content, ok := obj.(*fleet.Content)
+			if !ok {
+				return nil
+			}
+			return manifest.BlobIDs(content)
+		},
+	)
+}
+
+// AddBundleDependencyIndexer indexes Bundles by the bundles they depend on.
+func AddBundleDependencyIndexer(ctx context.Context, mgr manager.Manager) error {
+	return mgr.GetFieldIndexer().IndexField(
+		ctx,
+		&fleet.Bundle{},
+		config.BundleDependencyIndex,
+		func(obj client.Object) []string {
+			bundle, ok := obj.(*fleet.Bundle)
+			if !ok {
+				return nil
+			}
+			return target.DependencyIndexKeys(bundle)
+		},
+	)
+}
+
+// AddBundleDownstreamResourceIndexer indexes Bundles by their DownstreamResources (secrets and configmaps)
+// and the configmaps and secrets used by their sources.
 // This allows querying which bundles reference a specific secret or configmap, enabling reconciliation
 // when those resources change.
 func AddBundleDownstreamResourceIndexer(ctx context.Context, mgr manager.Manager) error {
@@ -304,6 +406,16 @@ func AddBundleDownstreamResourceIndexer(ctx context.Context, mgr manager.Manager
 					}
 				}
 			}
+			// Changes to configmap sources and their credentials also
+			// require a reconcile.
+			for _, source := range bundle.Spec.Sources {
+				if source.ConfigMap != nil {
+					resources = append(resources, "configmap/"+source.ConfigMap.Name)
+				}
+				if source.SecretName != "" {
+					resources = append(resources, "secret/"+source.SecretName)
+				}
+			}
 			return resources
 		},
 	)
@@ -33,10 +33,18 @@ func valuesFromKey(v fleet.ValuesFrom) string {
 	return "" // no stable identity; always append
 }
 
+func outputKey(o fleet.BundleOutput) string {
+	return o.Name
+}
+
 func comparePatchKey(p fleet.ComparePatch) string {
 	return p.APIVersion + "|" + p.Kind + "|" + p.Namespace + "|" + p.Name
 }
 
+func substituteFromKey(s fleet.SubstituteFrom) string {
+	return s.Kind + "|" + s.Namespace + "|" + s.Name
+}
+
 // DeploymentID hashes the options to a string
 func DeploymentID(manifestID string, opts fleet.BundleDeploymentOptions) (string, error) {
 	h := sha256.New()
@@ -142,6 +150,23 @@ func Merge(base, custom fleet.BundleDeploymentOptions) fleet.BundleDeploymentOpt
 	if len(custom.DownstreamResources) > 0 {
 		result.DownstreamResources = mergeUnique(result.DownstreamResources, custom.DownstreamResources, downstreamResourceKey)
 	}
+	if len(custom.Outputs) > 0 {
+		result.Outputs = mergeUnique(result.Outputs, custom.Outputs, outputKey)
+	}
+	// patches are applied in order, so the customization's patches run last
+	result.Patches = append(result.Patches, custom.Patches...)
+	if custom.Substitute != nil {
+		if result.Substitute == nil {
+			result.Substitute = &fleet.SubstituteOptions{}
+		}
+		if len(custom.Substitute.Vars) > 0 {
+			if result.Substitute.Vars == nil {
+				result.Substitute.Vars = map[string]string{}
+			}
+			maps.Copy(result.Substitute.Vars, custom.Substitute.Vars)
+		}
+		result.Substitute.VarsFrom = mergeUnique(result.Substitute.VarsFrom, custom.Substitute.VarsFrom, substituteFromKey)
+	}
 
 	return result
 }
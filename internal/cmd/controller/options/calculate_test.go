@@ -260,6 +260,67 @@ func TestMerge_ComparePatches_EmptyCustom(t *testing.T) {
 	a.Equal(base.Diff.ComparePatches, result.Diff.ComparePatches)
 }
 
+// ---------- Outputs ----------
+
+func TestMerge_Outputs_CustomTakesPrecedence(t *testing.T) {
+	a := assert.New(t)
+
+	base := fleet.BundleDeploymentOptions{
+		Outputs: []fleet.BundleOutput{
+			{Name: "ip", APIVersion: "v1", Kind: "Service", ResourceName: "lb", JSONPath: "{.status.loadBalancer.ingress[0].ip}"},
+			{Name: "port", APIVersion: "v1", Kind: "Service", ResourceName: "lb", JSONPath: "{.spec.ports[0].port}"},
+		},
+	}
+	custom := fleet.BundleDeploymentOptions{
+		Outputs: []fleet.BundleOutput{
+			{Name: "ip", APIVersion: "v1", Kind: "Service", ResourceName: "lb", JSONPath: "{.status.loadBalancer.ingress[0].hostname}"},
+		},
+	}
+
+	result := options.Merge(base, custom)
+	a.Len(result.Outputs, 2)
+	a.Equal("{.status.loadBalancer.ingress[0].hostname}", result.Outputs[0].JSONPath)
+	a.Equal("port", result.Outputs[1].Name)
+}
+
+func TestMerge_Patches_Appended(t *testing.T) {
+	a := assert.New(t)
+
+	base := fleet.BundleDeploymentOptions{
+		Patches: []fleet.ResourcePatch{{Target: fleet.PatchTarget{Kind: "Deployment"}, Patch: "spec:\n  replicas: 1\n"}},
+	}
+	custom := fleet.BundleDeploymentOptions{
+		Patches: []fleet.ResourcePatch{{Target: fleet.PatchTarget{Kind: "Deployment"}, Patch: "spec:\n  replicas: 3\n"}},
+	}
+
+	result := options.Merge(base, custom)
+	a.Len(result.Patches, 2)
+	a.Equal("spec:\n  replicas: 3\n", result.Patches[1].Patch)
+	a.Len(base.Patches, 1)
+}
+
+func TestMerge_Substitute(t *testing.T) {
+	a := assert.New(t)
+
+	base := fleet.BundleDeploymentOptions{
+		Substitute: &fleet.SubstituteOptions{
+			Vars:     map[string]string{"domain": "example.com", "replicas": "1"},
+			VarsFrom: []fleet.SubstituteFrom{{Kind: "ConfigMap", Name: "vars"}},
+		},
+	}
+	custom := fleet.BundleDeploymentOptions{
+		Substitute: &fleet.SubstituteOptions{
+			Vars:     map[string]string{"replicas": "3"},
+			VarsFrom: []fleet.SubstituteFrom{{Kind: "Secret", Name: "vars"}, {Kind: "ConfigMap", Name: "vars", Optional: true}},
+		},
+	}
+
+	result := options.Merge(base, custom)
+	a.Equal(map[string]string{"domain": "example.com", "replicas": "3"}, result.Substitute.Vars)
+	a.Equal([]fleet.SubstituteFrom{{Kind: "ConfigMap", Name: "vars", Optional: true}, {Kind: "Secret", Name: "vars"}}, result.Substitute.VarsFrom)
+	a.Equal("1", base.Substitute.Vars["replicas"])
+}
+
 // TestMergeChain verifies that chaining Merge calls (as done in AllMatches mode)
 // correctly accumulates values from multiple customizations.
 func TestMergeChain(t *testing.T) {
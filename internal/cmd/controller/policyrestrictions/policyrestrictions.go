@@ -3,12 +3,15 @@
 package policyrestrictions
 
 import (
+	"errors"
 	"fmt"
 	"regexp"
 	"slices"
 	"sort"
 
 	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // Merged is the aggregated result of one or more Policy objects in a namespace.
@@ -16,6 +19,12 @@ type Merged struct {
 	RequireServiceAccount  bool
 	AllowedServiceAccounts []string
 	AllowNamespaceCreation bool
+	ResourceRules          []fleet.ResourceRule
+
+	AllowedDependencyNamespaces []string
+
+	// Quota holds the lowest limit set by any Policy for each field.
+	Quota fleet.PolicyQuota
 
 	// GitRepo-specific
 	GitDefaultServiceAccount    string
@@ -54,6 +63,20 @@ func Aggregate(policies []fleet.Policy) Merged {
 			m.AllowNamespaceCreation = true
 		}
 		m.AllowedServiceAccounts = append(m.AllowedServiceAccounts, p.AllowedServiceAccounts...)
+		m.ResourceRules = append(m.ResourceRules, p.ResourceRules...)
+		m.AllowedDependencyNamespaces = append(m.AllowedDependencyNamespaces, p.AllowedDependencyNamespaces...)
+
+		// Quotas are limits, not allowances: the most restrictive one wins,
+		// so adding a Policy never lifts a quota set by another one.
+		if q := p.Quota; q != nil {
+			m.Quota.MaxGitRepos = minLimit(m.Quota.MaxGitRepos, q.MaxGitRepos)
+			m.Quota.MaxHelmOps = minLimit(m.Quota.MaxHelmOps, q.MaxHelmOps)
+			m.Quota.MaxBundles = minLimit(m.Quota.MaxBundles, q.MaxBundles)
+			m.Quota.MaxTargetClusters = minLimit(m.Quota.MaxTargetClusters, q.MaxTargetClusters)
+			if q.MaxBundleSize != nil && (m.Quota.MaxBundleSize == nil || q.MaxBundleSize.Cmp(*m.Quota.MaxBundleSize) < 0) {
+				m.Quota.MaxBundleSize = q.MaxBundleSize
+			}
+		}
 
 		if p.GitRepo != nil {
 			if m.GitDefaultServiceAccount == "" {
@@ -81,6 +104,61 @@ func Aggregate(policies []fleet.Policy) Merged {
 	return m
 }
 
+func minLimit(current, limit *int32) *int32 {
+	if limit == nil || (current != nil && *current <= *limit) {
+		return current
+	}
+	return limit
+}
+
+// ErrQuotaExceeded is wrapped by errors for objects, which exceed a Policy
+// quota. Unlike other Policy violations, these can resolve without a change
+// to the object, e.g. when other objects are deleted.
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// IsWithinCount checks that obj is among the first limit objects, ordered by
+// creation time, then name. obj is counted, even if it is missing from objs,
+// e.g. because the cache has not seen it yet. Objects being deleted are not
+// counted. A nil limit means there is no limit.
+func IsWithinCount(kind string, obj metav1.Object, objs []metav1.Object, limit *int32) error {
+	if limit == nil {
+		return nil
+	}
+
+	active := make([]metav1.Object, 0, len(objs)+1)
+	found := false
+	for _, o := range objs {
+		if o.GetName() == obj.GetName() {
+			found = true
+		}
+		if o.GetDeletionTimestamp() == nil {
+			active = append(active, o)
+		}
+	}
+	if !found && obj.GetDeletionTimestamp() == nil {
+		active = append(active, obj)
+	}
+	sort.Slice(active, func(i, j int) bool {
+		ti, tj := active[i].GetCreationTimestamp(), active[j].GetCreationTimestamp()
+		if !ti.Equal(&tj) {
+			return ti.Before(&tj)
+		}
+		return active[i].GetName() < active[j].GetName()
+	})
+
+	for i, o := range active {
+		if o.GetName() != obj.GetName() {
+			continue
+		}
+		if i >= int(*limit) {
+			return fmt.Errorf("%w: Policy allows at most %d %s in the namespace, found %d", ErrQuotaExceeded, *limit, kind, len(active))
+		}
+		return nil
+	}
+
+	return nil
+}
+
 // IsAllowed validates currentValue against an optional allowedValues list, applying defaultValue
 // when currentValue is empty.
 // Returns (resolved value, nil) on success, or (currentValue, error) when the value is disallowed.
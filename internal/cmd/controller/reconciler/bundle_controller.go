@@ -17,19 +17,24 @@ import (
 	"github.com/rancher/fleet/internal/cmd/agent/deployer/kv"
 	fleetutil "github.com/rancher/fleet/internal/cmd/controller/errorutil"
 	"github.com/rancher/fleet/internal/cmd/controller/finalize"
+	"github.com/rancher/fleet/internal/cmd/controller/policyrestrictions"
+	"github.com/rancher/fleet/internal/cmd/controller/sources"
 	"github.com/rancher/fleet/internal/cmd/controller/summary"
 	"github.com/rancher/fleet/internal/cmd/controller/target"
 	"github.com/rancher/fleet/internal/config"
 	"github.com/rancher/fleet/internal/manifest"
 	"github.com/rancher/fleet/internal/metrics"
 	"github.com/rancher/fleet/internal/ocistorage"
+	"github.com/rancher/fleet/internal/tracing"
 	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
 	"github.com/rancher/fleet/pkg/durations"
 	"github.com/rancher/fleet/pkg/helmvalues"
 	"github.com/rancher/fleet/pkg/sharding"
+	"go.opentelemetry.io/otel/attribute"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	errutil "k8s.io/apimachinery/pkg/util/errors"
@@ -45,6 +50,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
 const (
@@ -58,13 +64,18 @@ type BundleQuery interface {
 }
 
 type Store interface {
-	Store(context.Context, *manifest.Manifest) error
+	Store(ctx context.Context, m *manifest.Manifest, withContent bool) error
 }
 
 type TargetBuilder interface {
 	Targets(ctx context.Context, bundle *fleet.Bundle, manifestID string) ([]*target.Target, bool, error)
 }
 
+type OCIStore interface {
+	ManifestSize(ctx context.Context, opts ocistorage.OCIOpts, id string) (int64, error)
+	PullManifest(ctx context.Context, opts ocistorage.OCIOpts, id string) (*manifest.Manifest, error)
+}
+
 // BundleReconciler reconciles a Bundle object
 type BundleReconciler struct {
 	client.Client
@@ -75,14 +86,20 @@ type BundleReconciler struct {
 	Builder TargetBuilder
 	Store   Store
 	Query   BundleQuery
+	Sources SourceFetcher
+	OCI     OCIStore
 	ShardID string
 
+	// SourcesChan receives the bundles, whose sources were fetched in the
+	// background.
+	SourcesChan chan event.TypedGenericEvent[*fleet.Bundle]
+
 	Workers int
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *BundleReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
+	b := ctrl.NewControllerManagedBy(mgr).
 		For(&fleet.Bundle{},
 			builder.WithPredicates(
 				// do not trigger for bundle status changes (except for cache sync)
@@ -105,6 +122,12 @@ func (r *BundleReconciler) SetupWithManager(mgr ctrl.Manager) error {
 				sharding.FilterByShardID(r.ShardID),
 			),
 		).
+		Watches(
+			// Fan out from bundledeployment to the bundles depending on its
+			// bundle, which use its outputs in their templates.
+			&fleet.BundleDeployment{}, handler.EnqueueRequestsFromMapFunc(r.dependentBundlesMapFunc),
+			builder.WithPredicates(outputsChangedPredicate()),
+		).
 		Watches(
 			// Fan out from cluster to bundle, this is useful for targeting and templating.
 			&fleet.Cluster{},
@@ -146,8 +169,11 @@ func (r *BundleReconciler) SetupWithManager(mgr ctrl.Manager) error {
 			&corev1.ConfigMap{},
 			handler.EnqueueRequestsFromMapFunc(r.downstreamResourceMapFunc("ConfigMap")),
 			builder.WithPredicates(dataChangedPredicate()),
-		).
-		WithOptions(controller.Options{MaxConcurrentReconciles: r.Workers}).
+		)
+	if r.SourcesChan != nil {
+		b = b.WatchesRawSource(source.Channel(r.SourcesChan, &handler.TypedEnqueueRequestForObject[*fleet.Bundle]{}))
+	}
+	return b.WithOptions(controller.Options{MaxConcurrentReconciles: r.Workers}).
 		Complete(r)
 }
 
@@ -203,8 +229,9 @@ func (r *BundleReconciler) handleMatchedTarget(
 	}
 
 	// When content resources are stored in etcd, we need to keep track of the content resource so they
-	// are properly garbage-collected by the content controller.
-	if !contentsInOCI && !contentsInHelmChart {
+	// are properly garbage-collected by the content controller. HelmOps only
+	// use a content resource for the files of their sources.
+	if !contentsInOCI && (!contentsInHelmChart || manifestID != "") {
 		if bd.Labels == nil {
 			bd.Labels = make(map[string]string)
 		}
@@ -238,6 +265,10 @@ func (r *BundleReconciler) handleMatchedTarget(
 	}
 	bundleDeploymentUIDs.Insert(bd.UID)
 
+	if op != controllerutil.OperationResultNone && bundle.Generation != bundleOrig.Status.ObservedGeneration {
+		metrics.ObserveBundleLatency(bd, metrics.StageStaged, time.Now())
+	}
+
 	// At this stage, we know the UID of our bundle deployment, hence we can use it to populate the owner reference in the
 	// options secret.
 	// If the bundle deployment already existed and has simply been updated, the secret will already bear an owner
@@ -248,7 +279,7 @@ func (r *BundleReconciler) handleMatchedTarget(
 		}
 	}
 
-	if err := r.handleDownstreamObjects(ctx, bundle, bd); err != nil {
+	if err := r.handleDownstreamObjects(ctx, bundle, tgt.Cluster, bd); err != nil {
 		return r.computeResult(ctx, logger, bundleOrig, bundle, "failed to clone config maps and secrets downstream", err)
 	}
 
@@ -275,6 +306,10 @@ func (r *BundleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
+	// Join the trace of the change which updated the bundle, if any.
+	ctx, span := tracing.Start(tracing.Extract(ctx, bundle), "bundle.Reconcile", attribute.String("bundle", req.String()))
+	defer span.End()
+
 	if bundle.Labels[fleet.RepoLabel] != "" {
 		logger = logger.WithValues(
 			"gitrepo", bundle.Labels[fleet.RepoLabel],
@@ -305,7 +340,7 @@ func (r *BundleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 	// This closes the direct fleet-apply bypass path: a Bundle that violates policy is
 	// never deployed regardless of how it was created.
 	if err := r.authorizeBundle(ctx, bundle); err != nil {
-		return ctrl.Result{}, r.updateErrorStatus(ctx, bundleOrig, bundle, err)
+		return r.policyErrorResult(ctx, bundleOrig, bundle, err)
 	}
 
 	logger.V(1).Info(
@@ -324,9 +359,38 @@ func (r *BundleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		}
 	}
 
+	var sourceResources []fleet.BundleResource
+	if len(bundle.Spec.Sources) > 0 {
+		var err error
+		if sourceResources, err = r.resolveSources(ctx, bundle); errors.Is(err, sources.ErrPending) {
+			// The bundle is enqueued, once its sources were fetched.
+			logger.V(1).Info("Waiting for the sources of the bundle to be fetched")
+			return ctrl.Result{RequeueAfter: durations.SourcesPollingInterval}, nil
+		} else if err != nil {
+			return r.computeResult(ctx, logger, bundleOrig, bundle, "failed to resolve sources for bundle", err)
+		}
+	} else {
+		bundle.Status.Sources = nil
+	}
+
 	contentsInOCI := bundle.Spec.ContentsID != "" && ocistorage.OCIIsEnabled()
 	contentsInHelmChart := bundle.Spec.HelmOpOptions != nil
 
+	// The files of sources are added to the bundle's resources in a content
+	// resource. Agents can't add them to an OCI artifact or a Helm chart, so
+	// the resources of an OCI artifact are stored with them and a HelmOp's
+	// agents read them in addition to the chart.
+	resources := bundle.Spec.Resources
+	if len(sourceResources) > 0 && contentsInOCI {
+		m, err := r.pullOCIManifest(ctx, bundle)
+		if err != nil {
+			return r.computeResult(ctx, logger, bundleOrig, bundle, "failed to read OCI artifact to add the sources", err)
+		}
+		resources = m.Resources
+		contentsInOCI = false
+	}
+	storeContent := !contentsInOCI && (!contentsInHelmChart || len(sourceResources) > 0)
+
 	// Skip bundle deployment creation if the bundle is a HelmOps bundle and the configured Helm version is still a
 	// version constraint. That constraint should be resolved into a strict version by the HelmOps reconciler before bundle
 	// deployments can be created.
@@ -346,11 +410,15 @@ func (r *BundleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 
 	manifestID := bundle.Spec.ContentsID
 	var resourcesManifest *manifest.Manifest
-	if !contentsInOCI && !contentsInHelmChart {
+	if storeContent {
 		resourcesManifest = manifest.FromBundle(bundle)
 		if bundle.Generation != bundle.Status.ObservedGeneration {
 			resourcesManifest.ResetSHASum()
 		}
+		if len(sourceResources) > 0 {
+			// The files of the sources can change without a new generation.
+			resourcesManifest = manifest.New(append(slices.Clone(resources), sourceResources...))
+		}
 
 		manifestDigest, err := resourcesManifest.SHASum()
 		if err != nil {
@@ -377,7 +445,19 @@ func (r *BundleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		}
 	}
 
-	matchedTargets, secretsMissing, err := r.Builder.Targets(ctx, bundle, manifestID)
+	size, err := r.resourcesSize(ctx, bundle, resourcesManifest, contentsInOCI)
+	if err != nil {
+		return r.computeResult(ctx, logger, bundleOrig, bundle, "failed to get size of bundle resources", err)
+	}
+	bundle.Status.ResourcesSize = size
+	if err := r.authorizeBundleSize(ctx, bundle); err != nil {
+		return r.policyErrorResult(ctx, bundleOrig, bundle, err)
+	}
+
+	targetsCtx, targetsSpan := tracing.Start(ctx, "bundle.Targets")
+	matchedTargets, secretsMissing, err := r.Builder.Targets(targetsCtx, bundle, manifestID)
+	targetsSpan.SetAttributes(attribute.Int("targets", len(matchedTargets)))
+	tracing.End(targetsSpan, err)
 	if err != nil {
 		wrappedErr := fmt.Errorf("targeting error: %w", err)
 		if errors.Is(err, fleetutil.ErrHashMismatch) {
@@ -404,7 +484,11 @@ func (r *BundleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 			)
 	}
 
-	if (!contentsInOCI && !contentsInHelmChart) && len(matchedTargets) > 0 {
+	if err := r.authorizeBundleTargets(ctx, bundle, len(matchedTargets)); err != nil {
+		return r.policyErrorResult(ctx, bundleOrig, bundle, err)
+	}
+
+	if storeContent && len(matchedTargets) > 0 {
 		// when not using the OCI registry or helm chart we need to create a contents resource
 		// so the BundleDeployments are able to access the contents to be deployed.
 		// Otherwise, do not create a content resource if there are no targets.
@@ -416,7 +500,7 @@ func (r *BundleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		// the `helm.Chart` field, change which resources are used. The
 		// agents have access to all resources and use their specific
 		// set of `BundleDeploymentOptions`.
-		if err := r.Store.Store(ctx, resourcesManifest); err != nil {
+		if err := r.Store.Store(ctx, resourcesManifest, needsContent(matchedTargets)); err != nil {
 			return r.computeResult(ctx, logger, bundleOrig, bundle, "could not copy manifest into Content resource", err)
 		}
 	}
@@ -447,6 +531,9 @@ func (r *BundleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 	bundle.Status.ResourceKey = nil
 
 	summary.SetReadyConditions(&bundle.Status, "Cluster", bundle.Status.Summary)
+	if bundle.Generation != bundleOrig.Status.ObservedGeneration {
+		metrics.ObserveBundleLatency(bundle, metrics.StageBundleUpdated, time.Now())
+	}
 	bundle.Status.ObservedGeneration = bundle.Generation
 
 	// build BundleDeployments out of targets discarding Status, replacing DependsOn with the
@@ -477,6 +564,18 @@ func (r *BundleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		return ctrl.Result{RequeueAfter: durations.DefaultRequeueAfter}, errutil.NewAggregate(merr)
 	}
 
+	if bundle.Spec.Placement != nil {
+		// An agent going offline does not trigger a reconcile, recheck
+		// the placement periodically.
+		return ctrl.Result{RequeueAfter: durations.PlacementRecheckInterval}, errutil.NewAggregate(merr)
+	}
+
+	if hasRemoteSources(bundle) {
+		// Changes to git repositories and OCI artifacts are not watched,
+		// poll them periodically.
+		return ctrl.Result{RequeueAfter: durations.SourcesPollingInterval}, errutil.NewAggregate(merr)
+	}
+
 	return ctrl.Result{}, errutil.NewAggregate(merr)
 }
 
@@ -542,6 +641,12 @@ func (r *BundleReconciler) createBundleDeployment(
 ) (controllerutil.OperationResult, *fleet.BundleDeployment, error) {
 	logger := l.WithValues("deploymentID", bd.Spec.DeploymentID, "namespace", bd.Namespace, "name", bd.Name)
 
+	ctx, span := tracing.Start(ctx, "bundle.writeBundleDeployment",
+		attribute.String("bundledeployment", bd.Namespace+"/"+bd.Name),
+		attribute.String("deploymentID", bd.Spec.DeploymentID),
+	)
+	defer span.End()
+
 	updated := bd.DeepCopy()
 	op, err := controllerutil.CreateOrUpdate(ctx, r.Client, bd, func() error {
 		// check if there's any OCI secret that can be purged
@@ -551,6 +656,11 @@ func (r *BundleReconciler) createBundleDeployment(
 
 		bd.Spec = updated.Spec
 		bd.Labels = updated.GetLabels()
+		for _, k := range target.BundleDeploymentAnnotations {
+			if v, ok := updated.Annotations[k]; ok {
+				metav1.SetMetaDataAnnotation(&bd.ObjectMeta, k, v)
+			}
+		}
 
 		return nil
 	})
@@ -694,6 +804,66 @@ func (r *BundleReconciler) ensureOwnerReferences(ctx context.Context, bd *fleet.
 	return err
 }
 
+// needsContent returns true if the agent of a targeted cluster does not read
+// content blobs, so the whole manifest must be stored in the content resource.
+func needsContent(targets []*target.Target) bool {
+	return slices.ContainsFunc(targets, func(t *target.Target) bool {
+		return t.Cluster == nil || !t.Cluster.Status.Agent.ContentBlobs
+	})
+}
+
+// resourcesSize returns the size of the bundle's stored resources, i.e. of
+// the manifest stored in the Content resource or in the OCI artifact. HelmOp
+// bundles store no resources, the agents download the chart.
+func (r *BundleReconciler) resourcesSize(ctx context.Context, bundle *fleet.Bundle, m *manifest.Manifest, contentsInOCI bool) (int64, error) {
+	if m != nil {
+		var size int64
+		for _, resource := range m.Resources {
+			size += int64(len(resource.Content))
+		}
+		return size, nil
+	}
+	if !contentsInOCI || r.OCI == nil {
+		return 0, nil
+	}
+
+	// The ContentsID changes with the contents, so the size of an artifact
+	// only needs to be read once.
+	ref, err := r.getOCIReference(ctx, bundle)
+	if err != nil {
+		return 0, err
+	}
+	if ref == bundle.Status.OCIReference && bundle.Status.ResourcesSize > 0 {
+		return bundle.Status.ResourcesSize, nil
+	}
+
+	opts, err := ocistorage.ReadOptsFromSecret(ctx, r.Client, client.ObjectKey{Namespace: bundle.Namespace, Name: bundle.Spec.ContentsID})
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", fleetutil.ErrRetryable, err)
+	}
+	size, err := r.OCI.ManifestSize(ctx, opts, bundle.Spec.ContentsID)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", fleetutil.ErrRetryable, err)
+	}
+	return size, nil
+}
+
+// pullOCIManifest reads the bundle's resources from its OCI artifact.
+func (r *BundleReconciler) pullOCIManifest(ctx context.Context, bundle *fleet.Bundle) (*manifest.Manifest, error) {
+	if r.OCI == nil {
+		return nil, errors.New("OCI storage is not configured")
+	}
+	opts, err := ocistorage.ReadOptsFromSecret(ctx, r.Client, client.ObjectKey{Namespace: bundle.Namespace, Name: bundle.Spec.ContentsID})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", fleetutil.ErrRetryable, err)
+	}
+	m, err := r.OCI.PullManifest(ctx, opts, bundle.Spec.ContentsID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", fleetutil.ErrRetryable, err)
+	}
+	return m, nil
+}
+
 func (r *BundleReconciler) getOCIReference(ctx context.Context, bundle *fleet.Bundle) (string, error) {
 	if bundle.Spec.ContentsID == "" {
 		return "", errors.New("cannot get OCI reference. Bundle's ContentsID is not set")
@@ -779,6 +949,47 @@ func (r *BundleReconciler) cloneSecret(
 	if err := r.Get(ctx, namespacedName, &secret); err != nil {
 		return controllerutil.OperationResultNone, fmt.Errorf("failed to load source secret, cannot clone into %q: %w", ns, err)
 	}
+
+	return r.writeSecretClone(ctx, &secret, secretType, bd)
+}
+
+// distributeSecret clones the secret of a SecretDistribution into the
+// namespace of bd, with the data rendered for the bundle deployment's cluster.
+func (r *BundleReconciler) distributeSecret(
+	ctx context.Context,
+	ns string,
+	sdName string,
+	secretName string,
+	cluster *fleet.Cluster,
+	bd *fleet.BundleDeployment,
+) (controllerutil.OperationResult, error) {
+	sd := &fleet.SecretDistribution{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: ns, Name: sdName}, sd); err != nil {
+		return controllerutil.OperationResultNone, fmt.Errorf("failed to load secret distribution %s/%s: %w", ns, sdName, err)
+	}
+
+	var secret corev1.Secret
+	if err := r.Get(ctx, types.NamespacedName{Namespace: ns, Name: secretName}, &secret); err != nil {
+		return controllerutil.OperationResultNone, fmt.Errorf("failed to load source secret, cannot clone into %q: %w", ns, err)
+	}
+
+	data, err := target.RenderSecretData(sd.Spec.Templates, secret.Data, cluster)
+	if err != nil {
+		return controllerutil.OperationResultNone, err
+	}
+	secret.Data = data
+
+	return r.writeSecretClone(ctx, &secret, "", bd)
+}
+
+// writeSecretClone creates or updates a copy of secret in the namespace of bd,
+// owned by bd.
+func (r *BundleReconciler) writeSecretClone(
+	ctx context.Context,
+	secret *corev1.Secret,
+	secretType string,
+	bd *fleet.BundleDeployment,
+) (controllerutil.OperationResult, error) {
 	// clone the secret, and just change the namespace so it's in the target's namespace
 	targetSecret := &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
@@ -875,6 +1086,44 @@ func (r *BundleReconciler) authorizeBundle(ctx context.Context, bundle *fleet.Bu
 	return nil
 }
 
+// authorizeBundleSize checks the size of the bundle's stored resources against
+// the Policy quota and records a warning event if it is exceeded.
+func (r *BundleReconciler) authorizeBundleSize(ctx context.Context, bundle *fleet.Bundle) error {
+	if err := AuthorizeBundleSize(ctx, r.Client, bundle); err != nil {
+		r.Recorder.Eventf(
+			bundle,
+			nil,
+			corev1.EventTypeWarning,
+			"PolicyViolation",
+			"ApplyPolicyRestrictions",
+			"Bundle in namespace %s violates Policy: %v",
+			bundle.Namespace,
+			err,
+		)
+		return err
+	}
+	return nil
+}
+
+// authorizeBundleTargets checks the number of targeted clusters against the
+// Policy quota and records a warning event if it is exceeded.
+func (r *BundleReconciler) authorizeBundleTargets(ctx context.Context, bundle *fleet.Bundle, clusters int) error {
+	if err := AuthorizeBundleTargets(ctx, r.Client, bundle, clusters); err != nil {
+		r.Recorder.Eventf(
+			bundle,
+			nil,
+			corev1.EventTypeWarning,
+			"PolicyViolation",
+			"ApplyPolicyRestrictions",
+			"Bundle in namespace %s violates Policy: %v",
+			bundle.Namespace,
+			err,
+		)
+		return err
+	}
+	return nil
+}
+
 // updateErrorStatus sets the Ready condition in the bundle status and tries to update the resource.
 // Setting that condition makes the error message visible in the Rancher UI.
 // Upon successful update of the status, updateErrorStatus returns a TerminalError, preventing requeues.
@@ -893,14 +1142,40 @@ func (r *BundleReconciler) updateErrorStatus(
 	return reconcile.TerminalError(orgErr)
 }
 
-func (r *BundleReconciler) handleDownstreamObjects(ctx context.Context, bundle *fleet.Bundle, bd *fleet.BundleDeployment) error {
+// policyErrorResult reports a Policy violation in the bundle status. Quota
+// violations are rechecked periodically, as they resolve when other bundles
+// are deleted or the quota is raised, while other violations are terminal.
+func (r *BundleReconciler) policyErrorResult(ctx context.Context, orig, bundle *fleet.Bundle, err error) (ctrl.Result, error) {
+	if !errors.Is(err, policyrestrictions.ErrQuotaExceeded) {
+		return ctrl.Result{}, r.updateErrorStatus(ctx, orig, bundle, err)
+	}
+
+	SetCondition(string(fleet.Ready), &bundle.Status, err)
+	if statusErr := r.updateStatus(ctx, orig, bundle); statusErr != nil {
+		return ctrl.Result{}, errutil.NewAggregate([]error{err, fmt.Errorf("failed to update the status: %w", statusErr)})
+	}
+	return ctrl.Result{RequeueAfter: durations.QuotaRecheckInterval}, nil
+}
+
+func (r *BundleReconciler) handleDownstreamObjects(
+	ctx context.Context,
+	bundle *fleet.Bundle,
+	cluster *fleet.Cluster,
+	bd *fleet.BundleDeployment,
+) error {
 	// Track if any resources were created or updated
 	resourcesUpdated := false
 
 	for _, dr := range bd.Spec.Options.DownstreamResources {
 		switch strings.ToLower(dr.Kind) {
 		case "secret":
-			result, err := r.cloneSecret(ctx, bundle.Namespace, dr.Name, "", bd)
+			var result controllerutil.OperationResult
+			var err error
+			if sdName := bundle.Labels[fleet.SecretDistributionLabel]; sdName != "" {
+				result, err = r.distributeSecret(ctx, bundle.Namespace, sdName, dr.Name, cluster, bd)
+			} else {
+				result, err = r.cloneSecret(ctx, bundle.Namespace, dr.Name, "", bd)
+			}
 			if err != nil {
 				return fmt.Errorf(
 					"%w: failed to copy secret %s/%s to downstream cluster namespace: %w",
@@ -1090,6 +1365,47 @@ func (r *BundleReconciler) downstreamResourceMapFunc(kind string) func(ctx conte
 	}
 }
 
+// dependentBundlesMapFunc maps a BundleDeployment to the bundles which
+// depend on it. Bundles can depend on bundles in other namespaces, so they
+// are looked up in the dependency index, by name or, for dependencies
+// selected by labels, by matching their selectors.
+func (r *BundleReconciler) dependentBundlesMapFunc(ctx context.Context, obj client.Object) []reconcile.Request {
+	ns, name := target.BundleFromDeployment(obj.GetLabels())
+	if ns == "" || name == "" {
+		return nil
+	}
+
+	var bundles []fleet.Bundle
+	for _, key := range []string{ns + "/" + name, target.DependencyIndexSelector} {
+		bundleList := &fleet.BundleList{}
+		if err := r.List(ctx, bundleList, client.MatchingFields{config.BundleDependencyIndex: key}); err != nil {
+			return nil
+		}
+		bundles = append(bundles, bundleList.Items...)
+	}
+
+	var requests []reconcile.Request
+	for _, bundle := range bundles {
+		if (bundle.Namespace == ns && bundle.Name == name) || !sharding.ShouldProcess(&bundle, r.ShardID) {
+			continue
+		}
+		req := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: bundle.Namespace, Name: bundle.Name}}
+		if slices.Contains(requests, req) {
+			continue
+		}
+		for _, dep := range bundle.Spec.DependsOn {
+			selector, err := target.DependencySelector(dep, bundle.Namespace)
+			if err != nil || selector == nil || !selector.Matches(labels.Set(obj.GetLabels())) {
+				continue
+			}
+			requests = append(requests, req)
+			break
+		}
+	}
+
+	return requests
+}
+
 func batchDeleteBundleDeployments(ctx context.Context, c client.Client, list []fleet.BundleDeployment) error {
 	var errs []error
 	for _, bd := range list {
@@ -1143,6 +1459,32 @@ func dataChangedPredicate() predicate.Funcs {
 	}
 }
 
+// outputsChangedPredicate filters BundleDeployment events to only trigger
+// reconciliation when the reported outputs have changed.
+func outputsChangedPredicate() predicate.Funcs {
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			bd, ok := e.Object.(*fleet.BundleDeployment)
+			return ok && len(bd.Status.Outputs) > 0
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			n, nOK := e.ObjectNew.(*fleet.BundleDeployment)
+			o, oOK := e.ObjectOld.(*fleet.BundleDeployment)
+			if !nOK || !oOK {
+				return false
+			}
+			return !maps.Equal(n.Status.Outputs, o.Status.Outputs)
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			bd, ok := e.Object.(*fleet.BundleDeployment)
+			return ok && len(bd.Status.Outputs) > 0
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return false
+		},
+	}
+}
+
 // clusterChangedPredicate filters cluster events that relate to bundldeployment creation.
 func clusterChangedPredicate() predicate.Funcs {
 	return predicate.Funcs{
@@ -1175,6 +1517,10 @@ func clusterChangedPredicate() predicate.Funcs {
 			if n.Status.Agent.Namespace != o.Status.Agent.Namespace {
 				return true
 			}
+			// agents which do not read blobs need the whole manifest
+			if n.Status.Agent.ContentBlobs != o.Status.Agent.ContentBlobs {
+				return true
+			}
 
 			if n.Status.Scheduled != o.Status.Scheduled {
 				return true
@@ -15,7 +15,9 @@ import (
 	"github.com/rancher/fleet/internal/cmd/controller/finalize"
 	"github.com/rancher/fleet/internal/cmd/controller/reconciler"
 	"github.com/rancher/fleet/internal/cmd/controller/target"
+	"github.com/rancher/fleet/internal/manifest"
 	"github.com/rancher/fleet/internal/mocks"
+	"github.com/rancher/fleet/internal/ocistorage"
 	fleetv1 "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
 	"github.com/rancher/fleet/pkg/helmvalues"
 	"github.com/rancher/fleet/pkg/sharding"
@@ -26,12 +28,14 @@ import (
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -252,6 +256,69 @@ func TestReconcile_TargetsBuildingError(t *testing.T) {
 	}
 }
 
+func TestReconcile_StoreContentForAgentsWithoutBlobs(t *testing.T) {
+	cases := map[string]struct {
+		contentBlobs []bool
+		withContent  bool
+	}{
+		"all agents read blobs":         {contentBlobs: []bool{true, true}, withContent: false},
+		"one agent does not read blobs": {contentBlobs: []bool{true, false}, withContent: true},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			scheme := runtime.NewScheme()
+			utilruntime.Must(batchv1.AddToScheme(scheme))
+
+			// The invalid rollout strategy stops the reconcile after the
+			// content is stored.
+			bundle := fleetv1.Bundle{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "my-bundle",
+					Namespace: "default",
+				},
+				Spec: fleetv1.BundleSpec{
+					RolloutStrategy: &fleetv1.RolloutStrategy{
+						MaxUnavailable: &intstr.IntOrString{Type: intstr.String, StrVal: "foo"},
+					},
+				},
+			}
+
+			mockClient := mocks.NewMockK8sClient(mockCtrl)
+			expectGetWithFinalizer(mockClient, bundle)
+			statusClient := mocks.NewMockStatusWriter(mockCtrl)
+			mockClient.EXPECT().Status().Return(statusClient).Times(1)
+			expectStatusPatch(t, statusClient, "invalid maxUnavailable")
+
+			var matchedTargets []*target.Target
+			for i, blobs := range c.contentBlobs {
+				cluster := &fleetv1.Cluster{ObjectMeta: metav1.ObjectMeta{Namespace: "my-ns", Name: fmt.Sprintf("cluster-%d", i)}}
+				cluster.Status.Agent.ContentBlobs = blobs
+				matchedTargets = append(matchedTargets, &target.Target{
+					Bundle:       &bundle,
+					Cluster:      cluster,
+					Deployment:   &fleetv1.BundleDeployment{ObjectMeta: metav1.ObjectMeta{Namespace: "my-bd"}},
+					DeploymentID: "foo",
+				})
+			}
+			targetBuilderMock := mocks.NewMockTargetBuilder(mockCtrl)
+			targetBuilderMock.EXPECT().Targets(gomock.Any(), gomock.Any(), gomock.Any()).Return(matchedTargets, false, nil)
+
+			storeMock := mocks.NewMockStore(mockCtrl)
+			storeMock.EXPECT().Store(gomock.Any(), gomock.Any(), c.withContent).Return(nil)
+
+			r := reconciler.BundleReconciler{
+				Client:   mockClient,
+				Scheme:   scheme,
+				Recorder: mocks.NewMockEventRecorder(mockCtrl),
+				Builder:  targetBuilderMock,
+				Store:    storeMock,
+			}
+			_, _ = r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{Name: bundle.Name, Namespace: bundle.Namespace}})
+		})
+	}
+}
+
 func TestReconcile_StatusResetFromTargetsError(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
@@ -305,7 +372,7 @@ func TestReconcile_StatusResetFromTargetsError(t *testing.T) {
 	targetBuilderMock.EXPECT().Targets(gomock.Any(), gomock.Any(), gomock.Any()).Return(matchedTargets, false, nil)
 
 	storeMock := mocks.NewMockStore(mockCtrl)
-	storeMock.EXPECT().Store(gomock.Any(), gomock.Any()).Return(nil)
+	storeMock.EXPECT().Store(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
 
 	r := reconciler.BundleReconciler{
 		Client:   mockClient,
@@ -377,7 +444,7 @@ func TestReconcile_ManifestStorageError(t *testing.T) {
 			targetBuilderMock.EXPECT().Targets(gomock.Any(), gomock.Any(), gomock.Any()).Return(matchedTargets, false, nil)
 
 			storeMock := mocks.NewMockStore(mockCtrl)
-			storeMock.EXPECT().Store(gomock.Any(), gomock.Any()).Return(c.storeErr)
+			storeMock.EXPECT().Store(gomock.Any(), gomock.Any(), gomock.Any()).Return(c.storeErr)
 
 			r := reconciler.BundleReconciler{
 				Client:   mockClient,
@@ -488,7 +555,7 @@ func TestReconcile_OptionsSecretCreateUpdateError(t *testing.T) {
 			targetBuilderMock.EXPECT().Targets(gomock.Any(), gomock.Any(), gomock.Any()).Return(matchedTargets, false, nil)
 
 			storeMock := mocks.NewMockStore(mockCtrl)
-			storeMock.EXPECT().Store(gomock.Any(), gomock.Any()).Return(nil)
+			storeMock.EXPECT().Store(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
 
 			r := reconciler.BundleReconciler{
 				Client:   mockClient,
@@ -561,7 +628,7 @@ func TestReconcile_OptionsSecretDeletionError(t *testing.T) {
 	targetBuilderMock.EXPECT().Targets(gomock.Any(), gomock.Any(), gomock.Any()).Return(matchedTargets, false, nil)
 
 	storeMock := mocks.NewMockStore(mockCtrl)
-	storeMock.EXPECT().Store(gomock.Any(), gomock.Any()).Return(nil)
+	storeMock.EXPECT().Store(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
 
 	r := reconciler.BundleReconciler{
 		Client:   mockClient,
@@ -687,6 +754,76 @@ func TestReconcile_OCIReferenceSecretResolutionError(t *testing.T) {
 	}
 }
 
+type fakeOCIStore int64
+
+func (f fakeOCIStore) ManifestSize(context.Context, ocistorage.OCIOpts, string) (int64, error) {
+	return int64(f), nil
+}
+
+func (f fakeOCIStore) PullManifest(context.Context, ocistorage.OCIOpts, string) (*manifest.Manifest, error) {
+	return manifest.New([]fleetv1.BundleResource{{Name: "deployment.yaml", Content: "kind: Deployment"}}), nil
+}
+
+func TestReconcile_OCIBundleSize(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	scheme := runtime.NewScheme()
+	utilruntime.Must(batchv1.AddToScheme(scheme))
+
+	bundle := fleetv1.Bundle{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "my-bundle",
+			Namespace: "default",
+		},
+		Spec: fleetv1.BundleSpec{
+			ContentsID: "foo", // non-empty, resources are stored in an OCI artifact
+		},
+	}
+	namespacedName := types.NamespacedName{Name: bundle.Name, Namespace: bundle.Namespace}
+
+	mockClient := mocks.NewMockK8sClient(mockCtrl)
+	mockClient.EXPECT().Get(gomock.Any(), gomock.Any(), gomock.AssignableToTypeOf(&fleetv1.Bundle{}), gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ types.NamespacedName, b *fleetv1.Bundle, _ ...any) error {
+			bundle.DeepCopyInto(b)
+			controllerutil.AddFinalizer(b, finalize.BundleFinalizer)
+			return nil
+		},
+	)
+	mockClient.EXPECT().List(gomock.Any(), gomock.AssignableToTypeOf(&fleetv1.PolicyList{}), gomock.Any()).
+		SetArg(1, fleetv1.PolicyList{Items: []fleetv1.Policy{{
+			Quota: &fleetv1.PolicyQuota{MaxBundleSize: ptr.To(resource.MustParse("1Ki"))},
+		}}}).Return(nil).AnyTimes()
+	// OCI storage secret
+	mockClient.EXPECT().Get(gomock.Any(), types.NamespacedName{Namespace: "default", Name: "foo"}, gomock.AssignableToTypeOf(&corev1.Secret{}), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ types.NamespacedName, s *corev1.Secret, _ ...any) error {
+			s.Type = fleetv1.SecretTypeOCIStorage
+			s.Data = map[string][]byte{ocistorage.OCISecretReference: []byte("registry.example.com")}
+			return nil
+		}).Times(2)
+
+	statusClient := mocks.NewMockStatusWriter(mockCtrl)
+	mockClient.EXPECT().Status().Return(statusClient).Times(1)
+	expectStatusPatch(t, statusClient, "quota exceeded: bundle resources are 2Ki, Policy allows at most 1Ki per bundle")
+
+	recorderMock := mocks.NewMockEventRecorder(mockCtrl)
+	recorderMock.EXPECT().Eventf(gomock.Any(), nil, corev1.EventTypeWarning, "PolicyViolation", "ApplyPolicyRestrictions", gomock.Any(), gomock.Any(), gomock.Any())
+
+	r := reconciler.BundleReconciler{
+		Client:   mockClient,
+		Scheme:   scheme,
+		Recorder: recorderMock,
+		OCI:      fakeOCIStore(2048),
+	}
+
+	rs, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: namespacedName})
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if rs.RequeueAfter == 0 {
+		t.Errorf("expected the quota to be rechecked")
+	}
+}
+
 func TestReconcile_DownstreamObjectsHandlingError(t *testing.T) {
 	cases := []struct {
 		name                        string
@@ -827,7 +964,7 @@ func TestReconcile_DownstreamObjectsHandlingError(t *testing.T) {
 			targetBuilderMock.EXPECT().Targets(gomock.Any(), gomock.Any(), gomock.Any()).Return(matchedTargets, false, nil)
 
 			storeMock := mocks.NewMockStore(mockCtrl)
-			storeMock.EXPECT().Store(gomock.Any(), gomock.Any()).Return(nil)
+			storeMock.EXPECT().Store(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
 
 			r := reconciler.BundleReconciler{
 				Client:   mockClient,
@@ -961,7 +1098,7 @@ func TestReconcile_CreateBundleDeployment_AlreadyExists(t *testing.T) {
 			targetBuilderMock.EXPECT().Targets(gomock.Any(), gomock.Any(), gomock.Any()).Return(matchedTargets, false, nil)
 
 			storeMock := mocks.NewMockStore(mockCtrl)
-			storeMock.EXPECT().Store(gomock.Any(), gomock.Any()).Return(nil)
+			storeMock.EXPECT().Store(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
 
 			r := reconciler.BundleReconciler{
 				Client:    mockClient,
@@ -1500,7 +1637,7 @@ func TestReconcile_DownstreamResourcesGeneration_Increment(t *testing.T) {
 			targetBuilderMock.EXPECT().Targets(gomock.Any(), gomock.Any(), gomock.Any()).Return(matchedTargets, false, nil)
 
 			storeMock := mocks.NewMockStore(mockCtrl)
-			storeMock.EXPECT().Store(gomock.Any(), gomock.Any()).Return(nil)
+			storeMock.EXPECT().Store(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
 
 			// List BundleDeployments for cleanup
 			mockClient.EXPECT().List(gomock.Any(), gomock.AssignableToTypeOf(&fleetv1.BundleDeploymentList{}), gomock.Any()).
@@ -1635,7 +1772,7 @@ func setupBundleRaceTest(t *testing.T, reader client.Reader, helmValues map[stri
 	t.Cleanup(mockCtrl.Finish)
 
 	storeMock := mocks.NewMockStore(mockCtrl)
-	storeMock.EXPECT().Store(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	storeMock.EXPECT().Store(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
 
 	// If no reader was provided, fall through to the real fake client
 	// (the "secret is always found" control scenario).
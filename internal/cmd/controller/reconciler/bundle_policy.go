@@ -6,10 +6,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/rancher/fleet/internal/cmd/controller/policyrestrictions"
+	"github.com/rancher/fleet/internal/resourcepolicy"
 	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -28,12 +32,18 @@ func AuthorizeBundle(ctx context.Context, c client.Client, bundle *fleet.Bundle)
 		return err
 	}
 
+	pol := policyrestrictions.Aggregate(policies.Items)
+
+	// Dependencies on other namespaces must be allowed explicitly, so they
+	// are checked even if there is no Policy.
+	if err := authorizeDependencies(bundle, pol.AllowedDependencyNamespaces); err != nil {
+		return err
+	}
+
 	if len(policies.Items) == 0 {
 		return nil
 	}
 
-	pol := policyrestrictions.Aggregate(policies.Items)
-
 	// No defaulting at the Bundle level — there is no backstop.
 
 	// RequireServiceAccount: the top-level ServiceAccount is the fallback for
@@ -62,5 +72,127 @@ func AuthorizeBundle(ctx context.Context, c client.Client, bundle *fleet.Bundle)
 		}
 	}
 
+	if err := checkBundleQuota(ctx, c, bundle, pol.Quota); err != nil {
+		return err
+	}
+
+	// ResourceRules are evaluated by the agent. Reject invalid rules here, so
+	// they are reported on the Bundle instead of on every BundleDeployment.
+	if _, err := resourcepolicy.New(pol.ResourceRules); err != nil {
+		return fmt.Errorf("invalid resourceRules in Policy: %w", err)
+	}
+
+	return nil
+}
+
+// authorizeDependencies rejects dependencies on bundles in other namespaces,
+// unless the namespace is allowed. Namespaces can be selected by the
+// namespace field or by the bundle namespace label in the selector.
+func authorizeDependencies(bundle *fleet.Bundle, allowedNamespaces []string) error {
+	for _, dep := range bundle.Spec.DependsOn {
+		namespaces, err := dependencyNamespaces(dep)
+		if err != nil {
+			return err
+		}
+		for _, ns := range namespaces {
+			if ns == "" || ns == bundle.Namespace {
+				continue
+			}
+			if !slices.Contains(allowedNamespaces, ns) {
+				return fmt.Errorf("dependency on namespace %s is not allowed by Policy, see allowedDependencyNamespaces", ns)
+			}
+		}
+	}
+	return nil
+}
+
+// dependencyNamespaces returns the bundle namespaces dep refers to
+// explicitly. Selector expressions on the bundle namespace label must list
+// the namespaces, so they can be checked.
+func dependencyNamespaces(dep fleet.BundleRef) ([]string, error) {
+	namespaces := []string{dep.Namespace}
+	if dep.Selector == nil {
+		return namespaces, nil
+	}
+	if ns, ok := dep.Selector.MatchLabels[fleet.BundleNamespaceLabel]; ok {
+		namespaces = append(namespaces, ns)
+	}
+	for _, expr := range dep.Selector.MatchExpressions {
+		if expr.Key != fleet.BundleNamespaceLabel {
+			continue
+		}
+		if expr.Operator != metav1.LabelSelectorOpIn {
+			return nil, fmt.Errorf("dependsOn selector on %s must use the In operator", fleet.BundleNamespaceLabel)
+		}
+		namespaces = append(namespaces, expr.Values...)
+	}
+	return namespaces, nil
+}
+
+// checkBundleQuota rejects the bundle if the namespace already contains the
+// maximum number of older bundles.
+func checkBundleQuota(ctx context.Context, c client.Client, bundle *fleet.Bundle, quota fleet.PolicyQuota) error {
+	if quota.MaxBundles != nil {
+		bundles := &fleet.BundleList{}
+		if err := c.List(ctx, bundles, client.InNamespace(bundle.Namespace)); err != nil {
+			return err
+		}
+		objs := make([]metav1.Object, 0, len(bundles.Items))
+		for i := range bundles.Items {
+			objs = append(objs, &bundles.Items[i])
+		}
+		if err := policyrestrictions.IsWithinCount("Bundles", bundle, objs, quota.MaxBundles); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// AuthorizeBundleTargets rejects a bundle, which targets more clusters than
+// allowed by the Policy objects in its namespace.
+func AuthorizeBundleTargets(ctx context.Context, c client.Client, bundle *fleet.Bundle, clusters int) error {
+	policies := &fleet.PolicyList{}
+	if err := c.List(ctx, policies, client.InNamespace(bundle.Namespace)); err != nil {
+		if apimeta.IsNoMatchError(err) {
+			return nil
+		}
+		return err
+	}
+
+	if len(policies.Items) == 0 {
+		return nil
+	}
+
+	limit := policyrestrictions.Aggregate(policies.Items).Quota.MaxTargetClusters
+	if limit != nil && clusters > int(*limit) {
+		return fmt.Errorf("%w: bundle targets %d clusters, Policy allows at most %d", policyrestrictions.ErrQuotaExceeded, clusters, *limit)
+	}
+
+	return nil
+}
+
+// AuthorizeBundleSize rejects a bundle, whose stored resources are larger
+// than allowed by the Policy objects in its namespace. The size is taken from
+// the bundle's status, as it depends on where the resources are stored.
+func AuthorizeBundleSize(ctx context.Context, c client.Client, bundle *fleet.Bundle) error {
+	policies := &fleet.PolicyList{}
+	if err := c.List(ctx, policies, client.InNamespace(bundle.Namespace)); err != nil {
+		if apimeta.IsNoMatchError(err) {
+			return nil
+		}
+		return err
+	}
+
+	if len(policies.Items) == 0 {
+		return nil
+	}
+
+	limit := policyrestrictions.Aggregate(policies.Items).Quota.MaxBundleSize
+	if size := bundle.Status.ResourcesSize; limit != nil && size > limit.Value() {
+		return fmt.Errorf("%w: bundle resources are %s, Policy allows at most %s per bundle",
+			policyrestrictions.ErrQuotaExceeded, resource.NewQuantity(size, resource.BinarySI), limit)
+	}
+
 	return nil
 }
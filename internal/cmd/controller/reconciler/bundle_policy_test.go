@@ -4,10 +4,14 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
 	crclient "sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/rancher/fleet/internal/cmd/controller/reconciler"
@@ -29,6 +33,7 @@ func TestAuthorizeBundle(t *testing.T) {
 		name        string
 		input       fleet.Bundle
 		policies    *fleet.PolicyList
+		bundles     *fleet.BundleList
 		listErr     error
 		expectedErr string
 	}{
@@ -133,6 +138,111 @@ func TestAuthorizeBundle(t *testing.T) {
 			}},
 			policies: policy(fleet.Policy{AllowedServiceAccounts: []string{"good-sa"}}),
 		},
+		{
+			name:  "resourceRules: accept valid rules",
+			input: fleet.Bundle{},
+			policies: policy(fleet.Policy{ResourceRules: []fleet.ResourceRule{
+				{Name: "no-cluster-scoped", Expression: "!clusterScoped"},
+			}}),
+		},
+		{
+			name:  "resourceRules: reject rule which does not compile",
+			input: fleet.Bundle{},
+			policies: policy(fleet.Policy{ResourceRules: []fleet.ResourceRule{
+				{Name: "broken", Expression: "object.kind =="},
+			}}),
+			expectedErr: `invalid resourceRules in Policy: resource rule "broken".*`,
+		},
+		{
+			name:  "resourceRules: reject rule which is not a bool",
+			input: fleet.Bundle{},
+			policies: policy(fleet.Policy{ResourceRules: []fleet.ResourceRule{
+				{Name: "kind", Expression: "'kind'"},
+			}}),
+			expectedErr: "expression must evaluate to bool.*",
+		},
+		{
+			name:        "quota: reject bundle exceeding maxBundles",
+			input:       fleet.Bundle{ObjectMeta: metav1.ObjectMeta{Name: "c"}},
+			policies:    policy(fleet.Policy{Quota: &fleet.PolicyQuota{MaxBundles: ptr.To(int32(2))}}),
+			bundles:     bundleList("a", "b", "c"),
+			expectedErr: "quota exceeded: Policy allows at most 2 Bundles in the namespace, found 3",
+		},
+		{
+			name:     "quota: accept older bundle when maxBundles is exceeded",
+			input:    fleet.Bundle{ObjectMeta: metav1.ObjectMeta{Name: "b"}},
+			policies: policy(fleet.Policy{Quota: &fleet.PolicyQuota{MaxBundles: ptr.To(int32(2))}}),
+			bundles:  bundleList("a", "b", "c"),
+		},
+		{
+			name:  "quota: most restrictive maxBundles wins",
+			input: fleet.Bundle{ObjectMeta: metav1.ObjectMeta{Name: "b"}},
+			policies: &fleet.PolicyList{Items: []fleet.Policy{
+				{Quota: &fleet.PolicyQuota{MaxBundles: ptr.To(int32(5))}},
+				{Quota: &fleet.PolicyQuota{MaxBundles: ptr.To(int32(1))}},
+			}},
+			bundles:     bundleList("a", "b"),
+			expectedErr: "quota exceeded: Policy allows at most 1 Bundles in the namespace, found 2",
+		},
+		{
+			name:        "dependsOn: reject other namespace without policy",
+			input:       bundleWithDependency("platform"),
+			policies:    &fleet.PolicyList{},
+			expectedErr: "dependency on namespace platform is not allowed by Policy",
+		},
+		{
+			name:        "dependsOn: reject unlisted namespace",
+			input:       bundleWithDependency("platform"),
+			policies:    policy(fleet.Policy{AllowedDependencyNamespaces: []string{"other"}}),
+			expectedErr: "dependency on namespace platform is not allowed by Policy",
+		},
+		{
+			name:     "dependsOn: accept listed namespace",
+			input:    bundleWithDependency("platform"),
+			policies: policy(fleet.Policy{AllowedDependencyNamespaces: []string{"platform"}}),
+		},
+		{
+			name: "dependsOn: reject other namespace in selector labels",
+			input: fleet.Bundle{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: "app"},
+				Spec: fleet.BundleSpec{DependsOn: []fleet.BundleRef{{Selector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{fleet.BundleNamespaceLabel: "platform"},
+				}}}},
+			},
+			policies:    &fleet.PolicyList{},
+			expectedErr: "dependency on namespace platform is not allowed by Policy",
+		},
+		{
+			name: "dependsOn: reject other namespace in selector expressions",
+			input: fleet.Bundle{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: "app"},
+				Spec: fleet.BundleSpec{DependsOn: []fleet.BundleRef{{Selector: &metav1.LabelSelector{
+					MatchExpressions: []metav1.LabelSelectorRequirement{
+						{Key: fleet.BundleNamespaceLabel, Operator: metav1.LabelSelectorOpIn, Values: []string{"fleet-default", "platform"}},
+					},
+				}}}},
+			},
+			policies:    policy(fleet.Policy{AllowedDependencyNamespaces: []string{"other"}}),
+			expectedErr: "dependency on namespace platform is not allowed by Policy",
+		},
+		{
+			name: "dependsOn: reject selector expressions on namespaces without values",
+			input: fleet.Bundle{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: "app"},
+				Spec: fleet.BundleSpec{DependsOn: []fleet.BundleRef{{Selector: &metav1.LabelSelector{
+					MatchExpressions: []metav1.LabelSelectorRequirement{
+						{Key: fleet.BundleNamespaceLabel, Operator: metav1.LabelSelectorOpNotIn, Values: []string{"fleet-default"}},
+					},
+				}}}},
+			},
+			policies:    &fleet.PolicyList{},
+			expectedErr: "must use the In operator",
+		},
+		{
+			name:     "dependsOn: accept own namespace without policy",
+			input:    bundleWithDependency("fleet-default"),
+			policies: &fleet.PolicyList{},
+		},
 	}
 
 	for _, c := range cases {
@@ -143,6 +253,9 @@ func TestAuthorizeBundle(t *testing.T) {
 			mockClient := mocks.NewMockK8sClient(mockCtrl)
 			mockClient.EXPECT().List(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
 				func(_ context.Context, obj crclient.ObjectList, _ crclient.InNamespace) error {
+					if bl, ok := obj.(*fleet.BundleList); ok && c.bundles != nil {
+						bl.Items = c.bundles.Items
+					}
 					if pl, ok := obj.(*fleet.PolicyList); ok {
 						if c.listErr != nil {
 							return c.listErr
@@ -171,3 +284,125 @@ func TestAuthorizeBundle(t *testing.T) {
 		})
 	}
 }
+
+func bundleWithDependency(namespace string) fleet.Bundle {
+	return fleet.Bundle{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: "app"},
+		Spec: fleet.BundleSpec{
+			DependsOn: []fleet.BundleRef{{Name: "cert-manager", Namespace: namespace}},
+		},
+	}
+}
+
+func TestAuthorizeBundleTargets(t *testing.T) {
+	cases := []struct {
+		name        string
+		policies    *fleet.PolicyList
+		clusters    int
+		expectedErr string
+	}{
+		{
+			name:     "no-op when no policies exist",
+			policies: &fleet.PolicyList{},
+			clusters: 100,
+		},
+		{
+			name:     "no-op when no maxTargetClusters is set",
+			policies: &fleet.PolicyList{Items: []fleet.Policy{{RequireServiceAccount: true}}},
+			clusters: 100,
+		},
+		{
+			name:     "accept bundle targeting maxTargetClusters",
+			policies: &fleet.PolicyList{Items: []fleet.Policy{{Quota: &fleet.PolicyQuota{MaxTargetClusters: ptr.To(int32(3))}}}},
+			clusters: 3,
+		},
+		{
+			name:        "reject bundle targeting more than maxTargetClusters",
+			policies:    &fleet.PolicyList{Items: []fleet.Policy{{Quota: &fleet.PolicyQuota{MaxTargetClusters: ptr.To(int32(3))}}}},
+			clusters:    4,
+			expectedErr: "quota exceeded: bundle targets 4 clusters, Policy allows at most 3",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mockClient := mocks.NewMockK8sClient(mockCtrl)
+			mockClient.EXPECT().List(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+				func(_ context.Context, obj crclient.ObjectList, _ crclient.InNamespace) error {
+					if pl, ok := obj.(*fleet.PolicyList); ok {
+						pl.Items = c.policies.Items
+					}
+					return nil
+				},
+			)
+
+			err := reconciler.AuthorizeBundleTargets(context.TODO(), mockClient, &fleet.Bundle{}, c.clusters)
+			if c.expectedErr != "" {
+				require.Error(t, err)
+				assert.Equal(t, c.expectedErr, err.Error())
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+// bundleList returns bundles created in the order of their names.
+func TestAuthorizeBundleSize(t *testing.T) {
+	maxSize := &fleet.PolicyList{Items: []fleet.Policy{{Quota: &fleet.PolicyQuota{MaxBundleSize: ptr.To(resource.MustParse("1Ki"))}}}}
+	cases := []struct {
+		name        string
+		policies    *fleet.PolicyList
+		size        int64
+		expectedErr string
+	}{
+		{
+			name:     "no-op when no policies exist",
+			policies: &fleet.PolicyList{},
+			size:     2048,
+		},
+		{
+			name:     "accept bundle within maxBundleSize",
+			policies: maxSize,
+			size:     1024,
+		},
+		{
+			name:        "reject bundle larger than maxBundleSize",
+			policies:    maxSize,
+			size:        2048,
+			expectedErr: "quota exceeded: bundle resources are 2Ki, Policy allows at most 1Ki per bundle",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mockClient := mocks.NewMockK8sClient(mockCtrl)
+			mockClient.EXPECT().List(gomock.Any(), gomock.AssignableToTypeOf(&fleet.PolicyList{}), gomock.Any()).SetArg(1, *c.policies).Return(nil)
+
+			bundle := &fleet.Bundle{Status: fleet.BundleStatus{ResourcesSize: c.size}}
+			err := reconciler.AuthorizeBundleSize(context.TODO(), mockClient, bundle)
+			if c.expectedErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, c.expectedErr)
+			}
+		})
+	}
+}
+
+func bundleList(names ...string) *fleet.BundleList {
+	list := &fleet.BundleList{}
+	for i, name := range names {
+		list.Items = append(list.Items, fleet.Bundle{ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			CreationTimestamp: metav1.NewTime(time.Unix(int64(i), 0)),
+		}})
+	}
+	return list
+}
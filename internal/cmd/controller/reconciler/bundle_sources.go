@@ -0,0 +1,139 @@
+package reconciler
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rancher/fleet/internal/cmd/controller/sources"
+	"github.com/rancher/fleet/internal/content"
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/durations"
+	"github.com/rancher/wrangler/v3/pkg/data"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+type SourceFetcher interface {
+	Fetch(ctx context.Context, bundle client.ObjectKey, source fleet.BundleSource, revision string) (*sources.Result, error)
+}
+
+// resolveSources fetches the files of the bundle's sources and records their
+// revisions in the bundle status. Values files of the sources are merged into
+// the bundle's values, which is safe as we don't update bundle, just its
+// status. The files are returned as resources below ".sources/<name>/".
+// Git and OCI sources are polled at most every SourcesPollingInterval,
+// unless the bundle changed, otherwise the revision in the status is used.
+// They are fetched in the background, sources.ErrPending is returned until
+// the files of a source are available.
+func (r *BundleReconciler) resolveSources(ctx context.Context, bundle *fleet.Bundle) ([]fleet.BundleResource, error) {
+	previous := map[string]fleet.BundleSourceStatus{}
+	for _, s := range bundle.Status.Sources {
+		previous[s.Name] = s
+	}
+	now := time.Now()
+
+	files := map[string][]byte{}
+	status := make([]fleet.BundleSourceStatus, 0, len(bundle.Spec.Sources))
+	for _, source := range bundle.Spec.Sources {
+		s := fleet.BundleSourceStatus{Name: source.Name}
+		revision := ""
+		if source.Git != nil || source.OCI != nil {
+			prev, ok := previous[source.Name]
+			if ok && bundle.Generation == bundle.Status.ObservedGeneration &&
+				now.Sub(prev.LastPollingTime.Time) < durations.SourcesPollingInterval {
+				revision = prev.Revision
+				s.LastPollingTime = prev.LastPollingTime
+			} else {
+				s.LastPollingTime = metav1.NewTime(now)
+			}
+		}
+
+		result, err := r.Sources.Fetch(ctx, client.ObjectKeyFromObject(bundle), source, revision)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch source %q: %w", source.Name, err)
+		}
+		for name, data := range result.Files {
+			files[path.Join(fleet.BundleSourcesDir, source.Name, name)] = data
+		}
+		s.Revision = result.Revision
+		status = append(status, s)
+	}
+	bundle.Status.Sources = status
+
+	if err := mergeSourceValues(bundle, files); err != nil {
+		return nil, err
+	}
+
+	resources := make([]fleet.BundleResource, 0, len(files))
+	for _, name := range slices.Sorted(maps.Keys(files)) {
+		resource := fleet.BundleResource{Name: name}
+		if utf8.Valid(files[name]) {
+			resource.Content = string(files[name])
+		} else {
+			c, err := content.Base64GZ(files[name])
+			if err != nil {
+				return nil, err
+			}
+			resource.Content = c
+			resource.Encoding = "base64+gz"
+		}
+		resources = append(resources, resource)
+	}
+
+	return resources, nil
+}
+
+// mergeSourceValues merges the values files below ".sources/" into the
+// values of the bundle and its targets. As for local values files, the
+// files take precedence over inline values.
+func mergeSourceValues(bundle *fleet.Bundle, files map[string][]byte) error {
+	merge := func(helm *fleet.HelmOptions) error {
+		if helm == nil {
+			return nil
+		}
+		for _, name := range helm.ValuesFiles {
+			if !strings.HasPrefix(name, fleet.BundleSourcesDir+"/") {
+				continue
+			}
+			b, ok := files[path.Clean(name)]
+			if !ok {
+				return fmt.Errorf("values file %s not found in sources", name)
+			}
+			values := map[string]any{}
+			if err := yaml.Unmarshal(b, &values); err != nil {
+				return fmt.Errorf("reading values file %s: %w", name, err)
+			}
+			if helm.Values == nil {
+				helm.Values = &fleet.GenericMap{}
+			}
+			helm.Values = &fleet.GenericMap{Data: data.MergeMaps(helm.Values.Data, values)}
+		}
+		return nil
+	}
+
+	if err := merge(bundle.Spec.Helm); err != nil {
+		return err
+	}
+	for i := range bundle.Spec.Targets {
+		if err := merge(bundle.Spec.Targets[i].Helm); err != nil {
+			return fmt.Errorf("target %q: %w", bundle.Spec.Targets[i].Name, err)
+		}
+	}
+	return nil
+}
+
+// hasRemoteSources returns true if the bundle has git or OCI sources, whose
+// changes cannot be watched.
+func hasRemoteSources(bundle *fleet.Bundle) bool {
+	return slices.ContainsFunc(bundle.Spec.Sources, func(s fleet.BundleSource) bool {
+		return s.Git != nil || s.OCI != nil
+	})
+}
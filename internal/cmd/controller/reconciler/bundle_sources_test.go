@@ -0,0 +1,375 @@
+package reconciler_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"go.uber.org/mock/gomock"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/rancher/fleet/internal/cmd/controller/finalize"
+	"github.com/rancher/fleet/internal/cmd/controller/reconciler"
+	"github.com/rancher/fleet/internal/cmd/controller/sources"
+	"github.com/rancher/fleet/internal/cmd/controller/target"
+	"github.com/rancher/fleet/internal/manifest"
+	"github.com/rancher/fleet/internal/mocks"
+	"github.com/rancher/fleet/internal/ocistorage"
+	fleetv1 "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+)
+
+type fakeSourceFetcher map[string]*sources.Result
+
+func (f fakeSourceFetcher) Fetch(_ context.Context, _ client.ObjectKey, source fleetv1.BundleSource, _ string) (*sources.Result, error) {
+	if r, ok := f[source.Name]; ok {
+		return r, nil
+	}
+	return nil, errors.New("source not found")
+}
+
+// revisionSourceFetcher records the revisions passed to Fetch.
+type revisionSourceFetcher struct {
+	revisions []string
+}
+
+func (f *revisionSourceFetcher) Fetch(_ context.Context, _ client.ObjectKey, _ fleetv1.BundleSource, revision string) (*sources.Result, error) {
+	f.revisions = append(f.revisions, revision)
+	return nil, errors.New("fetch failed")
+}
+
+// pendingSourceFetcher fetches sources in the background, forever.
+type pendingSourceFetcher struct{}
+
+func (pendingSourceFetcher) Fetch(context.Context, client.ObjectKey, fleetv1.BundleSource, string) (*sources.Result, error) {
+	return nil, sources.ErrPending
+}
+
+func TestReconcile_Sources(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	scheme := runtime.NewScheme()
+	utilruntime.Must(batchv1.AddToScheme(scheme))
+
+	bundle := fleetv1.Bundle{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "my-bundle",
+			Namespace: "default",
+		},
+		Spec: fleetv1.BundleSpec{
+			BundleDeploymentOptions: fleetv1.BundleDeploymentOptions{
+				Helm: &fleetv1.HelmOptions{
+					Values: &fleetv1.GenericMap{Data: map[string]any{"replicas": 1, "name": "app"}},
+					GitOpsHelmOptions: fleetv1.GitOpsHelmOptions{
+						ValuesFiles: []string{".sources/shared/values.yaml"},
+					},
+				},
+			},
+			Resources: []fleetv1.BundleResource{{Name: "kustomization.yaml", Content: "resources: [.sources/shared/base]"}},
+			Sources: []fleetv1.BundleSource{{
+				Name: "shared",
+				Git:  &fleetv1.GitSource{Repo: "https://example.com/shared.git"},
+			}},
+		},
+	}
+	fetcher := fakeSourceFetcher{"shared": {
+		Revision: "abc",
+		Files: map[string][]byte{
+			"values.yaml":             []byte("replicas: 3\n"),
+			"base/kustomization.yaml": []byte("resources: [deployment.yaml]\n"),
+		},
+	}}
+
+	namespacedName := types.NamespacedName{Name: bundle.Name, Namespace: bundle.Namespace}
+
+	mockClient := mocks.NewMockK8sClient(mockCtrl)
+	expectGetWithFinalizer(mockClient, bundle)
+
+	statusClient := mocks.NewMockStatusWriter(mockCtrl)
+	mockClient.EXPECT().Status().Return(statusClient).Times(1)
+	expectStatusPatch(t, statusClient, "could not copy manifest into Content resource")
+
+	targetBuilderMock := mocks.NewMockTargetBuilder(mockCtrl)
+	targetBuilderMock.EXPECT().Targets(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, b *fleetv1.Bundle, _ string) ([]*target.Target, bool, error) {
+			if got := b.Spec.Helm.Values.Data["replicas"]; got != float64(3) {
+				t.Errorf("expected replicas from the source's values file, got %v", got)
+			}
+			if got := b.Spec.Helm.Values.Data["name"]; got != "app" {
+				t.Errorf("expected inline values to be kept, got %v", got)
+			}
+			if len(b.Status.Sources) != 1 || b.Status.Sources[0].Revision != "abc" {
+				t.Errorf("expected source revision in status, got %v", b.Status.Sources)
+			}
+			return []*target.Target{{DeploymentID: "foo"}}, false, nil
+		},
+	)
+
+	storeMock := mocks.NewMockStore(mockCtrl)
+	storeMock.EXPECT().Store(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, m *manifest.Manifest, _ bool) error {
+			var names []string
+			for _, r := range m.Resources {
+				names = append(names, r.Name)
+			}
+			want := []string{"kustomization.yaml", ".sources/shared/base/kustomization.yaml", ".sources/shared/values.yaml"}
+			if len(names) != len(want) {
+				t.Fatalf("expected resources %v, got %v", want, names)
+			}
+			for i := range want {
+				if names[i] != want[i] {
+					t.Errorf("expected resources %v, got %v", want, names)
+				}
+			}
+			return errors.New("something went wrong")
+		},
+	)
+
+	r := reconciler.BundleReconciler{
+		Client:   mockClient,
+		Scheme:   scheme,
+		Recorder: mocks.NewMockEventRecorder(mockCtrl),
+		Builder:  targetBuilderMock,
+		Store:    storeMock,
+		Sources:  fetcher,
+	}
+
+	if _, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: namespacedName}); err == nil {
+		t.Errorf("expected error from store")
+	}
+}
+
+func TestReconcile_SourcesOCI(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	scheme := runtime.NewScheme()
+	utilruntime.Must(batchv1.AddToScheme(scheme))
+
+	bundle := fleetv1.Bundle{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "my-bundle",
+			Namespace: "default",
+		},
+		Spec: fleetv1.BundleSpec{
+			ContentsID: "foo", // non-empty, resources are stored in an OCI artifact
+			Sources: []fleetv1.BundleSource{{
+				Name:      "shared",
+				ConfigMap: &fleetv1.LocalObjectReference{Name: "shared"},
+			}},
+		},
+	}
+	fetcher := fakeSourceFetcher{"shared": {
+		Files: map[string][]byte{"service.yaml": []byte("kind: Service\n")},
+	}}
+
+	namespacedName := types.NamespacedName{Name: bundle.Name, Namespace: bundle.Namespace}
+
+	mockClient := mocks.NewMockK8sClient(mockCtrl)
+	expectGetWithFinalizer(mockClient, bundle)
+	// OCI storage secret
+	mockClient.EXPECT().Get(gomock.Any(), types.NamespacedName{Namespace: "default", Name: "foo"}, gomock.AssignableToTypeOf(&corev1.Secret{}), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ types.NamespacedName, s *corev1.Secret, _ ...any) error {
+			s.Type = fleetv1.SecretTypeOCIStorage
+			s.Data = map[string][]byte{ocistorage.OCISecretReference: []byte("registry.example.com")}
+			return nil
+		})
+
+	statusClient := mocks.NewMockStatusWriter(mockCtrl)
+	mockClient.EXPECT().Status().Return(statusClient).Times(1)
+	expectStatusPatch(t, statusClient, "could not copy manifest into Content resource")
+
+	targetBuilderMock := mocks.NewMockTargetBuilder(mockCtrl)
+	targetBuilderMock.EXPECT().Targets(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return([]*target.Target{{DeploymentID: "foo"}}, false, nil)
+
+	storeMock := mocks.NewMockStore(mockCtrl)
+	storeMock.EXPECT().Store(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, m *manifest.Manifest, _ bool) error {
+			var names []string
+			for _, r := range m.Resources {
+				names = append(names, r.Name)
+			}
+			if !slices.Equal(names, []string{"deployment.yaml", ".sources/shared/service.yaml"}) {
+				t.Errorf("expected the OCI artifact's resources and the source's files, got %v", names)
+			}
+			return errors.New("something went wrong")
+		},
+	)
+
+	r := reconciler.BundleReconciler{
+		Client:   mockClient,
+		Scheme:   scheme,
+		Recorder: mocks.NewMockEventRecorder(mockCtrl),
+		Builder:  targetBuilderMock,
+		Store:    storeMock,
+		OCI:      fakeOCIStore(0),
+		Sources:  fetcher,
+	}
+
+	if _, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: namespacedName}); err == nil {
+		t.Errorf("expected error from store")
+	}
+}
+
+func TestReconcile_SourcesFetchError(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	scheme := runtime.NewScheme()
+	utilruntime.Must(batchv1.AddToScheme(scheme))
+
+	bundle := fleetv1.Bundle{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "my-bundle",
+			Namespace: "default",
+		},
+		Spec: fleetv1.BundleSpec{
+			Sources: []fleetv1.BundleSource{{
+				Name:      "missing",
+				ConfigMap: &fleetv1.LocalObjectReference{Name: "missing"},
+			}},
+		},
+	}
+
+	namespacedName := types.NamespacedName{Name: bundle.Name, Namespace: bundle.Namespace}
+
+	mockClient := mocks.NewMockK8sClient(mockCtrl)
+	expectGetWithFinalizer(mockClient, bundle)
+
+	statusClient := mocks.NewMockStatusWriter(mockCtrl)
+	mockClient.EXPECT().Status().Return(statusClient).Times(1)
+	expectStatusPatch(t, statusClient, `failed to resolve sources for bundle: failed to fetch source "missing"`)
+
+	r := reconciler.BundleReconciler{
+		Client:   mockClient,
+		Scheme:   scheme,
+		Recorder: mocks.NewMockEventRecorder(mockCtrl),
+		Sources:  fakeSourceFetcher{},
+	}
+
+	if _, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: namespacedName}); err == nil {
+		t.Errorf("expected error from fetching sources")
+	}
+}
+
+func TestReconcile_SourcesPending(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	scheme := runtime.NewScheme()
+	utilruntime.Must(batchv1.AddToScheme(scheme))
+
+	bundle := fleetv1.Bundle{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "my-bundle",
+			Namespace: "default",
+		},
+		Spec: fleetv1.BundleSpec{
+			Sources: []fleetv1.BundleSource{{
+				Name: "shared",
+				Git:  &fleetv1.GitSource{Repo: "https://example.com/shared.git"},
+			}},
+		},
+	}
+
+	namespacedName := types.NamespacedName{Name: bundle.Name, Namespace: bundle.Namespace}
+
+	mockClient := mocks.NewMockK8sClient(mockCtrl)
+	expectGetWithFinalizer(mockClient, bundle)
+
+	r := reconciler.BundleReconciler{
+		Client:   mockClient,
+		Scheme:   scheme,
+		Recorder: mocks.NewMockEventRecorder(mockCtrl),
+		Sources:  pendingSourceFetcher{},
+	}
+
+	rs, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: namespacedName})
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if rs.RequeueAfter == 0 {
+		t.Errorf("expected the bundle to be requeued while its sources are fetched")
+	}
+}
+
+func TestReconcile_SourcesPolling(t *testing.T) {
+	cases := []struct {
+		name               string
+		lastPolled         time.Duration
+		observedGeneration int64
+		revision           string
+	}{
+		{name: "recently polled", lastPolled: time.Minute, observedGeneration: 2, revision: "abc"},
+		{name: "polling interval passed", lastPolled: 10 * time.Minute, observedGeneration: 2},
+		{name: "bundle changed", lastPolled: time.Minute, observedGeneration: 1},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			scheme := runtime.NewScheme()
+			utilruntime.Must(batchv1.AddToScheme(scheme))
+
+			bundle := fleetv1.Bundle{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       "my-bundle",
+					Namespace:  "default",
+					Generation: 2,
+				},
+				Spec: fleetv1.BundleSpec{
+					Sources: []fleetv1.BundleSource{{
+						Name: "shared",
+						Git:  &fleetv1.GitSource{Repo: "https://example.com/shared.git"},
+					}},
+				},
+				Status: fleetv1.BundleStatus{
+					ObservedGeneration: tc.observedGeneration,
+					Sources: []fleetv1.BundleSourceStatus{{
+						Name:            "shared",
+						Revision:        "abc",
+						LastPollingTime: metav1.NewTime(time.Now().Add(-tc.lastPolled)),
+					}},
+				},
+			}
+
+			mockClient := mocks.NewMockK8sClient(mockCtrl)
+			mockClient.EXPECT().Get(gomock.Any(), gomock.Any(), gomock.AssignableToTypeOf(&fleetv1.Bundle{}), gomock.Any()).DoAndReturn(
+				func(_ context.Context, _ types.NamespacedName, b *fleetv1.Bundle, _ ...any) error {
+					bundle.DeepCopyInto(b)
+					controllerutil.AddFinalizer(b, finalize.BundleFinalizer)
+					return nil
+				},
+			)
+			mockClient.EXPECT().List(gomock.Any(), gomock.AssignableToTypeOf(&fleetv1.PolicyList{}), gomock.Any()).Return(nil).AnyTimes()
+
+			statusClient := mocks.NewMockStatusWriter(mockCtrl)
+			mockClient.EXPECT().Status().Return(statusClient).Times(1)
+			expectStatusPatch(t, statusClient, "fetch failed")
+
+			fetcher := &revisionSourceFetcher{}
+			r := reconciler.BundleReconciler{
+				Client:   mockClient,
+				Scheme:   scheme,
+				Recorder: mocks.NewMockEventRecorder(mockCtrl),
+				Sources:  fetcher,
+			}
+
+			namespacedName := types.NamespacedName{Name: bundle.Name, Namespace: bundle.Namespace}
+			if _, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: namespacedName}); err == nil {
+				t.Errorf("expected error from fetching sources")
+			}
+			if len(fetcher.revisions) != 1 || fetcher.revisions[0] != tc.revision {
+				t.Errorf("expected fetch of revision %q, got %v", tc.revision, fetcher.revisions)
+			}
+		})
+	}
+}
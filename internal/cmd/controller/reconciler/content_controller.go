@@ -2,9 +2,12 @@ package reconciler
 
 import (
 	"context"
+	"time"
 
 	"github.com/rancher/fleet/internal/config"
+	"github.com/rancher/fleet/internal/manifest"
 	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/durations"
 	"github.com/rancher/fleet/pkg/sharding"
 
 	"k8s.io/apimachinery/pkg/runtime"
@@ -19,6 +22,9 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 )
 
+// blobUnreferencedAnnotation records when a blob lost its last reference.
+const blobUnreferencedAnnotation = "fleet.cattle.io/unreferenced-since"
+
 // ContentReconciler reconciles a Content object
 type ContentReconciler struct {
 	client.Client
@@ -70,6 +76,19 @@ func (r *ContentReconciler) SetupWithManager(mgr ctrl.Manager) error {
 				},
 			),
 		).
+		Watches(
+			// Fan out from content to the blobs it references, to update their reference count
+			&fleet.Content{},
+			handler.EnqueueRequestsFromMapFunc(r.mapContentToBlobs),
+			builder.WithPredicates(
+				predicate.Funcs{
+					CreateFunc:  func(e event.CreateEvent) bool { return true },
+					UpdateFunc:  func(e event.UpdateEvent) bool { return false },
+					DeleteFunc:  func(e event.DeleteEvent) bool { return true },
+					GenericFunc: func(e event.GenericEvent) bool { return false },
+				},
+			),
+		).
 		WithEventFilter(sharding.FilterByShardID(r.ShardID)).
 		WithOptions(controller.Options{MaxConcurrentReconciles: r.Workers}).
 		Complete(r)
@@ -91,24 +110,19 @@ func (r *ContentReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		return ctrl.Result{}, err
 	}
 
-	// List all BundleDeployments that reference this Content resource
-	bdList := &fleet.BundleDeploymentList{}
-	err = r.List(ctx, bdList, client.MatchingFields{config.ContentNameIndex: content.Name})
+	newReferenceCount, err := r.referenceCount(ctx, content)
 	if err != nil {
-		logger.Error(err, "Failed to list BundleDeployments for Content resource")
+		logger.Error(err, "Failed to count references to Content resource")
 		return ctrl.Result{}, err
 	}
 
-	newReferenceCount := 0
-	for _, bd := range bdList.Items {
-		// Only count non-deleted BundleDeployments
-		if bd.DeletionTimestamp.IsZero() {
-			newReferenceCount++
+	if manifest.IsBlobID(content.Name) {
+		result, deleted, err := r.collectBlob(ctx, content, newReferenceCount, finalizersDeleted)
+		if err != nil || deleted || result.RequeueAfter > 0 {
+			return result, err
 		}
-	}
-
-	// If the Content resource has no more references... delete it
-	if newReferenceCount == 0 && (content.Status.ReferenceCount > 0 || finalizersDeleted) {
+	} else if newReferenceCount == 0 && (content.Status.ReferenceCount > 0 || finalizersDeleted) {
+		// If the Content resource has no more references... delete it
 		logger.V(1).Info("Content resource has no more references, deleting it")
 		return ctrl.Result{}, r.Delete(ctx, content)
 	}
@@ -126,6 +140,97 @@ func (r *ContentReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 	return ctrl.Result{}, nil
 }
 
+// collectBlob deletes a blob, once it has not been referenced for
+// durations.ContentBlobGracePeriod. The grace period prevents deleting a blob,
+// which the content store found while storing a new manifest, before the
+// manifest's Content resource is created. Returns true if the blob was
+// deleted.
+func (r *ContentReconciler) collectBlob(ctx context.Context, content *fleet.Content, refs int, finalizersDeleted bool) (ctrl.Result, bool, error) {
+	since, unreferenced := content.Annotations[blobUnreferencedAnnotation]
+	if refs > 0 {
+		if unreferenced {
+			orig := content.DeepCopy()
+			delete(content.Annotations, blobUnreferencedAnnotation)
+			return ctrl.Result{}, false, r.Patch(ctx, content, client.MergeFrom(orig))
+		}
+		return ctrl.Result{}, false, nil
+	}
+
+	if !unreferenced && content.Status.ReferenceCount == 0 && !finalizersDeleted {
+		// Not referenced yet, the manifest's Content resource is about to be created
+		return ctrl.Result{}, false, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, since)
+	if !unreferenced || err != nil {
+		orig := content.DeepCopy()
+		if content.Annotations == nil {
+			content.Annotations = map[string]string{}
+		}
+		content.Annotations[blobUnreferencedAnnotation] = time.Now().UTC().Format(time.RFC3339)
+		if err := r.Patch(ctx, content, client.MergeFrom(orig)); err != nil {
+			return ctrl.Result{}, false, err
+		}
+		return ctrl.Result{RequeueAfter: durations.ContentBlobGracePeriod}, false, nil
+	}
+
+	if wait := durations.ContentBlobGracePeriod - time.Since(t); wait > 0 {
+		return ctrl.Result{RequeueAfter: wait}, false, nil
+	}
+
+	log.FromContext(ctx).V(1).Info("Blob has no more references, deleting it")
+	return ctrl.Result{}, true, r.Delete(ctx, content)
+}
+
+// referenceCount returns the number of non-deleted BundleDeployments, which
+// reference the Content resource. Blobs are referenced by Content resources
+// instead.
+func (r *ContentReconciler) referenceCount(ctx context.Context, content *fleet.Content) (int, error) {
+	if manifest.IsBlobID(content.Name) {
+		contents := &fleet.ContentList{}
+		if err := r.List(ctx, contents, client.MatchingFields{config.ContentBlobIndex: content.Name}); err != nil {
+			return 0, err
+		}
+		n := 0
+		for _, c := range contents.Items {
+			if c.DeletionTimestamp.IsZero() {
+				n++
+			}
+		}
+		return n, nil
+	}
+
+	// List all BundleDeployments that reference this Content resource
+	bdList := &fleet.BundleDeploymentList{}
+	if err := r.List(ctx, bdList, client.MatchingFields{config.ContentNameIndex: content.Name}); err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for _, bd := range bdList.Items {
+		// Only count non-deleted BundleDeployments
+		if bd.DeletionTimestamp.IsZero() {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// mapContentToBlobs maps a Content resource to the blobs it references.
+func (r *ContentReconciler) mapContentToBlobs(ctx context.Context, obj client.Object) []ctrl.Request {
+	content, ok := obj.(*fleet.Content)
+	if !ok {
+		return nil
+	}
+
+	ids := manifest.BlobIDs(content)
+	requests := make([]ctrl.Request, 0, len(ids))
+	for _, id := range ids {
+		requests = append(requests, ctrl.Request{NamespacedName: types.NamespacedName{Name: id}})
+	}
+	return requests
+}
+
 // mapBundleDeploymentToContent maps a BundleDeployment to its associated Content resource.
 func (r *ContentReconciler) mapBundleDeploymentToContent(ctx context.Context, obj client.Object) []ctrl.Request {
 	bd, ok := obj.(*fleet.BundleDeployment)
@@ -8,7 +8,9 @@ import (
 	. "github.com/onsi/gomega"
 
 	"github.com/rancher/fleet/internal/config"
+	"github.com/rancher/fleet/internal/manifest"
 	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/durations"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -347,5 +349,77 @@ var _ = Describe("ContentReconciler", func() {
 				Expect(got.Status.ReferenceCount).To(Equal(1))
 			})
 		})
+
+		Context("when the Content is a blob", func() {
+			var manifestContent *fleet.Content
+
+			BeforeEach(func() {
+				shasum := manifest.BlobSHASum("kind: ConfigMap\n")
+				content = &fleet.Content{
+					ObjectMeta: metav1.ObjectMeta{Name: manifest.ToBlobID(shasum)},
+					SHA256Sum:  shasum,
+				}
+				manifestContent = &fleet.Content{
+					ObjectMeta: metav1.ObjectMeta{Name: "s-manifest"},
+					Blobs:      []fleet.ContentBlob{{Name: "cm.yaml", SHA256Sum: shasum}},
+				}
+
+				cl = fake.NewClientBuilder().WithScheme(sch).
+					WithIndex(&fleet.Content{}, config.ContentBlobIndex, func(obj client.Object) []string {
+						return manifest.BlobIDs(obj.(*fleet.Content))
+					}).
+					WithObjects(content, manifestContent).
+					WithStatusSubresource(&fleet.Content{}).
+					Build()
+			})
+
+			It("maps the manifest's Content to its blobs", func() {
+				res := r.mapContentToBlobs(ctx, manifestContent)
+				Expect(res).To(HaveLen(1))
+				Expect(res[0].NamespacedName.Name).To(Equal(content.Name))
+			})
+
+			It("counts the Contents referencing the blob and deletes it after a grace period once they are gone", func() {
+				_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKey{Name: content.Name}})
+				Expect(err).ToNot(HaveOccurred())
+
+				got := &fleet.Content{}
+				Expect(cl.Get(ctx, client.ObjectKey{Name: content.Name}, got)).To(Succeed())
+				Expect(got.Status.ReferenceCount).To(Equal(1))
+
+				Expect(cl.Delete(ctx, manifestContent)).To(Succeed())
+				res, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKey{Name: content.Name}})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(res.RequeueAfter).To(Equal(durations.ContentBlobGracePeriod))
+
+				Expect(cl.Get(ctx, client.ObjectKey{Name: content.Name}, got)).To(Succeed())
+				Expect(got.Annotations).To(HaveKey(blobUnreferencedAnnotation))
+
+				// a new manifest referencing the blob within the grace period keeps it
+				Expect(cl.Create(ctx, &fleet.Content{
+					ObjectMeta: metav1.ObjectMeta{Name: "s-other"},
+					Blobs:      manifestContent.Blobs,
+				})).To(Succeed())
+				_, err = r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKey{Name: content.Name}})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(cl.Get(ctx, client.ObjectKey{Name: content.Name}, got)).To(Succeed())
+				Expect(got.Annotations).ToNot(HaveKey(blobUnreferencedAnnotation))
+
+				Expect(cl.Delete(ctx, &fleet.Content{ObjectMeta: metav1.ObjectMeta{Name: "s-other"}})).To(Succeed())
+				_, err = r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKey{Name: content.Name}})
+				Expect(err).ToNot(HaveOccurred())
+
+				// the grace period has passed
+				Expect(cl.Get(ctx, client.ObjectKey{Name: content.Name}, got)).To(Succeed())
+				got.Annotations[blobUnreferencedAnnotation] = time.Now().Add(-durations.ContentBlobGracePeriod).UTC().Format(time.RFC3339)
+				Expect(cl.Update(ctx, got)).To(Succeed())
+				_, err = r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKey{Name: content.Name}})
+				Expect(err).ToNot(HaveOccurred())
+
+				gotList := &fleet.ContentList{}
+				Expect(cl.List(ctx, gotList)).To(Succeed())
+				Expect(gotList.Items).To(BeEmpty())
+			})
+		})
 	})
 })
@@ -295,7 +295,7 @@ func (c *CronDurationJob) executeStop(ctx context.Context) error {
 }
 
 // matchingClusters returns the list of clusters that match the given Schedule at this moment.
-func matchingClusters(ctx context.Context, matcher *matcher.ScheduleMatch, c client.Client, namespace string) ([]string, error) {
+func matchingClusters(ctx context.Context, scheduleMatch *matcher.ScheduleMatch, c client.Client, namespace string) ([]string, error) {
 	clusters := &fleet.ClusterList{}
 	if err := c.List(ctx, clusters, client.InNamespace(namespace)); err != nil {
 		return nil, fmt.Errorf("%w, listing clusters: %w", fleetutil.ErrRetryable, err)
@@ -307,7 +307,7 @@ func matchingClusters(ctx context.Context, matcher *matcher.ScheduleMatch, c cli
 			return nil, fmt.Errorf("%w, getting cluster groups from clusters: %w", fleetutil.ErrRetryable, err)
 		}
 
-		if matcher.MatchCluster(cluster.Name, target.ClusterGroupsToLabelMap(cgs), cluster.Labels) {
+		if scheduleMatch.MatchCluster(matcher.NewCluster(&cluster, target.ClusterGroupsToLabelMap(cgs))) {
 			clusterNames = append(clusterNames, cluster.Name)
 		}
 	}
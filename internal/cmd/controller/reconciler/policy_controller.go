@@ -0,0 +1,153 @@
+package reconciler
+
+import (
+	"context"
+	"reflect"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// PolicyReconciler reports the usage of a namespace, which is limited by the
+// quota of a Policy, in the Policy's status. Quotas are enforced by the
+// GitRepo, HelmOp and Bundle reconcilers.
+type PolicyReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *PolicyReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&fleet.Policy{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&fleet.GitRepo{}, handler.EnqueueRequestsFromMapFunc(r.mapToPolicies), builder.WithPredicates(usageChangedPredicate())).
+		Watches(&fleet.HelmOp{}, handler.EnqueueRequestsFromMapFunc(r.mapToPolicies), builder.WithPredicates(usageChangedPredicate())).
+		Watches(&fleet.Bundle{}, handler.EnqueueRequestsFromMapFunc(r.mapToPolicies), builder.WithPredicates(usageChangedPredicate())).
+		Complete(r)
+}
+
+//+kubebuilder:rbac:groups=fleet.cattle.io,resources=policies,verbs=get;list;watch
+//+kubebuilder:rbac:groups=fleet.cattle.io,resources=policies/status,verbs=get;update;patch
+
+// Reconcile counts the GitRepos, HelmOps and Bundles in the namespace of the
+// Policy and updates its status.
+func (r *PolicyReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx).WithName("policy")
+
+	policy := &fleet.Policy{}
+	if err := r.Get(ctx, req.NamespacedName, policy); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	usage, err := r.usage(ctx, req.Namespace)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if reflect.DeepEqual(policy.Status.Usage, usage) {
+		return ctrl.Result{}, nil
+	}
+
+	logger.V(1).Info("Updating policy usage", "usage", usage)
+	return ctrl.Result{}, r.updateStatus(ctx, req.NamespacedName, fleet.PolicyStatus{Usage: usage})
+}
+
+func (r *PolicyReconciler) usage(ctx context.Context, namespace string) (fleet.PolicyUsage, error) {
+	var usage fleet.PolicyUsage
+
+	gitrepos := &fleet.GitRepoList{}
+	if err := r.List(ctx, gitrepos, client.InNamespace(namespace)); err != nil {
+		return usage, err
+	}
+	for _, gitrepo := range gitrepos.Items {
+		if gitrepo.DeletionTimestamp == nil {
+			usage.GitRepos++
+		}
+	}
+
+	helmops := &fleet.HelmOpList{}
+	if err := r.List(ctx, helmops, client.InNamespace(namespace)); err != nil {
+		return usage, err
+	}
+	for _, helmop := range helmops.Items {
+		if helmop.DeletionTimestamp == nil {
+			usage.HelmOps++
+		}
+	}
+
+	bundles := &fleet.BundleList{}
+	if err := r.List(ctx, bundles, client.InNamespace(namespace)); err != nil {
+		return usage, err
+	}
+	var largest int64
+	for _, bundle := range bundles.Items {
+		if bundle.DeletionTimestamp != nil {
+			continue
+		}
+		usage.Bundles++
+		largest = max(largest, bundle.Status.ResourcesSize)
+		usage.MaxTargetClusters = max(usage.MaxTargetClusters, int32(bundle.Status.Summary.DesiredReady))
+	}
+	if usage.Bundles > 0 {
+		usage.LargestBundleSize = resource.NewQuantity(largest, resource.BinarySI)
+	}
+
+	return usage, nil
+}
+
+func (r *PolicyReconciler) updateStatus(ctx context.Context, req types.NamespacedName, status fleet.PolicyStatus) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		t := &fleet.Policy{}
+		err := r.Get(ctx, req, t)
+		if err != nil {
+			return err
+		}
+		t.Status = status
+		return r.Status().Update(ctx, t)
+	})
+}
+
+// mapToPolicies enqueues all policies in the namespace of the object.
+func (r *PolicyReconciler) mapToPolicies(ctx context.Context, obj client.Object) []ctrl.Request {
+	policies := &fleet.PolicyList{}
+	if err := r.List(ctx, policies, client.InNamespace(obj.GetNamespace())); err != nil {
+		log.FromContext(ctx).WithName("policy-handler").Error(err, "Failed to list policies", "namespace", obj.GetNamespace())
+		return nil
+	}
+
+	requests := make([]ctrl.Request, 0, len(policies.Items))
+	for _, p := range policies.Items {
+		requests = append(requests, ctrl.Request{NamespacedName: types.NamespacedName{Namespace: p.Namespace, Name: p.Name}})
+	}
+	return requests
+}
+
+// usageChangedPredicate filters updates, which cannot change the usage: only
+// creation, deletion, spec changes and, for bundles, changes to the number of
+// targeted clusters are relevant.
+func usageChangedPredicate() predicate.Funcs {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			if e.ObjectOld.GetGeneration() != e.ObjectNew.GetGeneration() {
+				return true
+			}
+			if (e.ObjectOld.GetDeletionTimestamp() == nil) != (e.ObjectNew.GetDeletionTimestamp() == nil) {
+				return true
+			}
+			o, ok1 := e.ObjectOld.(*fleet.Bundle)
+			n, ok2 := e.ObjectNew.(*fleet.Bundle)
+			return ok1 && ok2 && o.Status.Summary.DesiredReady != n.Status.Summary.DesiredReady
+		},
+	}
+}
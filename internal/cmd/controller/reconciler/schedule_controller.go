@@ -373,7 +373,7 @@ func (r *ScheduleReconciler) getClusterSchedules(
 		return nil, fmt.Errorf("%w, getting cluster groups from clusters: %w", fleetutil.ErrRetryable, err)
 	}
 
-	cgs := target.ClusterGroupsToLabelMap(groups)
+	matchCluster := matcher.NewCluster(cluster, target.ClusterGroupsToLabelMap(groups))
 
 	for i, s := range allSchedules.Items {
 		if !sharding.ShouldProcess(&s, r.ShardID) {
@@ -390,7 +390,7 @@ func (r *ScheduleReconciler) getClusterSchedules(
 			return nil, err
 		}
 
-		if matcher.MatchCluster(cluster.Name, cgs, cluster.Labels) {
+		if matcher.MatchCluster(matchCluster) {
 			schedules = append(schedules, &allSchedules.Items[i])
 		}
 	}
@@ -0,0 +1,185 @@
+package reconciler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/rancher/fleet/internal/cmd/controller/status"
+	"github.com/rancher/fleet/internal/cmd/controller/summary"
+	"github.com/rancher/fleet/internal/names"
+	"github.com/rancher/fleet/internal/resourcestatus"
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/sharding"
+	"github.com/rancher/wrangler/v3/pkg/condition"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// SecretDistributionReconciler creates a bundle for each SecretDistribution,
+// which transports the secret to the targeted clusters as a downstream
+// resource. The bundle reconciler renders the secret's templates per cluster.
+type SecretDistributionReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+
+	ShardID string
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *SecretDistributionReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&fleet.SecretDistribution{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		// the status of the secret distribution mirrors the status of its bundle
+		Owns(&fleet.Bundle{}).
+		WithEventFilter(sharding.FilterByShardID(r.ShardID)).
+		Complete(r)
+}
+
+//+kubebuilder:rbac:groups=fleet.cattle.io,resources=secretdistributions,verbs=get;list;watch
+//+kubebuilder:rbac:groups=fleet.cattle.io,resources=secretdistributions/status,verbs=get;update;patch
+
+// Reconcile creates or updates the bundle of a SecretDistribution and
+// computes its status from the bundle deployments. The bundle, and thereby
+// the copies of the secret on the clusters, are garbage collected when the
+// SecretDistribution is deleted.
+func (r *SecretDistributionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx).WithName("secretdistribution")
+
+	sd := &fleet.SecretDistribution{}
+	if err := r.Get(ctx, req.NamespacedName, sd); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !sd.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	bundle := &fleet.Bundle{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: sd.Namespace,
+			Name:      names.SafeConcatName(sd.Name, "secret"),
+		},
+	}
+	op, err := controllerutil.CreateOrUpdate(ctx, r.Client, bundle, func() error {
+		if name, ok := bundle.Labels[fleet.SecretDistributionLabel]; ok && name != sd.Name {
+			return fmt.Errorf("bundle %s/%s belongs to secret distribution %q", bundle.Namespace, bundle.Name, name)
+		}
+		setSecretDistributionBundle(sd, bundle)
+		return controllerutil.SetControllerReference(sd, bundle, r.Scheme)
+	})
+	if err != nil {
+		return ctrl.Result{}, r.updateErrorStatus(ctx, req.NamespacedName, err)
+	}
+	if op != controllerutil.OperationResultNone {
+		logger.V(1).Info("Updated secret distribution bundle", "bundle", bundle.Name, "operation", op)
+	}
+
+	bdList := &fleet.BundleDeploymentList{}
+	if err := r.List(ctx, bdList, client.MatchingLabels{
+		fleet.SecretDistributionLabel: sd.Name,
+		fleet.BundleNamespaceLabel:    sd.Namespace,
+	}); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{}, retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		t := &fleet.SecretDistribution{}
+		if err := r.Get(ctx, req.NamespacedName, t); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+		orig := t.Status.DeepCopy()
+		if err := setSecretDistributionStatus(bdList, bundle, t); err != nil {
+			return err
+		}
+		if equality.Semantic.DeepEqual(orig, &t.Status) {
+			return nil
+		}
+		return r.Status().Update(ctx, t)
+	})
+}
+
+// setSecretDistributionBundle sets the labels and spec of the bundle, which
+// transports the secret of sd. The bundle has no resources, it only references
+// the secret as a downstream resource.
+func setSecretDistributionBundle(sd *fleet.SecretDistribution, bundle *fleet.Bundle) {
+	if bundle.Labels == nil {
+		bundle.Labels = map[string]string{}
+	}
+	bundle.Labels[fleet.SecretDistributionLabel] = sd.Name
+	// the bundle is handled by the same shard as the secret distribution
+	if shardID, ok := sd.Labels[sharding.ShardingRefLabel]; ok {
+		bundle.Labels[sharding.ShardingRefLabel] = shardID
+	}
+
+	targets := sd.Spec.Targets
+	if len(targets) == 0 {
+		targets = []fleet.BundleTarget{{Name: "default", ClusterGroup: "default"}}
+	}
+
+	bundle.Spec = fleet.BundleSpec{
+		BundleDeploymentOptions: fleet.BundleDeploymentOptions{
+			TargetNamespace: sd.Spec.TargetNamespace,
+			DownstreamResources: []fleet.DownstreamResource{
+				{Kind: "Secret", Name: sd.Spec.SecretName},
+			},
+			// Changes to the templates are not visible in the bundle,
+			// force the bundle reconciler to render the secret again.
+			ForceSyncGeneration: sd.Generation,
+		},
+		Targets: targets,
+	}
+}
+
+func setSecretDistributionStatus(list *fleet.BundleDeploymentList, bundle *fleet.Bundle, sd *fleet.SecretDistribution) error {
+	// sort bundledeployments so lists in status are always in the same order
+	sort.Slice(list.Items, func(i, j int) bool {
+		return list.Items[i].UID < list.Items[j].UID
+	})
+
+	if err := status.SetFields(list, &sd.Status.StatusBase); err != nil {
+		return err
+	}
+	resourcestatus.SetResources(list.Items, &sd.Status.StatusBase)
+	summary.SetReadyConditions(&sd.Status, "Bundle", sd.Status.Summary)
+
+	// Errors rendering or copying the secret are only reported on the bundle.
+	ready := condition.Cond(fleet.BundleConditionReady)
+	if ready.IsFalse(bundle) {
+		if msg := ready.GetMessage(bundle); msg != "" {
+			ready.False(&sd.Status)
+			ready.Message(&sd.Status, msg)
+		}
+	}
+
+	sd.Status.Display.ReadyBundleDeployments = fmt.Sprintf("%d/%d",
+		sd.Status.Summary.Ready,
+		sd.Status.Summary.DesiredReady)
+	sd.Status.ObservedGeneration = sd.Generation
+
+	return nil
+}
+
+func (r *SecretDistributionReconciler) updateErrorStatus(ctx context.Context, req types.NamespacedName, orgErr error) error {
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		t := &fleet.SecretDistribution{}
+		if err := r.Get(ctx, req, t); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+		SetCondition(fleet.BundleConditionReady, &t.Status, orgErr)
+		return r.Status().Update(ctx, t)
+	})
+	if err != nil {
+		return fmt.Errorf("%w, failed to update the status: %w", orgErr, err)
+	}
+	return orgErr
+}
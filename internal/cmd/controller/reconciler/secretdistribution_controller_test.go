@@ -0,0 +1,176 @@
+package reconciler
+
+import (
+	"context"
+	"testing"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/wrangler/v3/pkg/genericcondition"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+func newSecretDistributionClient(objs ...client.Object) (client.Client, *runtime.Scheme) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(corev1.AddToScheme(scheme))
+	utilruntime.Must(fleet.AddToScheme(scheme))
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).
+		WithStatusSubresource(&fleet.SecretDistribution{}, &fleet.Bundle{}).Build()
+	return c, scheme
+}
+
+func TestSecretDistributionReconcile(t *testing.T) {
+	ctx := context.Background()
+	sd := &fleet.SecretDistribution{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: "registry", Generation: 2},
+		Spec: fleet.SecretDistributionSpec{
+			SecretName:      "registry-creds",
+			TargetNamespace: "apps",
+			Targets:         []fleet.BundleTarget{{ClusterSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}}}},
+		},
+	}
+	bd := &fleet.BundleDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "cluster-ns",
+			Name:      "registry-secret",
+			Labels: map[string]string{
+				fleet.SecretDistributionLabel: "registry",
+				fleet.BundleNamespaceLabel:    "fleet-default",
+				fleet.ClusterNamespaceLabel:   "fleet-default",
+				fleet.ClusterLabel:            "prod-1",
+			},
+		},
+		Spec:   fleet.BundleDeploymentSpec{DeploymentID: "s-1", StagedDeploymentID: "s-1"},
+		Status: fleet.BundleDeploymentStatus{AppliedDeploymentID: "s-1", Ready: true, NonModified: true},
+	}
+	c, scheme := newSecretDistributionClient(sd, bd)
+	r := &SecretDistributionReconciler{Client: c, Scheme: scheme}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "fleet-default", Name: "registry"}}
+
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+
+	bundle := &fleet.Bundle{}
+	if err := c.Get(ctx, types.NamespacedName{Namespace: "fleet-default", Name: "registry-secret"}, bundle); err != nil {
+		t.Fatal(err)
+	}
+	if bundle.Labels[fleet.SecretDistributionLabel] != "registry" {
+		t.Errorf("expected secret distribution label, got %v", bundle.Labels)
+	}
+	if owner := metav1.GetControllerOf(bundle); owner == nil || owner.Kind != "SecretDistribution" || owner.Name != "registry" {
+		t.Errorf("expected bundle to be owned by the secret distribution, got %v", owner)
+	}
+	if len(bundle.Spec.Resources) != 0 {
+		t.Errorf("expected bundle without resources, got %d", len(bundle.Spec.Resources))
+	}
+	if got := bundle.Spec.DownstreamResources; len(got) != 1 || got[0].Kind != "Secret" || got[0].Name != "registry-creds" {
+		t.Errorf("expected the secret as downstream resource, got %v", got)
+	}
+	if bundle.Spec.TargetNamespace != "apps" || bundle.Spec.ForceSyncGeneration != 2 || len(bundle.Spec.Targets) != 1 {
+		t.Errorf("unexpected bundle spec %+v", bundle.Spec)
+	}
+
+	if err := c.Get(ctx, req.NamespacedName, sd); err != nil {
+		t.Fatal(err)
+	}
+	if sd.Status.Display.ReadyBundleDeployments != "1/1" || sd.Status.ObservedGeneration != 2 {
+		t.Errorf("unexpected status %+v", sd.Status)
+	}
+
+	// errors of the bundle are reported on the secret distribution
+	bundle.Status.Conditions = []genericcondition.GenericCondition{
+		{Type: fleet.BundleConditionReady, Status: corev1.ConditionFalse, Message: "failed to render template"},
+	}
+	if err := c.Status().Update(ctx, bundle); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Get(ctx, req.NamespacedName, sd); err != nil {
+		t.Fatal(err)
+	}
+	if len(sd.Status.Conditions) != 1 || sd.Status.Conditions[0].Status != corev1.ConditionFalse ||
+		sd.Status.Conditions[0].Message != "failed to render template" {
+		t.Errorf("expected bundle error in conditions, got %+v", sd.Status.Conditions)
+	}
+}
+
+func TestDistributeSecret(t *testing.T) {
+	ctx := context.Background()
+	sd := &fleet.SecretDistribution{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: "tls"},
+		Spec: fleet.SecretDistributionSpec{
+			SecretName: "tls",
+			Templates:  map[string]string{"host": "${ .ClusterName }.${ .ClusterValues.domain }"},
+		},
+	}
+	source := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: "tls"},
+		Data:       map[string][]byte{"tls.crt": []byte("cert")},
+		Type:       corev1.SecretTypeOpaque,
+	}
+	bd := &fleet.BundleDeployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "cluster-ns", Name: "tls-secret", UID: "bd-uid"},
+	}
+	cluster := &fleet.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: "prod-1"},
+		Spec:       fleet.ClusterSpec{TemplateValues: &fleet.GenericMap{Data: map[string]any{"domain": "example.com"}}},
+	}
+	c, scheme := newSecretDistributionClient(sd, source, bd)
+	r := &BundleReconciler{Client: c, Scheme: scheme}
+
+	assertSecret := func(op, wantOp controllerutil.OperationResult, want map[string]string) {
+		t.Helper()
+		if op != wantOp {
+			t.Errorf("expected operation %q, got %q", wantOp, op)
+		}
+		secret := &corev1.Secret{}
+		if err := c.Get(ctx, types.NamespacedName{Namespace: "cluster-ns", Name: "tls"}, secret); err != nil {
+			t.Fatal(err)
+		}
+		if len(secret.Data) != len(want) {
+			t.Errorf("expected data %v, got %v", want, secret.Data)
+		}
+		for k, v := range want {
+			if string(secret.Data[k]) != v {
+				t.Errorf("expected %s=%q, got %q", k, v, secret.Data[k])
+			}
+		}
+		if owner := metav1.GetControllerOf(secret); owner == nil || owner.UID != "bd-uid" {
+			t.Errorf("expected secret to be owned by the bundle deployment, got %v", owner)
+		}
+	}
+
+	op, err := r.distributeSecret(ctx, "fleet-default", "tls", "tls", cluster, bd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSecret(op, controllerutil.OperationResultCreated, map[string]string{"tls.crt": "cert", "host": "prod-1.example.com"})
+
+	// rotating the source secret updates the copy
+	source.Data["tls.crt"] = []byte("rotated")
+	if err := c.Update(ctx, source); err != nil {
+		t.Fatal(err)
+	}
+	op, err = r.distributeSecret(ctx, "fleet-default", "tls", "tls", cluster, bd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSecret(op, controllerutil.OperationResultUpdated, map[string]string{"tls.crt": "rotated", "host": "prod-1.example.com"})
+
+	// the secret is rendered per cluster
+	cluster.Spec.TemplateValues = nil
+	if _, err := r.distributeSecret(ctx, "fleet-default", "tls", "tls", cluster, bd); err == nil {
+		t.Error("expected an error for a missing template value")
+	}
+}
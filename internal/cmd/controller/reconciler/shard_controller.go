@@ -0,0 +1,203 @@
+package reconciler
+
+import (
+	"context"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/sharding"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// ShardAssignmentReconciler assigns GitRepos, HelmOps, SecretDistributions and
+// Bundles without a shard label to one of ShardIDs, by consistent hashing of
+// their namespace and name. Bundles created by a GitRepo, HelmOp or
+// SecretDistribution are assigned to the same shard as their owner.
+//
+// Labels set by the user are not changed. Resources assigned automatically are
+// reassigned when ShardIDs change, i.e. on startup.
+type ShardAssignmentReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+
+	ShardIDs []string
+}
+
+// SetupWithManager sets up a controller per assigned resource type with the Manager.
+func (r *ShardAssignmentReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	shardChanged := predicate.Or(
+		predicate.LabelChangedPredicate{},
+		predicate.AnnotationChangedPredicate{},
+	)
+
+	if err := ctrl.NewControllerManagedBy(mgr).
+		Named("shard-assignment-gitrepo").
+		For(&fleet.GitRepo{}, builder.WithPredicates(shardChanged)).
+		Complete(reconcile.Func(func(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+			return ctrl.Result{}, r.reconcileOwner(ctx, req, &fleet.GitRepo{})
+		})); err != nil {
+		return err
+	}
+
+	if err := ctrl.NewControllerManagedBy(mgr).
+		Named("shard-assignment-helmop").
+		For(&fleet.HelmOp{}, builder.WithPredicates(shardChanged)).
+		Complete(reconcile.Func(func(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+			return ctrl.Result{}, r.reconcileOwner(ctx, req, &fleet.HelmOp{})
+		})); err != nil {
+		return err
+	}
+
+	if err := ctrl.NewControllerManagedBy(mgr).
+		Named("shard-assignment-secretdistribution").
+		For(&fleet.SecretDistribution{}, builder.WithPredicates(shardChanged)).
+		Complete(reconcile.Func(func(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+			return ctrl.Result{}, r.reconcileOwner(ctx, req, &fleet.SecretDistribution{})
+		})); err != nil {
+		return err
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("shard-assignment-bundle").
+		For(&fleet.Bundle{}, builder.WithPredicates(shardChanged)).
+		// Bundles follow their owner to its new shard.
+		Watches(&fleet.GitRepo{},
+			handler.EnqueueRequestsFromMapFunc(r.mapToBundles(fleet.RepoLabel)),
+			builder.WithPredicates(predicate.LabelChangedPredicate{}),
+		).
+		Watches(&fleet.HelmOp{},
+			handler.EnqueueRequestsFromMapFunc(r.mapToBundles(fleet.HelmOpLabel)),
+			builder.WithPredicates(predicate.LabelChangedPredicate{}),
+		).
+		Watches(&fleet.SecretDistribution{},
+			handler.EnqueueRequestsFromMapFunc(r.mapToBundles(fleet.SecretDistributionLabel)),
+			builder.WithPredicates(predicate.LabelChangedPredicate{}),
+		).
+		Complete(reconcile.Func(r.reconcileBundle))
+}
+
+// reconcileOwner assigns a GitRepo, HelmOp or SecretDistribution to a shard.
+func (r *ShardAssignmentReconciler) reconcileOwner(ctx context.Context, req ctrl.Request, obj client.Object) error {
+	if err := r.Get(ctx, req.NamespacedName, obj); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if !assignable(obj) {
+		return nil
+	}
+	return r.assign(ctx, obj, sharding.Assign(req.String(), r.ShardIDs), true)
+}
+
+// reconcileBundle assigns a bundle to the shard of its GitRepo, HelmOp or
+// SecretDistribution, if that was assigned automatically, or to a shard of
+// its own if it has no owner.
+func (r *ShardAssignmentReconciler) reconcileBundle(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	bundle := &fleet.Bundle{}
+	if err := r.Get(ctx, req.NamespacedName, bundle); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	owner, err := r.owner(ctx, bundle)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if owner != nil {
+		// The owner passes its labels on to the bundle. Unless the
+		// owner was assigned automatically, the bundle's shard is
+		// managed there.
+		if !sharding.IsAutoAssigned(owner) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, r.assign(ctx, bundle, owner.GetLabels()[sharding.ShardingRefLabel], false)
+	}
+
+	if !assignable(bundle) {
+		return ctrl.Result{}, nil
+	}
+	return ctrl.Result{}, r.assign(ctx, bundle, sharding.Assign(req.String(), r.ShardIDs), true)
+}
+
+// owner returns the GitRepo, HelmOp or SecretDistribution which created the
+// bundle, or nil.
+func (r *ShardAssignmentReconciler) owner(ctx context.Context, bundle *fleet.Bundle) (client.Object, error) {
+	var owner client.Object
+	var name string
+	if name = bundle.Labels[fleet.RepoLabel]; name != "" {
+		owner = &fleet.GitRepo{}
+	} else if name = bundle.Labels[fleet.HelmOpLabel]; name != "" {
+		owner = &fleet.HelmOp{}
+	} else if name = bundle.Labels[fleet.SecretDistributionLabel]; name != "" {
+		owner = &fleet.SecretDistribution{}
+	} else {
+		return nil, nil
+	}
+
+	err := r.Get(ctx, types.NamespacedName{Namespace: bundle.Namespace, Name: name}, owner)
+	if err != nil {
+		return nil, client.IgnoreNotFound(err)
+	}
+	return owner, nil
+}
+
+// assign sets the shard label of obj. If auto is true, the shard is also
+// recorded in the annotation, so it can be reassigned later.
+func (r *ShardAssignmentReconciler) assign(ctx context.Context, obj client.Object, shardID string, auto bool) error {
+	if shardID == "" || obj.GetLabels()[sharding.ShardingRefLabel] == shardID &&
+		(!auto || obj.GetAnnotations()[sharding.ShardingAutoAnnotation] == shardID) {
+		return nil
+	}
+
+	orig := obj.DeepCopyObject().(client.Object)
+	labels := obj.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[sharding.ShardingRefLabel] = shardID
+	obj.SetLabels(labels)
+	if auto {
+		annotations := obj.GetAnnotations()
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[sharding.ShardingAutoAnnotation] = shardID
+		obj.SetAnnotations(annotations)
+	}
+
+	if err := r.Patch(ctx, obj, client.MergeFrom(orig)); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	log.FromContext(ctx).WithName("shard-assignment").V(1).Info("Assigned to shard", "shardID", shardID, "previousShardID", orig.GetLabels()[sharding.ShardingRefLabel])
+	return nil
+}
+
+// mapToBundles returns a map func, which enqueues the bundles referencing the
+// owner by label.
+func (r *ShardAssignmentReconciler) mapToBundles(label string) handler.MapFunc {
+	return func(ctx context.Context, obj client.Object) []ctrl.Request {
+		bundles := &fleet.BundleList{}
+		if err := r.List(ctx, bundles, client.InNamespace(obj.GetNamespace()), client.MatchingLabels{label: obj.GetName()}); err != nil {
+			log.FromContext(ctx).Error(err, "failed to list bundles for shard assignment", "owner", obj.GetName())
+			return nil
+		}
+
+		requests := make([]ctrl.Request, 0, len(bundles.Items))
+		for _, bundle := range bundles.Items {
+			requests = append(requests, ctrl.Request{NamespacedName: types.NamespacedName{Namespace: bundle.Namespace, Name: bundle.Name}})
+		}
+		return requests
+	}
+}
+
+// assignable returns true if obj has no shard label or its shard was
+// assigned automatically.
+func assignable(obj client.Object) bool {
+	_, hasLabel := obj.GetLabels()[sharding.ShardingRefLabel]
+	return !hasLabel || sharding.IsAutoAssigned(obj)
+}
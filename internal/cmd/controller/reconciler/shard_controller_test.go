@@ -0,0 +1,139 @@
+package reconciler
+
+import (
+	"context"
+	"testing"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/sharding"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestShardAssignment(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	utilruntime.Must(fleet.AddToScheme(scheme))
+
+	meta := func(name string, labels map[string]string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Namespace: "fleet-default", Name: name, Labels: labels}
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&fleet.GitRepo{ObjectMeta: meta("auto", nil)},
+		&fleet.GitRepo{ObjectMeta: meta("explicit", map[string]string{sharding.ShardingRefLabel: "shard0"})},
+		&fleet.Bundle{ObjectMeta: meta("auto-bundle", map[string]string{fleet.RepoLabel: "auto"})},
+		&fleet.Bundle{ObjectMeta: meta("explicit-bundle", map[string]string{fleet.RepoLabel: "explicit", sharding.ShardingRefLabel: "shard0"})},
+		&fleet.Bundle{ObjectMeta: meta("standalone", nil)},
+	).Build()
+
+	r := &ShardAssignmentReconciler{Client: c, Scheme: scheme, ShardIDs: []string{"shard0", "shard1", "shard2"}}
+	reconcileAll := func() {
+		t.Helper()
+		for _, name := range []string{"auto", "explicit"} {
+			if err := r.reconcileOwner(ctx, request(name), &fleet.GitRepo{}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for _, name := range []string{"auto-bundle", "explicit-bundle", "standalone"} {
+			if _, err := r.reconcileBundle(ctx, request(name)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	shardOf := func(obj client.Object, name string) string {
+		t.Helper()
+		if err := c.Get(ctx, request(name).NamespacedName, obj); err != nil {
+			t.Fatal(err)
+		}
+		return obj.GetLabels()[sharding.ShardingRefLabel]
+	}
+
+	reconcileAll()
+
+	repo := &fleet.GitRepo{}
+	want := sharding.Assign("fleet-default/auto", r.ShardIDs)
+	if got := shardOf(repo, "auto"); got != want {
+		t.Errorf("expected gitrepo to be assigned to %q, got %q", want, got)
+	}
+	if !sharding.IsAutoAssigned(repo) {
+		t.Errorf("expected gitrepo to be marked as assigned automatically, got %v", repo.Annotations)
+	}
+	if got := shardOf(&fleet.Bundle{}, "auto-bundle"); got != want {
+		t.Errorf("expected bundle to follow its gitrepo to %q, got %q", want, got)
+	}
+	if got := shardOf(&fleet.GitRepo{}, "explicit"); got != "shard0" {
+		t.Errorf("expected explicit label to be kept, got %q", got)
+	}
+	if got := shardOf(&fleet.Bundle{}, "explicit-bundle"); got != "shard0" {
+		t.Errorf("expected bundle of explicitly labeled gitrepo to be kept, got %q", got)
+	}
+	standalone := sharding.Assign("fleet-default/standalone", r.ShardIDs)
+	if got := shardOf(&fleet.Bundle{}, "standalone"); got != standalone {
+		t.Errorf("expected standalone bundle to be assigned to %q, got %q", standalone, got)
+	}
+
+	// Removing the assigned shard moves the gitrepo and its bundle.
+	r.ShardIDs = []string{}
+	for _, id := range []string{"shard0", "shard1", "shard2"} {
+		if id != want {
+			r.ShardIDs = append(r.ShardIDs, id)
+		}
+	}
+	reconcileAll()
+
+	moved := sharding.Assign("fleet-default/auto", r.ShardIDs)
+	if got := shardOf(&fleet.GitRepo{}, "auto"); got != moved || got == want {
+		t.Errorf("expected gitrepo to move to %q, got %q", moved, got)
+	}
+	if got := shardOf(&fleet.Bundle{}, "auto-bundle"); got != moved {
+		t.Errorf("expected bundle to follow its gitrepo to %q, got %q", moved, got)
+	}
+	if got := shardOf(&fleet.GitRepo{}, "explicit"); got != "shard0" {
+		t.Errorf("expected explicit label to be kept, got %q", got)
+	}
+}
+
+func TestShardAssignment_SecretDistribution(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	utilruntime.Must(fleet.AddToScheme(scheme))
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&fleet.SecretDistribution{ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: "creds"}},
+		&fleet.Bundle{ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: "creds-secret", Labels: map[string]string{fleet.SecretDistributionLabel: "creds"}}},
+	).Build()
+	r := &ShardAssignmentReconciler{Client: c, Scheme: scheme, ShardIDs: []string{"shard0", "shard1", "shard2"}}
+
+	if err := r.reconcileOwner(ctx, request("creds"), &fleet.SecretDistribution{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.reconcileBundle(ctx, request("creds-secret")); err != nil {
+		t.Fatal(err)
+	}
+
+	sd := &fleet.SecretDistribution{}
+	if err := c.Get(ctx, request("creds").NamespacedName, sd); err != nil {
+		t.Fatal(err)
+	}
+	want := sharding.Assign("fleet-default/creds", r.ShardIDs)
+	if got := sd.Labels[sharding.ShardingRefLabel]; got != want {
+		t.Errorf("expected secret distribution to be assigned to %q, got %q", want, got)
+	}
+	bundle := &fleet.Bundle{}
+	if err := c.Get(ctx, request("creds-secret").NamespacedName, bundle); err != nil {
+		t.Fatal(err)
+	}
+	if got := bundle.Labels[sharding.ShardingRefLabel]; got != want {
+		t.Errorf("expected bundle to follow its secret distribution to %q, got %q", want, got)
+	}
+}
+
+func request(name string) ctrl.Request {
+	return ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "fleet-default", Name: name}}
+}
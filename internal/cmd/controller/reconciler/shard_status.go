@@ -0,0 +1,144 @@
+package reconciler
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rancher/fleet/internal/metrics"
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/sharding"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// ShardStatusConfigMap is the name of the config map, which holds the
+	// number of objects per shard.
+	ShardStatusConfigMap = "fleet-shard-status"
+	// ShardStatusKey is the key of the status in the config map.
+	ShardStatusKey = "status.yaml"
+)
+
+// ShardLoad is the number of objects assigned to a shard. The default shard
+// has an empty ID.
+type ShardLoad struct {
+	ID                string `json:"id"`
+	GitRepos          int    `json:"gitrepos"`
+	HelmOps           int    `json:"helmops"`
+	Bundles           int    `json:"bundles"`
+	BundleDeployments int    `json:"bundledeployments"`
+}
+
+// ShardStatusReporter periodically counts the objects per shard and stores
+// them in the ShardStatusConfigMap and the fleet_shard_objects metric. The
+// queue depth of each shard is available from the workqueue metrics of its
+// controller pods.
+type ShardStatusReporter struct {
+	Client    client.Client
+	Namespace string
+	// ShardIDs are reported even if no objects are assigned to them.
+	ShardIDs []string
+	Interval time.Duration
+}
+
+// Start implements manager.Runnable. As a leader election runnable, it only
+// runs on the leader.
+func (r *ShardStatusReporter) Start(ctx context.Context) error {
+	logger := log.FromContext(ctx).WithName("shard-status")
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+	for {
+		if err := r.report(ctx); err != nil {
+			logger.Error(err, "failed to report shard status")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *ShardStatusReporter) report(ctx context.Context) error {
+	counts := map[string]map[string]int{"": {}}
+	for _, id := range r.ShardIDs {
+		counts[id] = map[string]int{}
+	}
+
+	for kind, list := range map[string]client.ObjectList{
+		"GitRepo":          &fleet.GitRepoList{},
+		"HelmOp":           &fleet.HelmOpList{},
+		"Bundle":           &fleet.BundleList{},
+		"BundleDeployment": &fleet.BundleDeploymentList{},
+	} {
+		if err := r.Client.List(ctx, list); err != nil {
+			return err
+		}
+		items, err := meta.ExtractList(list)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			obj, err := meta.Accessor(item)
+			if err != nil {
+				return err
+			}
+			id := obj.GetLabels()[sharding.ShardingRefLabel]
+			if counts[id] == nil {
+				counts[id] = map[string]int{}
+			}
+			counts[id][kind]++
+		}
+	}
+	metrics.SetShardObjects(counts)
+
+	load := make([]ShardLoad, 0, len(counts))
+	for id, kinds := range counts {
+		load = append(load, ShardLoad{
+			ID:                id,
+			GitRepos:          kinds["GitRepo"],
+			HelmOps:           kinds["HelmOp"],
+			Bundles:           kinds["Bundle"],
+			BundleDeployments: kinds["BundleDeployment"],
+		})
+	}
+	slices.SortFunc(load, func(a, b ShardLoad) int { return strings.Compare(a.ID, b.ID) })
+
+	data, err := yaml.Marshal(load)
+	if err != nil {
+		return err
+	}
+	return r.save(ctx, string(data))
+}
+
+func (r *ShardStatusReporter) save(ctx context.Context, data string) error {
+	cm := &corev1.ConfigMap{}
+	err := r.Client.Get(ctx, client.ObjectKey{Namespace: r.Namespace, Name: ShardStatusConfigMap}, cm)
+	if apierrors.IsNotFound(err) {
+		cm = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: r.Namespace, Name: ShardStatusConfigMap},
+			Data:       map[string]string{ShardStatusKey: data},
+		}
+		return r.Client.Create(ctx, cm)
+	} else if err != nil {
+		return err
+	}
+
+	if cm.Data[ShardStatusKey] == data {
+		return nil
+	}
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+	cm.Data[ShardStatusKey] = data
+	return r.Client.Update(ctx, cm)
+}
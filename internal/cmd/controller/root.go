@@ -2,12 +2,15 @@
 package controller
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"slices"
 	"strconv"
+	"strings"
 
 	"github.com/rancher/fleet/internal/cmd/controller/agentmanagement"
 	"github.com/rancher/fleet/internal/cmd/controller/gitops"
@@ -21,6 +24,7 @@ import (
 
 	command "github.com/rancher/fleet/internal/cmd"
 	"github.com/rancher/fleet/internal/cmd/controller/cleanup"
+	"github.com/rancher/fleet/internal/tracing"
 	"github.com/rancher/fleet/pkg/version"
 )
 
@@ -30,6 +34,7 @@ type FleetController struct {
 	Namespace            string `usage:"namespace to watch" default:"cattle-fleet-system" env:"NAMESPACE"`
 	DisableMetrics       bool   `usage:"disable metrics" name:"disable-metrics"`
 	ShardID              string `usage:"only manage resources labeled with a specific shard ID" name:"shard-id"`
+	AutoShardIDs         string `usage:"comma separated list of shard IDs, unlabeled GitRepos, HelmOps and Bundles are assigned to them" name:"auto-shard-ids" env:"FLEET_AUTO_SHARD_IDS"`
 	EnableLeaderElection bool   `name:"leader-elect" default:"true" usage:"Enable leader election for controller manager. Enabling this will ensure there is only one active controller manager."`
 }
 
@@ -68,6 +73,12 @@ func (f *FleetController) Run(cmd *cobra.Command, args []string) error {
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(zopts)))
 	ctx := clog.IntoContext(cmd.Context(), ctrl.Log)
 
+	shutdownTracing, err := tracing.Setup(ctx, "fleet-controller")
+	if err != nil {
+		return fmt.Errorf("failed to set up tracing: %w", err)
+	}
+	defer func() { _ = shutdownTracing(context.WithoutCancel(ctx)) }()
+
 	kubeconfig := ctrl.GetConfigOrDie()
 	workersOpts := ControllerReconcilerWorkers{}
 
@@ -156,6 +167,7 @@ func (f *FleetController) Run(cmd *cobra.Command, args []string) error {
 		bindAddresses,
 		f.DisableMetrics,
 		f.ShardID,
+		autoShardIDs(f.AutoShardIDs),
 	); err != nil {
 		return err
 	}
@@ -164,6 +176,18 @@ func (f *FleetController) Run(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// autoShardIDs parses the comma separated list of shard IDs used for
+// automatic shard assignment.
+func autoShardIDs(s string) []string {
+	var ids []string
+	for _, id := range strings.Split(s, ",") {
+		if id = strings.TrimSpace(id); id != "" && !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
 func App() *cobra.Command {
 	root := command.Command(&FleetController{}, cobra.Command{
 		Version: version.FriendlyVersion(),
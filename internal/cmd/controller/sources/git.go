@@ -0,0 +1,159 @@
+package sources
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/storage/memory"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	fleetgit "github.com/rancher/fleet/pkg/git"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+var commitRegexp = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+func (f *Fetcher) fetchGit(ctx context.Context, key string, source *fleet.GitSource, secret *corev1.Secret) (*Result, error) {
+	auth, err := fleetgit.GetAuthFromSecret(source.Repo, secret, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get git credentials: %w", err)
+	}
+	proxy := fleetgit.ProxyOptsFromEnvironment(source.Repo)
+
+	commit, ref, err := resolveRevision(ctx, source, auth, proxy)
+	if err != nil {
+		return nil, err
+	}
+
+	if r := f.cached(key, commit); r != nil {
+		return r, nil
+	}
+
+	opts := &gogit.CloneOptions{
+		URL:          source.Repo,
+		Auth:         auth,
+		ProxyOptions: proxy,
+		NoCheckout:   true,
+	}
+	if ref != "" {
+		// branches and tags can be cloned shallow, commits need the history
+		opts.ReferenceName = ref
+		opts.SingleBranch = true
+		opts.Depth = 1
+	}
+	repo, err := gogit.CloneContext(ctx, memory.NewStorage(), nil, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to clone %s: %w", source.Repo, err)
+	}
+
+	files, err := readCommit(repo, plumbing.NewHash(commit), source.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s at %s: %w", source.Repo, commit, err)
+	}
+
+	r := &Result{Revision: commit, Files: files}
+	f.store(key, r)
+	return r, nil
+}
+
+// resolveRevision returns the commit of the source's revision and the
+// reference it was resolved from. The reference is empty if the revision is
+// a commit.
+func resolveRevision(ctx context.Context, source *fleet.GitSource, auth transport.AuthMethod, proxy transport.ProxyOptions) (string, plumbing.ReferenceName, error) {
+	if commitRegexp.MatchString(source.Revision) {
+		return source.Revision, "", nil
+	}
+
+	remote := gogit.NewRemote(memory.NewStorage(), &config.RemoteConfig{
+		Name: gogit.DefaultRemoteName,
+		URLs: []string{source.Repo},
+	})
+	refs, err := remote.ListContext(ctx, &gogit.ListOptions{
+		Auth:          auth,
+		ProxyOptions:  proxy,
+		PeelingOption: gogit.AppendPeeled,
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to list references of %s: %w", source.Repo, err)
+	}
+
+	hashes := map[string]string{}
+	for _, ref := range refs {
+		hashes[ref.Name().String()] = ref.Hash().String()
+	}
+	// HEAD is symbolic when listing local repositories
+	for _, ref := range refs {
+		if ref.Name() == plumbing.HEAD && ref.Type() == plumbing.SymbolicReference {
+			hashes[plumbing.HEAD.String()] = hashes[ref.Target().String()]
+		}
+	}
+
+	var candidates []string
+	if source.Revision == "" {
+		candidates = []string{"HEAD"}
+	} else {
+		tag := "refs/tags/" + source.Revision
+		// peeled tags point to the commit instead of the tag object
+		candidates = []string{"refs/heads/" + source.Revision, tag + "^{}", tag}
+	}
+	for _, name := range candidates {
+		if hash, ok := hashes[name]; ok && hash != plumbing.ZeroHash.String() {
+			name = strings.TrimSuffix(name, "^{}")
+			if name == "HEAD" {
+				return hash, plumbing.HEAD, nil
+			}
+			return hash, plumbing.ReferenceName(name), nil
+		}
+	}
+
+	return "", "", fmt.Errorf("revision %q not found in %s", source.Revision, source.Repo)
+}
+
+// readCommit returns the files below dir in the commit's tree.
+func readCommit(repo *gogit.Repository, hash plumbing.Hash, dir string) (map[string][]byte, error) {
+	commit, err := repo.CommitObject(hash)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+	if dir = path.Clean(strings.Trim(dir, "/")); dir != "." {
+		if tree, err = tree.Tree(dir); err != nil {
+			return nil, fmt.Errorf("path %s: %w", dir, err)
+		}
+	}
+
+	result := newFiles()
+	err = tree.Files().ForEach(func(file *object.File) error {
+		if file.Size > MaxSize {
+			return fmt.Errorf("files exceed the maximum size of %d bytes", MaxSize)
+		}
+		reader, err := file.Reader()
+		if err != nil {
+			return err
+		}
+		defer reader.Close()
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			return err
+		}
+		return result.add(file.Name, data)
+	})
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return result.data, nil
+}
@@ -0,0 +1,89 @@
+package sources
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func TestFetchGit(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := gogit.PlainInit(dir, false)
+	require.NoError(t, err)
+	wt, err := repo.Worktree()
+	require.NoError(t, err)
+
+	commit := func(name, content string) plumbing.Hash {
+		t.Helper()
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+		_, err := wt.Add(name)
+		require.NoError(t, err)
+		hash, err := wt.Commit("update "+name, &gogit.CommitOptions{
+			Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+		})
+		require.NoError(t, err)
+		return hash
+	}
+
+	first := commit("config/values.yaml", "replicas: 1\n")
+	commit("README.md", "shared config\n")
+	_, err = repo.CreateTag("v1", first, nil)
+	require.NoError(t, err)
+	head := commit("config/values.yaml", "replicas: 2\n")
+
+	events := make(chan event.TypedGenericEvent[*fleet.Bundle], 10)
+	f := NewFetcher(fake.NewClientBuilder().WithScheme(scheme.Scheme).Build(), events)
+	fetch := func(revision string) *Result {
+		t.Helper()
+		r, err := fetchAndWait(t, f, events, bundleKey("fleet-local"), fleet.BundleSource{
+			Name: "shared",
+			Git:  &fleet.GitSource{Repo: dir, Revision: revision, Path: "config"},
+		}, "")
+		require.NoError(t, err)
+		return r
+	}
+
+	r := fetch("")
+	assert.Equal(t, head.String(), r.Revision)
+	assert.Equal(t, map[string][]byte{"values.yaml": []byte("replicas: 2\n")}, r.Files)
+
+	r = fetch("v1")
+	assert.Equal(t, first.String(), r.Revision)
+	assert.Equal(t, map[string][]byte{"values.yaml": []byte("replicas: 1\n")}, r.Files)
+
+	r = fetch(first.String())
+	assert.Equal(t, first.String(), r.Revision)
+	assert.Equal(t, map[string][]byte{"values.yaml": []byte("replicas: 1\n")}, r.Files)
+
+	_, err = fetchAndWait(t, f, events, bundleKey("fleet-local"), fleet.BundleSource{
+		Name: "shared",
+		Git:  &fleet.GitSource{Repo: dir, Revision: "missing"},
+	}, "")
+	assert.ErrorContains(t, err, `revision "missing" not found`)
+
+	// A cached revision is returned without polling, but only to bundles
+	// in the same namespace.
+	require.NoError(t, os.RemoveAll(dir))
+	source := fleet.BundleSource{Name: "shared", Git: &fleet.GitSource{Repo: dir, Revision: "v1", Path: "config"}}
+	r, err = f.Fetch(context.TODO(), bundleKey("fleet-local"), source, first.String())
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{"values.yaml": []byte("replicas: 1\n")}, r.Files)
+
+	_, err = fetchAndWait(t, f, events, bundleKey("other"), source, first.String())
+	assert.ErrorContains(t, err, "failed to list references")
+}
@@ -0,0 +1,151 @@
+package sources
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/registry/remote"
+	"oras.land/oras-go/v2/registry/remote/auth"
+	"oras.land/oras-go/v2/registry/remote/retry"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func (f *Fetcher) fetchOCI(ctx context.Context, key string, source *fleet.OCISource, secret *corev1.Secret) (*Result, error) {
+	repo, err := remote.NewRepository(source.Reference)
+	if err != nil {
+		return nil, fmt.Errorf("invalid OCI reference %q: %w", source.Reference, err)
+	}
+	repo.Client = authClient(source.InsecureSkipTLSVerify, secret)
+
+	reference := repo.Reference.Reference
+	if reference == "" {
+		reference = "latest"
+	}
+	desc, err := repo.Resolve(ctx, reference)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", source.Reference, err)
+	}
+
+	if r := f.cached(key, desc.Digest.String()); r != nil {
+		return r, nil
+	}
+
+	data, err := content.FetchAll(ctx, repo, desc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch manifest of %s: %w", source.Reference, err)
+	}
+	var manifest ocispec.Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest of %s: %w", source.Reference, err)
+	}
+
+	files := newFiles()
+	for _, layer := range manifest.Layers {
+		if layer.Size > MaxSize {
+			return nil, fmt.Errorf("layer %s of %s exceeds the maximum size of %d bytes", layer.Digest, source.Reference, MaxSize)
+		}
+		data, err := content.FetchAll(ctx, repo, layer)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch layer %s of %s: %w", layer.Digest, source.Reference, err)
+		}
+		if err := addLayer(files, layer, data); err != nil {
+			return nil, fmt.Errorf("layer %s of %s: %w", layer.Digest, source.Reference, err)
+		}
+	}
+
+	r := &Result{Revision: desc.Digest.String(), Files: files.data}
+	f.store(key, r)
+	return r, nil
+}
+
+// addLayer adds the files of an OCI layer. Layers with a title annotation,
+// as pushed by "oras push", are added as a file of that name, other tar
+// layers are extracted.
+func addLayer(files *files, layer ocispec.Descriptor, data []byte) error {
+	if title := layer.Annotations[ocispec.AnnotationTitle]; title != "" && !isTar(layer.MediaType) {
+		return files.add(title, data)
+	}
+	if !isTar(layer.MediaType) {
+		return nil
+	}
+
+	var reader io.Reader = bytes.NewReader(data)
+	if strings.HasSuffix(layer.MediaType, "gzip") {
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		reader = gz
+	}
+
+	tr := tar.NewReader(reader)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if hdr.Size > MaxSize {
+			return fmt.Errorf("files exceed the maximum size of %d bytes", MaxSize)
+		}
+		data, err := io.ReadAll(io.LimitReader(tr, MaxSize+1))
+		if err != nil {
+			return err
+		}
+		if err := files.add(hdr.Name, data); err != nil {
+			return err
+		}
+	}
+}
+
+func isTar(mediaType string) bool {
+	return strings.HasSuffix(mediaType, ".tar") ||
+		strings.HasSuffix(mediaType, ".tar+gzip") ||
+		strings.HasSuffix(mediaType, ".tar.gzip")
+}
+
+// authClient returns a registry client, which uses the basic-auth
+// credentials of secret.
+func authClient(insecureSkipTLS bool, secret *corev1.Secret) *auth.Client {
+	client := &auth.Client{
+		Client: retry.DefaultClient,
+		Cache:  auth.NewCache(),
+	}
+	if insecureSkipTLS {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = &tls.Config{
+			InsecureSkipVerify: true, // #nosec G402
+		}
+		client.Client = &http.Client{Transport: retry.NewTransport(transport)}
+	}
+	if secret != nil && len(secret.Data[corev1.BasicAuthUsernameKey]) > 0 {
+		cred := auth.Credential{
+			Username: string(secret.Data[corev1.BasicAuthUsernameKey]),
+			Password: string(secret.Data[corev1.BasicAuthPasswordKey]),
+		}
+		client.Credential = func(context.Context, string) (auth.Credential, error) {
+			return cred, nil
+		}
+	}
+	return client
+}
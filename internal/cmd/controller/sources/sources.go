@@ -0,0 +1,280 @@
+// Package sources reads the files of a bundle's additional sources.
+package sources
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+// MaxSize is the maximum size of the files of a source. The files are
+// stored in the bundle's content resource.
+const MaxSize = 1024 * 1024
+
+// Result contains the files of a source, by their path relative to the
+// source, and the revision they were read from.
+type Result struct {
+	Revision string
+	Files    map[string][]byte
+}
+
+// cacheExpiry is how long cached files are kept after their last use.
+const cacheExpiry = 30 * time.Minute
+
+const (
+	// maxFetches is the number of git and OCI sources, which are fetched
+	// concurrently.
+	maxFetches = 4
+	// fetchTimeout is the timeout of fetching a git or OCI source.
+	fetchTimeout = 2 * time.Minute
+	// resultExpiry is how long the result of a fetch is returned, before
+	// the source is fetched again.
+	resultExpiry = time.Minute
+)
+
+// ErrPending is returned, while the files of a source are fetched for the
+// first time. The bundle is notified, once they are available.
+var ErrPending = errors.New("source is being fetched")
+
+// Fetcher reads the files of sources. Git and OCI sources are fetched in the
+// background, by at most maxFetches workers, so slow repositories and
+// registries don't block the reconciliation of bundles. The files of git and
+// OCI sources are cached by revision, so only changed revisions are
+// downloaded. Cached files are only shared by bundles in the same namespace
+// using the same secret, so a bundle cannot read the files of a source it has
+// no access to.
+type Fetcher struct {
+	client client.Reader
+	now    func() time.Time
+	// events receives the bundles waiting for a fetch, when it finished.
+	events  chan<- event.TypedGenericEvent[*fleet.Bundle]
+	workers chan struct{}
+
+	mu    sync.Mutex
+	cache map[string]*cacheEntry
+	jobs  map[string]*job
+}
+
+type cacheEntry struct {
+	result   *Result
+	lastUsed time.Time
+}
+
+// job is a fetch of a source in the background.
+type job struct {
+	done     bool
+	finished time.Time
+	result   *Result
+	err      error
+	// waiters are the bundles, which are notified when the job is done.
+	waiters map[client.ObjectKey]bool
+}
+
+// NewFetcher returns a fetcher, which sends the bundles waiting for a source
+// to events, once the source was fetched. Events are dropped if the channel
+// is full.
+func NewFetcher(c client.Reader, events chan<- event.TypedGenericEvent[*fleet.Bundle]) *Fetcher {
+	return &Fetcher{
+		client:  c,
+		now:     time.Now,
+		events:  events,
+		workers: make(chan struct{}, maxFetches),
+		cache:   map[string]*cacheEntry{},
+		jobs:    map[string]*job{},
+	}
+}
+
+// Fetch returns the files of source, for the bundle. If revision is not
+// empty and the files of that revision are cached, they are returned
+// without polling the source. Otherwise git and OCI sources are polled in
+// the background and the previously fetched files are returned, until the
+// bundle is notified. ErrPending is returned, if no files were fetched yet.
+func (f *Fetcher) Fetch(ctx context.Context, bundle client.ObjectKey, source fleet.BundleSource, revision string) (*Result, error) {
+	var secret *corev1.Secret
+	if source.SecretName != "" {
+		secret = &corev1.Secret{}
+		if err := f.client.Get(ctx, client.ObjectKey{Namespace: bundle.Namespace, Name: source.SecretName}, secret); err != nil {
+			return nil, fmt.Errorf("failed to get secret %s: %w", source.SecretName, err)
+		}
+	}
+
+	switch {
+	case source.Git != nil:
+		git := source.Git
+		key := cacheKey("git", bundle.Namespace, secret, git.Repo, git.Revision, git.Path)
+		if r := f.cached(key, revision); r != nil {
+			return r, nil
+		}
+		return f.fetchAsync(key, bundle, func(ctx context.Context) (*Result, error) {
+			return f.fetchGit(ctx, key, git, secret)
+		})
+	case source.OCI != nil:
+		oci := source.OCI
+		key := cacheKey("oci", bundle.Namespace, secret, oci.Reference)
+		if r := f.cached(key, revision); r != nil {
+			return r, nil
+		}
+		return f.fetchAsync(key, bundle, func(ctx context.Context) (*Result, error) {
+			return f.fetchOCI(ctx, key, oci, secret)
+		})
+	case source.ConfigMap != nil:
+		return f.fetchConfigMap(ctx, bundle.Namespace, source.ConfigMap.Name)
+	default:
+		return nil, errors.New("one of git, oci or configMap must be set")
+	}
+}
+
+// fetchAsync returns the result of the last fetch of key, if it finished
+// less than resultExpiry ago. Otherwise fetch is started in the background,
+// unless it is already running, and the cached files of key are returned,
+// or ErrPending if there are none. The bundle is notified when the fetch
+// finished.
+func (f *Fetcher) fetchAsync(key string, bundle client.ObjectKey, fetch func(context.Context) (*Result, error)) (*Result, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := f.now()
+	for k, j := range f.jobs {
+		if j.done && now.Sub(j.finished) >= resultExpiry {
+			delete(f.jobs, k)
+		}
+	}
+
+	j, ok := f.jobs[key]
+	if ok && j.done {
+		return j.result, j.err
+	}
+	if !ok {
+		j = &job{waiters: map[client.ObjectKey]bool{}}
+		f.jobs[key] = j
+		go f.run(j, fetch)
+	}
+	j.waiters[bundle] = true
+
+	if e, ok := f.cache[key]; ok {
+		e.lastUsed = now
+		return e.result, nil
+	}
+	return nil, ErrPending
+}
+
+// run runs the fetch of job, once a worker is available, and notifies the
+// waiting bundles.
+func (f *Fetcher) run(j *job, fetch func(context.Context) (*Result, error)) {
+	f.workers <- struct{}{}
+	ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+	result, err := fetch(ctx)
+	cancel()
+	<-f.workers
+
+	f.mu.Lock()
+	j.done, j.finished, j.result, j.err = true, f.now(), result, err
+	waiters := j.waiters
+	j.waiters = nil
+	f.mu.Unlock()
+
+	if f.events == nil {
+		return
+	}
+	for key := range waiters {
+		bundle := &fleet.Bundle{ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: key.Name}}
+		select {
+		case f.events <- event.TypedGenericEvent[*fleet.Bundle]{Object: bundle}:
+		default:
+			// the bundle controller requeues waiting bundles
+		}
+	}
+}
+
+func (f *Fetcher) fetchConfigMap(ctx context.Context, namespace, name string) (*Result, error) {
+	cm := &corev1.ConfigMap{}
+	if err := f.client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, cm); err != nil {
+		return nil, fmt.Errorf("failed to get configmap %s: %w", name, err)
+	}
+
+	files := newFiles()
+	for k, v := range cm.Data {
+		if err := files.add(k, []byte(v)); err != nil {
+			return nil, err
+		}
+	}
+	for k, v := range cm.BinaryData {
+		if err := files.add(k, v); err != nil {
+			return nil, err
+		}
+	}
+	return &Result{Revision: cm.ResourceVersion, Files: files.data}, nil
+}
+
+// cacheKey returns the key of a source's cached files. The key contains the
+// namespace and the version of the secret, as the files must only be shared
+// with bundles which have the same access to the source.
+func cacheKey(kind, namespace string, secret *corev1.Secret, location ...string) string {
+	credentials := ""
+	if secret != nil {
+		credentials = secret.Name + "@" + secret.ResourceVersion
+	}
+	return strings.Join(append([]string{kind, namespace, credentials}, location...), "|")
+}
+
+// cached returns the cached result for key, if it was read from revision.
+func (f *Fetcher) cached(key, revision string) *Result {
+	if revision == "" {
+		return nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if e, ok := f.cache[key]; ok && e.result.Revision == revision {
+		e.lastUsed = f.now()
+		return e.result
+	}
+	return nil
+}
+
+// store replaces the cached result for key and evicts the results, which
+// were not used for cacheExpiry.
+func (f *Fetcher) store(key string, r *Result) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := f.now()
+	for k, e := range f.cache {
+		if now.Sub(e.lastUsed) > cacheExpiry {
+			delete(f.cache, k)
+		}
+	}
+	f.cache[key] = &cacheEntry{result: r, lastUsed: now}
+}
+
+// files collects the files of a source, enforcing MaxSize.
+type files struct {
+	data map[string][]byte
+	size int
+}
+
+func newFiles() *files {
+	return &files{data: map[string][]byte{}}
+}
+
+func (f *files) add(name string, data []byte) error {
+	name = path.Clean(name)
+	if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+		return fmt.Errorf("invalid file name %q", name)
+	}
+	f.size += len(data)
+	if f.size > MaxSize {
+		return fmt.Errorf("files exceed the maximum size of %d bytes", MaxSize)
+	}
+	f.data[name] = data
+	return nil
+}
@@ -0,0 +1,184 @@
+package sources
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func bundleKey(namespace string) client.ObjectKey {
+	return client.ObjectKey{Namespace: namespace, Name: "app"}
+}
+
+// fetchAndWait fetches the source and waits for the fetch in the
+// background to finish, if there is no previous result.
+func fetchAndWait(t *testing.T, f *Fetcher, events <-chan event.TypedGenericEvent[*fleet.Bundle], bundle client.ObjectKey, source fleet.BundleSource, revision string) (*Result, error) {
+	t.Helper()
+	r, err := f.Fetch(context.TODO(), bundle, source, revision)
+	if !errors.Is(err, ErrPending) {
+		return r, err
+	}
+	select {
+	case e := <-events:
+		assert.Equal(t, bundle, client.ObjectKeyFromObject(e.Object))
+	case <-time.After(fetchTimeout):
+		t.Fatal("timed out waiting for the source to be fetched")
+	}
+	return f.Fetch(context.TODO(), bundle, source, revision)
+}
+
+func TestFetchAsync(t *testing.T) {
+	events := make(chan event.TypedGenericEvent[*fleet.Bundle], 10)
+	f := NewFetcher(fake.NewClientBuilder().WithScheme(scheme.Scheme).Build(), events)
+	now := time.Now()
+	f.now = func() time.Time { return now }
+
+	release := make(chan struct{})
+	revision := "1"
+	fetch := func(context.Context) (*Result, error) {
+		<-release
+		r := &Result{Revision: revision}
+		f.store("key", r)
+		return r, nil
+	}
+
+	// nothing is cached yet
+	_, err := f.fetchAsync("key", bundleKey("a"), fetch)
+	assert.ErrorIs(t, err, ErrPending)
+	_, err = f.fetchAsync("key", bundleKey("b"), fetch)
+	assert.ErrorIs(t, err, ErrPending)
+	close(release)
+
+	// both waiting bundles are notified
+	var notified []string
+	for range 2 {
+		e := <-events
+		notified = append(notified, e.Object.Namespace+"/"+e.Object.Name)
+	}
+	assert.ElementsMatch(t, []string{"a/app", "b/app"}, notified)
+
+	r, err := f.fetchAsync("key", bundleKey("a"), fetch)
+	require.NoError(t, err)
+	assert.Equal(t, "1", r.Revision)
+
+	// after the result expired, the source is fetched again and the
+	// cached files are returned meanwhile
+	now = now.Add(resultExpiry)
+	revision = "2"
+	r, err = f.fetchAsync("key", bundleKey("a"), fetch)
+	require.NoError(t, err)
+	assert.Equal(t, "1", r.Revision)
+	<-events
+	r, err = f.fetchAsync("key", bundleKey("a"), fetch)
+	require.NoError(t, err)
+	assert.Equal(t, "2", r.Revision)
+}
+
+func TestFetchConfigMap(t *testing.T) {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "shared", Namespace: "fleet-local"},
+		Data:       map[string]string{"values.yaml": "replicas: 3\n"},
+		BinaryData: map[string][]byte{"logo.png": {0x89, 0x50}},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(cm).Build()
+	f := NewFetcher(c, nil)
+
+	result, err := f.Fetch(context.TODO(), bundleKey("fleet-local"), fleet.BundleSource{
+		Name:      "shared",
+		ConfigMap: &fleet.LocalObjectReference{Name: "shared"},
+	}, "")
+	require.NoError(t, err)
+	assert.NotEmpty(t, result.Revision)
+	assert.Equal(t, map[string][]byte{
+		"values.yaml": []byte("replicas: 3\n"),
+		"logo.png":    {0x89, 0x50},
+	}, result.Files)
+
+	_, err = f.Fetch(context.TODO(), bundleKey("other"), fleet.BundleSource{
+		Name:      "shared",
+		ConfigMap: &fleet.LocalObjectReference{Name: "shared"},
+	}, "")
+	assert.ErrorContains(t, err, "failed to get configmap shared")
+
+	_, err = f.Fetch(context.TODO(), bundleKey("fleet-local"), fleet.BundleSource{Name: "empty"}, "")
+	assert.ErrorContains(t, err, "one of git, oci or configMap must be set")
+}
+
+func TestAddLayer(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range map[string]string{
+		"base/kustomization.yaml": "resources: [deployment.yaml]\n",
+		"values.yaml":             "replicas: 3\n",
+	} {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+
+	files := newFiles()
+	require.NoError(t, addLayer(files, ocispec.Descriptor{MediaType: ocispec.MediaTypeImageLayerGzip}, buf.Bytes()))
+	require.NoError(t, addLayer(files, ocispec.Descriptor{
+		MediaType:   "application/vnd.oci.image.layer.v1.yaml",
+		Annotations: map[string]string{ocispec.AnnotationTitle: "prod.yaml"},
+	}, []byte("replicas: 5\n")))
+	require.NoError(t, addLayer(files, ocispec.Descriptor{MediaType: "application/vnd.example.config"}, []byte("ignored")))
+
+	assert.Equal(t, map[string][]byte{
+		"base/kustomization.yaml": []byte("resources: [deployment.yaml]\n"),
+		"values.yaml":             []byte("replicas: 3\n"),
+		"prod.yaml":               []byte("replicas: 5\n"),
+	}, files.data)
+}
+
+func TestCacheExpiry(t *testing.T) {
+	now := time.Now()
+	f := NewFetcher(fake.NewClientBuilder().WithScheme(scheme.Scheme).Build(), nil)
+	f.now = func() time.Time { return now }
+
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "auth", ResourceVersion: "1"}}
+	key := cacheKey("git", "fleet-local", secret, "https://example.com/repo", "config")
+	f.store(key, &Result{Revision: "abc"})
+	assert.NotNil(t, f.cached(key, "abc"))
+	assert.Nil(t, f.cached(key, "def"))
+	assert.Nil(t, f.cached(key, ""))
+
+	secret.ResourceVersion = "2"
+	assert.Nil(t, f.cached(cacheKey("git", "fleet-local", secret, "https://example.com/repo", "config"), "abc"))
+	assert.Nil(t, f.cached(cacheKey("git", "other", nil, "https://example.com/repo", "config"), "abc"))
+
+	now = now.Add(cacheExpiry + time.Second)
+	f.store("other", &Result{Revision: "abc"})
+	assert.Nil(t, f.cached(key, "abc"))
+	assert.Len(t, f.cache, 1)
+}
+
+func TestFilesAdd(t *testing.T) {
+	files := newFiles()
+	assert.NoError(t, files.add("./a/b.yaml", []byte("a")))
+	assert.Contains(t, files.data, "a/b.yaml")
+
+	assert.ErrorContains(t, files.add("../escape.yaml", nil), "invalid file name")
+	assert.ErrorContains(t, files.add("/etc/passwd", nil), "invalid file name")
+	assert.ErrorContains(t, files.add("big", make([]byte, MaxSize)), "maximum size")
+}
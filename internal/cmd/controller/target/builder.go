@@ -6,9 +6,11 @@ import (
 	"context"
 	"fmt"
 	"maps"
+	"slices"
 	"sort"
 	"strings"
 	"text/template"
+	"time"
 
 	"github.com/Masterminds/sprig/v3"
 	"github.com/go-logr/logr"
@@ -59,9 +61,9 @@ func (m *Manager) Targets(ctx context.Context, bundle *fleet.Bundle, manifestID
 		return nil, false, fmt.Errorf("failed to get namespace selector: %w", err)
 	}
 
-	createNamespace, err := m.getCreateNamespaceForBundle(ctx, bundle)
+	policyOpts, err := m.getPolicyOptionsForBundle(ctx, bundle)
 	if err != nil {
-		return nil, false, fmt.Errorf("failed to resolve createNamespace from Policy: %w", err)
+		return nil, false, fmt.Errorf("failed to resolve options from Policy: %w", err)
 	}
 
 	bm, err := matcher.New(bundle)
@@ -73,6 +75,12 @@ func (m *Manager) Targets(ctx context.Context, bundle *fleet.Bundle, manifestID
 	if err != nil {
 		return nil, false, err
 	}
+
+	outputs, err := m.dependencyOutputs(ctx, bundle)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get outputs of dependencies: %w", err)
+	}
+
 	var targets []*Target
 	for _, namespace := range namespaces {
 		clusters := &fleet.ClusterList{}
@@ -88,9 +96,9 @@ func (m *Manager) Targets(ctx context.Context, bundle *fleet.Bundle, manifestID
 				return nil, false, err
 			}
 
-			clusterGroupsAsLabelMap := ClusterGroupsToLabelMap(clusterGroups)
+			matchCluster := matcher.NewCluster(&cluster, ClusterGroupsToLabelMap(clusterGroups))
 
-			target := bm.Match(cluster.Name, clusterGroupsAsLabelMap, cluster.Labels)
+			target := bm.Match(matchCluster)
 			if target == nil {
 				continue
 			}
@@ -110,7 +118,7 @@ func (m *Manager) Targets(ctx context.Context, bundle *fleet.Bundle, manifestID
 			if bundle.Spec.TargetCustomizationMode == fleet.TargetCustomizationModeAllMatches {
 				// AllMatches mode: merge all matching customizations
 				// Check if any matching customization has doNotDeploy=true (OR logic)
-				matchedCustomizations := bm.MatchAllTargetCustomizations(cluster.Name, clusterGroupsAsLabelMap, cluster.Labels)
+				matchedCustomizations := bm.MatchAllTargetCustomizations(matchCluster)
 				for _, tc := range matchedCustomizations {
 					if tc.DoNotDeploy {
 						logger.V(1).Info("Skipping BundleDeployment creation because doNotDeploy is set to true.",
@@ -126,7 +134,7 @@ func (m *Manager) Targets(ctx context.Context, bundle *fleet.Bundle, manifestID
 				}
 			} else {
 				// FirstMatch mode: apply only the first matching customization
-				if targetCustomized := bm.MatchTargetCustomizations(cluster.Name, clusterGroupsAsLabelMap, cluster.Labels); targetCustomized != nil {
+				if targetCustomized := bm.MatchTargetCustomizations(matchCluster); targetCustomized != nil {
 					// Check if the first matching targetCustomization has doNotDeploy set
 					if targetCustomized.DoNotDeploy {
 						logger.V(1).Info("Skipping BundleDeployment creation because doNotDeploy is set to true.",
@@ -146,11 +154,38 @@ func (m *Manager) Targets(ctx context.Context, bundle *fleet.Bundle, manifestID
 			if namespaceSelector != nil {
 				opts.AllowedTargetNamespaceSelector = namespaceSelector
 			}
-			if createNamespace != nil {
-				opts.CreateNamespace = createNamespace
+			if policyOpts.createNamespace != nil {
+				opts.CreateNamespace = policyOpts.createNamespace
+			}
+			// Resource rules are only ever set from Policy, never by users.
+			opts.ResourceRules = policyOpts.resourceRules
+
+			clusterOutputs := outputs[cluster.Status.Namespace]
+			if clusterOutputs == nil && len(bundle.Spec.DependsOn) > 0 {
+				// render .Outputs before the dependencies reported any
+				clusterOutputs = map[string]map[string]string{}
+			}
+			err = preprocessHelmValues(logger, &opts, &cluster, clusterOutputs)
+			if err != nil {
+				return nil, false, fmt.Errorf("cluster %s in namespace %s: %w", cluster.Name, cluster.Namespace, err)
+			}
+			// the agent waits until these match the dependencies' outputs
+			opts.DependencyOutputs = nil
+			if len(clusterOutputs) > 0 {
+				opts.DependencyOutputs = clusterOutputs
 			}
 
-			err = preprocessHelmValues(logger, &opts, &cluster)
+			err = renderVaultPaths(&opts, &cluster)
+			if err != nil {
+				return nil, false, fmt.Errorf("cluster %s in namespace %s: %w", cluster.Name, cluster.Namespace, err)
+			}
+
+			err = renderPatches(&opts, &cluster)
+			if err != nil {
+				return nil, false, fmt.Errorf("cluster %s in namespace %s: %w", cluster.Name, cluster.Namespace, err)
+			}
+
+			err = addSubstituteVars(&opts, &cluster)
 			if err != nil {
 				return nil, false, fmt.Errorf("cluster %s in namespace %s: %w", cluster.Name, cluster.Namespace, err)
 			}
@@ -174,6 +209,8 @@ func (m *Manager) Targets(ctx context.Context, bundle *fleet.Bundle, manifestID
 		return targets[i].Cluster.Name < targets[j].Cluster.Name
 	})
 
+	targets = place(bundle, targets, time.Now())
+
 	// add the existing bundledeployments to the targets.
 	bundleDeployments := &fleet.BundleDeploymentList{}
 	err = m.client.List(ctx, bundleDeployments, client.MatchingLabels{
@@ -266,16 +303,13 @@ func (m *Manager) getNamespacesForBundle(ctx context.Context, bundle *fleet.Bund
 	return nses.List(), nil
 }
 
-func preprocessHelmValues(logger logr.Logger, opts *fleet.BundleDeploymentOptions, cluster *fleet.Cluster) (err error) {
-	clusterLabels := yaml.CleanAnnotationsForExport(cluster.Labels)
-	clusterAnnotations := yaml.CleanAnnotationsForExport(cluster.Annotations)
-
-	for k, v := range cluster.Labels {
-		if strings.HasPrefix(k, fleet.FleetLabelPrefix) || strings.HasPrefix(k, fleet.ManagementLabelPrefix) {
-			clusterLabels[k] = v
-		}
-	}
-	if len(clusterLabels) == 0 {
+// preprocessHelmValues renders the cluster labels and templates in the helm
+// values. outputs contains the outputs of the bundle's dependencies on the
+// cluster, by bundle name. Values are not rendered for clusters without
+// labels, unless outputs is set.
+func preprocessHelmValues(logger logr.Logger, opts *fleet.BundleDeploymentOptions, cluster *fleet.Cluster, outputs map[string]map[string]string) (err error) {
+	clusterLabels := templateClusterLabels(cluster)
+	if len(clusterLabels) == 0 && outputs == nil {
 		return nil
 	}
 
@@ -302,18 +336,11 @@ func preprocessHelmValues(logger logr.Logger, opts *fleet.BundleDeploymentOption
 	}
 
 	if !opts.Helm.DisablePreProcess {
-		templateValues := map[string]any{}
-		if cluster.Spec.TemplateValues != nil {
-			templateValues = cluster.Spec.TemplateValues.Data
-		}
-
-		values := map[string]any{
-			"ClusterNamespace":   cluster.Namespace,
-			"ClusterName":        cluster.Name,
-			"ClusterLabels":      toDict(clusterLabels),
-			"ClusterAnnotations": toDict(clusterAnnotations),
-			"ClusterValues":      templateValues,
+		values := templateContext(cluster, clusterLabels)
+		if outputs == nil {
+			outputs = map[string]map[string]string{}
 		}
+		values["Outputs"] = outputs
 
 		opts.Helm.Values.Data, err = processTemplateValues(opts.Helm.Values.Data, values)
 		if err != nil {
@@ -334,6 +361,97 @@ func preprocessHelmValues(logger logr.Logger, opts *fleet.BundleDeploymentOption
 
 }
 
+// renderVaultPaths renders the path templates of the Vault values sources
+// in opts for cluster. The rendered template replaces the path, so the agent
+// does not need to know about the cluster.
+func renderVaultPaths(opts *fleet.BundleDeploymentOptions, cluster *fleet.Cluster) error {
+	if opts.Helm == nil || !slices.ContainsFunc(opts.Helm.ValuesFrom, func(v fleet.ValuesFrom) bool {
+		return v.VaultRef != nil && v.VaultRef.PathTemplate != ""
+	}) {
+		return nil
+	}
+
+	opts.Helm = opts.Helm.DeepCopy()
+	templateContext := templateContext(cluster, templateClusterLabels(cluster))
+	for _, v := range opts.Helm.ValuesFrom {
+		if v.VaultRef == nil || v.VaultRef.PathTemplate == "" {
+			continue
+		}
+		path, err := renderTemplate(v.VaultRef.PathTemplate, templateContext)
+		if err != nil {
+			return fmt.Errorf("failed to render vault path template: %w", err)
+		}
+		v.VaultRef.Path = path
+		v.VaultRef.PathTemplate = ""
+	}
+
+	return nil
+}
+
+// renderPatches renders the patch templates in opts for cluster.
+func renderPatches(opts *fleet.BundleDeploymentOptions, cluster *fleet.Cluster) error {
+	if len(opts.Patches) == 0 {
+		return nil
+	}
+
+	opts.Patches = slices.Clone(opts.Patches)
+	templateContext := templateContext(cluster, templateClusterLabels(cluster))
+	for i, p := range opts.Patches {
+		patch, err := renderTemplate(p.Patch, templateContext)
+		if err != nil {
+			return fmt.Errorf("failed to render patches[%d]: %w", i, err)
+		}
+		opts.Patches[i].Patch = patch
+	}
+
+	return nil
+}
+
+// renderTemplate renders text with the template functions and delimiters of
+// templateValues.
+func renderTemplate(text string, templateContext map[string]any) (string, error) {
+	tmpl, err := template.New("template").Funcs(tplFuncMap()).Option("missingkey=error").Delims("${", "}").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, templateContext); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// templateClusterLabels returns the labels of cluster, which are available in
+// templates, i.e. without the labels of kubernetes and cattle, but including
+// the labels of fleet.
+func templateClusterLabels(cluster *fleet.Cluster) map[string]string {
+	clusterLabels := yaml.CleanAnnotationsForExport(cluster.Labels)
+	for k, v := range cluster.Labels {
+		if strings.HasPrefix(k, fleet.FleetLabelPrefix) || strings.HasPrefix(k, fleet.ManagementLabelPrefix) {
+			clusterLabels[k] = v
+		}
+	}
+	return clusterLabels
+}
+
+// templateContext returns the values available to templates, which are
+// rendered for cluster.
+func templateContext(cluster *fleet.Cluster, clusterLabels map[string]string) map[string]any {
+	templateValues := map[string]any{}
+	if cluster.Spec.TemplateValues != nil {
+		templateValues = cluster.Spec.TemplateValues.Data
+	}
+
+	return map[string]any{
+		"ClusterNamespace":   cluster.Namespace,
+		"ClusterName":        cluster.Name,
+		"ClusterLabels":      toDict(clusterLabels),
+		"ClusterAnnotations": toDict(yaml.CleanAnnotationsForExport(cluster.Annotations)),
+		"ClusterValues":      templateValues,
+	}
+}
+
 // sprig dictionary functions like "default" and "hasKey" expect map[string]interface{}
 func toDict(values map[string]string) map[string]any {
 	dict := make(map[string]any, len(values))
@@ -480,28 +598,38 @@ func (m *Manager) getNamespaceSelectorForBundle(ctx context.Context, bundle *fle
 	return result, nil
 }
 
-// getCreateNamespaceForBundle resolves whether namespace creation should be
-// disabled based on Policy objects in the bundle's namespace. Returns a pointer
-// to false when the aggregated policy requires a ServiceAccount but does not
-// allow namespace creation. Returns nil otherwise (no change to default).
-func (m *Manager) getCreateNamespaceForBundle(ctx context.Context, bundle *fleet.Bundle) (*bool, error) {
+// policyOptions are the deployment options derived from the Policy objects
+// in a bundle's namespace.
+type policyOptions struct {
+	// createNamespace is false when the aggregated policy requires a
+	// ServiceAccount but does not allow namespace creation, nil otherwise (no
+	// change to default).
+	createNamespace *bool
+	// resourceRules are evaluated by the agent against the rendered resources.
+	resourceRules []fleet.ResourceRule
+}
+
+// getPolicyOptionsForBundle resolves the deployment options enforced by
+// Policy objects in the bundle's namespace.
+func (m *Manager) getPolicyOptionsForBundle(ctx context.Context, bundle *fleet.Bundle) (policyOptions, error) {
 	policies := &fleet.PolicyList{}
 	if err := m.client.List(ctx, policies, client.InNamespace(bundle.Namespace)); err != nil {
 		if apimeta.IsNoMatchError(err) {
-			return nil, nil
+			return policyOptions{}, nil
 		}
-		return nil, fmt.Errorf("failed to list Policies: %w", err)
+		return policyOptions{}, fmt.Errorf("failed to list Policies: %w", err)
 	}
 
 	if len(policies.Items) == 0 {
-		return nil, nil
+		return policyOptions{}, nil
 	}
 
 	pol := policyrestrictions.Aggregate(policies.Items)
+	opts := policyOptions{resourceRules: pol.ResourceRules}
 	if pol.RequireServiceAccount && !pol.AllowNamespaceCreation {
 		v := false
-		return &v, nil
+		opts.createNamespace = &v
 	}
 
-	return nil, nil
+	return opts, nil
 }
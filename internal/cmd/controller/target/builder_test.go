@@ -0,0 +1,245 @@
+package target
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestTargets_PolicyOptions(t *testing.T) {
+	cluster := &fleet.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "c1", Namespace: "fleet-default", Labels: map[string]string{"env": "prod"}},
+	}
+	policies := []*fleet.Policy{
+		{
+			ObjectMeta:            metav1.ObjectMeta{Name: "b", Namespace: "fleet-default"},
+			RequireServiceAccount: true,
+			ResourceRules:         []fleet.ResourceRule{{Name: "namespaced", Expression: "!clusterScoped"}},
+		},
+		{
+			ObjectMeta:    metav1.ObjectMeta{Name: "a", Namespace: "fleet-default"},
+			ResourceRules: []fleet.ResourceRule{{Name: "no-secrets", Expression: "object.kind != 'Secret'"}},
+		},
+	}
+	bundle := &fleet.Bundle{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "fleet-default"},
+		Spec: fleet.BundleSpec{
+			BundleDeploymentOptions: fleet.BundleDeploymentOptions{
+				ServiceAccount: "tenant",
+				// Rules set on the bundle itself are ignored.
+				ResourceRules: []fleet.ResourceRule{{Name: "allow-all", Expression: "true"}},
+			},
+			Targets: []fleet.BundleTarget{
+				{Name: "prod", ClusterSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}}},
+			},
+		},
+	}
+
+	c := fake.NewClientBuilder().WithScheme(newExplainScheme(t)).WithObjects(cluster, policies[0], policies[1], bundle).Build()
+
+	targets, _, err := New(c, c).Targets(context.Background(), bundle, "manifest")
+	require.NoError(t, err)
+	require.Len(t, targets, 1)
+
+	opts := targets[0].Options
+	assert.Equal(t, []fleet.ResourceRule{
+		{Name: "no-secrets", Expression: "object.kind != 'Secret'"},
+		{Name: "namespaced", Expression: "!clusterScoped"},
+	}, opts.ResourceRules)
+	require.NotNil(t, opts.CreateNamespace)
+	assert.False(t, *opts.CreateNamespace)
+}
+
+func TestTargets_DependencyOutputs(t *testing.T) {
+	clusters := []*fleet.Cluster{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "c1", Namespace: "fleet-default", Labels: map[string]string{"env": "prod"}},
+			Status:     fleet.ClusterStatus{Namespace: "cluster-1"},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "c2", Namespace: "fleet-default", Labels: map[string]string{"env": "prod"}},
+			Status:     fleet.ClusterStatus{Namespace: "cluster-2"},
+		},
+	}
+	infraLabels := map[string]string{fleet.BundleLabel: "infra", fleet.BundleNamespaceLabel: "fleet-default"}
+	bds := []*fleet.BundleDeployment{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "infra", Namespace: "cluster-1", Labels: infraLabels},
+			Status:     fleet.BundleDeploymentStatus{Outputs: map[string]string{"ip": "10.0.0.1"}},
+		},
+		// outputs not reported yet
+		{ObjectMeta: metav1.ObjectMeta{Name: "infra", Namespace: "cluster-2", Labels: infraLabels}},
+	}
+	bundle := &fleet.Bundle{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "fleet-default"},
+		Spec: fleet.BundleSpec{
+			BundleDeploymentOptions: fleet.BundleDeploymentOptions{
+				Helm: &fleet.HelmOptions{TemplateValues: map[string]string{
+					"lbIP": `${ index .Outputs "infra" "ip" }`,
+				}},
+			},
+			DependsOn: []fleet.BundleRef{{Name: "infra"}},
+			Targets: []fleet.BundleTarget{
+				{Name: "prod", ClusterSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}}},
+			},
+		},
+	}
+
+	c := fake.NewClientBuilder().WithScheme(newExplainScheme(t)).
+		WithObjects(clusters[0], clusters[1], bds[0], bds[1], bundle).
+		WithStatusSubresource(&fleet.BundleDeployment{}).
+		Build()
+
+	targets, _, err := New(c, c).Targets(context.Background(), bundle, "manifest")
+	require.NoError(t, err)
+	require.Len(t, targets, 2)
+
+	assert.Equal(t, "10.0.0.1", targets[0].Options.Helm.Values.Data["lbIP"])
+	assert.Nil(t, targets[1].Options.Helm.Values.Data["lbIP"])
+	assert.NotEqual(t, targets[0].DeploymentID, targets[1].DeploymentID)
+	assert.Equal(t, map[string]map[string]string{"infra": {"ip": "10.0.0.1"}}, targets[0].Options.DependencyOutputs)
+	assert.Nil(t, targets[1].Options.DependencyOutputs)
+}
+
+func TestTargets_DependencyOutputsSameName(t *testing.T) {
+	cluster := &fleet.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "c1", Namespace: "fleet-default"},
+		Status:     fleet.ClusterStatus{Namespace: "cluster-1"},
+	}
+	var bds []client.Object
+	for _, ns := range []string{"fleet-default", "platform"} {
+		bds = append(bds, &fleet.BundleDeployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "infra-" + ns, Namespace: "cluster-1", Labels: map[string]string{
+				fleet.BundleLabel:          "infra",
+				fleet.BundleNamespaceLabel: ns,
+			}},
+			Status: fleet.BundleDeploymentStatus{Outputs: map[string]string{"ip": "10.0.0.1"}},
+		})
+	}
+	bundle := &fleet.Bundle{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "fleet-default"},
+		Spec: fleet.BundleSpec{
+			DependsOn: []fleet.BundleRef{{Name: "infra"}, {Name: "infra", Namespace: "platform"}},
+			Targets:   []fleet.BundleTarget{{Name: "all", ClusterName: "c1"}},
+		},
+	}
+
+	c := fake.NewClientBuilder().WithScheme(newExplainScheme(t)).
+		WithObjects(append(bds, cluster, bundle)...).
+		WithStatusSubresource(&fleet.BundleDeployment{}).
+		Build()
+
+	_, _, err := New(c, c).Targets(context.Background(), bundle, "manifest")
+	assert.ErrorContains(t, err, "dependencies fleet-default/infra and platform/infra have the same name")
+}
+
+func TestDependencyIndexKeys(t *testing.T) {
+	bundle := &fleet.Bundle{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "fleet-default"},
+		Spec: fleet.BundleSpec{DependsOn: []fleet.BundleRef{
+			{Name: "infra"},
+			{Name: "cert-manager", Namespace: "platform"},
+			{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"role": "db"}}},
+			{},
+		}},
+	}
+	assert.Equal(t, []string{"fleet-default/infra", "platform/cert-manager", DependencyIndexSelector}, DependencyIndexKeys(bundle))
+}
+
+func TestPreprocessHelmValues_OutputsWithoutClusterLabels(t *testing.T) {
+	cluster := &fleet.Cluster{ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: "c1"}}
+	opts := &fleet.BundleDeploymentOptions{Helm: &fleet.HelmOptions{TemplateValues: map[string]string{
+		"lbIP": `${ index .Outputs "infra" "ip" }`,
+	}}}
+
+	outputs := map[string]map[string]string{"infra": {"ip": "10.0.0.1"}}
+	require.NoError(t, preprocessHelmValues(logr.Discard(), opts, cluster, outputs))
+	assert.Equal(t, "10.0.0.1", opts.Helm.Values.Data["lbIP"])
+}
+
+func TestRenderVaultPaths(t *testing.T) {
+	cluster := &fleet.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: "prod-1", Labels: map[string]string{"env": "prod"}},
+	}
+	shared := &fleet.HelmOptions{
+		ValuesFrom: []fleet.ValuesFrom{
+			{SecretKeyRef: &fleet.SecretKeySelector{LocalObjectReference: fleet.LocalObjectReference{Name: "values"}}},
+			{VaultRef: &fleet.VaultValuesSource{Path: "static"}},
+			{VaultRef: &fleet.VaultValuesSource{PathTemplate: "${ .ClusterLabels.env }/${ .ClusterName }"}},
+		},
+	}
+	opts := &fleet.BundleDeploymentOptions{Helm: shared}
+
+	require.NoError(t, renderVaultPaths(opts, cluster))
+	assert.Equal(t, "static", opts.Helm.ValuesFrom[1].VaultRef.Path)
+	assert.Equal(t, "prod/prod-1", opts.Helm.ValuesFrom[2].VaultRef.Path)
+	assert.Empty(t, opts.Helm.ValuesFrom[2].VaultRef.PathTemplate)
+	// the options shared by all targets are not modified
+	assert.Equal(t, "", shared.ValuesFrom[2].VaultRef.Path)
+
+	opts = &fleet.BundleDeploymentOptions{Helm: &fleet.HelmOptions{
+		ValuesFrom: []fleet.ValuesFrom{{VaultRef: &fleet.VaultValuesSource{PathTemplate: "${ .ClusterValues.missing }"}}},
+	}}
+	require.ErrorContains(t, renderVaultPaths(opts, cluster), "failed to render vault path template")
+}
+
+func TestRenderPatches(t *testing.T) {
+	cluster := &fleet.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: "prod-1", Labels: map[string]string{"region": "eu"}},
+	}
+	shared := []fleet.ResourcePatch{
+		{Target: fleet.PatchTarget{Kind: "Deployment"}, Patch: "metadata:\n  labels:\n    region: ${ .ClusterLabels.region }\n"},
+	}
+	opts := &fleet.BundleDeploymentOptions{Patches: shared}
+
+	require.NoError(t, renderPatches(opts, cluster))
+	assert.Equal(t, "metadata:\n  labels:\n    region: eu\n", opts.Patches[0].Patch)
+	// the options shared by all targets are not modified
+	assert.Contains(t, shared[0].Patch, "${ .ClusterLabels.region }")
+
+	opts = &fleet.BundleDeploymentOptions{Patches: []fleet.ResourcePatch{{Patch: "${ .ClusterValues.missing }"}}}
+	require.ErrorContains(t, renderPatches(opts, cluster), "failed to render patches[0]")
+}
+
+func TestAddSubstituteVars(t *testing.T) {
+	cluster := &fleet.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "fleet-default",
+			Name:      "prod-1",
+			Labels:    map[string]string{"region": "eu"},
+		},
+		Spec: fleet.ClusterSpec{TemplateValues: &fleet.GenericMap{Data: map[string]any{
+			"replicas": float64(3),
+			"db":       map[string]any{"host": "db.eu"},
+			"zones":    []any{"a", "b"},
+		}}},
+	}
+	shared := &fleet.SubstituteOptions{Vars: map[string]string{"ClusterLabels.region": "override", "domain": "example.com"}}
+	opts := &fleet.BundleDeploymentOptions{Substitute: shared}
+
+	require.NoError(t, addSubstituteVars(opts, cluster))
+	assert.Equal(t, map[string]string{
+		"ClusterName":            "prod-1",
+		"ClusterNamespace":       "fleet-default",
+		"ClusterLabels.region":   "override",
+		"ClusterValues.replicas": "3",
+		"ClusterValues.db.host":  "db.eu",
+		"ClusterValues.zones":    `["a","b"]`,
+		"domain":                 "example.com",
+	}, opts.Substitute.Vars)
+	// the options shared by all targets are not modified
+	assert.Len(t, shared.Vars, 2)
+
+	opts = &fleet.BundleDeploymentOptions{}
+	require.NoError(t, addSubstituteVars(opts, cluster))
+	assert.Nil(t, opts.Substitute)
+}
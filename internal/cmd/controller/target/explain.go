@@ -0,0 +1,270 @@
+package target
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/rancher/fleet/internal/cmd/controller/target/matcher"
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Explanation describes why a bundle is, or is not, deployed to a cluster.
+type Explanation struct {
+	Bundle        string   `json:"bundle"`
+	Cluster       string   `json:"cluster"`
+	ClusterGroups []string `json:"clusterGroups,omitempty"`
+
+	// InScope is true if the cluster's namespace is the bundle's namespace,
+	// or a BundleNamespaceMapping maps the bundle into it.
+	InScope           bool                 `json:"inScope"`
+	NamespaceMappings []MappingExplanation `json:"namespaceMappings,omitempty"`
+
+	matcher.BundleExplanation
+
+	// GitTargets are the targets of the GitRepo which created the bundle.
+	// They are the source of the bundle's target restrictions.
+	GitTargets []matcher.TargetExplanation `json:"gitTargets,omitempty"`
+
+	CustomizationMode fleet.TargetCustomizationMode `json:"customizationMode,omitempty"`
+	// MatchedTarget is the name of the target, which selected the cluster.
+	MatchedTarget string `json:"matchedTarget,omitempty"`
+	// SelectedCustomizations are the names of the customizations, whose
+	// options are merged into the BundleDeployment.
+	SelectedCustomizations []string `json:"selectedCustomizations,omitempty"`
+
+	Schedules []ScheduleExplanation `json:"schedules,omitempty"`
+
+	// Blockers lists everything preventing a deployment to the cluster.
+	Blockers []string `json:"blockers,omitempty"`
+
+	// BundleDeployment is the name of the existing BundleDeployment for
+	// the cluster, if any.
+	BundleDeployment      string `json:"bundleDeployment,omitempty"`
+	BundleDeploymentState string `json:"bundleDeploymentState,omitempty"`
+}
+
+// MappingExplanation explains whether a BundleNamespaceMapping maps the
+// bundle into the cluster's namespace.
+type MappingExplanation struct {
+	Name             string `json:"name"`
+	BundleMatched    bool   `json:"bundleMatched"`
+	NamespaceMatched bool   `json:"namespaceMatched"`
+	Invalid          string `json:"invalid,omitempty"`
+}
+
+// ScheduleExplanation explains whether a Schedule applies to the cluster.
+type ScheduleExplanation struct {
+	Name    string `json:"name"`
+	Matched bool   `json:"matched"`
+	Active  bool   `json:"active"`
+}
+
+// Explain evaluates the targeting of the bundle for a single cluster. It uses
+// the same matchers as Targets, but records every decision instead of
+// returning the matching targets.
+func (m *Manager) Explain(ctx context.Context, bundle *fleet.Bundle, cluster *fleet.Cluster) (*Explanation, error) {
+	e := &Explanation{
+		Bundle:            bundle.Namespace + "/" + bundle.Name,
+		Cluster:           cluster.Namespace + "/" + cluster.Name,
+		CustomizationMode: bundle.Spec.TargetCustomizationMode,
+	}
+	if e.CustomizationMode == "" {
+		e.CustomizationMode = fleet.TargetCustomizationModeFirstMatch
+	}
+
+	if err := m.explainScope(ctx, bundle, cluster, e); err != nil {
+		return nil, err
+	}
+	if !e.InScope {
+		e.Blockers = append(e.Blockers, fmt.Sprintf("cluster namespace %q is not the bundle namespace and no BundleNamespaceMapping maps the bundle into it", cluster.Namespace))
+	}
+
+	clusterGroups, err := m.clusterGroupsForCluster(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+	for _, cg := range clusterGroups {
+		e.ClusterGroups = append(e.ClusterGroups, cg.Name)
+	}
+	sort.Strings(e.ClusterGroups)
+	groups := ClusterGroupsToLabelMap(clusterGroups)
+
+	bm, err := matcher.New(bundle)
+	if err != nil {
+		return nil, err
+	}
+	e.BundleExplanation = bm.Explain(matcher.NewCluster(cluster, groups))
+
+	if err := m.explainGitTargets(ctx, bundle, cluster, groups, e); err != nil {
+		return nil, err
+	}
+
+	target := bm.Match(matcher.NewCluster(cluster, groups))
+	switch {
+	case target == nil && e.Restricted:
+		e.Blockers = append(e.Blockers, "cluster does not match any target restriction")
+	case target == nil:
+		e.Blockers = append(e.Blockers, "cluster does not match any target")
+	default:
+		e.MatchedTarget = target.Name
+		if target.DoNotDeploy {
+			e.Blockers = append(e.Blockers, fmt.Sprintf("target %q has doNotDeploy set", target.Name))
+		}
+	}
+	if target != nil && bundle.Spec.Placement != nil && !isPlaced(bundle.Status.Placement, cluster) {
+		e.Blockers = append(e.Blockers, "cluster was not selected by the placement strategy")
+	}
+
+	var customizations []*fleet.BundleTarget
+	if e.CustomizationMode == fleet.TargetCustomizationModeAllMatches {
+		customizations = bm.MatchAllTargetCustomizations(matcher.NewCluster(cluster, groups))
+	} else if tc := bm.MatchTargetCustomizations(matcher.NewCluster(cluster, groups)); tc != nil {
+		customizations = append(customizations, tc)
+	}
+	for _, tc := range customizations {
+		e.SelectedCustomizations = append(e.SelectedCustomizations, tc.Name)
+		if tc.DoNotDeploy {
+			e.Blockers = append(e.Blockers, fmt.Sprintf("targetCustomization %q has doNotDeploy set", tc.Name))
+		}
+	}
+
+	if bundle.Spec.Paused {
+		e.Blockers = append(e.Blockers, "bundle is paused, existing BundleDeployments are not updated")
+	}
+	if cluster.Spec.Paused {
+		e.Blockers = append(e.Blockers, "cluster is paused, existing BundleDeployments are not updated")
+	}
+
+	if err := m.explainSchedules(ctx, cluster, groups, e); err != nil {
+		return nil, err
+	}
+	if cluster.Status.Scheduled && !cluster.Status.ActiveSchedule {
+		e.Blockers = append(e.Blockers, "cluster is off schedule, BundleDeployments are created with offSchedule and not deployed until the schedule is active")
+	}
+
+	if err := m.explainBundleDeployment(ctx, bundle, cluster, e); err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+func (m *Manager) explainScope(ctx context.Context, bundle *fleet.Bundle, cluster *fleet.Cluster, e *Explanation) error {
+	if cluster.Namespace == bundle.Namespace {
+		e.InScope = true
+		return nil
+	}
+
+	mappings := &fleet.BundleNamespaceMappingList{}
+	if err := m.client.List(ctx, mappings, client.InNamespace(bundle.Namespace)); err != nil {
+		return err
+	}
+	for _, mapping := range mappings.Items {
+		me := MappingExplanation{Name: mapping.Name}
+		bm, err := newBundleMapping(&mapping)
+		if err != nil {
+			me.Invalid = err.Error()
+			e.NamespaceMappings = append(e.NamespaceMappings, me)
+			continue
+		}
+		me.BundleMatched = bm.Matches(bundle)
+		me.NamespaceMatched = bm.MatchesNamespace(ctx, m.client, cluster.Namespace)
+		if me.BundleMatched && me.NamespaceMatched {
+			e.InScope = true
+		}
+		e.NamespaceMappings = append(e.NamespaceMappings, me)
+	}
+
+	return nil
+}
+
+func (m *Manager) explainGitTargets(ctx context.Context, bundle *fleet.Bundle, cluster *fleet.Cluster, groups map[string]map[string]string, e *Explanation) error {
+	repoName := bundle.Labels[fleet.RepoLabel]
+	if repoName == "" {
+		return nil
+	}
+
+	gitrepo := &fleet.GitRepo{}
+	err := m.client.Get(ctx, client.ObjectKey{Namespace: bundle.Namespace, Name: repoName}, gitrepo)
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	for _, t := range gitrepo.Spec.Targets {
+		cm, err := matcher.NewClusterMatcher(t.ClusterName, t.ClusterGroup, t.ClusterGroupSelector, t.ClusterSelector, t.ClusterExpression)
+		if err != nil {
+			return fmt.Errorf("invalid target %q in gitrepo %s: %w", t.Name, repoName, err)
+		}
+		e.GitTargets = append(e.GitTargets, matcher.TargetExplanation{
+			Name:        t.Name,
+			MatchResult: cm.ExplainGroups(matcher.NewCluster(cluster, groups)),
+		})
+	}
+
+	return nil
+}
+
+func (m *Manager) explainSchedules(ctx context.Context, cluster *fleet.Cluster, groups map[string]map[string]string, e *Explanation) error {
+	schedules := &fleet.ScheduleList{}
+	if err := m.client.List(ctx, schedules, client.InNamespace(cluster.Namespace)); err != nil {
+		return err
+	}
+	for _, schedule := range schedules.Items {
+		sm, err := matcher.NewScheduleMatch(&schedule)
+		if err != nil {
+			return fmt.Errorf("invalid schedule %s: %w", schedule.Name, err)
+		}
+		e.Schedules = append(e.Schedules, ScheduleExplanation{
+			Name:    schedule.Name,
+			Matched: sm.MatchCluster(matcher.NewCluster(cluster, groups)),
+			Active:  schedule.Status.Active,
+		})
+	}
+
+	return nil
+}
+
+func (m *Manager) explainBundleDeployment(ctx context.Context, bundle *fleet.Bundle, cluster *fleet.Cluster, e *Explanation) error {
+	if cluster.Status.Namespace == "" {
+		return nil
+	}
+
+	bds := &fleet.BundleDeploymentList{}
+	err := m.client.List(ctx, bds, client.InNamespace(cluster.Status.Namespace), client.MatchingLabels{
+		fleet.BundleLabel:          bundle.Name,
+		fleet.BundleNamespaceLabel: bundle.Namespace,
+	})
+	if err != nil {
+		return err
+	}
+	if len(bds.Items) == 0 {
+		return nil
+	}
+
+	bd := bds.Items[0]
+	e.BundleDeployment = bd.Namespace + "/" + bd.Name
+	e.BundleDeploymentState = bd.Status.Display.State
+	if bd.Spec.OffSchedule && !(cluster.Status.Scheduled && !cluster.Status.ActiveSchedule) {
+		e.Blockers = append(e.Blockers, "BundleDeployment is marked offSchedule")
+	}
+
+	return nil
+}
+
+func isPlaced(status *fleet.PlacementStatus, cluster *fleet.Cluster) bool {
+	if status == nil {
+		return false
+	}
+	for _, c := range status.Clusters {
+		if c.Namespace == cluster.Namespace && c.Name == cluster.Name {
+			return true
+		}
+	}
+	return false
+}
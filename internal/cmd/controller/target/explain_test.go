@@ -0,0 +1,109 @@
+package target
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newExplainScheme(t *testing.T) *runtime.Scheme {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	require.NoError(t, fleet.AddToScheme(scheme))
+	require.NoError(t, corev1.AddToScheme(scheme))
+	return scheme
+}
+
+func TestExplain(t *testing.T) {
+	cluster := &fleet.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "c1", Namespace: "fleet-default", Labels: map[string]string{"env": "prod", "region": "us"}},
+		Spec:       fleet.ClusterSpec{Paused: true},
+		Status:     fleet.ClusterStatus{Scheduled: true, ActiveSchedule: false},
+	}
+	group := &fleet.ClusterGroup{
+		ObjectMeta: metav1.ObjectMeta{Name: "prod", Namespace: "fleet-default"},
+		Spec:       fleet.ClusterGroupSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}}},
+	}
+	gitrepo := &fleet.GitRepo{
+		ObjectMeta: metav1.ObjectMeta{Name: "repo", Namespace: "fleet-default"},
+		Spec: fleet.GitRepoSpec{Targets: []fleet.GitTarget{
+			{Name: "prod-group", ClusterGroup: "prod"},
+		}},
+	}
+	schedule := &fleet.Schedule{
+		ObjectMeta: metav1.ObjectMeta{Name: "nightly", Namespace: "fleet-default"},
+		Spec: fleet.ScheduleSpec{Targets: fleet.ScheduleTargets{Clusters: []fleet.ScheduleTarget{
+			{ClusterName: "c1"},
+		}}},
+	}
+	bundle := &fleet.Bundle{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "fleet-default", Labels: map[string]string{fleet.RepoLabel: "repo"}},
+		Spec: fleet.BundleSpec{
+			Targets: []fleet.BundleTarget{
+				{Name: "us", ClusterSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"region": "us"}}, DoNotDeploy: true},
+				{Name: "prod-group", ClusterGroup: "prod"},
+			},
+			TargetRestrictions: []fleet.BundleTargetRestriction{
+				{Name: "prod-group", ClusterGroup: "prod"},
+			},
+		},
+	}
+
+	c := fake.NewClientBuilder().WithScheme(newExplainScheme(t)).WithObjects(cluster, group, gitrepo, schedule, bundle).Build()
+
+	e, err := New(c, c).Explain(context.Background(), bundle, cluster)
+	require.NoError(t, err)
+
+	assert.True(t, e.InScope)
+	assert.Equal(t, []string{"prod"}, e.ClusterGroups)
+	require.Len(t, e.GitTargets, 1)
+	assert.True(t, e.GitTargets[0].Matched)
+	assert.Equal(t, "prod", e.GitTargets[0].ClusterGroup)
+	assert.Equal(t, "prod-group", e.MatchedTarget)
+	assert.Equal(t, []string{"us"}, e.SelectedCustomizations)
+	assert.Equal(t, []ScheduleExplanation{{Name: "nightly", Matched: true}}, e.Schedules)
+	assert.Equal(t, []string{
+		`targetCustomization "us" has doNotDeploy set`,
+		"cluster is paused, existing BundleDeployments are not updated",
+		"cluster is off schedule, BundleDeployments are created with offSchedule and not deployed until the schedule is active",
+	}, e.Blockers)
+}
+
+func TestExplain_NamespaceMapping(t *testing.T) {
+	cluster := &fleet.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "c1", Namespace: "other", Labels: map[string]string{"env": "prod"}},
+	}
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "other", Labels: map[string]string{"team": "a"}}}
+	mapping := &fleet.BundleNamespaceMapping{
+		ObjectMeta:        metav1.ObjectMeta{Name: "mapping", Namespace: "fleet-default"},
+		BundleSelector:    &metav1.LabelSelector{MatchLabels: map[string]string{"shared": "true"}},
+		NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+	}
+	bundle := &fleet.Bundle{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "fleet-default"},
+		Spec: fleet.BundleSpec{Targets: []fleet.BundleTarget{
+			{Name: "dev", ClusterSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"env": "dev"}}},
+		}},
+	}
+
+	c := fake.NewClientBuilder().WithScheme(newExplainScheme(t)).WithObjects(cluster, ns, mapping, bundle).Build()
+
+	e, err := New(c, c).Explain(context.Background(), bundle, cluster)
+	require.NoError(t, err)
+
+	assert.False(t, e.InScope)
+	assert.Equal(t, []MappingExplanation{{Name: "mapping", BundleMatched: false, NamespaceMatched: true}}, e.NamespaceMappings)
+	require.Len(t, e.Targets, 1)
+	assert.Equal(t, "cluster labels do not satisfy env=dev", e.Targets[0].Criteria[0].Reason)
+	assert.Empty(t, e.MatchedTarget)
+	assert.Contains(t, e.Blockers, "cluster does not match any target")
+}
@@ -10,7 +10,7 @@ type BundleMatch struct {
 	matcher *matcher
 }
 
-type findCriteriaMatch func(targetMatch targetMatch, clusterName, clusterGroup string, clusterGroupLabels, clusterLabels map[string]string) bool
+type findCriteriaMatch func(targetMatch targetMatch, cluster *Cluster, clusterGroup string, clusterGroupLabels map[string]string) bool
 
 func New(bundle *fleet.Bundle) (*BundleMatch, error) {
 	bm := &BundleMatch{
@@ -33,8 +33,8 @@ func (a *BundleMatch) MatchForTarget(name string) *fleet.BundleTarget {
 // It checks for restrictions, which means that just targets included in the GitRepo can be returned.
 // TargetCustomizations described in the fleet.yaml will be ignored.
 // All GitRepo targets are added as TargetRestrictions, which acts as a whitelist.
-func (a *BundleMatch) Match(clusterName string, clusterGroups map[string]map[string]string, clusterLabels map[string]string) *fleet.BundleTarget {
-	if m := a.matcher.match(clusterName, clusterLabels, clusterGroups, a.matcher.criteriaWithRestrictions); m != nil {
+func (a *BundleMatch) Match(cluster *Cluster) *fleet.BundleTarget {
+	if m := a.matcher.match(cluster, a.matcher.criteriaWithRestrictions); m != nil {
 		return m
 	}
 
@@ -43,8 +43,8 @@ func (a *BundleMatch) Match(clusterName string, clusterGroups map[string]map[str
 
 // MatchTargetCustomizations returns the first BundleTarget that matches the target criteria. Targets are evaluated in order.
 // It doesn't check for restrictions, which means TargetCustomizations described in the fleet.yaml are considered.
-func (a *BundleMatch) MatchTargetCustomizations(clusterName string, clusterGroups map[string]map[string]string, clusterLabels map[string]string) *fleet.BundleTarget {
-	if m := a.matcher.matchCustomization(clusterName, clusterLabels, clusterGroups); m != nil {
+func (a *BundleMatch) MatchTargetCustomizations(cluster *Cluster) *fleet.BundleTarget {
+	if m := a.matcher.matchCustomization(cluster); m != nil {
 		return m
 	}
 
@@ -53,26 +53,79 @@ func (a *BundleMatch) MatchTargetCustomizations(clusterName string, clusterGroup
 
 // MatchAllTargetCustomizations returns all BundleTargets marked as customizations that match the target criteria, in list order.
 // Used when TargetCustomizationMode is AllMatches.
-func (a *BundleMatch) MatchAllTargetCustomizations(clusterName string, clusterGroups map[string]map[string]string, clusterLabels map[string]string) []*fleet.BundleTarget {
+func (a *BundleMatch) MatchAllTargetCustomizations(cluster *Cluster) []*fleet.BundleTarget {
 	var result []*fleet.BundleTarget
 	for _, tm := range a.matcher.matches {
 		if !tm.isCustomization {
 			continue
 		}
-		if len(clusterGroups) == 0 {
-			if tm.criteria.Match(clusterName, "", nil, clusterLabels) {
-				result = append(result, tm.bundleTarget)
+		// MatchGroups stops at the first matching group, so the same target is not added multiple times
+		if tm.criteria.MatchGroups(cluster) {
+			result = append(result, tm.bundleTarget)
+		}
+	}
+	return result
+}
+
+// TargetExplanation explains the matching decision for a single target or
+// target restriction of a bundle.
+type TargetExplanation struct {
+	Name string `json:"name"`
+	MatchResult
+	DoNotDeploy bool `json:"doNotDeploy,omitempty"`
+}
+
+// BundleExplanation explains how the targets of a bundle were evaluated for
+// a cluster.
+type BundleExplanation struct {
+	// Restrictions are the targets of the GitRepo or HelmOp, which act as an
+	// allow list. They are empty for bundles created by other means.
+	Restrictions []TargetExplanation `json:"restrictions,omitempty"`
+	// Restricted is true if none of the restrictions matched.
+	Restricted bool `json:"restricted,omitempty"`
+	// Targets are the GitRepo or HelmOp targets, or all targets if the
+	// bundle has no restrictions.
+	Targets []TargetExplanation `json:"targets,omitempty"`
+	// Customizations are the targetCustomizations from fleet.yaml.
+	Customizations []TargetExplanation `json:"customizations,omitempty"`
+}
+
+// Explain evaluates all targets, restrictions and customizations of the
+// bundle against the cluster. Unlike Match, it does not stop at the first
+// match.
+func (a *BundleMatch) Explain(cluster *Cluster) BundleExplanation {
+	var e BundleExplanation
+
+	for i, r := range a.matcher.restrictions {
+		e.Restrictions = append(e.Restrictions, TargetExplanation{
+			Name:        a.bundle.Spec.TargetRestrictions[i].Name,
+			MatchResult: r.ExplainGroups(cluster),
+		})
+	}
+	if len(e.Restrictions) > 0 {
+		e.Restricted = true
+		for _, r := range e.Restrictions {
+			if r.Matched {
+				e.Restricted = false
+				break
 			}
+		}
+	}
+
+	for _, tm := range a.matcher.matches {
+		te := TargetExplanation{
+			Name:        tm.bundleTarget.Name,
+			MatchResult: tm.criteria.ExplainGroups(cluster),
+			DoNotDeploy: tm.bundleTarget.DoNotDeploy,
+		}
+		if tm.isCustomization {
+			e.Customizations = append(e.Customizations, te)
 		} else {
-			for cg, cgLabels := range clusterGroups {
-				if tm.criteria.Match(clusterName, cg, cgLabels, clusterLabels) {
-					result = append(result, tm.bundleTarget)
-					break // matched via one group — don't add the same target multiple times
-				}
-			}
+			e.Targets = append(e.Targets, te)
 		}
 	}
-	return result
+
+	return e
 }
 
 type targetMatch struct {
@@ -94,7 +147,7 @@ func (a *BundleMatch) initMatcher() error {
 	numCustomizations := len(a.bundle.Spec.Targets) - numRestrictions
 
 	for i, target := range a.bundle.Spec.Targets {
-		clusterMatcher, err := NewClusterMatcher(target.ClusterName, target.ClusterGroup, target.ClusterGroupSelector, target.ClusterSelector)
+		clusterMatcher, err := NewClusterMatcher(target.ClusterName, target.ClusterGroup, target.ClusterGroupSelector, target.ClusterSelector, target.ClusterExpression)
 		if err != nil {
 			return err
 		}
@@ -108,7 +161,7 @@ func (a *BundleMatch) initMatcher() error {
 	}
 
 	for _, target := range a.bundle.Spec.TargetRestrictions {
-		clusterMatcher, err := NewClusterMatcher(target.ClusterName, target.ClusterGroup, target.ClusterGroupSelector, target.ClusterSelector)
+		clusterMatcher, err := NewClusterMatcher(target.ClusterName, target.ClusterGroup, target.ClusterGroupSelector, target.ClusterSelector, target.ClusterExpression)
 		if err != nil {
 			return err
 		}
@@ -135,14 +188,14 @@ func determineIsCustomization(index int, numCustomizations int, numRestrictions
 	return index < numCustomizations
 }
 
-func (m *matcher) isRestricted(clusterName, clusterGroup string, clusterGroupLabels, clusterLabels map[string]string) bool {
+func (m *matcher) isRestricted(cluster *Cluster, clusterGroup string, clusterGroupLabels map[string]string) bool {
 	// No restrictions means this Bundle was not created by a GitRepo (HelmOps and CLI bundles don't set restrictions).
 	if len(m.restrictions) == 0 {
 		return false
 	}
 
 	for _, restriction := range m.restrictions {
-		if restriction.Match(clusterName, clusterGroup, clusterGroupLabels, clusterLabels) {
+		if restriction.Match(cluster, clusterGroup, clusterGroupLabels) {
 			return false
 		}
 	}
@@ -153,28 +206,28 @@ func (m *matcher) isRestricted(clusterName, clusterGroup string, clusterGroupLab
 // criteriaWithRestrictions checks that the cluster passes the restriction allowlist
 // and matches the target's cluster selector. Used for GitRepo targets only;
 // customization targets (from fleet.yaml) are excluded.
-func (m *matcher) criteriaWithRestrictions(targetMatch targetMatch, clusterName, clusterGroup string, clusterGroupLabels, clusterLabels map[string]string) bool {
+func (m *matcher) criteriaWithRestrictions(targetMatch targetMatch, cluster *Cluster, clusterGroup string, clusterGroupLabels map[string]string) bool {
 	if targetMatch.isCustomization {
 		return false
 	}
-	if !m.isRestricted(clusterName, clusterGroup, clusterGroupLabels, clusterLabels) &&
-		targetMatch.criteria.Match(clusterName, clusterGroup, clusterGroupLabels, clusterLabels) {
+	if !m.isRestricted(cluster, clusterGroup, clusterGroupLabels) &&
+		targetMatch.criteria.Match(cluster, clusterGroup, clusterGroupLabels) {
 		return true
 	}
 
 	return false
 }
 
-// match returns the first BundleTarget, from the matcher's target matches, which matches the specified cluster name, groups and labels, using matching logic implemented via findCriteriaMatch.
-func (m *matcher) match(clusterName string, clusterLabels map[string]string, clusterGroups map[string]map[string]string, findCriteriaMatch findCriteriaMatch) *fleet.BundleTarget {
+// match returns the first BundleTarget, from the matcher's target matches, which matches the specified cluster, using matching logic implemented via findCriteriaMatch.
+func (m *matcher) match(cluster *Cluster, findCriteriaMatch findCriteriaMatch) *fleet.BundleTarget {
 	for _, targetMatch := range m.matches {
-		if len(clusterGroups) == 0 {
-			if findCriteriaMatch(targetMatch, clusterName, "", nil, clusterLabels) {
+		if len(cluster.Groups) == 0 {
+			if findCriteriaMatch(targetMatch, cluster, "", nil) {
 				return targetMatch.bundleTarget
 			}
 		} else {
-			for clusterGroup, clusterGroupLabels := range clusterGroups {
-				if findCriteriaMatch(targetMatch, clusterName, clusterGroup, clusterGroupLabels, clusterLabels) {
+			for clusterGroup, clusterGroupLabels := range cluster.Groups {
+				if findCriteriaMatch(targetMatch, cluster, clusterGroup, clusterGroupLabels) {
 					return targetMatch.bundleTarget
 				}
 			}
@@ -184,21 +237,10 @@ func (m *matcher) match(clusterName string, clusterLabels map[string]string, clu
 }
 
 // matchCustomization returns the first customization target that matches the cluster.
-func (m *matcher) matchCustomization(clusterName string, clusterLabels map[string]string, clusterGroups map[string]map[string]string) *fleet.BundleTarget {
+func (m *matcher) matchCustomization(cluster *Cluster) *fleet.BundleTarget {
 	for _, tm := range m.matches {
-		if !tm.isCustomization {
-			continue
-		}
-		if len(clusterGroups) == 0 {
-			if tm.criteria.Match(clusterName, "", nil, clusterLabels) {
-				return tm.bundleTarget
-			}
-		} else {
-			for clusterGroup, clusterGroupLabels := range clusterGroups {
-				if tm.criteria.Match(clusterName, clusterGroup, clusterGroupLabels, clusterLabels) {
-					return tm.bundleTarget
-				}
-			}
+		if tm.isCustomization && tm.criteria.MatchGroups(cluster) {
+			return tm.bundleTarget
 		}
 	}
 	return nil
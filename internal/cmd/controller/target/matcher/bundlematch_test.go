@@ -98,7 +98,7 @@ func TestMatchAllTargetCustomizations_NoGroups(t *testing.T) {
 			bm, err := New(makeBundle(gitRepoTarget, tt.customizationTargets))
 			require.NoError(t, err)
 
-			got := bm.MatchAllTargetCustomizations("local", nil, tt.clusterLabels)
+			got := bm.MatchAllTargetCustomizations(&Cluster{Name: "local", Labels: tt.clusterLabels})
 
 			var gotNames []string
 			for _, bt := range got {
@@ -122,7 +122,7 @@ func TestMatchAllTargetCustomizations_DoNotDeployIncluded(t *testing.T) {
 	bm, err := New(makeBundle(gitRepoTarget, customizations))
 	require.NoError(t, err)
 
-	got := bm.MatchAllTargetCustomizations("local", nil, map[string]string{"edge": "true"})
+	got := bm.MatchAllTargetCustomizations(&Cluster{Name: "local", Labels: map[string]string{"edge": "true"}})
 	var names []string
 	for _, g := range got {
 		names = append(names, g.Name)
@@ -144,7 +144,7 @@ func TestMatchTargetCustomizations_StillFirstMatch(t *testing.T) {
 	bm, err := New(makeBundle(gitRepoTarget, customizations))
 	require.NoError(t, err)
 
-	got := bm.MatchTargetCustomizations("local", nil, map[string]string{"edge": "true", "extra": "true"})
+	got := bm.MatchTargetCustomizations(&Cluster{Name: "local", Labels: map[string]string{"edge": "true", "extra": "true"}})
 	require.NotNil(t, got)
 	// "edge" is the first customization in the list and matches — "extra" is never reached.
 	assert.Equal(t, "edge", got.Name, "MatchTargetCustomizations returns first match without restrictions")
@@ -195,14 +195,14 @@ func TestCustomizationWithSameSelectorsAsGitRepoTarget(t *testing.T) {
 	t.Run("MatchTargetCustomizations should return the customization", func(t *testing.T) {
 		// Position-based detection correctly identifies index 0 as a customization
 		// even though it has identical selectors to the GitRepo target
-		got := bm.MatchTargetCustomizations("cluster1", nil, clusterLabels)
+		got := bm.MatchTargetCustomizations(&Cluster{Name: "cluster1", Labels: clusterLabels})
 		require.NotNil(t, got, "customization should match even when it has same selectors as GitRepo target")
 		assert.Equal(t, "production", got.Name)
 	})
 
 	t.Run("MatchAllTargetCustomizations should include the customization", func(t *testing.T) {
 		// Position-based detection correctly includes the customization at index 0
-		got := bm.MatchAllTargetCustomizations("cluster1", nil, clusterLabels)
+		got := bm.MatchAllTargetCustomizations(&Cluster{Name: "cluster1", Labels: clusterLabels})
 		require.Len(t, got, 1, "should return the customization even when selectors match a GitRepo target")
 		assert.Equal(t, "production", got[0].Name)
 	})
@@ -274,3 +274,31 @@ func TestDetermineIsCustomization(t *testing.T) {
 		})
 	}
 }
+
+// TestExplain verifies that all targets, restrictions and customizations are
+// evaluated, not just the first match.
+func TestExplain(t *testing.T) {
+	gitRepoTarget := fleet.BundleTarget{Name: "all", ClusterSelector: labelSelector(map[string]string{"env": "prod"})}
+	bundle := makeBundle(gitRepoTarget, []fleet.BundleTarget{
+		{Name: "eu", ClusterSelector: labelSelector(map[string]string{"region": "eu"})},
+		{Name: "us", ClusterSelector: labelSelector(map[string]string{"region": "us"}), DoNotDeploy: true},
+	})
+
+	bm, err := New(bundle)
+	require.NoError(t, err)
+
+	e := bm.Explain(&Cluster{Name: "c1", Labels: map[string]string{"env": "prod", "region": "us"}})
+	assert.False(t, e.Restricted)
+	require.Len(t, e.Restrictions, 1)
+	assert.True(t, e.Restrictions[0].Matched)
+	require.Len(t, e.Targets, 1)
+	assert.Equal(t, "all", e.Targets[0].Name)
+	assert.True(t, e.Targets[0].Matched)
+	require.Len(t, e.Customizations, 2)
+	assert.False(t, e.Customizations[0].Matched)
+	assert.True(t, e.Customizations[1].Matched)
+	assert.True(t, e.Customizations[1].DoNotDeploy)
+
+	e = bm.Explain(&Cluster{Name: "c1", Labels: map[string]string{"env": "dev"}})
+	assert.True(t, e.Restricted)
+}
@@ -1,6 +1,12 @@
 package matcher
 
 import (
+	"fmt"
+	"sort"
+	"strings"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 )
@@ -10,36 +16,98 @@ const (
 	ClusterDisplayNameLabel = "management.cattle.io/cluster-display-name"
 )
 
-type criteria func(clusterName, clusterGroup string, clusterGroupLabels, clusterLabels map[string]string) bool
+// Cluster holds the attributes of a cluster, which targets are matched
+// against.
+type Cluster struct {
+	Name        string
+	Labels      map[string]string
+	Annotations map[string]string
+	// Groups maps the names of the cluster groups the cluster is a member
+	// of to their labels.
+	Groups map[string]map[string]string
+	// Facts are reported by the agent, they are only used by cluster
+	// expressions.
+	Facts *fleet.ClusterFacts
+}
+
+// NewCluster returns the attributes of the cluster, which is a member of the
+// given cluster groups.
+func NewCluster(cluster *fleet.Cluster, clusterGroups map[string]map[string]string) *Cluster {
+	return &Cluster{
+		Name:        cluster.Name,
+		Labels:      cluster.Labels,
+		Annotations: cluster.Annotations,
+		Groups:      clusterGroups,
+		Facts:       cluster.Status.Facts,
+	}
+}
+
+type criteria func(cluster *Cluster, clusterGroup string, clusterGroupLabels map[string]string) bool
+
+// criterion is a single condition of a ClusterMatcher. The description and
+// reason are only used to explain matching decisions.
+type criterion struct {
+	description string
+	match       criteria
+	// reason returns why the criterion did not match.
+	reason func(cluster *Cluster, clusterGroup string, clusterGroupLabels map[string]string) string
+}
 
 type ClusterMatcher struct {
-	criteria []criteria
+	criteria []criterion
+}
+
+// CriterionResult is the result of evaluating one criterion of a
+// ClusterMatcher against a cluster.
+type CriterionResult struct {
+	Criterion string `json:"criterion"`
+	Matched   bool   `json:"matched"`
+	// Reason explains why the criterion did not match.
+	Reason string `json:"reason,omitempty"`
 }
 
 func toSelector(labels *metav1.LabelSelector) (labels.Selector, error) {
 	return metav1.LabelSelectorAsSelector(labels)
 }
 
-func NewClusterMatcher(clusterName, clusterGroup string, clusterGroupSelector *metav1.LabelSelector, clusterSelector *metav1.LabelSelector) (*ClusterMatcher, error) {
+func NewClusterMatcher(clusterName, clusterGroup string, clusterGroupSelector *metav1.LabelSelector, clusterSelector *metav1.LabelSelector, clusterExpression string) (*ClusterMatcher, error) {
 	t := &ClusterMatcher{}
 
 	if clusterName != "" {
-		t.criteria = append(t.criteria, func(clusterNameTest, _ string, _, clusterLabels map[string]string) bool {
-			// Match by cluster name (resource name)
-			if clusterName == clusterNameTest {
-				return true
-			}
-			// Also match by display name label for backward compatibility with Rancher
-			if displayName, ok := clusterLabels[ClusterDisplayNameLabel]; ok && clusterName == displayName {
-				return true
-			}
-			return false
+		t.criteria = append(t.criteria, criterion{
+			description: fmt.Sprintf("clusterName=%s", clusterName),
+			match: func(cluster *Cluster, _ string, _ map[string]string) bool {
+				// Match by cluster name (resource name)
+				if clusterName == cluster.Name {
+					return true
+				}
+				// Also match by display name label for backward compatibility with Rancher
+				if displayName, ok := cluster.Labels[ClusterDisplayNameLabel]; ok && clusterName == displayName {
+					return true
+				}
+				return false
+			},
+			reason: func(cluster *Cluster, _ string, _ map[string]string) string {
+				if displayName, ok := cluster.Labels[ClusterDisplayNameLabel]; ok {
+					return fmt.Sprintf("cluster name is %q, display name is %q", cluster.Name, displayName)
+				}
+				return fmt.Sprintf("cluster name is %q", cluster.Name)
+			},
 		})
 	}
 
 	if clusterGroup != "" {
-		t.criteria = append(t.criteria, func(_, clusterGroupTest string, _, _ map[string]string) bool {
-			return clusterGroup == clusterGroupTest
+		t.criteria = append(t.criteria, criterion{
+			description: fmt.Sprintf("clusterGroup=%s", clusterGroup),
+			match: func(_ *Cluster, clusterGroupTest string, _ map[string]string) bool {
+				return clusterGroup == clusterGroupTest
+			},
+			reason: func(_ *Cluster, clusterGroupTest string, _ map[string]string) string {
+				if clusterGroupTest == "" {
+					return "cluster is not a member of any cluster group"
+				}
+				return fmt.Sprintf("cluster group is %q", clusterGroupTest)
+			},
 		})
 	}
 
@@ -48,8 +116,17 @@ func NewClusterMatcher(clusterName, clusterGroup string, clusterGroupSelector *m
 		if err != nil {
 			return nil, err
 		}
-		t.criteria = append(t.criteria, func(_, _ string, clusterGroupLabels, _ map[string]string) bool {
-			return selector.Matches(labels.Set(clusterGroupLabels))
+		t.criteria = append(t.criteria, criterion{
+			description: fmt.Sprintf("clusterGroupSelector=%s", selector),
+			match: func(_ *Cluster, _ string, clusterGroupLabels map[string]string) bool {
+				return selector.Matches(labels.Set(clusterGroupLabels))
+			},
+			reason: func(_ *Cluster, clusterGroupTest string, clusterGroupLabels map[string]string) string {
+				if clusterGroupTest == "" {
+					return "cluster is not a member of any cluster group"
+				}
+				return fmt.Sprintf("labels of cluster group %q do not satisfy %s", clusterGroupTest, unmetRequirements(selector, clusterGroupLabels))
+			},
 		})
 	}
 
@@ -58,22 +135,156 @@ func NewClusterMatcher(clusterName, clusterGroup string, clusterGroupSelector *m
 		if err != nil {
 			return nil, err
 		}
-		t.criteria = append(t.criteria, func(_, _ string, _, clusterLabels map[string]string) bool {
-			return selector.Matches(labels.Set(clusterLabels))
+		t.criteria = append(t.criteria, criterion{
+			description: fmt.Sprintf("clusterSelector=%s", selector),
+			match: func(cluster *Cluster, _ string, _ map[string]string) bool {
+				return selector.Matches(labels.Set(cluster.Labels))
+			},
+			reason: func(cluster *Cluster, _ string, _ map[string]string) string {
+				return fmt.Sprintf("cluster labels do not satisfy %s", unmetRequirements(selector, cluster.Labels))
+			},
+		})
+	}
+
+	if clusterExpression != "" {
+		prg, err := compileExpression(clusterExpression)
+		if err != nil {
+			return nil, err
+		}
+		// An expression which fails to evaluate, e.g. because it accesses
+		// a missing label, does not match.
+		t.criteria = append(t.criteria, criterion{
+			description: fmt.Sprintf("clusterExpression=%s", clusterExpression),
+			match: func(cluster *Cluster, clusterGroup string, _ map[string]string) bool {
+				matched, err := evalExpression(prg, cluster, clusterGroup)
+				return err == nil && matched
+			},
+			reason: func(cluster *Cluster, clusterGroup string, _ map[string]string) string {
+				if _, err := evalExpression(prg, cluster, clusterGroup); err != nil {
+					return fmt.Sprintf("expression failed to evaluate: %v", err)
+				}
+				return "expression evaluated to false"
+			},
 		})
 	}
 
 	return t, nil
 }
 
-func (t *ClusterMatcher) Match(clusterName, clusterGroup string, clusterGroupLabels, clusterLabels map[string]string) bool {
+// Match returns true if the cluster matches all criteria, as a member of the
+// given cluster group.
+func (t *ClusterMatcher) Match(cluster *Cluster, clusterGroup string, clusterGroupLabels map[string]string) bool {
 	if len(t.criteria) == 0 {
 		return false
 	}
-	for _, criteria := range t.criteria {
-		if !criteria(clusterName, clusterGroup, clusterGroupLabels, clusterLabels) {
+	for _, c := range t.criteria {
+		if !c.match(cluster, clusterGroup, clusterGroupLabels) {
 			return false
 		}
 	}
 	return true
 }
+
+// MatchGroups returns true if the cluster matches all criteria as a member
+// of any of its cluster groups, or without a group if it has none.
+func (t *ClusterMatcher) MatchGroups(cluster *Cluster) bool {
+	if len(cluster.Groups) == 0 {
+		return t.Match(cluster, "", nil)
+	}
+	for clusterGroup, clusterGroupLabels := range cluster.Groups {
+		if t.Match(cluster, clusterGroup, clusterGroupLabels) {
+			return true
+		}
+	}
+	return false
+}
+
+// Explain evaluates every criterion, instead of stopping at the first one
+// which does not match, and returns the results in order.
+func (t *ClusterMatcher) Explain(cluster *Cluster, clusterGroup string, clusterGroupLabels map[string]string) []CriterionResult {
+	if len(t.criteria) == 0 {
+		return []CriterionResult{{Criterion: "<none>", Reason: "target has no criteria, it matches no cluster"}}
+	}
+
+	results := make([]CriterionResult, 0, len(t.criteria))
+	for _, c := range t.criteria {
+		r := CriterionResult{Criterion: c.description}
+		r.Matched = c.match(cluster, clusterGroup, clusterGroupLabels)
+		if !r.Matched {
+			r.Reason = c.reason(cluster, clusterGroup, clusterGroupLabels)
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+// MatchResult explains whether a ClusterMatcher matched a cluster.
+type MatchResult struct {
+	Matched bool `json:"matched"`
+	// ClusterGroup is the cluster group which made the matcher match. If
+	// nothing matched, it is the group which came closest.
+	ClusterGroup string            `json:"clusterGroup,omitempty"`
+	Criteria     []CriterionResult `json:"criteria"`
+}
+
+// ExplainGroups evaluates the matcher for each of the cluster's groups, the
+// same way the bundle matcher does. It returns the first group, in
+// alphabetical order, for which all criteria match. If there is none, the
+// result for the group with the most matching criteria is returned.
+func (t *ClusterMatcher) ExplainGroups(cluster *Cluster) MatchResult {
+	if len(cluster.Groups) == 0 {
+		return newMatchResult("", t.Explain(cluster, "", nil))
+	}
+
+	groups := make([]string, 0, len(cluster.Groups))
+	for cg := range cluster.Groups {
+		groups = append(groups, cg)
+	}
+	sort.Strings(groups)
+
+	var best MatchResult
+	bestCount := -1
+	for _, cg := range groups {
+		r := newMatchResult(cg, t.Explain(cluster, cg, cluster.Groups[cg]))
+		if r.Matched {
+			return r
+		}
+		count := 0
+		for _, c := range r.Criteria {
+			if c.Matched {
+				count++
+			}
+		}
+		if count > bestCount {
+			best, bestCount = r, count
+		}
+	}
+	return best
+}
+
+func newMatchResult(clusterGroup string, criteria []CriterionResult) MatchResult {
+	r := MatchResult{Matched: true, ClusterGroup: clusterGroup, Criteria: criteria}
+	for _, c := range criteria {
+		if !c.Matched {
+			r.Matched = false
+		}
+	}
+	return r
+}
+
+// unmetRequirements returns the requirements of the selector, which are not
+// met by the given labels.
+func unmetRequirements(selector labels.Selector, set map[string]string) string {
+	reqs, selectable := selector.Requirements()
+	if !selectable {
+		return "selector, which matches nothing"
+	}
+
+	var unmet []string
+	for _, r := range reqs {
+		if !r.Matches(labels.Set(set)) {
+			unmet = append(unmet, r.String())
+		}
+	}
+	return strings.Join(unmet, ",")
+}
@@ -5,6 +5,12 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestNewClusterMatcher_DisplayNameMatching(t *testing.T) {
@@ -76,10 +82,10 @@ func TestNewClusterMatcher_DisplayNameMatching(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			matcher, err := NewClusterMatcher(tt.clusterName, "", nil, nil)
+			matcher, err := NewClusterMatcher(tt.clusterName, "", nil, nil, "")
 			require.NoError(t, err)
 
-			result := matcher.Match(tt.testClusterName, "", nil, tt.testClusterLabels)
+			result := matcher.Match(&Cluster{Name: tt.testClusterName, Labels: tt.testClusterLabels}, "", nil)
 			assert.Equal(t, tt.expectedMatch, result, tt.description)
 		})
 	}
@@ -87,47 +93,141 @@ func TestNewClusterMatcher_DisplayNameMatching(t *testing.T) {
 
 func TestNewClusterMatcher_EmptyClusterName(t *testing.T) {
 	// When clusterName is empty, no criteria should be added
-	matcher, err := NewClusterMatcher("", "", nil, nil)
+	matcher, err := NewClusterMatcher("", "", nil, nil, "")
 	require.NoError(t, err)
 
 	// With no criteria, Match should return false
-	result := matcher.Match("any-cluster", "", nil, map[string]string{})
+	result := matcher.Match(&Cluster{Name: "any-cluster"}, "", nil)
 	assert.False(t, result, "Should return false when no criteria are defined")
 }
 
 func TestNewClusterMatcher_ClusterGroup(t *testing.T) {
-	matcher, err := NewClusterMatcher("", "my-group", nil, nil)
+	matcher, err := NewClusterMatcher("", "my-group", nil, nil, "")
 	require.NoError(t, err)
 
 	// Should match when clusterGroup matches
-	result := matcher.Match("any-cluster", "my-group", nil, map[string]string{})
+	result := matcher.Match(&Cluster{Name: "any-cluster"}, "my-group", nil)
 	assert.True(t, result, "Should match when clusterGroup matches")
 
 	// Should not match when clusterGroup doesn't match
-	result = matcher.Match("any-cluster", "other-group", nil, map[string]string{})
+	result = matcher.Match(&Cluster{Name: "any-cluster"}, "other-group", nil)
 	assert.False(t, result, "Should not match when clusterGroup doesn't match")
 }
 
 func TestNewClusterMatcher_CombinedCriteria(t *testing.T) {
 	// Test with both clusterName and clusterGroup - all criteria must match
-	matcher, err := NewClusterMatcher("my-cluster", "my-group", nil, nil)
+	matcher, err := NewClusterMatcher("my-cluster", "my-group", nil, nil, "")
 	require.NoError(t, err)
 
 	// Both match - should succeed
-	result := matcher.Match("c-12345", "my-group", nil, map[string]string{
+	result := matcher.Match(&Cluster{Name: "c-12345", Labels: map[string]string{
 		ClusterDisplayNameLabel: "my-cluster",
-	})
+	}}, "my-group", nil)
 	assert.True(t, result, "Should match when both clusterName (via display name) and clusterGroup match")
 
 	// Only clusterName matches - should fail
-	result = matcher.Match("c-12345", "other-group", nil, map[string]string{
+	result = matcher.Match(&Cluster{Name: "c-12345", Labels: map[string]string{
 		ClusterDisplayNameLabel: "my-cluster",
-	})
+	}}, "other-group", nil)
 	assert.False(t, result, "Should not match when only clusterName matches but clusterGroup doesn't")
 
 	// Only clusterGroup matches - should fail
-	result = matcher.Match("c-12345", "my-group", nil, map[string]string{
+	result = matcher.Match(&Cluster{Name: "c-12345", Labels: map[string]string{
 		ClusterDisplayNameLabel: "other-cluster",
-	})
+	}}, "my-group", nil)
 	assert.False(t, result, "Should not match when only clusterGroup matches but clusterName doesn't")
 }
+
+func TestClusterMatcher_Explain(t *testing.T) {
+	m, err := NewClusterMatcher("", "prod", nil, &metav1.LabelSelector{
+		MatchLabels: map[string]string{"env": "prod", "region": "eu"},
+	}, "")
+	require.NoError(t, err)
+
+	results := m.Explain(&Cluster{Name: "c1", Labels: map[string]string{"env": "prod", "region": "us"}}, "dev", nil)
+	require.Len(t, results, 2)
+	assert.Equal(t, CriterionResult{Criterion: "clusterGroup=prod", Reason: `cluster group is "dev"`}, results[0])
+	assert.False(t, results[1].Matched)
+	assert.Equal(t, "cluster labels do not satisfy region=eu", results[1].Reason)
+
+	empty, err := NewClusterMatcher("", "", nil, nil, "")
+	require.NoError(t, err)
+	results = empty.Explain(&Cluster{Name: "c1"}, "", nil)
+	require.Len(t, results, 1)
+	assert.False(t, results[0].Matched)
+}
+
+func TestClusterMatcher_ExplainGroups(t *testing.T) {
+	m, err := NewClusterMatcher("", "", &metav1.LabelSelector{
+		MatchLabels: map[string]string{"tier": "gold"},
+	}, nil, "")
+	require.NoError(t, err)
+
+	r := m.ExplainGroups(&Cluster{Name: "c1", Groups: map[string]map[string]string{
+		"a": {"tier": "silver"},
+		"b": {"tier": "gold"},
+	}})
+	assert.True(t, r.Matched)
+	assert.Equal(t, "b", r.ClusterGroup)
+
+	r = m.ExplainGroups(&Cluster{Name: "c1"})
+	assert.False(t, r.Matched)
+	assert.Equal(t, "cluster is not a member of any cluster group", r.Criteria[0].Reason)
+}
+
+func TestClusterMatcher_Expression(t *testing.T) {
+	cluster := &Cluster{
+		Name:        "c1",
+		Labels:      map[string]string{"gpu": "true", "region": "eu-west-1", "env": "prod"},
+		Annotations: map[string]string{"owner": "team-a"},
+		Groups:      map[string]map[string]string{"edge": {}},
+		Facts: &fleet.ClusterFacts{
+			KubernetesVersion: "v1.30.2+k3s1",
+			NodeCount:         3,
+			Allocatable: corev1.ResourceList{
+				corev1.ResourceMemory: resource.MustParse("16Gi"),
+			},
+		},
+	}
+
+	tests := []struct {
+		expression string
+		matched    bool
+	}{
+		{`semver(facts.kubernetesVersion, true).compareTo(semver("1.29.0")) >= 0 && labels.region != "eu-west-3" && ("gpu" in labels || labels[?"env"].orValue("") == "dev")`, true},
+		{`semver(facts.kubernetesVersion, true).isLessThan(semver("1.29.0"))`, false},
+		{`"edge" in clusterGroups && annotations.owner == "team-a"`, true},
+		{`facts.nodeCount >= 3 && quantity(facts.allocatable.memory).isGreaterThan(quantity("8Gi"))`, true},
+		{`name.startsWith("c")`, true},
+		// evaluation errors do not match
+		{`labels.missing == "x"`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			m, err := NewClusterMatcher("", "", nil, nil, tt.expression)
+			require.NoError(t, err)
+			assert.Equal(t, tt.matched, m.MatchGroups(cluster))
+		})
+	}
+
+	// facts are empty until the agent reports them
+	m, err := NewClusterMatcher("", "", nil, nil, `has(facts.kubernetesVersion)`)
+	require.NoError(t, err)
+	assert.False(t, m.MatchGroups(&Cluster{Name: "c1"}))
+
+	m, err = NewClusterMatcher("", "", nil, nil, `labels.missing == "x"`)
+	require.NoError(t, err)
+	r := m.ExplainGroups(cluster)
+	assert.False(t, r.Matched)
+	assert.Contains(t, r.Criteria[0].Reason, "expression failed to evaluate: no such key: missing")
+}
+
+func TestNewClusterMatcher_InvalidExpression(t *testing.T) {
+	_, err := NewClusterMatcher("", "", nil, nil, `labels.env ==`)
+	assert.ErrorContains(t, err, "invalid clusterExpression")
+
+	_, err = NewClusterMatcher("", "", nil, nil, `labels.env`)
+	assert.ErrorContains(t, err, "expression must evaluate to bool")
+
+	assert.NoError(t, ValidateExpression(""))
+}
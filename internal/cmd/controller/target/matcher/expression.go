@@ -0,0 +1,101 @@
+package matcher
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/google/cel-go/cel"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/cel/library"
+)
+
+// expressionCostLimit bounds the runtime cost of evaluating a cluster
+// expression, which happens for every cluster and target.
+const expressionCostLimit = 1000000
+
+func newExpressionEnv() (*cel.Env, error) {
+	return cel.NewEnv(
+		cel.Variable("name", cel.StringType),
+		cel.Variable("labels", cel.MapType(cel.StringType, cel.StringType)),
+		cel.Variable("annotations", cel.MapType(cel.StringType, cel.StringType)),
+		cel.Variable("clusterGroups", cel.ListType(cel.StringType)),
+		cel.Variable("facts", cel.MapType(cel.StringType, cel.DynType)),
+		cel.OptionalTypes(),
+		// version 1 adds semver(string, bool), which accepts "v1.30.2+k3s1"
+		library.SemverLib(library.SemverVersion(1)),
+		library.Quantity(),
+		library.Lists(),
+	)
+}
+
+// compileExpression compiles a cluster expression. It returns an error if
+// the expression is not valid CEL or does not evaluate to a bool.
+func compileExpression(expr string) (cel.Program, error) {
+	env, err := newExpressionEnv()
+	if err != nil {
+		return nil, err
+	}
+	ast, iss := env.Compile(expr)
+	if iss.Err() != nil {
+		return nil, fmt.Errorf("invalid clusterExpression %q: %w", expr, iss.Err())
+	}
+	if ast.OutputType() != cel.BoolType {
+		return nil, fmt.Errorf("invalid clusterExpression %q: expression must evaluate to bool, got %s", expr, ast.OutputType())
+	}
+	return env.Program(ast, cel.CostLimit(expressionCostLimit))
+}
+
+// ValidateExpression returns an error if expr is not a valid cluster
+// expression. An empty expression is valid.
+func ValidateExpression(expr string) error {
+	if expr == "" {
+		return nil
+	}
+	_, err := compileExpression(expr)
+	return err
+}
+
+// evalExpression evaluates the program against the cluster. clusterGroup is
+// added to the cluster's groups, for callers which only know a single group.
+func evalExpression(prg cel.Program, c *Cluster, clusterGroup string) (bool, error) {
+	groups := make([]string, 0, len(c.Groups)+1)
+	for cg := range c.Groups {
+		groups = append(groups, cg)
+	}
+	if clusterGroup != "" && !slices.Contains(groups, clusterGroup) {
+		groups = append(groups, clusterGroup)
+	}
+	slices.Sort(groups)
+
+	facts := map[string]any{}
+	if c.Facts != nil {
+		var err error
+		if facts, err = runtime.DefaultUnstructuredConverter.ToUnstructured(c.Facts); err != nil {
+			return false, err
+		}
+	}
+
+	out, _, err := prg.Eval(map[string]any{
+		"name":          c.Name,
+		"labels":        nonNil(c.Labels),
+		"annotations":   nonNil(c.Annotations),
+		"clusterGroups": groups,
+		"facts":         facts,
+	})
+	if err != nil {
+		return false, err
+	}
+	matched, ok := out.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("expression evaluated to %v, not a bool", out.Value())
+	}
+	return matched, nil
+}
+
+func nonNil(m map[string]string) map[string]string {
+	if m == nil {
+		return map[string]string{}
+	}
+	return m
+}
@@ -19,20 +19,12 @@ func NewScheduleMatch(schedule *fleet.Schedule) (*ScheduleMatch, error) {
 	return bm, bm.initMatcher()
 }
 
-// MatchCluster returns true if the given cluster name, cluster groups or cluster labels
-// match any of the schedule matchers.
-func (m *ScheduleMatch) MatchCluster(clusterName string, clusterGroups map[string]map[string]string, clusterLabels map[string]string) bool {
+// MatchCluster returns true if the given cluster matches any of the schedule
+// matchers.
+func (m *ScheduleMatch) MatchCluster(cluster *Cluster) bool {
 	for _, m := range m.clusterMatchers {
-		if len(clusterGroups) == 0 {
-			if m.Match(clusterName, "", nil, clusterLabels) {
-				return true
-			}
-		} else {
-			for clusterGroup, clusterGroupLabels := range clusterGroups {
-				if m.Match(clusterName, clusterGroup, clusterGroupLabels, clusterLabels) {
-					return true
-				}
-			}
+		if m.MatchGroups(cluster) {
+			return true
 		}
 	}
 
@@ -46,6 +38,7 @@ func (m *ScheduleMatch) initMatcher() error {
 			target.ClusterGroup,
 			target.ClusterGroupSelector,
 			target.ClusterSelector,
+			target.ClusterExpression,
 		)
 		if err != nil {
 			return err
@@ -0,0 +1,99 @@
+package target
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+)
+
+// DependencySelector returns the selector for the BundleDeployments of a
+// dependency of a bundle in bundleNamespace. It matches the agent's
+// dependency check, so a name is a shortcut for the bundle labels.
+// A nil selector is returned for empty references.
+func DependencySelector(dep fleet.BundleRef, bundleNamespace string) (labels.Selector, error) {
+	if dep.Name == "" && dep.Selector == nil {
+		return nil, nil
+	}
+
+	ls := &metav1.LabelSelector{}
+	if dep.Selector != nil {
+		ls = dep.Selector.DeepCopy()
+	}
+	if dep.Name != "" {
+		ls = metav1.AddLabelToSelector(ls, fleet.BundleLabel, dep.Name)
+		ls = metav1.AddLabelToSelector(ls, fleet.BundleNamespaceLabel, bundleNamespace)
+	}
+	if dep.Namespace != "" {
+		ls = metav1.AddLabelToSelector(ls, fleet.BundleNamespaceLabel, dep.Namespace)
+	}
+	return metav1.LabelSelectorAsSelector(ls)
+}
+
+// DependencyIndexSelector is the dependency index key of bundles, which
+// select a dependency by labels only.
+const DependencyIndexSelector = "*"
+
+// DependencyIndexKeys returns the keys to index bundle by its dependencies:
+// "namespace/name" for dependencies referenced by name, and
+// DependencyIndexSelector for the others.
+func DependencyIndexKeys(bundle *fleet.Bundle) []string {
+	var keys []string
+	for _, dep := range bundle.Spec.DependsOn {
+		switch {
+		case dep.Name != "":
+			ns := dep.Namespace
+			if ns == "" {
+				ns = bundle.Namespace
+			}
+			keys = append(keys, ns+"/"+dep.Name)
+		case dep.Selector != nil:
+			keys = append(keys, DependencyIndexSelector)
+		}
+	}
+	return keys
+}
+
+// dependencyOutputs returns the outputs reported for the bundle's
+// dependencies, by BundleDeployment namespace, i.e. per cluster, and by
+// bundle name. The bundle name is the key in templates, so dependencies from
+// different namespaces with the same name are rejected.
+func (m *Manager) dependencyOutputs(ctx context.Context, bundle *fleet.Bundle) (map[string]map[string]map[string]string, error) {
+	result := map[string]map[string]map[string]string{}
+	// bundle namespace of the outputs, by BundleDeployment namespace and bundle name
+	owners := map[string]map[string]string{}
+	for _, dep := range bundle.Spec.DependsOn {
+		selector, err := DependencySelector(dep, bundle.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		if selector == nil {
+			continue
+		}
+
+		bds := &fleet.BundleDeploymentList{}
+		if err := m.client.List(ctx, bds, client.MatchingLabelsSelector{Selector: selector}); err != nil {
+			return nil, err
+		}
+		for _, bd := range bds.Items {
+			if len(bd.Status.Outputs) == 0 {
+				continue
+			}
+			name, ns := bd.Labels[fleet.BundleLabel], bd.Labels[fleet.BundleNamespaceLabel]
+			if owners[bd.Namespace] == nil {
+				owners[bd.Namespace] = map[string]string{}
+				result[bd.Namespace] = map[string]map[string]string{}
+			}
+			if other, ok := owners[bd.Namespace][name]; ok && other != ns {
+				return nil, fmt.Errorf("dependencies %s/%s and %s/%s have the same name, their outputs can't be told apart", other, name, ns, name)
+			}
+			owners[bd.Namespace][name] = ns
+			result[bd.Namespace][name] = bd.Status.Outputs
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,143 @@
+package target
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rancher/fleet/internal/config"
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/durations"
+)
+
+// offlineCheckins is the number of missed agent check-ins, after which a
+// cluster is considered not ready for placement.
+const offlineCheckins = 3
+
+// place selects the targets for the bundle's placement strategy and records
+// the decision in the bundle status. Clusters selected by a previous
+// reconcile are kept, as long as they are still targeted and ready, so
+// deployments don't move between clusters.
+func place(bundle *fleet.Bundle, targets []*Target, now time.Time) []*Target {
+	strategy := bundle.Spec.Placement
+	if strategy == nil {
+		bundle.Status.Placement = nil
+		return targets
+	}
+
+	previous := map[string]bool{}
+	if bundle.Status.Placement != nil {
+		for _, c := range bundle.Status.Placement.Clusters {
+			previous[c.Namespace+"/"+c.Name] = true
+		}
+	}
+
+	// group the ready candidates by topology domain
+	domains := map[string][]*Target{}
+	for _, t := range targets {
+		if !clusterReady(t.Cluster, now) {
+			continue
+		}
+		domain := ""
+		if strategy.TopologyKey != "" {
+			var ok bool
+			if domain, ok = t.Cluster.Labels[strategy.TopologyKey]; !ok {
+				continue
+			}
+		}
+		domains[domain] = append(domains[domain], t)
+	}
+
+	status := &fleet.PlacementStatus{}
+	selected := map[*Target]bool{}
+	var short []string
+	for _, domain := range slices.Sorted(maps.Keys(domains)) {
+		candidates := domains[domain]
+		slices.SortStableFunc(candidates, func(a, b *Target) int {
+			// previously selected clusters first, then by preference
+			pa, pb := previous[clusterKey(a)], previous[clusterKey(b)]
+			if pa != pb {
+				if pa {
+					return -1
+				}
+				return 1
+			}
+			if c := comparePreference(strategy, a.Cluster, b.Cluster); c != 0 {
+				return c
+			}
+			return strings.Compare(clusterKey(a), clusterKey(b))
+		})
+
+		n := min(strategy.Clusters, len(candidates))
+		if n < strategy.Clusters {
+			short = append(short, fmt.Sprintf("%q has %d of %d", domain, n, strategy.Clusters))
+		}
+		for _, t := range candidates[:n] {
+			selected[t] = true
+			status.Clusters = append(status.Clusters, fleet.PlacedCluster{
+				Namespace: t.Cluster.Namespace,
+				Name:      t.Cluster.Name,
+				Topology:  domain,
+			})
+		}
+	}
+
+	switch {
+	case len(domains) == 0:
+		status.Message = "no ready cluster matches the targets"
+	case len(short) > 0 && strategy.TopologyKey == "":
+		status.Message = fmt.Sprintf("only %d of %d clusters are ready", len(status.Clusters), strategy.Clusters)
+	case len(short) > 0:
+		status.Message = "not enough ready clusters: " + strings.Join(short, ", ")
+	}
+	bundle.Status.Placement = status
+
+	result := make([]*Target, 0, len(selected))
+	for _, t := range targets {
+		if selected[t] {
+			result = append(result, t)
+		}
+	}
+	return result
+}
+
+// clusterReady returns true if the cluster's agent checked in recently.
+// The health of the bundle's deployments is not considered, so a bundle
+// which fails everywhere does not rotate through all clusters.
+func clusterReady(cluster *fleet.Cluster, now time.Time) bool {
+	if !cluster.DeletionTimestamp.IsZero() || cluster.Status.Agent.LastSeen.IsZero() {
+		return false
+	}
+	interval := config.Get().AgentCheckinInterval.Duration
+	if interval <= 0 {
+		interval = durations.DefaultClusterCheckInterval
+	}
+	return now.Sub(cluster.Status.Agent.LastSeen.Time) < offlineCheckins*interval
+}
+
+// comparePreference orders clusters by the strategy's preference, preferred
+// clusters first.
+func comparePreference(strategy *fleet.PlacementStrategy, a, b *fleet.Cluster) int {
+	if strategy.Prefer == fleet.PlacementPreferLabelWeight {
+		return cmp.Compare(labelWeight(b, strategy.WeightLabel), labelWeight(a, strategy.WeightLabel))
+	}
+	return cmp.Compare(a.Status.Summary.DesiredReady, b.Status.Summary.DesiredReady)
+}
+
+// labelWeight returns the integer value of the label, or 0 if it is missing
+// or not an integer.
+func labelWeight(cluster *fleet.Cluster, label string) int {
+	w, err := strconv.Atoi(cluster.Labels[label])
+	if err != nil {
+		return 0
+	}
+	return w
+}
+
+func clusterKey(t *Target) string {
+	return t.Cluster.Namespace + "/" + t.Cluster.Name
+}
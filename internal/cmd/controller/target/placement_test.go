@@ -0,0 +1,115 @@
+package target
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/rancher/fleet/internal/config"
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	"github.com/rancher/wrangler/v3/pkg/genericcondition"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func placementTarget(name, region string, bundles int, lastSeen time.Time) *Target {
+	return &Target{
+		Cluster: &fleet.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "fleet-default",
+				Name:      name,
+				Labels:    map[string]string{"region": region, "weight": "1"},
+			},
+			Status: fleet.ClusterStatus{
+				Agent:   fleet.AgentStatus{LastSeen: metav1.NewTime(lastSeen)},
+				Summary: fleet.BundleSummary{DesiredReady: bundles},
+			},
+		},
+	}
+}
+
+func placedNames(targets []*Target) []string {
+	var names []string
+	for _, t := range targets {
+		names = append(names, t.Cluster.Name)
+	}
+	return names
+}
+
+func TestPlace(t *testing.T) {
+	config.Set(config.DefaultConfig())
+	now := time.Now()
+	targets := []*Target{
+		placementTarget("a", "eu", 5, now),
+		placementTarget("b", "eu", 1, now),
+		placementTarget("c", "eu", 3, now),
+		placementTarget("d", "us", 2, now),
+		placementTarget("e", "us", 0, now.Add(-time.Hour)), // agent offline
+		placementTarget("f", "", 0, now),
+	}
+	delete(targets[5].Cluster.Labels, "region")
+
+	bundle := &fleet.Bundle{Spec: fleet.BundleSpec{Placement: &fleet.PlacementStrategy{
+		Clusters:    2,
+		TopologyKey: "region",
+	}}}
+
+	got := place(bundle, targets, now)
+	if want := []string{"b", "c", "d"}; !slices.Equal(placedNames(got), want) {
+		t.Errorf("expected placed clusters %v, got %v", want, placedNames(got))
+	}
+	if want := `not enough ready clusters: "us" has 1 of 2`; bundle.Status.Placement.Message != want {
+		t.Errorf("expected message %q, got %q", want, bundle.Status.Placement.Message)
+	}
+	if bundle.Status.Placement.Clusters[0].Topology != "eu" {
+		t.Errorf("expected topology eu, got %q", bundle.Status.Placement.Clusters[0].Topology)
+	}
+
+	// selections are kept, even if other clusters are preferred now
+	targets[0].Cluster.Status.Summary.DesiredReady = 0
+	got = place(bundle, targets, now)
+	if want := []string{"b", "c", "d"}; !slices.Equal(placedNames(got), want) {
+		t.Errorf("expected stable placement %v, got %v", want, placedNames(got))
+	}
+
+	// clusters which go offline are replaced
+	targets[2].Cluster.Status.Agent.LastSeen = metav1.NewTime(now.Add(-time.Hour))
+	got = place(bundle, targets, now)
+	if want := []string{"a", "b", "d"}; !slices.Equal(placedNames(got), want) {
+		t.Errorf("expected replaced placement %v, got %v", want, placedNames(got))
+	}
+
+	// clusters on which the bundle is not ready are kept
+	targets[0].Deployment = &fleet.BundleDeployment{
+		Status: fleet.BundleDeploymentStatus{
+			Conditions: []genericcondition.GenericCondition{{
+				Type:           fleet.BundleDeploymentConditionReady,
+				Status:         "False",
+				LastUpdateTime: now.Add(-time.Hour).UTC().Format(time.RFC3339),
+			}},
+		},
+	}
+	got = place(bundle, targets, now)
+	if want := []string{"a", "b", "d"}; !slices.Equal(placedNames(got), want) {
+		t.Errorf("expected placement to ignore bundle health %v, got %v", want, placedNames(got))
+	}
+	targets[0].Deployment = nil
+
+	// label weight, without topology
+	targets[3].Cluster.Labels["weight"] = "10"
+	bundle = &fleet.Bundle{Spec: fleet.BundleSpec{Placement: &fleet.PlacementStrategy{
+		Clusters:    1,
+		Prefer:      fleet.PlacementPreferLabelWeight,
+		WeightLabel: "weight",
+	}}}
+	got = place(bundle, targets, now)
+	if want := []string{"d"}; !slices.Equal(placedNames(got), want) {
+		t.Errorf("expected placed clusters %v, got %v", want, placedNames(got))
+	}
+
+	// without a strategy all targets are used
+	bundle.Spec.Placement = nil
+	if got := place(bundle, targets, now); len(got) != len(targets) || bundle.Status.Placement != nil {
+		t.Errorf("expected all targets without placement, got %v", placedNames(got))
+	}
+}
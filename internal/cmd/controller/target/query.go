@@ -32,7 +32,7 @@ func (m *Manager) BundlesForCluster(ctx context.Context, cluster *fleet.Cluster)
 			return nil, nil, err
 		}
 
-		match := bm.Match(cluster.Name, ClusterGroupsToLabelMap(cgs), cluster.Labels)
+		match := bm.Match(matcher.NewCluster(cluster, ClusterGroupsToLabelMap(cgs)))
 		if match != nil {
 			bundlesToRefresh = append(bundlesToRefresh, bundle)
 		} else {
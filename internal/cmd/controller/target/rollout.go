@@ -43,23 +43,15 @@ func manualPartition(rollout *fleet.RolloutStrategy, targets []*Target) ([]parti
 	)
 
 	for _, partitionDef := range rollout.Partitions {
-		matcher, err := matcher.NewClusterMatcher(partitionDef.ClusterName, partitionDef.ClusterGroup, partitionDef.ClusterGroupSelector, partitionDef.ClusterSelector)
+		clusterMatcher, err := matcher.NewClusterMatcher(partitionDef.ClusterName, partitionDef.ClusterGroup, partitionDef.ClusterGroupSelector, partitionDef.ClusterSelector, partitionDef.ClusterExpression)
 		if err != nil {
 			return nil, err
 		}
 
 		var partitionTargets []*Target
-	targetLoop:
 		for _, target := range targets {
-			for _, cg := range target.ClusterGroups {
-				if matcher.Match(target.Cluster.Name, cg.Name, cg.Labels, target.Cluster.Labels) {
-					partitionTargets = append(partitionTargets, target)
-					continue targetLoop
-				}
-			}
-			if len(target.ClusterGroups) == 0 && matcher.Match(target.Cluster.Name, "", nil, target.Cluster.Labels) {
+			if clusterMatcher.MatchGroups(matcher.NewCluster(target.Cluster, ClusterGroupsToLabelMap(target.ClusterGroups))) {
 				partitionTargets = append(partitionTargets, target)
-				continue targetLoop
 			}
 		}
 
@@ -0,0 +1,39 @@
+package target
+
+import (
+	"fmt"
+	"maps"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+)
+
+// RenderSecretData returns the data of a secret distributed to cluster. It
+// contains the data of the source secret, with the keys of templates replaced
+// by their rendered values. Templates can access the cluster's template context
+// and the source data as strings in .Secret.
+func RenderSecretData(templates map[string]string, data map[string][]byte, cluster *fleet.Cluster) (map[string][]byte, error) {
+	rendered := maps.Clone(data)
+	if len(templates) == 0 {
+		return rendered, nil
+	}
+	if rendered == nil {
+		rendered = make(map[string][]byte, len(templates))
+	}
+
+	secret := make(map[string]any, len(data))
+	for k, v := range data {
+		secret[k] = string(v)
+	}
+	templateContext := templateContext(cluster, templateClusterLabels(cluster))
+	templateContext["Secret"] = secret
+
+	for k, v := range templates {
+		value, err := renderTemplate(v, templateContext)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render template for secret key %q: %w", k, err)
+		}
+		rendered[k] = []byte(value)
+	}
+
+	return rendered, nil
+}
@@ -0,0 +1,77 @@
+package target
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRenderSecretData(t *testing.T) {
+	cluster := &fleet.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "fleet-default",
+			Name:      "prod-1",
+			Labels:    map[string]string{"env": "prod"},
+		},
+		Spec: fleet.ClusterSpec{
+			TemplateValues: &fleet.GenericMap{Data: map[string]any{"region": "eu-west-1"}},
+		},
+	}
+	data := map[string][]byte{
+		"password": []byte("s3cr3t"),
+		"ca.crt":   []byte("cert"),
+	}
+
+	tests := []struct {
+		name      string
+		templates map[string]string
+		want      map[string][]byte
+		wantErr   string
+	}{
+		{
+			name: "no templates",
+			want: data,
+		},
+		{
+			name: "templates add and overwrite keys",
+			templates: map[string]string{
+				"url":      "https://${ .ClusterName }.${ .ClusterValues.region }.example.com",
+				"password": "${ .Secret.password }-${ .ClusterLabels.env }",
+			},
+			want: map[string][]byte{
+				"password": []byte("s3cr3t-prod"),
+				"ca.crt":   []byte("cert"),
+				"url":      []byte("https://prod-1.eu-west-1.example.com"),
+			},
+		},
+		{
+			name:      "missing key",
+			templates: map[string]string{"url": "${ .ClusterValues.zone }"},
+			wantErr:   `failed to render template for secret key "url"`,
+		},
+		{
+			name:      "env is not available",
+			templates: map[string]string{"home": `${ env "HOME" }`},
+			wantErr:   `failed to render template for secret key "home": template: template:1: function "env" not defined`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RenderSecretData(tt.templates, data, cluster)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	// the source data is not modified
+	assert.Equal(t, []byte("s3cr3t"), data["password"])
+}
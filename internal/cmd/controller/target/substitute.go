@@ -0,0 +1,61 @@
+package target
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+)
+
+// addSubstituteVars adds the variables of cluster to the substitute options
+// in opts. Variables set by the user are kept.
+func addSubstituteVars(opts *fleet.BundleDeploymentOptions, cluster *fleet.Cluster) error {
+	if opts.Substitute == nil {
+		return nil
+	}
+
+	vars := map[string]string{
+		"ClusterName":      cluster.Name,
+		"ClusterNamespace": cluster.Namespace,
+	}
+	for k, v := range templateClusterLabels(cluster) {
+		vars["ClusterLabels."+k] = v
+	}
+	if cluster.Spec.TemplateValues != nil {
+		if err := flattenVars(vars, "ClusterValues", cluster.Spec.TemplateValues.Data); err != nil {
+			return fmt.Errorf("failed to add cluster values to substitute variables: %w", err)
+		}
+	}
+
+	opts.Substitute = opts.Substitute.DeepCopy()
+	maps.Copy(vars, opts.Substitute.Vars)
+	opts.Substitute.Vars = vars
+
+	return nil
+}
+
+// flattenVars adds the values to vars, with the dotted path of nested maps
+// as names. Lists are added as JSON.
+func flattenVars(vars map[string]string, prefix string, values map[string]any) error {
+	for k, v := range values {
+		name := prefix + "." + k
+		switch v := v.(type) {
+		case map[string]any:
+			if err := flattenVars(vars, name, v); err != nil {
+				return err
+			}
+		case []any:
+			b, err := json.Marshal(v)
+			if err != nil {
+				return err
+			}
+			vars[name] = string(b)
+		case nil:
+			vars[name] = ""
+		default:
+			vars[name] = fmt.Sprint(v)
+		}
+	}
+	return nil
+}
@@ -55,8 +55,16 @@ type Target struct {
 	DeploymentID  string
 }
 
+// BundleDeploymentAnnotations are the annotations copied from a bundle to
+// its bundledeployments.
+var BundleDeploymentAnnotations = []string{
+	fleet.ChangeDetectedAtAnnotation,
+	fleet.TraceParentAnnotation,
+	fleet.TraceStateAnnotation,
+}
+
 // BundleDeployment returns a new BundleDeployment, it discards annotations, status, etc.
-// The labels are copied from the Bundle.
+// The labels and BundleDeploymentAnnotations are copied from the Bundle.
 func (t *Target) BundleDeployment() *fleet.BundleDeployment {
 	bd := &fleet.BundleDeployment{
 		ObjectMeta: metav1.ObjectMeta{
@@ -67,6 +75,11 @@ func (t *Target) BundleDeployment() *fleet.BundleDeployment {
 		},
 		Spec: t.Deployment.Spec,
 	}
+	for _, k := range BundleDeploymentAnnotations {
+		if v, ok := t.Bundle.Annotations[k]; ok {
+			metav1.SetMetaDataAnnotation(&bd.ObjectMeta, k, v)
+		}
+	}
 	bd.Spec.Paused = t.IsPaused()
 	bd.Spec.OffSchedule = t.Cluster.Status.Scheduled && !t.Cluster.Status.ActiveSchedule
 
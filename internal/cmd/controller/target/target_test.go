@@ -448,7 +448,7 @@ func TestDisablePreProcessFlagEnabled(t *testing.T) {
 		t.Fatal(err.Error())
 	}
 
-	err = preprocessHelmValues(zap.New(), bundle, cluster)
+	err = preprocessHelmValues(zap.New(), bundle, cluster, nil)
 	if err != nil {
 		t.Fatalf("error during cluster processing %v", err)
 	}
@@ -505,7 +505,7 @@ func TestDisablePreProcessFlagDisabled(t *testing.T) {
 		t.Fatal(err.Error())
 	}
 
-	err = preprocessHelmValues(zap.New(), bundle, cluster)
+	err = preprocessHelmValues(zap.New(), bundle, cluster, nil)
 	if err != nil {
 		t.Fatalf("error during cluster processing %v", err)
 	}
@@ -549,7 +549,7 @@ func TestDisablePreProcessFlagMissing(t *testing.T) {
 		t.Fatal(err.Error())
 	}
 
-	err = preprocessHelmValues(zap.New(), bundle, cluster)
+	err = preprocessHelmValues(zap.New(), bundle, cluster, nil)
 	if err != nil {
 		t.Fatalf("error during cluster processing %v", err)
 	}
@@ -65,6 +65,9 @@ const (
 	// ContentNameIndex is the name of the index for the content name label in bundle deployments
 	ContentNameIndex = "metadata.labels." + fleet.ContentNameLabel
 
+	// ContentBlobIndex is the name of the index for the blobs referenced by content resources
+	ContentBlobIndex = "blobs"
+
 	// RepoNameIndex is the name of the index for the gitrepo name in bundles
 	RepoNameIndex = "metadata.labels." + fleet.RepoLabel
 
@@ -74,6 +77,9 @@ const (
 	// BundleDownstreamResourceIndex is the name of the index for downstream resources (secrets and configmaps) in bundles
 	BundleDownstreamResourceIndex = "spec.downstreamResources"
 
+	// BundleDependencyIndex is the name of the index for the bundles a bundle depends on
+	BundleDependencyIndex = "spec.dependsOn"
+
 	// GitRepoClientSecretNameIndex is the name of the index for the client secret name in gitrepos
 	GitRepoClientSecretNameIndex = "spec.clientSecretName" //nolint:gosec // not a credential
 
@@ -111,6 +117,15 @@ type Config struct {
 	// AgentCheckinInterval determines how often agents update their clusters status, defaults to 15m
 	AgentCheckinInterval metav1.Duration `json:"agentCheckinInterval,omitzero"`
 
+	// AgentCredentialRotationInterval determines how often the
+	// credentials agents use to access the upstream cluster are replaced.
+	// Rotation is disabled if zero.
+	AgentCredentialRotationInterval metav1.Duration `json:"agentCredentialRotationInterval,omitzero"`
+
+	// AgentCredentialGracePeriod is how long previous credentials remain
+	// valid after an agent switched to a rotated credential, defaults to 1h.
+	AgentCredentialGracePeriod metav1.Duration `json:"agentCredentialGracePeriod,omitzero"`
+
 	// ManageAgent if present and set to false, no bundles will be created to manage agents
 	ManageAgent *bool `json:"manageAgent,omitempty"`
 
@@ -143,6 +158,10 @@ type Config struct {
 
 	Bootstrap Bootstrap `json:"bootstrap,omitzero"`
 
+	// ClusterFactLabels if set to true, the facts reported by agents are
+	// copied to the cluster labels, prefixed with facts.fleet.cattle.io/.
+	ClusterFactLabels bool `json:"clusterFactLabels,omitempty"`
+
 	// IgnoreClusterRegistrationLabels if set to true, the labels on the cluster registration resource will not be copied to the cluster resource.
 	IgnoreClusterRegistrationLabels bool `json:"ignoreClusterRegistrationLabels,omitempty"`
 
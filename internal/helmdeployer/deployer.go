@@ -8,6 +8,7 @@ import (
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
 	"github.com/rancher/fleet/internal/helmdeployer/helmcache"
+	"github.com/rancher/fleet/internal/helmdeployer/vault"
 	"helm.sh/helm/v4/pkg/action"
 	"helm.sh/helm/v4/pkg/kube"
 	"helm.sh/helm/v4/pkg/storage"
@@ -51,6 +52,7 @@ type Helm struct {
 	defaultNamespace string
 	labelPrefix      string
 	labelSuffix      string
+	vault            *vault.Resolver
 }
 
 // Resources contains information from a helm release
@@ -78,6 +80,7 @@ func New(agentNamespace, defaultNamespace, labelPrefix, labelSuffix string) *Hel
 		defaultNamespace: defaultNamespace,
 		labelPrefix:      labelPrefix,
 		labelSuffix:      labelSuffix,
+		vault:            vault.NewResolver(),
 	}
 }
 
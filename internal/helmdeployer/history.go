@@ -31,6 +31,23 @@ func (h *Helm) EnsureInstalled(bundleID, resourcesID string) (bool, error) {
 	return true, nil
 }
 
+// ChartVersion returns the version of the chart deployed by the release.
+func (h *Helm) ChartVersion(bundleID, resourcesID string) (string, error) {
+	releaseName, version, namespace, err := getReleaseNameVersionAndNamespace(bundleID, resourcesID)
+	if err != nil {
+		return "", err
+	}
+
+	release, err := h.getRelease(releaseName, namespace, version)
+	if err != nil {
+		return "", err
+	}
+	if release.Chart == nil || release.Chart.Metadata == nil {
+		return "", nil
+	}
+	return release.Chart.Metadata.Version, nil
+}
+
 // Resources returns the resources from the helm release history
 func (h *Helm) Resources(bundleID, resourcesID string) (*Resources, error) {
 	releaseName, version, namespace, err := getReleaseNameVersionAndNamespace(bundleID, resourcesID)
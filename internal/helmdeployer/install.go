@@ -3,9 +3,13 @@ package helmdeployer
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"maps"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -19,11 +23,14 @@ import (
 	"helm.sh/helm/v4/pkg/storage/driver"
 
 	"github.com/rancher/fleet/internal/helmdeployer/render"
+	"github.com/rancher/fleet/internal/helmdeployer/vault"
 	"github.com/rancher/fleet/internal/manifest"
+	"github.com/rancher/fleet/internal/resourcepolicy"
 	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/yaml"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
@@ -36,7 +43,16 @@ type dryRunConfig struct {
 }
 
 // Deploy deploys an unpacked content resource with helm. bundleID is the name of the bundledeployment.
-func (h *Helm) Deploy(ctx context.Context, bundleID string, manifest *manifest.Manifest, options fleet.BundleDeploymentOptions) (*releasev1.Release, error) {
+// external are the values read from external stores by ExternalValues. If
+// nil, they are read before deploying.
+func (h *Helm) Deploy(ctx context.Context, bundleID string, manifest *manifest.Manifest, options fleet.BundleDeploymentOptions, external *ExternalValues) (*releasev1.Release, error) {
+	if external == nil {
+		var err error
+		if external, err = h.ExternalValues(ctx, options); err != nil {
+			return nil, err
+		}
+	}
+
 	if options.Helm == nil {
 		options.Helm = &fleet.HelmOptions{}
 	}
@@ -66,17 +82,17 @@ func (h *Helm) Deploy(ctx context.Context, bundleID string, manifest *manifest.M
 		chart.Metadata.Annotations[CommitAnnotation] = manifest.Commit
 	}
 
-	if release, err := h.install(ctx, bundleID, manifest, chart, options, getDryRunConfig(chart, true)); err != nil {
+	if release, err := h.install(ctx, bundleID, manifest, chart, options, external, getDryRunConfig(chart, true)); err != nil {
 		return nil, err
 	} else if h.template {
 		return release, nil
 	}
 
-	return h.install(ctx, bundleID, manifest, chart, options, getDryRunConfig(chart, false))
+	return h.install(ctx, bundleID, manifest, chart, options, external, getDryRunConfig(chart, false))
 }
 
 // install runs helm install or upgrade and supports dry running the action. Will run helm rollback in case of a failed upgrade.
-func (h *Helm) install(ctx context.Context, bundleID string, manifest *manifest.Manifest, chart *chartv2.Chart, options fleet.BundleDeploymentOptions, dryRunCfg dryRunConfig) (*releasev1.Release, error) {
+func (h *Helm) install(ctx context.Context, bundleID string, manifest *manifest.Manifest, chart *chartv2.Chart, options fleet.BundleDeploymentOptions, external *ExternalValues, dryRunCfg dryRunConfig) (*releasev1.Release, error) {
 	logger := log.FromContext(ctx).WithName("helm-deployer").WithName("install").WithValues("commit", manifest.Commit, "dryRun", dryRunCfg.DryRun)
 	timeout, defaultNamespace, releaseName := h.getOpts(bundleID, options)
 
@@ -95,7 +111,7 @@ func (h *Helm) install(ctx context.Context, bundleID string, manifest *manifest.
 		}
 	}
 
-	values, err := h.getValues(ctx, options, defaultNamespace, kubeClient)
+	values, err := h.getValues(ctx, options, defaultNamespace, kubeClient, external)
 	if err != nil {
 		return nil, err
 	}
@@ -127,6 +143,10 @@ func (h *Helm) install(ctx context.Context, bundleID string, manifest *manifest.
 	if err != nil {
 		return nil, err
 	}
+	if pr.vars, err = substituteVars(ctx, options, defaultNamespace, kubeClient); err != nil {
+		return nil, err
+	}
+	pr.allowMissingVars = kubeClient == nil
 
 	if install {
 		return h.runInstall(ctx, cfg, chart, values, releaseName, defaultNamespace, timeout, options, pr, dryRunCfg)
@@ -153,6 +173,12 @@ func (h *Helm) createPostRenderer(cfg *action.Configuration, bundleID string, ma
 		chart:       chart,
 	}
 
+	rules, err := resourcepolicy.New(options.ResourceRules)
+	if err != nil {
+		return nil, err
+	}
+	pr.rules = rules
+
 	if !h.useGlobalCfg {
 		mapper, err := cfg.RESTClientGetter.ToRESTMapper()
 		if err != nil {
@@ -478,7 +504,7 @@ func handleOrphanedRelease(ctx context.Context, cfg *action.Configuration, lastR
 	return false, nil
 }
 
-func (h *Helm) getValues(ctx context.Context, options fleet.BundleDeploymentOptions, defaultNamespace string, kubeClient kubernetes.Interface) (map[string]any, error) {
+func (h *Helm) getValues(ctx context.Context, options fleet.BundleDeploymentOptions, defaultNamespace string, kubeClient kubernetes.Interface, external *ExternalValues) (map[string]any, error) {
 	if options.Helm == nil {
 		return nil, nil
 	}
@@ -494,7 +520,7 @@ func (h *Helm) getValues(ctx context.Context, options fleet.BundleDeploymentOpti
 	}
 	// kubeClient is nil in template mode; skip the cluster lookups in that case.
 	if kubeClient != nil {
-		for _, valuesFrom := range options.Helm.ValuesFrom {
+		for i, valuesFrom := range options.Helm.ValuesFrom {
 			var tempValues map[string]any
 			if valuesFrom.ConfigMapKeyRef != nil {
 				name := valuesFrom.ConfigMapKeyRef.Name
@@ -547,12 +573,128 @@ func (h *Helm) getValues(ctx context.Context, options fleet.BundleDeploymentOpti
 			if tempValues != nil {
 				values = mergeValues(values, tempValues)
 			}
+
+			if valuesFrom.VaultRef != nil {
+				vaultValues, ok := external.vaultValues(i)
+				if !ok {
+					return nil, fmt.Errorf("values of vault source %s were not read", valuesFrom.VaultRef.Path)
+				}
+				values = mergeValues(values, vaultValues)
+			}
 		}
 	}
 
 	return values, nil
 }
 
+// ExternalValues are the values of a bundle deployment, which are read from
+// external stores, like Vault. They are read once per deployment and passed
+// to Deploy, so that their hash matches the deployed values.
+type ExternalValues struct {
+	// Hash of the values, empty if options do not reference external
+	// values.
+	Hash string
+	// Refresh is the interval after which the values should be read again.
+	Refresh time.Duration
+
+	// vault holds the values of the Vault sources by their index in
+	// ValuesFrom.
+	vault map[int]map[string]any
+}
+
+func (e *ExternalValues) vaultValues(i int) (map[string]any, bool) {
+	if e == nil {
+		return nil, false
+	}
+	values, ok := e.vault[i]
+	return values, ok
+}
+
+// ExternalValues reads the values of options from external stores, like
+// Vault. The values are cached, so this is cheap to call on every reconcile.
+func (h *Helm) ExternalValues(ctx context.Context, options fleet.BundleDeploymentOptions) (*ExternalValues, error) {
+	external := &ExternalValues{}
+	if options.Helm == nil || h.template {
+		return external, nil
+	}
+	if !slices.ContainsFunc(options.Helm.ValuesFrom, func(v fleet.ValuesFrom) bool { return v.VaultRef != nil }) {
+		return external, nil
+	}
+
+	// Build the client like getCfg, but without the helm configuration,
+	// which would query the API server for its capabilities.
+	getter := h.getter
+	serviceAccountNamespace, serviceAccountName, err := h.getServiceAccount(ctx, options.ServiceAccount)
+	if err != nil {
+		return nil, err
+	}
+	if serviceAccountName != "" {
+		getter, err = newImpersonatingGetter(serviceAccountNamespace, serviceAccountName, h.getter)
+		if err != nil {
+			return nil, err
+		}
+	}
+	kubeClient, err := kubeClientFromGetter(getter)
+	if err != nil {
+		return nil, err
+	}
+	_, defaultNamespace, _ := h.getOpts("", options)
+	creds, err := h.vaultCredentials(ctx, options, kubeClient, defaultNamespace)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := sha256.New()
+	external.vault = map[int]map[string]any{}
+	for i, valuesFrom := range options.Helm.ValuesFrom {
+		src := valuesFrom.VaultRef
+		if src == nil {
+			continue
+		}
+		values, err := h.vault.Resolve(ctx, src, creds)
+		if err != nil {
+			return nil, err
+		}
+		external.vault[i] = values
+
+		// json.Marshal sorts map keys, which makes the hash stable
+		b, err := json.Marshal(values)
+		if err != nil {
+			return nil, err
+		}
+		hash.Write(b)
+
+		if d := vault.RefreshInterval(src); external.Refresh == 0 || d < external.Refresh {
+			external.Refresh = d
+		}
+	}
+	external.Hash = hex.EncodeToString(hash.Sum(nil))
+
+	return external, nil
+}
+
+// vaultCredentials returns the credentials to authenticate to Vault with.
+// Secrets are read with kubeClient from the release namespace, tokens are
+// only requested for the bundle deployment's service account, never for the
+// agent's own.
+func (h *Helm) vaultCredentials(ctx context.Context, options fleet.BundleDeploymentOptions, kubeClient kubernetes.Interface, defaultNamespace string) (vault.Credentials, error) {
+	creds := vault.Credentials{Client: kubeClient, Namespace: defaultNamespace}
+	serviceAccountNamespace, serviceAccountName, err := h.getServiceAccount(ctx, options.ServiceAccount)
+	if err != nil {
+		return creds, err
+	}
+	if serviceAccountName == "" {
+		return creds, nil
+	}
+	tokenClient, err := kubeClientFromGetter(h.getter)
+	if err != nil {
+		return creds, err
+	}
+	creds.ServiceAccount = types.NamespacedName{Namespace: serviceAccountNamespace, Name: serviceAccountName}
+	creds.TokenClient = tokenClient
+	return creds, nil
+}
+
 // restConfigGetter produces a *rest.Config. Both
 // genericclioptions.RESTClientGetter and action.RESTClientGetter satisfy it.
 type restConfigGetter interface {
@@ -581,7 +581,7 @@ func TestValuesFromUsesDefaultNamespaceWhenResourceCopiedDownstream(t *testing.T
 		DownstreamResources: []fleet.DownstreamResource{{Kind: "ConfigMap", Name: "cm-down"}, {Kind: "Secret", Name: "sec-down"}},
 	}
 
-	vals, err := h.getValues(context.TODO(), opts, defaultNS, kubeClient)
+	vals, err := h.getValues(context.TODO(), opts, defaultNS, kubeClient, nil)
 	r.NoError(err)
 
 	// configmap and secret data should have been read from defaultNS
@@ -614,11 +614,43 @@ func TestValuesFromUsesProvidedNamespaceWhenNotCopiedDownstream(t *testing.T) {
 		DownstreamResources: []fleet.DownstreamResource{{Kind: "ConfigMap", Name: "some-other"}},
 	}
 
-	vals, err := h.getValues(context.TODO(), opts, defaultNS, kubeClient)
+	vals, err := h.getValues(context.TODO(), opts, defaultNS, kubeClient, nil)
 	r.NoError(err)
 	a.Equal("cmProvided", vals["cmVal"])
 }
 
+func TestValuesFromVaultUsesResolvedValues(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	cm := corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "cm", Namespace: "default"},
+		Data:       map[string]string{DefaultKey: "replicas: 1\nimage: app"},
+	}
+	kubeClient := kubernetesfake.NewSimpleClientset(&cm)
+	h := &Helm{template: false}
+
+	opts := fleet.BundleDeploymentOptions{
+		Helm: &fleet.HelmOptions{
+			ValuesFrom: []fleet.ValuesFrom{
+				{ConfigMapKeyRef: &fleet.ConfigMapKeySelector{LocalObjectReference: fleet.LocalObjectReference{Name: "cm"}}},
+				// the address is unreachable, the values must not be read again
+				{VaultRef: &fleet.VaultValuesSource{Address: "http://127.0.0.1:1", Path: "app"}},
+			},
+		},
+	}
+
+	// the values hashed by ExternalValues are deployed
+	external := &ExternalValues{Hash: "hash", vault: map[int]map[string]any{1: {"replicas": 3}}}
+	vals, err := h.getValues(context.TODO(), opts, "default", kubeClient, external)
+	r.NoError(err)
+	a.Equal(3, vals["replicas"])
+	a.Equal("app", vals["image"])
+
+	_, err = h.getValues(context.TODO(), opts, "default", kubeClient, nil)
+	r.ErrorContains(err, "values of vault source app were not read")
+}
+
 func TestInstallActionCorrectDriftForce(t *testing.T) {
 	a := assert.New(t)
 	h := &Helm{}
@@ -0,0 +1,110 @@
+package helmdeployer
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/client-go/kubernetes/scheme"
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+// applyPatches applies the patches in order to the objects matching their
+// targets.
+func applyPatches(objs []runtime.Object, patches []fleet.ResourcePatch) ([]runtime.Object, error) {
+	for i, patch := range patches {
+		data, err := sigsyaml.YAMLToJSON([]byte(patch.Patch))
+		if err != nil {
+			return nil, fmt.Errorf("patches[%d]: invalid patch: %w", i, err)
+		}
+
+		for j, obj := range objs {
+			m, err := meta.Accessor(obj)
+			if err != nil {
+				return nil, err
+			}
+			if !patchTargetMatches(patch.Target, obj, m) {
+				continue
+			}
+
+			patched, err := applyPatch(obj, patch.Type, data)
+			if err != nil {
+				return nil, fmt.Errorf("patches[%d]: failed to patch %s %s: %w",
+					i, obj.GetObjectKind().GroupVersionKind().Kind, m.GetName(), err)
+			}
+			objs[j] = patched
+		}
+	}
+
+	return objs, nil
+}
+
+// patchTargetMatches returns true if obj is selected by target.
+func patchTargetMatches(target fleet.PatchTarget, obj runtime.Object, m metav1.Object) bool {
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	if target.Group != "" && target.Group != gvk.Group && (target.Group != "core" || gvk.Group != "") {
+		return false
+	}
+	if target.Version != "" && target.Version != gvk.Version {
+		return false
+	}
+	if target.Kind != "" && target.Kind != gvk.Kind {
+		return false
+	}
+	if target.Namespace != "" && target.Namespace != m.GetNamespace() {
+		return false
+	}
+	if target.Name != "" {
+		if ok, err := path.Match(target.Name, m.GetName()); err != nil || !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// applyPatch applies the JSON patch document of the given type to obj.
+// Strategic-merge patches of kinds without a Go type, e.g. custom
+// resources, are applied as JSON merge patches.
+func applyPatch(obj runtime.Object, patchType string, patch []byte) (runtime.Object, error) {
+	original, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	switch patchType {
+	case fleet.ResourcePatchJSON6902:
+		ops, err := jsonpatch.DecodePatch(patch)
+		if err != nil {
+			return nil, err
+		}
+		if data, err = ops.Apply(original); err != nil {
+			return nil, err
+		}
+	case "", fleet.ResourcePatchStrategicMerge:
+		if typed, err := scheme.Scheme.New(obj.GetObjectKind().GroupVersionKind()); err == nil {
+			data, err = strategicpatch.StrategicMergePatch(original, patch, typed)
+			if err != nil {
+				return nil, err
+			}
+		} else if data, err = jsonpatch.MergePatch(original, patch); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown patch type %q", patchType)
+	}
+
+	result := &unstructured.Unstructured{}
+	if err := result.UnmarshalJSON(data); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -17,11 +17,14 @@ import (
 	"github.com/rancher/fleet/internal/helmdeployer/kustomize"
 	"github.com/rancher/fleet/internal/helmdeployer/rawyaml"
 	"github.com/rancher/fleet/internal/manifest"
+	"github.com/rancher/fleet/internal/resourcepolicy"
 	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
 
 	"github.com/rancher/wrangler/v3/pkg/yaml"
 
 	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
 	sigsyaml "sigs.k8s.io/yaml"
 )
@@ -146,6 +149,14 @@ type postRender struct {
 	chart       *chartv2.Chart
 	mapper      meta.RESTMapper
 	opts        fleet.BundleDeploymentOptions
+	// rules are the resource rules from Policy, which every object must
+	// satisfy.
+	rules *resourcepolicy.Evaluator
+	// vars are the variables for opts.Substitute.
+	vars map[string]string
+	// allowMissingVars keeps references to missing variables, instead of
+	// failing, because VarsFrom can't be read in template mode.
+	allowMissingVars bool
 }
 
 func (p *postRender) Run(renderedManifests *bytes.Buffer) (modifiedManifests *bytes.Buffer, err error) {
@@ -193,6 +204,20 @@ func (p *postRender) Run(renderedManifests *bytes.Buffer) (modifiedManifests *by
 	}
 	objs = append(objs, yamlObjs...)
 
+	if p.opts.Substitute != nil {
+		objs, err = substitute(objs, p.vars, p.allowMissingVars)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(p.opts.Patches) > 0 {
+		objs, err = applyPatches(objs, p.opts.Patches)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	setID := desiredset.GetSetID(p.bundleID, p.labelPrefix, p.labelSuffix)
 	labels, annotations, err := desiredset.GetLabelsAndAnnotations(setID)
 	if err != nil {
@@ -232,6 +257,52 @@ func (p *postRender) Run(renderedManifests *bytes.Buffer) (modifiedManifests *by
 		}
 	}
 
+	if err := p.checkRules(objs); err != nil {
+		return nil, err
+	}
+
 	data, err = yaml.ToBytes(objs)
 	return bytes.NewBuffer(data), err
 }
+
+// checkRules evaluates the resource rules against all objects and returns a
+// *resourcepolicy.ViolationError listing every violation.
+func (p *postRender) checkRules(objs []runtime.Object) error {
+	if p.rules.Empty() {
+		return nil
+	}
+
+	var violations []resourcepolicy.Violation
+	for _, obj := range objs {
+		clusterScoped, err := p.isClusterScoped(obj.GetObjectKind().GroupVersionKind())
+		if err != nil {
+			return err
+		}
+		v, err := p.rules.Evaluate(obj, clusterScoped)
+		if err != nil {
+			return err
+		}
+		violations = append(violations, v...)
+	}
+
+	if len(violations) > 0 {
+		return &resourcepolicy.ViolationError{Violations: violations}
+	}
+	return nil
+}
+
+// isClusterScoped uses the mapper to look up the scope of a kind. Without a
+// mapper, or for kinds not yet known to the cluster, e.g. custom resources
+// whose CRD is part of the same bundle, it falls back to the built-in kinds.
+func (p *postRender) isClusterScoped(gvk schema.GroupVersionKind) (bool, error) {
+	if p.mapper != nil {
+		mapping, err := p.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err == nil {
+			return mapping.Scope.Name() == meta.RESTScopeNameRoot, nil
+		}
+		if !meta.IsNoMatchError(err) {
+			return false, err
+		}
+	}
+	return resourcepolicy.IsBuiltinClusterScoped(gvk.GroupKind()), nil
+}
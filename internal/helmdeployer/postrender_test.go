@@ -2,9 +2,12 @@ package helmdeployer
 
 import (
 	"bytes"
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/rancher/fleet/internal/manifest"
+	"github.com/rancher/fleet/internal/resourcepolicy"
 	"github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
 	"github.com/rancher/wrangler/v3/pkg/yaml"
 
@@ -293,3 +296,175 @@ func objectKinds(objs []kruntime.Object) []string {
 	}
 	return kinds
 }
+
+func TestPostRenderer_Run_ResourceRules(t *testing.T) {
+	input := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: allowed
+  labels:
+    team: a
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: forbidden
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: admin
+  labels:
+    team: a
+`
+	rules, err := resourcepolicy.New([]v1alpha1.ResourceRule{
+		{Name: "team-label", Expression: "has(object.metadata.labels) && 'team' in object.metadata.labels"},
+		{Name: "namespaced", Expression: "!clusterScoped", Message: "cluster-scoped resources are not allowed"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	pr := postRender{
+		manifest: &manifest.Manifest{Resources: []v1alpha1.BundleResource{}},
+		chart:    &chartv2.Chart{},
+		rules:    rules,
+	}
+
+	_, err = pr.Run(bytes.NewBufferString(input))
+	var violationErr *resourcepolicy.ViolationError
+	if !errors.As(err, &violationErr) {
+		t.Fatalf("expected a violation error, got %v", err)
+	}
+
+	var got []string
+	for _, v := range violationErr.Violations {
+		got = append(got, v.Kind+"/"+v.Name+":"+v.Rule)
+	}
+	want := []string{"Secret/forbidden:team-label", "ClusterRole/admin:namespaced"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected violations (-want +got):\n%s", diff)
+	}
+}
+
+func TestPostRenderer_Run_Patches(t *testing.T) {
+	input := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web-frontend
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        image: web:v1
+      - name: sidecar
+        image: proxy:v1
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: db
+spec:
+  replicas: 1
+---
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: web-widget
+spec:
+  size: small
+  color: red
+`
+	pr := postRender{
+		manifest: &manifest.Manifest{Resources: []v1alpha1.BundleResource{}},
+		chart:    &chartv2.Chart{},
+		opts: v1alpha1.BundleDeploymentOptions{Patches: []v1alpha1.ResourcePatch{
+			{
+				Target: v1alpha1.PatchTarget{Group: "apps", Kind: "Deployment", Name: "web-*"},
+				Patch:  "spec:\n  template:\n    spec:\n      containers:\n      - name: web\n        image: web:v2\n",
+			},
+			{
+				Target: v1alpha1.PatchTarget{Kind: "Deployment", Name: "db"},
+				Type:   v1alpha1.ResourcePatchJSON6902,
+				Patch:  `[{"op": "replace", "path": "/spec/replicas", "value": 3}]`,
+			},
+			{
+				// kinds without a Go type use a JSON merge patch
+				Target: v1alpha1.PatchTarget{Kind: "Widget"},
+				Patch:  "spec:\n  size: large\n  color: null\n",
+			},
+			{
+				Target: v1alpha1.PatchTarget{Group: "core", Kind: "Deployment"},
+				Patch:  "metadata:\n  labels:\n    unexpected: match\n",
+			},
+		}},
+	}
+
+	out, err := pr.Run(bytes.NewBufferString(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	objs, err := yaml.ToObjects(out)
+	if err != nil {
+		t.Fatalf("unexpected error parsing output: %v", err)
+	}
+	if len(objs) != 3 {
+		t.Fatalf("expected 3 objects, got %d", len(objs))
+	}
+
+	got := map[string]map[string]any{}
+	for _, obj := range objs {
+		m, err := kruntime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got[mustAccessor(t, obj).GetName()] = m
+		if labels := mustAccessor(t, obj).GetLabels(); labels["unexpected"] != "" {
+			t.Errorf("patch for core group matched %s", mustAccessor(t, obj).GetName())
+		}
+	}
+
+	// strategic-merge patches merge lists by their merge key
+	wantContainers := []any{
+		map[string]any{"name": "web", "image": "web:v2"},
+		map[string]any{"name": "sidecar", "image": "proxy:v1"},
+	}
+	containers := got["web-frontend"]["spec"].(map[string]any)["template"].(map[string]any)["spec"].(map[string]any)["containers"]
+	if diff := cmp.Diff(wantContainers, containers); diff != "" {
+		t.Errorf("containers mismatch (-want +got):\n%s", diff)
+	}
+	if replicas := got["db"]["spec"].(map[string]any)["replicas"]; replicas != int64(3) {
+		t.Errorf("expected 3 replicas, got %v", replicas)
+	}
+	if diff := cmp.Diff(map[string]any{"size": "large"}, got["web-widget"]["spec"]); diff != "" {
+		t.Errorf("widget spec mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestPostRenderer_Run_InvalidPatch(t *testing.T) {
+	input := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cm\n"
+	pr := postRender{
+		manifest: &manifest.Manifest{Resources: []v1alpha1.BundleResource{}},
+		chart:    &chartv2.Chart{},
+		opts: v1alpha1.BundleDeploymentOptions{Patches: []v1alpha1.ResourcePatch{{
+			Target: v1alpha1.PatchTarget{Kind: "ConfigMap"},
+			Type:   v1alpha1.ResourcePatchJSON6902,
+			Patch:  `[{"op": "replace", "path": "/data/missing", "value": "x"}]`,
+		}}},
+	}
+
+	_, err := pr.Run(bytes.NewBufferString(input))
+	if err == nil || !strings.Contains(err.Error(), "patches[0]: failed to patch ConfigMap cm") {
+		t.Errorf("expected patch error, got %v", err)
+	}
+}
+
+func mustAccessor(t *testing.T, obj kruntime.Object) metav1.Object {
+	t.Helper()
+	m, err := meta.Accessor(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
@@ -83,7 +83,10 @@ func manifests(m *manifest.Manifest) (result []fleet.BundleResource) {
 
 outer:
 	for _, resource := range m.Resources {
-		if fleetyaml.IsFleetYaml(resource.Name) {
+		if fleetyaml.IsFleetYaml(resource.Name) ||
+			strings.HasPrefix(resource.Name, fleet.BundleSourcesDir+"/") {
+			// files of sources are only deployed if referenced, e.g. by
+			// a kustomization
 			continue
 		}
 		if !strings.HasSuffix(resource.Name, ".yaml") &&
@@ -0,0 +1,130 @@
+package helmdeployer
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+)
+
+// substituteRegexp matches ${var}, ${var:=default} and the escaped $${var}.
+var substituteRegexp = regexp.MustCompile(`\$(\$?)\{([A-Za-z_][A-Za-z0-9_./-]*)(?::=([^}]*))?\}`)
+
+// substituteVars returns the variables for substitution. Variables from
+// VarsFrom are read with kubeClient, which is nil in template mode.
+func substituteVars(ctx context.Context, options fleet.BundleDeploymentOptions, defaultNamespace string, kubeClient kubernetes.Interface) (map[string]string, error) {
+	if options.Substitute == nil {
+		return nil, nil
+	}
+
+	vars := map[string]string{}
+	if kubeClient != nil {
+		for _, from := range options.Substitute.VarsFrom {
+			namespace := from.Namespace
+			if namespace == "" || isInDownstreamResources(from.Name, from.Kind, options) {
+				namespace = defaultNamespace
+			}
+
+			var err error
+			switch from.Kind {
+			case "ConfigMap":
+				var configMap *corev1.ConfigMap
+				if configMap, err = kubeClient.CoreV1().ConfigMaps(namespace).Get(ctx, from.Name, metav1.GetOptions{}); err == nil {
+					maps.Copy(vars, configMap.Data)
+				}
+			case "Secret":
+				var secret *corev1.Secret
+				if secret, err = kubeClient.CoreV1().Secrets(namespace).Get(ctx, from.Name, metav1.GetOptions{}); err == nil {
+					for k, v := range secret.Data {
+						vars[k] = string(v)
+					}
+				}
+			default:
+				return nil, fmt.Errorf("substitute: unknown kind %q in varsFrom", from.Kind)
+			}
+			if apierrors.IsNotFound(err) && from.Optional {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("substitute: failed to read variables from %s %s/%s: %w", from.Kind, namespace, from.Name, err)
+			}
+		}
+	}
+	maps.Copy(vars, options.Substitute.Vars)
+
+	return vars, nil
+}
+
+// substitute replaces the variable references in the string values of the
+// objects. Values are not parsed, so they can't change the structure of an
+// object or the type of a field. Unless allowMissing is true, it returns an
+// error listing all variables which are referenced without a default, but
+// not set.
+func substitute(objs []runtime.Object, vars map[string]string, allowMissing bool) ([]runtime.Object, error) {
+	missing := map[string]bool{}
+	replace := func(s string) string {
+		return substituteRegexp.ReplaceAllStringFunc(s, func(ref string) string {
+			m := substituteRegexp.FindStringSubmatchIndex(ref)
+			if m[3] > m[2] {
+				// escaped reference, drop the leading '$'
+				return ref[1:]
+			}
+			name := ref[m[4]:m[5]]
+			if v := vars[name]; v != "" {
+				return v
+			}
+			if m[6] >= 0 {
+				return ref[m[6]:m[7]]
+			}
+			if _, ok := vars[name]; ok {
+				return ""
+			}
+			if !allowMissing {
+				missing[name] = true
+			}
+			return ref
+		})
+	}
+
+	for i, obj := range objs {
+		data, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			return nil, err
+		}
+		objs[i] = &unstructured.Unstructured{Object: substituteValue(data, replace).(map[string]any)}
+	}
+
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("substitute: missing variables: %s", strings.Join(slices.Sorted(maps.Keys(missing)), ", "))
+	}
+	return objs, nil
+}
+
+// substituteValue returns v, with replace applied to all string values in
+// maps and lists. Keys are not changed.
+func substituteValue(v any, replace func(string) string) any {
+	switch v := v.(type) {
+	case string:
+		return replace(v)
+	case map[string]any:
+		for k, e := range v {
+			v[k] = substituteValue(e, replace)
+		}
+	case []any:
+		for i, e := range v {
+			v[i] = substituteValue(e, replace)
+		}
+	}
+	return v
+}
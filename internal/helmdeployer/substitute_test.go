@@ -0,0 +1,160 @@
+package helmdeployer
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/rancher/fleet/internal/manifest"
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/wrangler/v3/pkg/yaml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	chartv2 "helm.sh/helm/v4/pkg/chart/v2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestSubstituteVars(t *testing.T) {
+	kubeClient := kubernetesfake.NewSimpleClientset(
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "vars", Namespace: "app"},
+			Data:       map[string]string{"domain": "example.com", "replicas": "2"},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "creds", Namespace: "other"},
+			Data:       map[string][]byte{"password": []byte("s3cr3t")},
+		},
+	)
+	opts := fleet.BundleDeploymentOptions{Substitute: &fleet.SubstituteOptions{
+		Vars: map[string]string{"replicas": "3", "ClusterName": "prod-1"},
+		VarsFrom: []fleet.SubstituteFrom{
+			{Kind: "ConfigMap", Name: "vars"},
+			{Kind: "Secret", Name: "creds", Namespace: "other"},
+			{Kind: "Secret", Name: "missing", Optional: true},
+		},
+	}}
+
+	vars, err := substituteVars(context.TODO(), opts, "app", kubeClient)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"domain":      "example.com",
+		"replicas":    "3",
+		"password":    "s3cr3t",
+		"ClusterName": "prod-1",
+	}, vars)
+
+	opts.Substitute.VarsFrom[2].Optional = false
+	_, err = substituteVars(context.TODO(), opts, "app", kubeClient)
+	require.ErrorContains(t, err, "substitute: failed to read variables from Secret app/missing")
+
+	// without a client, e.g. in template mode, only vars are used
+	vars, err = substituteVars(context.TODO(), opts, "app", nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"replicas": "3", "ClusterName": "prod-1"}, vars)
+}
+
+func TestPostRenderer_Run_Substitute(t *testing.T) {
+	input := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  labels:
+    region: ${ClusterLabels.region}
+    tier: ${tier:=frontend}
+    empty: "${empty}"
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+      - name: web
+        image: web:${version:=v1}
+        args: ["--domain=${ClusterName}.example.com", "--literal=$${ClusterName}"]
+`
+	pr := postRender{
+		manifest: &manifest.Manifest{Resources: []fleet.BundleResource{}},
+		chart:    &chartv2.Chart{},
+		opts:     fleet.BundleDeploymentOptions{Substitute: &fleet.SubstituteOptions{}},
+		vars: map[string]string{
+			"ClusterName":          "prod-1",
+			"ClusterLabels.region": "eu",
+			"version":              "",
+			"empty":                "",
+		},
+	}
+
+	out, err := pr.Run(bytes.NewBufferString(input))
+	require.NoError(t, err)
+	objs, err := yaml.ToObjects(out)
+	require.NoError(t, err)
+	require.Len(t, objs, 1)
+	obj := objs[0].(*unstructured.Unstructured)
+
+	assert.Equal(t, "eu", obj.GetLabels()["region"])
+	assert.Equal(t, "frontend", obj.GetLabels()["tier"])
+	assert.Equal(t, "", obj.GetLabels()["empty"])
+	replicas, _, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+	assert.Equal(t, int64(3), replicas)
+	containers, _, _ := unstructured.NestedSlice(obj.Object, "spec", "template", "spec", "containers")
+	container := containers[0].(map[string]any)
+	assert.Equal(t, "web:v1", container["image"])
+	assert.Equal(t, []any{"--domain=prod-1.example.com", "--literal=${ClusterName}"}, container["args"])
+
+	// missing variables are reported
+	pr.vars = map[string]string{}
+	_, err = pr.Run(bytes.NewBufferString(input))
+	require.EqualError(t, err, "substitute: missing variables: ClusterLabels.region, ClusterName, empty")
+
+	// unless in template mode
+	pr.allowMissingVars = true
+	_, err = pr.Run(bytes.NewBufferString(input))
+	require.NoError(t, err)
+}
+
+func TestSubstitute_Values(t *testing.T) {
+	obj := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]any{"name": "${name}"},
+		"data": map[string]any{
+			"cert":     "${cert}",
+			"colon":    "${colon}",
+			"version":  "${version}",
+			"enabled":  "${enabled}",
+			"mode":     "${mode}",
+			"embedded": "v${version}: ${colon}",
+			"${name}":  "keys are kept",
+		},
+		"list": []any{"${enabled}", int64(1), true},
+	}}
+	vars := map[string]string{
+		"name":    "cm",
+		"cert":    "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n",
+		"colon":   "key: value # comment",
+		"version": "1.20",
+		"enabled": "true",
+		"mode":    "0123",
+	}
+
+	objs, err := substitute([]runtime.Object{obj}, vars, false)
+	require.NoError(t, err)
+	require.Len(t, objs, 1)
+	result := objs[0].(*unstructured.Unstructured)
+
+	assert.Equal(t, "cm", result.GetName())
+	assert.Equal(t, map[string]any{
+		"cert":     "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n",
+		"colon":    "key: value # comment",
+		"version":  "1.20",
+		"enabled":  "true",
+		"mode":     "0123",
+		"embedded": "v1.20: key: value # comment",
+		"${name}":  "keys are kept",
+	}, result.Object["data"])
+	assert.Equal(t, []any{"true", int64(1), true}, result.Object["list"])
+}
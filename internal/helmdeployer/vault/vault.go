@@ -0,0 +1,367 @@
+// Package vault reads helm values from a Vault-compatible KV secrets engine.
+package vault
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/ptr"
+)
+
+const (
+	DefaultMount           = "secret"
+	DefaultKVVersion       = 2
+	DefaultRefreshInterval = 5 * time.Minute
+	DefaultAudience        = "vault"
+
+	// tokenExpirationSeconds is the lifetime of service account tokens
+	// requested for the kubernetes auth method. They are only used to log in.
+	tokenExpirationSeconds = 10 * 60
+)
+
+// RefreshInterval returns the interval at which the values of src are read again.
+func RefreshInterval(src *fleet.VaultValuesSource) time.Duration {
+	if src.RefreshInterval != nil && src.RefreshInterval.Duration > 0 {
+		return src.RefreshInterval.Duration
+	}
+	return DefaultRefreshInterval
+}
+
+// Credentials determine which Kubernetes credentials are used to
+// authenticate to Vault.
+type Credentials struct {
+	// Client reads the secrets referenced by a source.
+	Client kubernetes.Interface
+	// Namespace of the helm release. Secrets are only read from this
+	// namespace.
+	Namespace string
+	// ServiceAccount of the bundle deployment. A token for it is requested
+	// for the kubernetes auth method, if no secret is referenced.
+	ServiceAccount types.NamespacedName
+	// TokenClient requests the tokens for ServiceAccount.
+	TokenClient kubernetes.Interface
+}
+
+type entry struct {
+	values  map[string]any
+	expires time.Time
+}
+
+// Resolver reads values from Vault and caches them for the refresh interval
+// of their source, so that frequent reconciles do not hit the Vault server.
+type Resolver struct {
+	mu    sync.Mutex
+	cache map[string]entry
+	now   func() time.Time
+}
+
+// NewResolver returns a resolver with an empty cache.
+func NewResolver() *Resolver {
+	return &Resolver{
+		cache: map[string]entry{},
+		now:   time.Now,
+	}
+}
+
+// Resolve returns the values of src, authenticating with creds.
+func (r *Resolver) Resolve(ctx context.Context, src *fleet.VaultValuesSource, creds Credentials) (map[string]any, error) {
+	key, err := cacheKey(src, creds)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	e, ok := r.cache[key]
+	r.mu.Unlock()
+	if ok && r.now().Before(e.expires) {
+		return e.values, nil
+	}
+
+	values, err := r.read(ctx, src, creds)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	now := r.now()
+	// Sources of deleted bundle deployments are never read again, evict
+	// all expired entries.
+	for k, e := range r.cache {
+		if !now.Before(e.expires) {
+			delete(r.cache, k)
+		}
+	}
+	r.cache[key] = entry{values: values, expires: now.Add(RefreshInterval(src))}
+	r.mu.Unlock()
+
+	return values, nil
+}
+
+func cacheKey(src *fleet.VaultValuesSource, creds Credentials) (string, error) {
+	b, err := json.Marshal(struct {
+		Source         *fleet.VaultValuesSource
+		Namespace      string
+		ServiceAccount types.NamespacedName
+	}{src, creds.Namespace, creds.ServiceAccount})
+	return string(b), err
+}
+
+func (r *Resolver) read(ctx context.Context, src *fleet.VaultValuesSource, creds Credentials) (map[string]any, error) {
+	if src.Address == "" {
+		return nil, errors.New("vault address is required")
+	}
+	if src.Path == "" {
+		return nil, errors.New("vault secret path is required")
+	}
+
+	httpClient, err := newHTTPClient(src.CABundle)
+	if err != nil {
+		return nil, err
+	}
+	c := &client{http: httpClient, address: strings.TrimSuffix(src.Address, "/")}
+
+	if err := login(ctx, c, src.Auth, creds); err != nil {
+		return nil, fmt.Errorf("failed to authenticate to vault at %s: %w", src.Address, err)
+	}
+
+	data, err := c.readKV(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+
+	if src.Key == "" {
+		return data, nil
+	}
+	raw, ok := data[src.Key]
+	if !ok {
+		return nil, fmt.Errorf("key %s is missing from vault secret %s, can't use it in valuesFrom", src.Key, src.Path)
+	}
+	s, ok := raw.(string)
+	if !ok {
+		return nil, fmt.Errorf("key %s of vault secret %s is not a string, can't use it in valuesFrom", src.Key, src.Path)
+	}
+	var values map[string]any
+	if err := yaml.NewYAMLToJSONDecoder(strings.NewReader(s)).Decode(&values); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to decode values from key %s of vault secret %s: %w", src.Key, src.Path, err)
+	}
+	return values, nil
+}
+
+func login(ctx context.Context, c *client, auth fleet.VaultAuth, creds Credentials) error {
+	mountPath := auth.MountPath
+	if mountPath == "" {
+		mountPath = auth.Method
+	}
+	mountPath = strings.Trim(mountPath, "/")
+
+	secret := func(keys ...string) (map[string]string, error) {
+		if auth.SecretName == "" {
+			return nil, fmt.Errorf("secretName is required for the %s auth method", auth.Method)
+		}
+		ns := creds.Namespace
+		s, err := creds.Client.CoreV1().Secrets(ns).Get(ctx, auth.SecretName, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		result := map[string]string{}
+		for _, k := range keys {
+			v, ok := s.Data[k]
+			if !ok {
+				return nil, fmt.Errorf("key %s is missing from secret %s/%s", k, ns, auth.SecretName)
+			}
+			result[k] = string(v)
+		}
+		return result, nil
+	}
+
+	switch auth.Method {
+	case "token":
+		s, err := secret("token")
+		if err != nil {
+			return err
+		}
+		c.token = strings.TrimSpace(s["token"])
+		return nil
+	case "kubernetes":
+		if auth.Role == "" {
+			return errors.New("role is required for the kubernetes auth method")
+		}
+		var jwt string
+		if auth.SecretName != "" {
+			s, err := secret("token")
+			if err != nil {
+				return err
+			}
+			jwt = s["token"]
+		} else {
+			t, err := serviceAccountToken(ctx, auth, creds)
+			if err != nil {
+				return err
+			}
+			jwt = t
+		}
+		return c.login(ctx, mountPath, map[string]string{"role": auth.Role, "jwt": strings.TrimSpace(jwt)})
+	case "approle":
+		s, err := secret("role_id", "secret_id")
+		if err != nil {
+			return err
+		}
+		return c.login(ctx, mountPath, s)
+	default:
+		return fmt.Errorf("unsupported vault auth method %q", auth.Method)
+	}
+}
+
+// serviceAccountToken requests a short-lived token for the bundle
+// deployment's service account, bound to the Vault audience. The agent's own
+// token is never sent to Vault.
+func serviceAccountToken(ctx context.Context, auth fleet.VaultAuth, creds Credentials) (string, error) {
+	sa := creds.ServiceAccount
+	if sa.Name == "" || creds.TokenClient == nil {
+		return "", errors.New("the kubernetes auth method requires a secretName or a service account for the bundle")
+	}
+	audience := auth.Audience
+	if audience == "" {
+		audience = DefaultAudience
+	}
+	tr, err := creds.TokenClient.CoreV1().ServiceAccounts(sa.Namespace).CreateToken(ctx, sa.Name, &authenticationv1.TokenRequest{
+		Spec: authenticationv1.TokenRequestSpec{
+			Audiences:         []string{audience},
+			ExpirationSeconds: ptr.To[int64](tokenExpirationSeconds),
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to request a token for service account %s: %w", sa, err)
+	}
+	return tr.Status.Token, nil
+}
+
+func newHTTPClient(caBundle []byte) (*http.Client, error) {
+	if len(caBundle) == 0 {
+		return &http.Client{Timeout: 30 * time.Second}, nil
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(caBundle) {
+		return nil, errors.New("failed to parse vault CA bundle")
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	return &http.Client{Timeout: 30 * time.Second, Transport: transport}, nil
+}
+
+// client implements the subset of the Vault HTTP API needed to log in and
+// read KV secrets.
+type client struct {
+	http    *http.Client
+	address string
+	token   string
+}
+
+func (c *client) login(ctx context.Context, mountPath string, body map[string]string) error {
+	var resp struct {
+		Auth struct {
+			ClientToken string `json:"client_token"`
+		} `json:"auth"`
+	}
+	if err := c.do(ctx, http.MethodPost, "auth/"+mountPath+"/login", body, &resp); err != nil {
+		return err
+	}
+	if resp.Auth.ClientToken == "" {
+		return errors.New("login response did not contain a token")
+	}
+	c.token = resp.Auth.ClientToken
+	return nil
+}
+
+func (c *client) readKV(ctx context.Context, src *fleet.VaultValuesSource) (map[string]any, error) {
+	mount := strings.Trim(src.Mount, "/")
+	if mount == "" {
+		mount = DefaultMount
+	}
+	path := strings.Trim(src.Path, "/")
+
+	switch src.KVVersion {
+	case 1:
+		var resp struct {
+			Data map[string]any `json:"data"`
+		}
+		if err := c.do(ctx, http.MethodGet, mount+"/"+path, nil, &resp); err != nil {
+			return nil, fmt.Errorf("failed to read vault secret %s/%s: %w", mount, path, err)
+		}
+		return resp.Data, nil
+	case 0, DefaultKVVersion:
+		var resp struct {
+			Data struct {
+				Data map[string]any `json:"data"`
+			} `json:"data"`
+		}
+		if err := c.do(ctx, http.MethodGet, mount+"/data/"+path, nil, &resp); err != nil {
+			return nil, fmt.Errorf("failed to read vault secret %s/%s: %w", mount, path, err)
+		}
+		return resp.Data.Data, nil
+	default:
+		return nil, fmt.Errorf("unsupported vault KV version %d", src.KVVersion)
+	}
+}
+
+func (c *client) do(ctx context.Context, method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.address+"/v1/"+path, reader)
+	if err != nil {
+		return err
+	}
+	if c.token != "" {
+		req.Header.Set("X-Vault-Token", c.token)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Errors []string `json:"errors"`
+		}
+		if json.Unmarshal(data, &e) == nil && len(e.Errors) > 0 {
+			return fmt.Errorf("%s: %s", resp.Status, strings.Join(e.Errors, "; "))
+		}
+		return errors.New(resp.Status)
+	}
+	return json.Unmarshal(data, out)
+}
@@ -0,0 +1,280 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// fakeVault implements the login and KV endpoints of the Vault API.
+type fakeVault struct {
+	reads  atomic.Int32
+	values map[string]any
+}
+
+func (f *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	login := func(valid func(map[string]string) bool) {
+		var body map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || !valid(body) {
+			http.Error(w, `{"errors":["permission denied"]}`, http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write([]byte(`{"auth":{"client_token":"issued"}}`))
+	}
+
+	switch r.URL.Path {
+	case "/v1/auth/approle/login":
+		login(func(b map[string]string) bool { return b["role_id"] == "role" && b["secret_id"] == "secret" })
+		return
+	case "/v1/auth/k8s/login":
+		login(func(b map[string]string) bool { return b["role"] == "fleet" && b["jwt"] == "jwt" })
+		return
+	}
+
+	if token := r.Header.Get("X-Vault-Token"); token != "root" && token != "issued" {
+		http.Error(w, `{"errors":["permission denied"]}`, http.StatusForbidden)
+		return
+	}
+	f.reads.Add(1)
+	switch r.URL.Path {
+	case "/v1/secret/data/clusters/prod":
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"data": f.values}})
+	case "/v1/kv/clusters/prod":
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": f.values})
+	default:
+		http.Error(w, `{"errors":[]}`, http.StatusNotFound)
+	}
+}
+
+func newKubeClient() *fake.Clientset {
+	return fake.NewClientset(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "vault-token"},
+			Data:       map[string][]byte{"token": []byte("root\n")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "vault-approle"},
+			Data:       map[string][]byte{"role_id": []byte("role"), "secret_id": []byte("secret")},
+		},
+	)
+}
+
+// newTokenClient returns a client, which issues the token "jwt" for the
+// fleet-default service account, if it is requested for the vault audience.
+func newTokenClient() *fake.Clientset {
+	c := fake.NewClientset()
+	c.PrependReactor("create", "serviceaccounts", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		create := action.(k8stesting.CreateAction)
+		tr := create.GetObject().(*authenticationv1.TokenRequest)
+		if create.GetSubresource() != "token" || create.GetNamespace() != "cattle-fleet-system" ||
+			len(tr.Spec.Audiences) != 1 || tr.Spec.Audiences[0] != DefaultAudience {
+			return true, nil, errors.New("unexpected token request")
+		}
+		tr.Status.Token = "jwt"
+		return true, tr, nil
+	})
+	return c
+}
+
+func newCredentials() Credentials {
+	return Credentials{
+		Client:         newKubeClient(),
+		Namespace:      "apps",
+		ServiceAccount: types.NamespacedName{Namespace: "cattle-fleet-system", Name: "fleet-default"},
+		TokenClient:    newTokenClient(),
+	}
+}
+
+func TestResolve(t *testing.T) {
+	fv := &fakeVault{values: map[string]any{
+		"values.yaml": "image:\n  tag: v1\n",
+		"password":    "s3cr3t",
+	}}
+	srv := httptest.NewServer(fv)
+	defer srv.Close()
+
+	tests := []struct {
+		name    string
+		src     fleet.VaultValuesSource
+		creds   func(*Credentials)
+		want    map[string]any
+		wantErr string
+	}{
+		{
+			name: "token auth, KV v2, values from key",
+			src: fleet.VaultValuesSource{
+				Path: "clusters/prod",
+				Key:  "values.yaml",
+				Auth: fleet.VaultAuth{Method: "token", SecretName: "vault-token"},
+			},
+			want: map[string]any{"image": map[string]any{"tag": "v1"}},
+		},
+		{
+			name: "approle auth, KV v1, all keys",
+			src: fleet.VaultValuesSource{
+				Mount:     "kv",
+				KVVersion: 1,
+				Path:      "/clusters/prod",
+				Auth:      fleet.VaultAuth{Method: "approle", SecretName: "vault-approle"},
+			},
+			want: fv.values,
+		},
+		{
+			name: "kubernetes auth with service account token",
+			src: fleet.VaultValuesSource{
+				Path: "clusters/prod",
+				Key:  "values.yaml",
+				Auth: fleet.VaultAuth{Method: "kubernetes", MountPath: "k8s", Role: "fleet"},
+			},
+			want: map[string]any{"image": map[string]any{"tag": "v1"}},
+		},
+		{
+			name: "kubernetes auth without service account",
+			src: fleet.VaultValuesSource{
+				Path: "clusters/prod",
+				Auth: fleet.VaultAuth{Method: "kubernetes", MountPath: "k8s", Role: "fleet"},
+			},
+			creds:   func(c *Credentials) { c.ServiceAccount = types.NamespacedName{} },
+			wantErr: "requires a secretName or a service account",
+		},
+		{
+			name: "secret outside the release namespace",
+			src: fleet.VaultValuesSource{
+				Path: "clusters/prod",
+				Auth: fleet.VaultAuth{Method: "token", SecretName: "vault-token"},
+			},
+			creds:   func(c *Credentials) { c.Namespace = "other" },
+			wantErr: `secrets "vault-token" not found`,
+		},
+		{
+			name: "login denied",
+			src: fleet.VaultValuesSource{
+				Path: "clusters/prod",
+				Auth: fleet.VaultAuth{Method: "kubernetes", MountPath: "k8s", Role: "other"},
+			},
+			wantErr: "403 Forbidden: permission denied",
+		},
+		{
+			name: "missing secret",
+			src: fleet.VaultValuesSource{
+				Path: "clusters/dev",
+				Auth: fleet.VaultAuth{Method: "token", SecretName: "vault-token"},
+			},
+			wantErr: "failed to read vault secret secret/clusters/dev: 404 Not Found",
+		},
+		{
+			name: "missing key",
+			src: fleet.VaultValuesSource{
+				Path: "clusters/prod",
+				Key:  "other.yaml",
+				Auth: fleet.VaultAuth{Method: "token", SecretName: "vault-token"},
+			},
+			wantErr: "key other.yaml is missing from vault secret clusters/prod",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			creds := newCredentials()
+			if tt.creds != nil {
+				tt.creds(&creds)
+			}
+			tt.src.Address = srv.URL
+			got, err := NewResolver().Resolve(context.Background(), &tt.src, creds)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestResolveCache(t *testing.T) {
+	fv := &fakeVault{values: map[string]any{"replicas": "1"}}
+	srv := httptest.NewServer(fv)
+	defer srv.Close()
+
+	now := time.Now()
+	r := NewResolver()
+	r.now = func() time.Time { return now }
+	src := &fleet.VaultValuesSource{
+		Address:         srv.URL,
+		Path:            "clusters/prod",
+		Auth:            fleet.VaultAuth{Method: "token", SecretName: "vault-token"},
+		RefreshInterval: &metav1.Duration{Duration: time.Minute},
+	}
+	creds := newCredentials()
+
+	for range 3 {
+		got, err := r.Resolve(context.Background(), src, creds)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"replicas": "1"}, got)
+	}
+	assert.Equal(t, int32(1), fv.reads.Load())
+
+	// values are read again after the refresh interval
+	fv.values = map[string]any{"replicas": "2"}
+	now = now.Add(time.Minute)
+	got, err := r.Resolve(context.Background(), src, creds)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"replicas": "2"}, got)
+	assert.Equal(t, int32(2), fv.reads.Load())
+
+	// expired entries of other sources are evicted
+	other := *src
+	other.RefreshInterval = &metav1.Duration{Duration: 30 * time.Second}
+	_, err = r.Resolve(context.Background(), &other, creds)
+	require.NoError(t, err)
+	assert.Len(t, r.cache, 2)
+	now = now.Add(time.Minute)
+	_, err = r.Resolve(context.Background(), src, creds)
+	require.NoError(t, err)
+	assert.Len(t, r.cache, 1)
+}
+
+// TestResolveDevServer runs against a Vault dev-mode server, if VAULT_ADDR
+// and VAULT_TOKEN are set, e.g.:
+//
+//	vault server -dev -dev-root-token-id=root &
+//	vault kv put secret/fleet/test values.yaml="replicas: 3"
+//	VAULT_ADDR=http://127.0.0.1:8200 VAULT_TOKEN=root go test ./internal/helmdeployer/vault/
+func TestResolveDevServer(t *testing.T) {
+	addr, token := os.Getenv("VAULT_ADDR"), os.Getenv("VAULT_TOKEN")
+	if addr == "" || token == "" {
+		t.Skip("VAULT_ADDR and VAULT_TOKEN are not set")
+	}
+
+	kubeClient := fake.NewClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "vault-token"},
+		Data:       map[string][]byte{"token": []byte(token)},
+	})
+	got, err := NewResolver().Resolve(context.Background(), &fleet.VaultValuesSource{
+		Address: strings.TrimSuffix(addr, "/"),
+		Path:    "fleet/test",
+		Key:     "values.yaml",
+		Auth:    fleet.VaultAuth{Method: "token", SecretName: "vault-token"},
+	}, Credentials{Client: kubeClient, Namespace: "apps"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"replicas": float64(3)}, got)
+}
@@ -0,0 +1,100 @@
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/rancher/fleet/internal/content"
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+)
+
+const blobPrefix = "b-"
+
+// ToBlobID generates the name of the Content resource, which stores a
+// resource with the provided SHA256 sum as a blob.
+func ToBlobID(shasum string) string {
+	return (blobPrefix + shasum)[:63]
+}
+
+// IsBlobID returns true if name is the name of a blob Content resource.
+func IsBlobID(name string) bool {
+	return strings.HasPrefix(name, blobPrefix)
+}
+
+// BlobSHASum returns the SHA256 sum of the resource content, which addresses
+// its blob.
+func BlobSHASum(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+// BlobIDs returns the names of the blob Content resources referenced by c.
+func BlobIDs(c *fleet.Content) []string {
+	ids := make([]string, 0, len(c.Blobs))
+	seen := map[string]bool{}
+	for _, b := range c.Blobs {
+		if id := ToBlobID(b.SHA256Sum); !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// BlobData returns the resource content stored in the blob Content resource
+// and verifies its SHA256 sum.
+func BlobData(c *fleet.Content) (string, error) {
+	data, err := content.GUnzip(c.Content)
+	if err != nil {
+		return "", err
+	}
+	if shasum := BlobSHASum(string(data)); shasum != c.SHA256Sum {
+		return "", fmt.Errorf("blob %s does not match hash got %s, expected %s", c.Name, shasum, c.SHA256Sum)
+	}
+	return string(data), nil
+}
+
+// FromContent returns the manifest stored in the Content resource c. If its
+// resources are stored as blobs, getBlob is called with the SHA256 sum of each
+// resource to read its content. If a blob cannot be read, the manifest is read
+// from the Content field, if set. The SHA256 sum of the reassembled manifest is
+// verified.
+func FromContent(c *fleet.Content, getBlob func(shasum string) (string, error)) (*Manifest, error) {
+	fromContent := func() (*Manifest, error) {
+		data, err := content.GUnzip(c.Content)
+		if err != nil {
+			return nil, err
+		}
+		return FromJSON(data, c.SHA256Sum)
+	}
+	if len(c.Blobs) == 0 && len(c.Content) > 0 {
+		return fromContent()
+	}
+
+	resources := make([]fleet.BundleResource, 0, len(c.Blobs))
+	for _, b := range c.Blobs {
+		data, err := getBlob(b.SHA256Sum)
+		if err != nil && len(c.Content) > 0 {
+			return fromContent()
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read blob of resource %s: %w", b.Name, err)
+		}
+		resources = append(resources, fleet.BundleResource{
+			Name:     b.Name,
+			Content:  data,
+			Encoding: b.Encoding,
+		})
+	}
+
+	m := New(resources)
+	shasum, err := m.SHASum()
+	if err != nil {
+		return nil, err
+	}
+	if shasum != c.SHA256Sum {
+		return nil, fmt.Errorf("content does not match hash got %s, expected %s", shasum, c.SHA256Sum)
+	}
+	return m, nil
+}
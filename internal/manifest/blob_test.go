@@ -0,0 +1,209 @@
+package manifest_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/rancher/fleet/internal/content"
+	"github.com/rancher/fleet/internal/manifest"
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+)
+
+func newResources(n int) []fleet.BundleResource {
+	resources := make([]fleet.BundleResource, n)
+	for i := range resources {
+		resources[i] = fleet.BundleResource{
+			Name:    fmt.Sprintf("cm-%d.yaml", i),
+			Content: fmt.Sprintf("kind: ConfigMap\nmetadata:\n  name: cm-%d\n", i),
+		}
+	}
+	return resources
+}
+
+func newContentClient(t *testing.T, gets *int) client.Client {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	utilruntime.Must(fleet.AddToScheme(scheme))
+	return fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(interceptor.Funcs{
+		Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+			if manifest.IsBlobID(key.Name) {
+				*gets++
+			}
+			return c.Get(ctx, key, obj, opts...)
+		},
+	}).Build()
+}
+
+func TestStoreBlobs(t *testing.T) {
+	ctx := context.Background()
+	var blobGets int
+	c := newContentClient(t, &blobGets)
+	store := manifest.NewStore(c)
+	lookup := manifest.NewLookup()
+
+	resources := newResources(10)
+	// Resources with the same content share a blob.
+	resources = append(resources, fleet.BundleResource{Name: "copy.yaml", Content: resources[0].Content})
+	m := manifest.New(resources)
+	if err := store.Store(ctx, m, false); err != nil {
+		t.Fatal(err)
+	}
+
+	contents := &fleet.ContentList{}
+	if err := c.List(ctx, contents); err != nil {
+		t.Fatal(err)
+	}
+	if len(contents.Items) != 11 {
+		t.Fatalf("expected 10 blobs and 1 manifest content, got %d contents", len(contents.Items))
+	}
+
+	id := mustID(t, m)
+	got, err := lookup.Get(ctx, c, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mustID(t, got) != id || len(got.Resources) != 11 || got.Resources[10].Content != resources[0].Content {
+		t.Errorf("expected reassembled manifest to match, got %+v", got.Resources)
+	}
+
+	// Changing one resource only stores one new blob, and the lookup only
+	// fetches that blob.
+	changed := newResources(10)
+	changed[3].Content += "data:\n  key: value\n"
+	m2 := manifest.New(changed)
+	if err := store.Store(ctx, m2, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.List(ctx, contents); err != nil {
+		t.Fatal(err)
+	}
+	if len(contents.Items) != 13 {
+		t.Fatalf("expected 1 new blob and 1 new manifest content, got %d contents", len(contents.Items))
+	}
+
+	blobGets = 0
+	if _, err := lookup.Get(ctx, c, mustID(t, m2)); err != nil {
+		t.Fatal(err)
+	}
+	if blobGets != 1 {
+		t.Errorf("expected lookup to fetch 1 missing blob, got %d", blobGets)
+	}
+}
+
+func TestLookupRejectsTamperedBlob(t *testing.T) {
+	ctx := context.Background()
+	var blobGets int
+	c := newContentClient(t, &blobGets)
+
+	m := manifest.New(newResources(1))
+	if err := manifest.NewStore(c).Store(ctx, m, true); err != nil {
+		t.Fatal(err)
+	}
+
+	blob := &fleet.Content{}
+	key := types.NamespacedName{Name: manifest.ToBlobID(manifest.BlobSHASum(m.Resources[0].Content))}
+	if err := c.Get(ctx, key, blob); err != nil {
+		t.Fatal(err)
+	}
+	var err error
+	if blob.Content, err = content.Gzip([]byte("kind: Secret\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Update(ctx, blob); err != nil {
+		t.Fatal(err)
+	}
+
+	// the manifest is read from the content resource instead
+	got, err := manifest.NewLookup().Get(ctx, c, mustID(t, m))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Resources[0].Content != m.Resources[0].Content {
+		t.Errorf("expected the original resource, got %q", got.Resources[0].Content)
+	}
+
+	manifestContent := &fleet.Content{}
+	if err := c.Get(ctx, types.NamespacedName{Name: mustID(t, m)}, manifestContent); err != nil {
+		t.Fatal(err)
+	}
+	manifestContent.Content = nil
+	if err := c.Update(ctx, manifestContent); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := manifest.NewLookup().Get(ctx, c, mustID(t, m)); err == nil {
+		t.Error("expected an error for a blob, which does not match its hash")
+	}
+}
+
+func TestStoreWithContent(t *testing.T) {
+	ctx := context.Background()
+	var blobGets int
+	c := newContentClient(t, &blobGets)
+	store := manifest.NewStore(c)
+
+	m := manifest.New(newResources(2))
+	if err := store.Store(ctx, m, false); err != nil {
+		t.Fatal(err)
+	}
+	obj := &fleet.Content{}
+	if err := c.Get(ctx, types.NamespacedName{Name: mustID(t, m)}, obj); err != nil {
+		t.Fatal(err)
+	}
+	if len(obj.Content) != 0 || len(obj.Blobs) != 2 {
+		t.Fatalf("expected only blobs for agents reading blobs, got %d bytes of content and %d blobs", len(obj.Content), len(obj.Blobs))
+	}
+
+	// An agent which does not read blobs is targeted later.
+	if err := store.Store(ctx, m, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Get(ctx, types.NamespacedName{Name: mustID(t, m)}, obj); err != nil {
+		t.Fatal(err)
+	}
+	data, err := content.GUnzip(obj.Content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := manifest.FromJSON(data, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mustID(t, got) != mustID(t, m) {
+		t.Errorf("expected the whole manifest in the content resource, got %+v", got.Resources)
+	}
+}
+
+func TestStoreEmptyManifest(t *testing.T) {
+	ctx := context.Background()
+	var blobGets int
+	c := newContentClient(t, &blobGets)
+
+	m := manifest.New(nil)
+	if err := manifest.NewStore(c).Store(ctx, m, false); err != nil {
+		t.Fatal(err)
+	}
+	got, err := manifest.NewLookup().Get(ctx, c, mustID(t, m))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Resources) != 0 {
+		t.Errorf("expected no resources, got %v", got.Resources)
+	}
+}
+
+func mustID(t *testing.T, m *manifest.Manifest) string {
+	t.Helper()
+	id, err := m.ID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
@@ -1,20 +1,37 @@
 package manifest
 
 import (
+	"container/list"
 	"context"
+	"sync"
 
-	"github.com/rancher/fleet/internal/content"
 	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
 
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// DefaultBlobCacheSize is the number of bytes of blob content, which a Lookup
+// keeps in memory.
+const DefaultBlobCacheSize = 64 * 1024 * 1024
+
 func NewLookup() *Lookup {
 	return &Lookup{}
 }
 
+// Lookup reads manifests from content resources. It caches the blobs of
+// recently read manifests, up to DefaultBlobCacheSize bytes, so only the
+// blobs of changed resources are fetched when a manifest changes.
 type Lookup struct {
+	mu    sync.Mutex
+	size  int
+	blobs map[string]*list.Element
+	lru   *list.List
+}
+
+type cachedBlob struct {
+	shasum string
+	data   string
 }
 
 func (l *Lookup) Get(ctx context.Context, client client.Reader, id string) (*Manifest, error) {
@@ -24,9 +41,59 @@ func (l *Lookup) Get(ctx context.Context, client client.Reader, id string) (*Man
 		return nil, err
 	}
 
-	data, err := content.GUnzip(c.Content)
+	return FromContent(c, func(shasum string) (string, error) {
+		return l.blob(ctx, client, shasum)
+	})
+}
+
+func (l *Lookup) blob(ctx context.Context, client client.Reader, shasum string) (string, error) {
+	if data, ok := l.cached(shasum); ok {
+		return data, nil
+	}
+
+	c := &fleet.Content{}
+	if err := client.Get(ctx, types.NamespacedName{Name: ToBlobID(shasum)}, c); err != nil {
+		return "", err
+	}
+	data, err := BlobData(c)
 	if err != nil {
-		return nil, err
+		return "", err
+	}
+
+	l.add(shasum, data)
+	return data, nil
+}
+
+func (l *Lookup) cached(shasum string) (string, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e, ok := l.blobs[shasum]
+	if !ok {
+		return "", false
+	}
+	l.lru.MoveToFront(e)
+	return e.Value.(*cachedBlob).data, true
+}
+
+func (l *Lookup) add(shasum, data string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.blobs == nil {
+		l.blobs = map[string]*list.Element{}
+		l.lru = list.New()
+	}
+	if _, ok := l.blobs[shasum]; ok || len(data) > DefaultBlobCacheSize {
+		return
+	}
+	l.blobs[shasum] = l.lru.PushFront(&cachedBlob{shasum: shasum, data: data})
+	l.size += len(data)
+
+	for l.size > DefaultBlobCacheSize {
+		e := l.lru.Back()
+		b := l.lru.Remove(e).(*cachedBlob)
+		delete(l.blobs, b.shasum)
+		l.size -= len(b.data)
 	}
-	return FromJSON(data, c.SHA256Sum)
 }
@@ -4,10 +4,13 @@ import (
 	"archive/tar"
 	"bytes"
 	"compress/gzip"
+	"encoding/base64"
 	"io"
 	"time"
+	"unicode/utf8"
 
 	"github.com/rancher/fleet/internal/content"
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
 )
 
 func (m *Manifest) ToTarGZ() (io.Reader, error) {
@@ -42,3 +45,43 @@ func (m *Manifest) ToTarGZ() (io.Reader, error) {
 
 	return buf, gz.Close()
 }
+
+// FromTarGZ reads a manifest from an archive written by ToTarGZ. Resources
+// which are not valid UTF-8 are stored base64 encoded.
+func FromTarGZ(r io.Reader) (*Manifest, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	var resources []fleet.BundleResource
+	tr := tar.NewReader(gz)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if h.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		resource := fleet.BundleResource{Name: h.Name}
+		if utf8.Valid(data) {
+			resource.Content = string(data)
+		} else {
+			resource.Content = base64.StdEncoding.EncodeToString(data)
+			resource.Encoding = "base64"
+		}
+		resources = append(resources, resource)
+	}
+
+	return New(resources), nil
+}
@@ -25,45 +25,110 @@ type ContentStore struct {
 }
 
 // Store stores the manifest as a content resource.
-// It copies the resources from the bundle to the content resource.
-func (c *ContentStore) Store(ctx context.Context, manifest *Manifest) error {
+// Each resource of the manifest is stored once, in a blob content resource
+// named after the SHA256 sum of the resource content. The manifest's content
+// resource references the blobs, so a change to a single resource only writes
+// a new blob and a new, small manifest content resource.
+// If withContent is true, the whole manifest is also stored in the Content
+// field, for agents which do not read blobs. It is added to an existing
+// content resource, which was stored without it.
+func (c *ContentStore) Store(ctx context.Context, manifest *Manifest, withContent bool) error {
 	id, err := manifest.ID()
 	if err != nil {
 		return err
 	}
 
-	if err := c.Client.Get(ctx, types.NamespacedName{Name: id}, &fleet.Content{}); err != nil && !apierrors.IsNotFound(err) {
+	// Blobs are ensured even if the manifest's content resource exists,
+	// in case one of them was deleted, while the content resource was
+	// created.
+	blobs, err := c.storeBlobs(ctx, manifest)
+	if err != nil {
+		return err
+	}
+
+	existing := &fleet.Content{}
+	if err := c.Client.Get(ctx, types.NamespacedName{Name: id}, existing); err != nil && !apierrors.IsNotFound(err) {
 		return fmt.Errorf("%w: %w", errorutil.ErrRetryable, err)
 	} else if err == nil {
-		return nil
+		if !withContent || len(existing.Content) > 0 {
+			return nil
+		}
+		return c.addContent(ctx, existing, manifest)
 	}
 
-	return c.createContents(ctx, id, manifest)
-}
-
-func (c *ContentStore) createContents(ctx context.Context, id string, manifest *Manifest) error {
-	data, err := manifest.Content()
-	if err != nil {
+	if err := c.createContents(ctx, id, manifest, blobs, withContent); err != nil {
 		return err
 	}
-	digest, err := manifest.SHASum()
-	if err != nil {
-		return err
+
+	// A blob, which was found above, might have been garbage collected
+	// before the content resource referencing it was created. Check again,
+	// now that the reference exists.
+	_, err = c.storeBlobs(ctx, manifest)
+	return err
+}
+
+// storeBlobs creates the missing blob content resources for the manifest's
+// resources and returns the references to them.
+func (c *ContentStore) storeBlobs(ctx context.Context, manifest *Manifest) ([]fleet.ContentBlob, error) {
+	blobs := make([]fleet.ContentBlob, 0, len(manifest.Resources))
+	stored := map[string]bool{}
+	for _, r := range manifest.Resources {
+		shasum := BlobSHASum(r.Content)
+		blobs = append(blobs, fleet.ContentBlob{
+			Name:      r.Name,
+			Encoding:  r.Encoding,
+			SHA256Sum: shasum,
+		})
+		if stored[shasum] {
+			continue
+		}
+		stored[shasum] = true
+
+		id := ToBlobID(shasum)
+		if err := c.Client.Get(ctx, types.NamespacedName{Name: id}, &fleet.Content{}); err != nil && !apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("%w: %w", errorutil.ErrRetryable, err)
+		} else if err == nil {
+			continue
+		}
+
+		compressed, err := content.Gzip([]byte(r.Content))
+		if err != nil {
+			return nil, err
+		}
+		err = c.Client.Create(ctx, &fleet.Content{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: id,
+			},
+			Content:   compressed,
+			SHA256Sum: shasum,
+		})
+		if err = client.IgnoreAlreadyExists(err); err != nil {
+			return nil, fmt.Errorf("%w: %w", errorutil.ErrRetryable, err)
+		}
 	}
+	return blobs, nil
+}
 
-	// Contents do not exist in the cluster
-	compressed, err := content.Gzip(data)
+func (c *ContentStore) createContents(ctx context.Context, id string, manifest *Manifest, blobs []fleet.ContentBlob, withContent bool) error {
+	digest, err := manifest.SHASum()
 	if err != nil {
 		return err
 	}
 
-	err = c.Client.Create(ctx, &fleet.Content{
+	obj := &fleet.Content{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: id,
 		},
-		Content:   compressed,
+		Blobs:     blobs,
 		SHA256Sum: digest,
-	})
+	}
+	if withContent {
+		if obj.Content, err = gzipManifest(manifest); err != nil {
+			return err
+		}
+	}
+
+	err = c.Client.Create(ctx, obj)
 	err = client.IgnoreAlreadyExists(err)
 	if err != nil {
 		err = fmt.Errorf("%w: %w", errorutil.ErrRetryable, err)
@@ -71,3 +136,26 @@ func (c *ContentStore) createContents(ctx context.Context, id string, manifest *
 
 	return err
 }
+
+// addContent adds the whole manifest to a content resource, which was
+// created for agents reading blobs only.
+func (c *ContentStore) addContent(ctx context.Context, obj *fleet.Content, manifest *Manifest) error {
+	compressed, err := gzipManifest(manifest)
+	if err != nil {
+		return err
+	}
+	orig := obj.DeepCopy()
+	obj.Content = compressed
+	if err := c.Client.Patch(ctx, obj, client.MergeFrom(orig)); err != nil {
+		return fmt.Errorf("%w: %w", errorutil.ErrRetryable, err)
+	}
+	return nil
+}
+
+func gzipManifest(manifest *Manifest) ([]byte, error) {
+	data, err := manifest.Content()
+	if err != nil {
+		return nil, err
+	}
+	return content.Gzip(data)
+}
@@ -69,18 +69,28 @@ func Test_contentStore_Store(t *testing.T) {
 			ctx := context.TODO()
 			nsn := types.NamespacedName{Name: tt.want}
 
+			blobSum := manifest.BlobSHASum("bar")
+			blobNSN := types.NamespacedName{Name: manifest.ToBlobID(blobSum)}
 			if tt.args.cached {
+				client.EXPECT().Get(ctx, blobNSN, gomock.Any()).Return(nil)
 				client.EXPECT().Get(ctx, nsn, gomock.Any()).Return(nil)
 				client.EXPECT().Create(ctx, gomock.Any()).Times(0)
 			} else {
+				client.EXPECT().Get(ctx, blobNSN, gomock.Any()).Return(apierrors.NewNotFound(fleet.GroupResource("Content"), blobNSN.Name))
+				client.EXPECT().Create(ctx, &contentMatcher{
+					name:      blobNSN.Name,
+					sha256sum: blobSum,
+				}).Times(1)
 				client.EXPECT().Get(ctx, nsn, gomock.Any()).Return(apierrors.NewNotFound(fleet.GroupResource("Content"), tt.want))
 				client.EXPECT().Create(ctx, &contentMatcher{
 					name:      tt.want,
 					sha256sum: checksum,
 				}).Times(1)
+				// blobs are checked again, once the content referencing them exists
+				client.EXPECT().Get(ctx, blobNSN, gomock.Any()).Return(nil)
 			}
 
-			err := store.Store(ctx, tt.args.manifest)
+			err := store.Store(ctx, tt.args.manifest, false)
 			if err != nil {
 				t.Errorf("Store() error = %v", err)
 				return
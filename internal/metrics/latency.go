@@ -0,0 +1,153 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// Deployment stages, for which the time since the deployed change was
+// detected is recorded.
+const (
+	StageGitJobFinished = "gitjob_finished"
+	StageBundleUpdated  = "bundle_updated"
+	StageStaged         = "staged"
+	StageApplied        = "applied"
+	StageReady          = "ready"
+)
+
+var (
+	BucketsDeploymentLatency = []float64{1, 5, 10, 30, 60, 120, 300, 600, 1200, 1800, 3600}
+
+	deploymentLatency = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: metricPrefix,
+			Name:      "deployment_latency_seconds",
+			Help: "Duration in seconds from detecting a change, e.g. a new commit, until a deployment stage was reached. " +
+				"Recorded per GitRepo or HelmOp.",
+			Buckets: BucketsDeploymentLatency,
+		},
+		[]string{"kind", "name", "namespace", "stage"},
+	)
+
+	clusterDeploymentLatency = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: metricPrefix,
+			Subsystem: "agent",
+			Name:      "deployment_latency_seconds",
+			Help: "Duration in seconds from detecting a change, e.g. a new commit, until the agent applied it or it became ready. " +
+				"Recorded per bundle deployment.",
+			Buckets: BucketsDeploymentLatency,
+		},
+		[]string{"name", "namespace", "cluster_name", "repo", "helmop", "bundle", "bundle_namespace", "stage"},
+	)
+)
+
+func init() {
+	objMetrics = append(objMetrics, deploymentLatency)
+}
+
+// RegisterAgentMetrics registers the metrics exposed by the agent.
+func RegisterAgentMetrics() {
+	metrics.Registry.MustRegister(clusterDeploymentLatency)
+}
+
+// ChangeDetectedAt returns the time stored in the ChangeDetectedAtAnnotation
+// of obj, if any.
+func ChangeDetectedAt(obj metav1.Object) (time.Time, bool) {
+	v, ok := obj.GetAnnotations()[fleet.ChangeDetectedAtAnnotation]
+	if !ok {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// FormatChangeDetectedAt formats t for use in the ChangeDetectedAtAnnotation.
+func FormatChangeDetectedAt(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// ObserveDeploymentLatency records the time from detected until now for the
+// given stage of the GitRepo or HelmOp.
+func ObserveDeploymentLatency(kind, name, namespace, stage string, detected, now time.Time) {
+	if detected.IsZero() {
+		return
+	}
+	deploymentLatency.WithLabelValues(kind, name, namespace, stage).Observe(latency(detected, now))
+}
+
+// ObserveBundleLatency records the time since the change deployed by a
+// bundle or bundle deployment was detected, for the GitRepo or HelmOp which
+// created it. Objects without a change detection time are ignored.
+func ObserveBundleLatency(obj metav1.Object, stage string, now time.Time) {
+	detected, ok := ChangeDetectedAt(obj)
+	if !ok {
+		return
+	}
+
+	labels := obj.GetLabels()
+	namespace := obj.GetNamespace()
+	if ns := labels[fleet.BundleNamespaceLabel]; ns != "" {
+		namespace = ns
+	}
+	switch {
+	case labels[fleet.RepoLabel] != "":
+		ObserveDeploymentLatency("GitRepo", labels[fleet.RepoLabel], namespace, stage, detected, now)
+	case labels[fleet.HelmOpLabel] != "":
+		ObserveDeploymentLatency("HelmOp", labels[fleet.HelmOpLabel], namespace, stage, detected, now)
+	}
+}
+
+// DeleteDeploymentLatency deletes the deployment latency metrics of a
+// GitRepo or HelmOp.
+func DeleteDeploymentLatency(kind, name, namespace string) int {
+	return deploymentLatency.DeletePartialMatch(prometheus.Labels{
+		"kind":      kind,
+		"name":      name,
+		"namespace": namespace,
+	})
+}
+
+// ObserveClusterDeploymentLatency records the time since the change deployed
+// by bd was detected, for the given stage. Bundle deployments without a
+// change detection time are ignored.
+func ObserveClusterDeploymentLatency(bd *fleet.BundleDeployment, stage string, now time.Time) {
+	detected, ok := ChangeDetectedAt(bd)
+	if !ok {
+		return
+	}
+	clusterDeploymentLatency.WithLabelValues(
+		bd.Name,
+		bd.Namespace,
+		bd.Labels[fleet.ClusterLabel],
+		bd.Labels[fleet.RepoLabel],
+		bd.Labels[fleet.HelmOpLabel],
+		bd.Labels[fleet.BundleLabel],
+		bd.Labels[fleet.BundleNamespaceLabel],
+		stage,
+	).Observe(latency(detected, now))
+}
+
+// DeleteClusterDeploymentLatency deletes the deployment latency metrics of a
+// bundle deployment.
+func DeleteClusterDeploymentLatency(name, namespace string) int {
+	return clusterDeploymentLatency.DeletePartialMatch(prometheus.Labels{
+		"name":      name,
+		"namespace": namespace,
+	})
+}
+
+// latency returns the seconds between detected and now. Clock skew between
+// the controller and the agent must not produce negative values.
+func latency(detected, now time.Time) float64 {
+	return max(now.Sub(detected).Seconds(), 0)
+}
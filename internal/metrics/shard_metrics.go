@@ -0,0 +1,30 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var shardObjects = promauto.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: metricPrefix,
+		Subsystem: "shard",
+		Name:      "objects",
+		Help:      "The number of objects of a kind assigned to a controller shard. The default shard has an empty shard ID.",
+	},
+	[]string{"shard_id", "kind"},
+)
+
+func init() {
+	objMetrics = append(objMetrics, shardObjects)
+}
+
+// SetShardObjects replaces the number of objects per shard ID and kind.
+func SetShardObjects(counts map[string]map[string]int) {
+	shardObjects.Reset()
+	for shardID, kinds := range counts {
+		for kind, n := range kinds {
+			shardObjects.WithLabelValues(shardID, kind).Set(float64(n))
+		}
+	}
+}
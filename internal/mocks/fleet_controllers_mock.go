@@ -235,3 +235,17 @@ func (mr *MockFleetControllersMockRecorder) Schedule() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Schedule", reflect.TypeOf((*MockFleetControllers)(nil).Schedule))
 }
+
+// SecretDistribution mocks base method.
+func (m *MockFleetControllers) SecretDistribution() v1alpha1.SecretDistributionController {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SecretDistribution")
+	ret0, _ := ret[0].(v1alpha1.SecretDistributionController)
+	return ret0
+}
+
+// SecretDistribution indicates an expected call of SecretDistribution.
+func (mr *MockFleetControllersMockRecorder) SecretDistribution() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SecretDistribution", reflect.TypeOf((*MockFleetControllers)(nil).SecretDistribution))
+}
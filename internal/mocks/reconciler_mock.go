@@ -44,9 +44,9 @@ func (m *MockTargetBuilder) EXPECT() *MockTargetBuilderMockRecorder {
 }
 
 // Targets mocks base method.
-func (m *MockTargetBuilder) Targets(arg0 context.Context, arg1 *v1alpha1.Bundle, arg2 string) ([]*target.Target, bool, error) {
+func (m *MockTargetBuilder) Targets(ctx context.Context, bundle *v1alpha1.Bundle, manifestID string) ([]*target.Target, bool, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Targets", arg0, arg1, arg2)
+	ret := m.ctrl.Call(m, "Targets", ctx, bundle, manifestID)
 	ret0, _ := ret[0].([]*target.Target)
 	ret1, _ := ret[1].(bool)
 	ret2, _ := ret[2].(error)
@@ -84,15 +84,15 @@ func (m *MockStore) EXPECT() *MockStoreMockRecorder {
 }
 
 // Store mocks base method.
-func (m *MockStore) Store(arg0 context.Context, arg1 *manifest.Manifest) error {
-	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Store", arg0, arg1)
+func (m_2 *MockStore) Store(ctx context.Context, m *manifest.Manifest, withContent bool) error {
+	m_2.ctrl.T.Helper()
+	ret := m_2.ctrl.Call(m_2, "Store", ctx, m, withContent)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Store indicates an expected call of Store.
-func (mr *MockStoreMockRecorder) Store(arg0, arg1 any) *gomock.Call {
+func (mr *MockStoreMockRecorder) Store(ctx, m, withContent any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Store", reflect.TypeOf((*MockStore)(nil).Store), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Store", reflect.TypeOf((*MockStore)(nil).Store), ctx, m, withContent)
 }
@@ -327,6 +327,36 @@ func (o *OCIWrapper) DeleteManifest(ctx context.Context, opts OCIOpts, id string
 	return repo.Delete(ctx, desc)
 }
 
+// ManifestSize returns the size of the fleet manifest stored in the OCI
+// artifact identified by the given id, without pulling it.
+func (o *OCIWrapper) ManifestSize(ctx context.Context, opts OCIOpts, id string) (int64, error) {
+	repo, err := newOCIRepository(id, opts)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create repository for %s: %w", id, err)
+	}
+
+	tag := "latest"
+	desc, err := repo.Resolve(ctx, tag)
+	if err != nil {
+		return 0, fmt.Errorf("failed to resolve tag '%s' for artifact '%s': %w", tag, id, err)
+	}
+	data, err := getDataFromDescriptor(ctx, repo, desc)
+	if err != nil {
+		return 0, err
+	}
+
+	var root struct {
+		Layers []ocispec.Descriptor
+	}
+	if err := json.Unmarshal(data, &root); err != nil {
+		return 0, err
+	}
+	if len(root.Layers) != 1 {
+		return 0, fmt.Errorf("expected 1 layer in OCI manifest, %d found", len(root.Layers))
+	}
+	return root.Layers[0].Size, nil
+}
+
 // OCIIsEnabled returns true if the OCI_STORAGE env variable is not set or
 // if it's set to true
 func OCIIsEnabled() bool {
@@ -5,6 +5,16 @@ import (
 	"encoding/hex"
 )
 
+const (
+	// AgentCredentialSecretName is the secret in the cluster namespace on
+	// upstream, which contains the latest credential issued to the agent by
+	// credential rotation.
+	AgentCredentialSecretName = "fleet-agent-credential" //nolint:gosec // not a credential
+	// AgentCredentialGenerationKey is the key of the credential's generation
+	// in the agent credential secret.
+	AgentCredentialGenerationKey = "generation"
+)
+
 func SecretName(clientID, clientRandom string) string {
 	d := sha256.New()
 	d.Write([]byte(clientID))
@@ -0,0 +1,192 @@
+// Package resourcepolicy evaluates the resource rules of Fleet Policy objects
+// against rendered resources. The rules are CEL expressions, they are
+// checked by the agent before a BundleDeployment is applied and by the fleet
+// validate command.
+package resourcepolicy
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// costLimit bounds the runtime cost of a single rule evaluation, so a rule
+// cannot stall the agent on large resources.
+const costLimit = 1000000
+
+type rule struct {
+	name    string
+	message string
+	program cel.Program
+}
+
+// Evaluator checks resources against a set of compiled rules.
+type Evaluator struct {
+	rules []rule
+}
+
+// Violation describes a resource, which does not satisfy a rule.
+type Violation struct {
+	APIVersion string
+	Kind       string
+	Namespace  string
+	Name       string
+	Rule       string
+	Message    string
+}
+
+func (v Violation) String() string {
+	name := v.Name
+	if v.Namespace != "" {
+		name = v.Namespace + "/" + name
+	}
+	return fmt.Sprintf("%s %s violates rule %q: %s", v.Kind, name, v.Rule, v.Message)
+}
+
+// ViolationError is returned when rendered resources violate resource rules.
+type ViolationError struct {
+	Violations []Violation
+}
+
+func (e *ViolationError) Error() string {
+	msgs := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		msgs = append(msgs, v.String())
+	}
+	return "resources violate policy: " + strings.Join(msgs, "; ")
+}
+
+func newEnv() (*cel.Env, error) {
+	return cel.NewEnv(
+		cel.Variable("object", cel.DynType),
+		cel.Variable("podSpec", cel.DynType),
+		cel.Variable("clusterScoped", cel.BoolType),
+		cel.OptionalTypes(),
+	)
+}
+
+// New compiles the rules. It returns an error if a rule is not a valid CEL
+// expression or does not evaluate to a bool.
+func New(rules []fleet.ResourceRule) (*Evaluator, error) {
+	env, err := newEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	e := &Evaluator{}
+	for _, r := range rules {
+		if r.Name == "" {
+			return nil, errors.New("resource rule has no name")
+		}
+		ast, iss := env.Compile(r.Expression)
+		if iss.Err() != nil {
+			return nil, fmt.Errorf("resource rule %q: %w", r.Name, iss.Err())
+		}
+		if ast.OutputType() != cel.BoolType {
+			return nil, fmt.Errorf("resource rule %q: expression must evaluate to bool, got %s", r.Name, ast.OutputType())
+		}
+		prg, err := env.Program(ast, cel.CostLimit(costLimit))
+		if err != nil {
+			return nil, fmt.Errorf("resource rule %q: %w", r.Name, err)
+		}
+
+		msg := r.Message
+		if msg == "" {
+			msg = fmt.Sprintf("expression %q evaluated to false", r.Expression)
+		}
+		e.rules = append(e.rules, rule{name: r.Name, message: msg, program: prg})
+	}
+
+	return e, nil
+}
+
+// Empty returns true if there are no rules to evaluate.
+func (e *Evaluator) Empty() bool {
+	return e == nil || len(e.rules) == 0
+}
+
+// Evaluate checks a single resource against all rules. A rule which fails to
+// evaluate, e.g. because it accesses a missing field, counts as violated.
+func (e *Evaluator) Evaluate(obj runtime.Object, clusterScoped bool) ([]Violation, error) {
+	if e.Empty() {
+		return nil, nil
+	}
+
+	data, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	apiVersion, kind := gvk.ToAPIVersionAndKind()
+	metadata, _ := data["metadata"].(map[string]any)
+	name, _ := metadata["name"].(string)
+	namespace, _ := metadata["namespace"].(string)
+
+	vars := map[string]any{
+		"object":        data,
+		"podSpec":       podSpec(gvk.GroupKind(), data),
+		"clusterScoped": clusterScoped,
+	}
+
+	var violations []Violation
+	for _, r := range e.rules {
+		msg := ""
+		out, _, err := r.program.Eval(vars)
+		if err != nil {
+			msg = fmt.Sprintf("evaluation failed: %v", err)
+		} else if allowed, ok := out.Value().(bool); !ok || !allowed {
+			msg = r.message
+		}
+		if msg == "" {
+			continue
+		}
+		violations = append(violations, Violation{
+			APIVersion: apiVersion,
+			Kind:       kind,
+			Namespace:  namespace,
+			Name:       name,
+			Rule:       r.name,
+			Message:    msg,
+		})
+	}
+
+	return violations, nil
+}
+
+// podSpec returns the pod spec of pods and the pod template spec of
+// workloads. It returns an empty map for all other kinds.
+func podSpec(gk schema.GroupKind, obj map[string]any) map[string]any {
+	var path []string
+	switch gk {
+	case schema.GroupKind{Kind: "Pod"}:
+		path = []string{"spec"}
+	case schema.GroupKind{Kind: "ReplicationController"},
+		schema.GroupKind{Group: "apps", Kind: "Deployment"},
+		schema.GroupKind{Group: "apps", Kind: "StatefulSet"},
+		schema.GroupKind{Group: "apps", Kind: "DaemonSet"},
+		schema.GroupKind{Group: "apps", Kind: "ReplicaSet"},
+		schema.GroupKind{Group: "batch", Kind: "Job"}:
+		path = []string{"spec", "template", "spec"}
+	case schema.GroupKind{Group: "batch", Kind: "CronJob"}:
+		path = []string{"spec", "jobTemplate", "spec", "template", "spec"}
+	default:
+		return map[string]any{}
+	}
+
+	cur := obj
+	for _, p := range path {
+		next, ok := cur[p].(map[string]any)
+		if !ok {
+			return map[string]any{}
+		}
+		cur = next
+	}
+	return cur
+}
@@ -0,0 +1,172 @@
+package resourcepolicy
+
+import (
+	"strings"
+	"testing"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+const privilegedDeployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: team-a
+  labels:
+    team: a
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx
+        securityContext:
+          privileged: true
+`
+
+const plainCronJob = `apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: backup
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+          - name: backup
+            image: busybox
+`
+
+const clusterRole = `apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: admin
+`
+
+var rules = []fleet.ResourceRule{
+	{
+		Name:       "no-privileged",
+		Expression: "!podSpec.?containers.orValue([]).exists(c, c.?securityContext.?privileged.orValue(false))",
+		Message:    "privileged containers are not allowed",
+	},
+	{
+		Name:       "team-label",
+		Expression: "has(object.metadata.labels) && 'team' in object.metadata.labels",
+	},
+	{
+		Name:       "no-secrets",
+		Expression: "object.kind != 'Secret'",
+		Message:    "secrets must not be deployed",
+	},
+	{
+		Name:       "namespaced",
+		Expression: "!clusterScoped",
+		Message:    "cluster-scoped resources are not allowed",
+	},
+}
+
+func toObject(t *testing.T, data string) *unstructured.Unstructured {
+	t.Helper()
+	obj := &unstructured.Unstructured{}
+	if err := yaml.Unmarshal([]byte(data), &obj.Object); err != nil {
+		t.Fatal(err)
+	}
+	return obj
+}
+
+func TestEvaluate(t *testing.T) {
+	e, err := New(rules)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := map[string]struct {
+		obj           string
+		clusterScoped bool
+		want          []string
+	}{
+		"privileged deployment": {
+			obj:  privilegedDeployment,
+			want: []string{`Deployment team-a/web violates rule "no-privileged": privileged containers are not allowed`},
+		},
+		"cronjob pod template without label": {
+			obj:  plainCronJob,
+			want: []string{`CronJob backup violates rule "team-label": expression "has(object.metadata.labels) && 'team' in object.metadata.labels" evaluated to false`},
+		},
+		"cluster scoped": {
+			obj:           clusterRole,
+			clusterScoped: true,
+			want: []string{
+				`ClusterRole admin violates rule "team-label": expression "has(object.metadata.labels) && 'team' in object.metadata.labels" evaluated to false`,
+				`ClusterRole admin violates rule "namespaced": cluster-scoped resources are not allowed`,
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			violations, err := e.Evaluate(toObject(t, tc.obj), tc.clusterScoped)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var got []string
+			for _, v := range violations {
+				got = append(got, v.String())
+			}
+			if strings.Join(got, "\n") != strings.Join(tc.want, "\n") {
+				t.Errorf("expected violations:\n%s\ngot:\n%s", strings.Join(tc.want, "\n"), strings.Join(got, "\n"))
+			}
+		})
+	}
+}
+
+func TestEvaluate_RuntimeError(t *testing.T) {
+	e, err := New([]fleet.ResourceRule{{Name: "labels", Expression: "object.metadata.labels.team == 'a'"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	violations, err := e.Evaluate(toObject(t, clusterRole), true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(violations) != 1 || !strings.HasPrefix(violations[0].Message, "evaluation failed: ") {
+		t.Errorf("expected a failed evaluation to count as violation, got %v", violations)
+	}
+}
+
+func TestNew_Invalid(t *testing.T) {
+	tests := map[string]struct {
+		rule fleet.ResourceRule
+		want string
+	}{
+		"no name":       {rule: fleet.ResourceRule{Expression: "true"}, want: "resource rule has no name"},
+		"syntax error":  {rule: fleet.ResourceRule{Name: "r", Expression: "object.kind =="}, want: `resource rule "r": ERROR`},
+		"unknown var":   {rule: fleet.ResourceRule{Name: "r", Expression: "resource.kind == 'Pod'"}, want: "undeclared reference to 'resource'"},
+		"not a boolean": {rule: fleet.ResourceRule{Name: "r", Expression: "object.kind"}, want: "expression must evaluate to bool"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := New([]fleet.ResourceRule{tc.rule})
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("expected error containing %q, got %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestViolationError(t *testing.T) {
+	err := &ViolationError{Violations: []Violation{
+		{Kind: "Pod", Namespace: "ns", Name: "a", Rule: "r1", Message: "m1"},
+		{Kind: "ClusterRole", Name: "b", Rule: "r2", Message: "m2"},
+	}}
+	want := `resources violate policy: Pod ns/a violates rule "r1": m1; ClusterRole b violates rule "r2": m2`
+	if err.Error() != want {
+		t.Errorf("expected %q, got %q", want, err.Error())
+	}
+}
@@ -0,0 +1,203 @@
+// Package tracing sets up OpenTelemetry tracing for Fleet components and
+// propagates trace context between them.
+//
+// Tracing is configured with the standard OTEL_* environment variables and is
+// only enabled when an OTLP endpoint is set. Trace context travels from the
+// gitops controller to the fleet apply job through environment variables, and
+// from there to the bundle controller and the agents through annotations on
+// Bundles and BundleDeployments.
+package tracing
+
+import (
+	"context"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+const (
+	tracerName = "github.com/rancher/fleet"
+
+	// TraceParentEnvVar and TraceStateEnvVar carry the trace context to
+	// child processes, such as the fleet apply job.
+	TraceParentEnvVar = "TRACEPARENT"
+	TraceStateEnvVar  = "TRACESTATE"
+
+	// HeadersSecretEnvVar names a secret, whose HeadersSecretKey is passed
+	// to child processes as OTEL_EXPORTER_OTLP_HEADERS.
+	HeadersSecretEnvVar = "FLEET_OTEL_HEADERS_SECRET"
+	HeadersSecretKey    = "headers"
+)
+
+// propagatedEnvVars are the OTEL_* variables passed on to child processes.
+var propagatedEnvVars = []string{
+	"OTEL_EXPORTER_OTLP_ENDPOINT",
+	"OTEL_EXPORTER_OTLP_TRACES_ENDPOINT",
+	"OTEL_EXPORTER_OTLP_INSECURE",
+	"OTEL_EXPORTER_OTLP_TRACES_INSECURE",
+	"OTEL_RESOURCE_ATTRIBUTES",
+}
+
+var propagator = propagation.TraceContext{}
+
+// Enabled returns true if an OTLP endpoint is configured for traces.
+func Enabled() bool {
+	return os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" ||
+		os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != ""
+}
+
+// Setup installs a global tracer provider exporting spans via OTLP, if
+// tracing is enabled. The returned function flushes pending spans and must be
+// called on shutdown.
+func Setup(ctx context.Context, serviceName string) (func(context.Context) error, error) {
+	if !Enabled() {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracegrpc.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Resource attributes from OTEL_RESOURCE_ATTRIBUTES and OTEL_SERVICE_NAME
+	// take precedence over the default service name.
+	res, err := resource.New(ctx,
+		resource.WithTelemetrySDK(),
+		resource.WithAttributes(attribute.String("service.name", serviceName)),
+		resource.WithFromEnv(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagator)
+
+	return tp.Shutdown, nil
+}
+
+// Start starts a span named name as a child of the span in ctx, using the
+// global tracer provider.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End ends span, recording err if it is not nil.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Inject stores the trace context of ctx in the annotations of obj. Nothing
+// is stored if ctx does not contain a sampled span.
+func Inject(ctx context.Context, obj metav1.Object) {
+	propagator.Inject(ctx, annotationCarrier{obj: obj})
+}
+
+// Extract returns a copy of ctx containing the trace context stored in the
+// annotations of obj, if any.
+func Extract(ctx context.Context, obj metav1.Object) context.Context {
+	return propagator.Extract(ctx, annotationCarrier{obj: obj})
+}
+
+// FromEnv returns a copy of ctx containing the trace context passed in
+// TraceParentEnvVar, if any.
+func FromEnv(ctx context.Context) context.Context {
+	return propagator.Extract(ctx, propagation.MapCarrier{
+		"traceparent": os.Getenv(TraceParentEnvVar),
+		"tracestate":  os.Getenv(TraceStateEnvVar),
+	})
+}
+
+// EnvVars returns the environment variables needed by a child process to
+// export spans as part of the trace in ctx: the endpoint and resource OTEL_*
+// variables of the current process and the trace context.
+// Other OTEL_* variables are not passed on, as they end up in plain text in
+// the job spec. Notably OTEL_EXPORTER_OTLP_HEADERS often contains
+// credentials, it is read from the secret named by HeadersSecretEnvVar
+// instead, which must exist in the namespace of the child process.
+func EnvVars(ctx context.Context) []corev1.EnvVar {
+	var envVars []corev1.EnvVar
+	for _, name := range propagatedEnvVars {
+		if value, ok := os.LookupEnv(name); ok {
+			envVars = append(envVars, corev1.EnvVar{Name: name, Value: value})
+		}
+	}
+	if name := os.Getenv(HeadersSecretEnvVar); name != "" {
+		envVars = append(envVars, corev1.EnvVar{
+			Name: "OTEL_EXPORTER_OTLP_HEADERS",
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: name},
+					Key:                  HeadersSecretKey,
+					Optional:             ptr.To(true),
+				},
+			},
+		})
+	}
+
+	carrier := propagation.MapCarrier{}
+	propagator.Inject(ctx, carrier)
+	if v := carrier.Get("traceparent"); v != "" {
+		envVars = append(envVars, corev1.EnvVar{Name: TraceParentEnvVar, Value: v})
+	}
+	if v := carrier.Get("tracestate"); v != "" {
+		envVars = append(envVars, corev1.EnvVar{Name: TraceStateEnvVar, Value: v})
+	}
+
+	return envVars
+}
+
+// annotationCarrier maps the W3C trace context headers to annotations.
+type annotationCarrier struct {
+	obj metav1.Object
+}
+
+var annotationKeys = map[string]string{
+	"traceparent": fleet.TraceParentAnnotation,
+	"tracestate":  fleet.TraceStateAnnotation,
+}
+
+func (c annotationCarrier) Get(key string) string {
+	k, ok := annotationKeys[key]
+	if !ok {
+		return ""
+	}
+	return c.obj.GetAnnotations()[k]
+}
+
+func (c annotationCarrier) Set(key, value string) {
+	k, ok := annotationKeys[key]
+	if !ok {
+		return
+	}
+	annotations := c.obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[k] = value
+	c.obj.SetAnnotations(annotations)
+}
+
+func (c annotationCarrier) Keys() []string {
+	return []string{"traceparent", "tracestate"}
+}
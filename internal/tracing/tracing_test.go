@@ -0,0 +1,117 @@
+package tracing_test
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/rancher/fleet/internal/tracing"
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+)
+
+func setupExporter(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(tp)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prev)
+		_ = tp.Shutdown(context.Background())
+	})
+
+	return exporter
+}
+
+func TestPropagationThroughAnnotations(t *testing.T) {
+	exporter := setupExporter(t)
+
+	ctx, parent := tracing.Start(context.Background(), "fleet.apply")
+	bundle := &fleet.Bundle{}
+	tracing.Inject(ctx, bundle)
+	parent.End()
+
+	if bundle.Annotations[fleet.TraceParentAnnotation] == "" {
+		t.Fatalf("expected %s annotation to be set, got %v", fleet.TraceParentAnnotation, bundle.Annotations)
+	}
+
+	// The bundledeployment carries the bundle's annotations to the agent.
+	bd := &fleet.BundleDeployment{}
+	bd.Annotations = bundle.Annotations
+
+	_, child := tracing.Start(tracing.Extract(context.Background(), bd), "agent.Reconcile")
+	child.End()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	if spans[0].SpanContext.TraceID() != spans[1].SpanContext.TraceID() {
+		t.Errorf("expected spans to share a trace, got %s and %s", spans[0].SpanContext.TraceID(), spans[1].SpanContext.TraceID())
+	}
+	if spans[1].Parent.SpanID() != spans[0].SpanContext.SpanID() {
+		t.Errorf("expected %q to be a child of %q", spans[1].Name, spans[0].Name)
+	}
+}
+
+func TestPropagationThroughEnv(t *testing.T) {
+	exporter := setupExporter(t)
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://collector:4317")
+	t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "authorization=Bearer secret")
+	t.Setenv(tracing.HeadersSecretEnvVar, "otel-headers")
+
+	ctx, parent := tracing.Start(context.Background(), "gitops.createJob")
+	env := tracing.EnvVars(ctx)
+	parent.End()
+
+	found := map[string]string{}
+	for _, e := range env {
+		found[e.Name] = e.Value
+		if e.Name == "OTEL_EXPORTER_OTLP_HEADERS" {
+			if e.Value != "" || e.ValueFrom == nil || e.ValueFrom.SecretKeyRef == nil {
+				t.Fatalf("expected OTEL_EXPORTER_OTLP_HEADERS to be read from a secret, got %v", e)
+			}
+			if ref := e.ValueFrom.SecretKeyRef; ref.Name != "otel-headers" || ref.Key != tracing.HeadersSecretKey {
+				t.Errorf("expected OTEL_EXPORTER_OTLP_HEADERS from secret otel-headers, got %v", ref)
+			}
+		}
+	}
+	if found["OTEL_EXPORTER_OTLP_ENDPOINT"] != "http://collector:4317" {
+		t.Errorf("expected OTEL_EXPORTER_OTLP_ENDPOINT to be passed on, got %v", found)
+	}
+	if _, ok := found["OTEL_EXPORTER_OTLP_HEADERS"]; !ok {
+		t.Errorf("expected OTEL_EXPORTER_OTLP_HEADERS to be set from a secret, got %v", found)
+	}
+	if found[tracing.TraceParentEnvVar] == "" {
+		t.Fatalf("expected %s to be set, got %v", tracing.TraceParentEnvVar, found)
+	}
+
+	t.Setenv(tracing.TraceParentEnvVar, found[tracing.TraceParentEnvVar])
+	_, child := tracing.Start(tracing.FromEnv(context.Background()), "fleet.apply")
+	child.End()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	if spans[1].Parent.SpanID() != spans[0].SpanContext.SpanID() {
+		t.Errorf("expected %q to be a child of %q", spans[1].Name, spans[0].Name)
+	}
+}
+
+func TestInjectWithoutSpan(t *testing.T) {
+	bundle := &fleet.Bundle{}
+	tracing.Inject(context.Background(), bundle)
+
+	if len(bundle.Annotations) != 0 {
+		t.Errorf("expected no annotations without a span, got %v", bundle.Annotations)
+	}
+	if sc := trace.SpanContextFromContext(tracing.Extract(context.Background(), bundle)); sc.IsValid() {
+		t.Errorf("expected no trace context, got %v", sc)
+	}
+}
@@ -43,6 +43,17 @@ const (
 	// InternalSecretLabel is a label added to any secret created by Fleet to propagate Bundle or
 	// BundleDeployment secrets storing credential details for OCI storage or HelmOps.
 	InternalSecretLabel = "fleet.cattle.io/bundle-internal-secret"
+
+	// ChangeDetectedAtAnnotation holds the time, in RFC3339 format, at which
+	// the change deployed by a Bundle or BundleDeployment was detected, e.g.
+	// when a new commit was seen. It is used to measure deployment latency.
+	ChangeDetectedAtAnnotation = "fleet.cattle.io/change-detected-at"
+
+	// TraceParentAnnotation and TraceStateAnnotation hold the W3C trace
+	// context of the change deployed by a Bundle or BundleDeployment, so
+	// that spans of the controller and the agents join the same trace.
+	TraceParentAnnotation = "fleet.cattle.io/traceparent"
+	TraceStateAnnotation  = "fleet.cattle.io/tracestate"
 )
 
 var (
@@ -107,11 +118,24 @@ type BundleSpec struct {
 	// +nullable
 	RolloutStrategy *RolloutStrategy `json:"rolloutStrategy,omitempty"`
 
+	// Placement selects a number of clusters from the clusters matched by
+	// the targets, instead of deploying to all of them.
+	// +nullable
+	Placement *PlacementStrategy `json:"placement,omitempty"`
+
 	// Resources contains the resources that were read from the bundle's
 	// path. This includes the content of downloaded helm charts.
 	// +nullable
 	Resources []BundleResource `json:"resources,omitempty" jsonschema:"-"`
 
+	// Sources are additional sources of files for the bundle, e.g. values
+	// files owned by another team. The files of a source are available
+	// below ".sources/<name>/" and can be referenced as Helm valuesFiles,
+	// Helm chart or kustomize base. The charts of HelmOps are not read from
+	// sources, but their kustomize dir can be.
+	// +nullable
+	Sources []BundleSource `json:"sources,omitempty"`
+
 	// TargetCustomizationMode controls how targetCustomizations from fleet.yaml
 	// are evaluated. "FirstMatch" (default) stops at the first matching entry.
 	// "AllMatches" applies all matching entries in order, merging them.
@@ -160,6 +184,21 @@ type BundleRef struct {
 	// Example: ["Ready", "Modified"] will accept dependencies that are either ready or have drifted from their desired state.
 	// +nullable
 	AcceptedStates []BundleState `json:"acceptedStates,omitempty"`
+	// Namespace of the bundle, to depend on a bundle from another namespace,
+	// e.g. created by a platform GitRepo in another workspace. Defaults to
+	// the bundle's own namespace for references by name. The namespace must
+	// be listed in allowedDependencyNamespaces by a Policy in the bundle's
+	// namespace.
+	// +nullable
+	Namespace string `json:"namespace,omitempty"`
+	// Version is a semver constraint, e.g. ">= 1.14", which the chart
+	// version deployed by the dependency on the cluster must satisfy.
+	// +nullable
+	Version string `json:"version,omitempty"`
+	// Commit is the git commit, or a prefix of it, from which the dependency
+	// must be deployed on the cluster.
+	// +nullable
+	Commit string `json:"commit,omitempty"`
 }
 
 // BundleResource represents the content of a single resource from the bundle, like a YAML manifest.
@@ -220,6 +259,37 @@ type RolloutStrategy struct {
 	Partitions []Partition `json:"partitions,omitempty"`
 }
 
+const (
+	// PlacementPreferFewestBundles prefers clusters with the fewest
+	// BundleDeployments.
+	PlacementPreferFewestBundles = "FewestBundles"
+	// PlacementPreferLabelWeight prefers clusters with the highest integer
+	// value in the weight label.
+	PlacementPreferLabelWeight = "LabelWeight"
+)
+
+// PlacementStrategy picks a number of clusters from the matched clusters,
+// optionally spread across the values of a topology label.
+type PlacementStrategy struct {
+	// Clusters is the number of clusters to select. If topologyKey is set,
+	// it is the number of clusters for each value of the topology label.
+	// +kubebuilder:validation:Minimum=1
+	Clusters int `json:"clusters"`
+	// TopologyKey is a cluster label, e.g. "topology.kubernetes.io/region".
+	// Clusters without this label are not selected.
+	// +optional
+	TopologyKey string `json:"topologyKey,omitempty"`
+	// Prefer defines the order in which clusters are selected, either
+	// FewestBundles (default) or LabelWeight.
+	// +kubebuilder:validation:Enum=FewestBundles;LabelWeight
+	// +optional
+	Prefer string `json:"prefer,omitempty"`
+	// WeightLabel is the cluster label, which holds the weight of a cluster
+	// for LabelWeight. Clusters with higher weights are preferred.
+	// +optional
+	WeightLabel string `json:"weightLabel,omitempty"`
+}
+
 // Partition defines a separate rollout strategy for a set of clusters.
 type Partition struct {
 	// A user-friendly name given to the partition used for Display (optional).
@@ -238,6 +308,9 @@ type Partition struct {
 	// Selector matching cluster group labels to include in this partition
 	// +nullable
 	ClusterGroupSelector *metav1.LabelSelector `json:"clusterGroupSelector,omitempty"`
+	// CEL expression matching clusters to include in this partition
+	// +nullable
+	ClusterExpression string `json:"clusterExpression,omitempty"`
 }
 
 // BundleTargetRestriction is used internally by Fleet and should not be modified.
@@ -254,6 +327,8 @@ type BundleTargetRestriction struct {
 	ClusterGroup string `json:"clusterGroup,omitempty"`
 	// +nullable
 	ClusterGroupSelector *metav1.LabelSelector `json:"clusterGroupSelector,omitempty"`
+	// +nullable
+	ClusterExpression string `json:"clusterExpression,omitempty"`
 }
 
 // BundleTarget declares clusters to deploy to. Fleet will merge the
@@ -280,6 +355,12 @@ type BundleTarget struct {
 	// ClusterGroupSelector is a selector to match cluster groups.
 	// +nullable
 	ClusterGroupSelector *metav1.LabelSelector `json:"clusterGroupSelector,omitempty"`
+	// ClusterExpression is a CEL expression, which must evaluate to true
+	// for a cluster to be selected. It can access the cluster's name,
+	// labels, annotations, clusterGroups and the facts reported by its
+	// agent, e.g. 'semver(facts.kubernetesVersion, true).compareTo(semver("1.29.0")) >= 0'.
+	// +nullable
+	ClusterExpression string `json:"clusterExpression,omitempty"`
 	// DoNotDeploy if set to true, will not deploy to this target.
 	DoNotDeploy bool `json:"doNotDeploy,omitempty"`
 	// NamespaceLabels are labels that will be appended to the namespace created by Fleet.
@@ -410,6 +491,11 @@ type BundleStatus struct {
 	MaxUnavailablePartitions int `json:"maxUnavailablePartitions"`
 	// PartitionStatus lists the status of each partition.
 	PartitionStatus []PartitionStatus `json:"partitions,omitempty"`
+	// Placement records the clusters selected by the placement strategy.
+	// Selected clusters are kept, as long as they match and their agent
+	// checks in. The health of the bundle on a cluster is not considered.
+	// +optional
+	Placement *PlacementStatus `json:"placement,omitempty"`
 	// Display contains the number of ready, desiredready clusters and a
 	// summary state for the bundle's resources.
 	Display BundleDisplay `json:"display,omitempty"`
@@ -426,6 +512,79 @@ type BundleStatus struct {
 	ObservedGeneration int64 `json:"observedGeneration"`
 	// ResourcesSHA256Sum corresponds to the JSON serialization of the .Spec.Resources field
 	ResourcesSHA256Sum string `json:"resourcesSha256Sum,omitempty"`
+	// ResourcesSize is the size of the bundle's stored resources, in its
+	// Content resource or OCI artifact.
+	// +optional
+	ResourcesSize int64 `json:"resourcesSize,omitempty"`
+	// Sources contains the revision of each source of the bundle.
+	// +nullable
+	Sources []BundleSourceStatus `json:"sources,omitempty"`
+}
+
+// BundleSourcesDir is the directory containing the files of the bundle's
+// sources. Hidden directories are not read from the bundle's path, so the
+// files of sources never collide with the bundle's own files.
+const BundleSourcesDir = ".sources"
+
+// BundleSource is an additional source of files for a bundle. Exactly one
+// of Git, OCI and ConfigMap must be set.
+type BundleSource struct {
+	// Name of the source, its files are available below ".sources/<name>/".
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name"`
+	// Git reads the files from a git repository.
+	// +nullable
+	Git *GitSource `json:"git,omitempty"`
+	// OCI reads the files from an OCI artifact.
+	// +nullable
+	OCI *OCISource `json:"oci,omitempty"`
+	// ConfigMap uses the keys of a ConfigMap in the bundle's namespace as
+	// file names.
+	// +nullable
+	ConfigMap *LocalObjectReference `json:"configMap,omitempty"`
+	// SecretName is the name of a secret in the bundle's namespace, which
+	// contains the credentials for the git repository or OCI registry. Git
+	// supports basic-auth and ssh-auth secrets, OCI basic-auth secrets.
+	// +nullable
+	SecretName string `json:"secretName,omitempty"`
+}
+
+// GitSource references a directory in a git repository.
+type GitSource struct {
+	// Repo is the URL of the git repository.
+	Repo string `json:"repo"`
+	// Revision is a branch, tag or commit. Defaults to the repository's
+	// default branch.
+	// +nullable
+	Revision string `json:"revision,omitempty"`
+	// Path is the directory in the repository, whose files are used.
+	// Defaults to the root of the repository.
+	// +nullable
+	Path string `json:"path,omitempty"`
+}
+
+// OCISource references an OCI artifact. Layers with a title annotation are
+// used as files, gzipped tarballs are extracted.
+type OCISource struct {
+	// Reference of the artifact, e.g.
+	// "registry.example.com/platform/values:1.0".
+	Reference string `json:"reference"`
+	// InsecureSkipTLSVerify disables verification of the registry's
+	// certificate.
+	InsecureSkipTLSVerify bool `json:"insecureSkipTLSVerify,omitempty"`
+}
+
+// BundleSourceStatus is the revision of a bundle's source.
+type BundleSourceStatus struct {
+	// Name of the source.
+	Name string `json:"name"`
+	// Revision is the commit of a git source, the digest of an OCI artifact
+	// or the resource version of a ConfigMap.
+	Revision string `json:"revision,omitempty"`
+	// LastPollingTime is the last time a git or OCI source was polled for
+	// a new revision.
+	// +nullable
+	LastPollingTime metav1.Time `json:"lastPollingTime,omitempty"`
 }
 
 // ResourceKey lists resources, which will likely be deployed.
@@ -444,6 +603,25 @@ type ResourceKey struct {
 	Name string `json:"name,omitempty"`
 }
 
+// PlacementStatus is the result of the placement strategy of a bundle.
+type PlacementStatus struct {
+	// Clusters are the selected clusters.
+	// +optional
+	Clusters []PlacedCluster `json:"clusters,omitempty"`
+	// Message explains why fewer clusters than requested were selected.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// PlacedCluster is a cluster selected by a placement strategy.
+type PlacedCluster struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// Topology is the cluster's value of the topology label.
+	// +optional
+	Topology string `json:"topology,omitempty"`
+}
+
 // BundleDisplay contains the number of ready, desiredready clusters and a
 // summary state for the bundle.
 type BundleDisplay struct {
@@ -132,6 +132,31 @@ type BundleDeploymentOptions struct {
 	// namespace.
 	DownstreamResources []DownstreamResource `json:"downstreamResources,omitempty"`
 
+	// Outputs are values read from the deployed resources, which the agent
+	// reports in the BundleDeployment status. Bundles which depend on this
+	// bundle can use them in their Helm templateValues, by bundle name and
+	// output name, e.g. '${ index .Outputs "infra-lb" "ip" }'. The namespace
+	// of the bundle is not part of the key, so a bundle can't depend on
+	// bundles with the same name from different namespaces, which have
+	// outputs.
+	// Only namespaced resources of the bundle, in the namespace it is
+	// deployed to, can be read. Secrets can't be read.
+	// +nullable
+	Outputs []BundleOutput `json:"outputs,omitempty"`
+
+	// Patches are applied to the rendered resources of Helm charts,
+	// kustomize and raw YAML, before they are deployed. The patches are
+	// templated like Helm templateValues, e.g. with
+	// '${ .ClusterLabels.region }'.
+	// +nullable
+	Patches []ResourcePatch `json:"patches,omitempty"`
+
+	// Substitute replaces ${var} references in the rendered resources. It
+	// lets raw YAML and kustomize bundles use cluster specific values,
+	// without converting them to Helm charts.
+	// +nullable
+	Substitute *SubstituteOptions `json:"substitute,omitempty"`
+
 	// Overwrites indicates which resources, if any, come from this bundle and overwrite another existing bundle.
 	// This flag is set internally by Fleet, and should not be altered by users.
 	Overwrites []OverwrittenResource `json:"overwrites,omitempty" jsonschema:"-"`
@@ -140,6 +165,18 @@ type BundleDeploymentOptions struct {
 	// Propagated from GitRepoRestriction and validated by the agent on the downstream cluster.
 	// +nullable
 	AllowedTargetNamespaceSelector *metav1.LabelSelector `json:"allowedTargetNamespaceSelector,omitempty" jsonschema:"-"`
+
+	// ResourceRules are checked by the agent against each rendered resource before applying it.
+	// Propagated from the Policy objects in the bundle's namespace and set internally by Fleet.
+	// +nullable
+	ResourceRules []ResourceRule `json:"resourceRules,omitempty" jsonschema:"-"`
+
+	// DependencyOutputs are the outputs of the dependencies, by bundle name
+	// and output name, with which the options were rendered. The agent
+	// doesn't deploy, until they match the outputs reported by the
+	// dependencies. Set internally by Fleet.
+	// +nullable
+	DependencyOutputs map[string]map[string]string `json:"dependencyOutputs,omitempty" jsonschema:"-"`
 }
 
 // GitOpsBundleDeploymentOptions contains options which only make sense for GitOps
@@ -231,6 +268,11 @@ type HelmOptions struct {
 
 	// +nullable
 	// Repo is the name of the HTTPS helm repo to download the chart from.
+	// HelmOps also accept a git repository, e.g.
+	// "git::https://github.com/org/charts?ref=main" or
+	// "https://github.com/org/charts.git", in which case Chart is the path
+	// of the chart in the repository and Version an optional constraint on
+	// the version in its Chart.yaml.
 	Repo string `json:"repo,omitempty"`
 
 	// ReleaseName sets a custom release name to deploy the chart as. If
@@ -259,6 +301,8 @@ type HelmOptions struct {
 	// first, before serializing to yaml. This allows to template complex values,
 	// like ranges and maps.
 	// templateValues keys have precedence over values keys in case of conflict.
+	// The outputs of the bundles in dependsOn are available as .Outputs,
+	// by bundle name and output name, e.g. '${ index .Outputs "infra-lb" "ip" }'.
 	// +nullable
 	TemplateValues map[string]string `json:"templateValues,omitempty"`
 
@@ -320,6 +364,73 @@ type ValuesFrom struct {
 	// +optional
 	// +nullable
 	SecretKeyRef *SecretKeySelector `json:"secretKeyRef,omitempty"`
+	// The reference to a secret with release values in a Vault-compatible
+	// KV secrets engine.
+	// +optional
+	// +nullable
+	VaultRef *VaultValuesSource `json:"vaultRef,omitempty"`
+}
+
+// VaultValuesSource references a secret in a Vault-compatible KV secrets
+// engine. The secret is read by the agent on the downstream cluster.
+type VaultValuesSource struct {
+	// Address of the Vault server, e.g. https://vault.example.com:8200.
+	// +kubebuilder:validation:Required
+	Address string `json:"address"`
+	// Mount is the path the KV secrets engine is mounted at. Defaults to "secret".
+	// +optional
+	Mount string `json:"mount,omitempty"`
+	// KVVersion is the version of the KV secrets engine, 1 or 2. Defaults to 2.
+	// +optional
+	// +kubebuilder:validation:Enum=1;2
+	KVVersion int `json:"kvVersion,omitempty"`
+	// Path of the secret within the secrets engine.
+	// +optional
+	Path string `json:"path,omitempty"`
+	// PathTemplate is rendered for each cluster by the Fleet controller and
+	// replaces Path, e.g. "clusters/${ .ClusterName }/app". It has access to
+	// the same values as helm templateValues.
+	// +optional
+	PathTemplate string `json:"pathTemplate,omitempty"`
+	// Key of the secret, which contains the values as YAML. If empty, all
+	// keys of the secret are used as values.
+	// +optional
+	Key string `json:"key,omitempty"`
+	// Auth configures how the agent authenticates to Vault.
+	Auth VaultAuth `json:"auth"`
+	// CABundle is a PEM encoded CA bundle to verify the Vault server.
+	// +optional
+	CABundle []byte `json:"caBundle,omitempty"`
+	// RefreshInterval is the interval at which the secret is read again.
+	// The bundle deployment is redeployed if it changed. Defaults to 5m.
+	// +optional
+	// +nullable
+	RefreshInterval *metav1.Duration `json:"refreshInterval,omitempty"`
+}
+
+// VaultAuth configures the authentication to Vault.
+type VaultAuth struct {
+	// Method is the authentication method: "token", "kubernetes" or "approle".
+	// +kubebuilder:validation:Enum=token;kubernetes;approle
+	Method string `json:"method"`
+	// MountPath of the authentication method. Defaults to the name of the method.
+	// +optional
+	MountPath string `json:"mountPath,omitempty"`
+	// Role used for the kubernetes authentication method.
+	// +optional
+	Role string `json:"role,omitempty"`
+	// SecretName is the name of a secret on the downstream cluster with
+	// the credentials: the "token" key for the token method, the "role_id"
+	// and "secret_id" keys for the approle method. For the kubernetes
+	// method, an optional "token" key replaces the token requested for the
+	// bundle's service account. The secret is read from the namespace of
+	// the helm release.
+	// +optional
+	SecretName string `json:"secretName,omitempty"`
+	// Audience of the service account token requested for the kubernetes
+	// method. Defaults to "vault".
+	// +optional
+	Audience string `json:"audience,omitempty"`
 }
 
 type ConfigMapKeySelector struct {
@@ -416,6 +527,101 @@ type DownstreamResource struct {
 	Name string `json:"name,omitempty"`
 }
 
+// BundleOutput reads a value from a deployed resource.
+type BundleOutput struct {
+	// Name of the output, used to reference it from other bundles.
+	Name string `json:"name"`
+	// APIVersion of the resource, e.g. "v1".
+	APIVersion string `json:"apiVersion"`
+	// Kind of the resource, e.g. "Service".
+	Kind string `json:"kind"`
+	// Namespace of the resource. Defaults to, and must be, the namespace
+	// the bundle is deployed to.
+	// +nullable
+	Namespace string `json:"namespace,omitempty"`
+	// ResourceName is the name of the resource.
+	ResourceName string `json:"resourceName"`
+	// JSONPath is evaluated against the resource to produce the output's
+	// value, e.g. "{.status.loadBalancer.ingress[0].ip}".
+	JSONPath string `json:"jsonPath"`
+}
+
+const (
+	// ResourcePatchStrategicMerge patches resources with a strategic-merge
+	// patch. Kinds unknown to Fleet fall back to a JSON merge patch.
+	ResourcePatchStrategicMerge = "strategic-merge"
+	// ResourcePatchJSON6902 patches resources with a list of JSON6902
+	// operations.
+	ResourcePatchJSON6902 = "json6902"
+)
+
+// ResourcePatch patches the rendered resources selected by its target.
+type ResourcePatch struct {
+	// Target selects the resources to patch.
+	Target PatchTarget `json:"target"`
+	// Type of the patch, either "strategic-merge" (default) or "json6902".
+	// +kubebuilder:validation:Enum=strategic-merge;json6902
+	// +nullable
+	Type string `json:"type,omitempty"`
+	// Patch is the YAML or JSON patch document. For "strategic-merge" it
+	// is a partial resource, for "json6902" a list of operations.
+	Patch string `json:"patch"`
+}
+
+// PatchTarget selects resources by group, version, kind and name. Empty
+// fields match all resources.
+type PatchTarget struct {
+	// Group of the resource, e.g. "apps". Use "core" to only match
+	// resources of the core group.
+	// +nullable
+	Group string `json:"group,omitempty"`
+	// Version of the resource, e.g. "v1".
+	// +nullable
+	Version string `json:"version,omitempty"`
+	// Kind of the resource, e.g. "Deployment".
+	// +nullable
+	Kind string `json:"kind,omitempty"`
+	// Name of the resource, supports shell glob patterns like "web-*".
+	// +nullable
+	Name string `json:"name,omitempty"`
+	// Namespace of the resource.
+	// +nullable
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// SubstituteOptions configure the substitution of ${var} references in the
+// rendered resources. A reference can set a default with ${var:=default},
+// "$${var}" is kept as "${var}". Deploying fails if a variable without a
+// default is not set. Variables are only substituted in string values,
+// which stay strings, so they can't set numbers, booleans or keys.
+type SubstituteOptions struct {
+	// Vars are variables with fixed values. For each target, Fleet adds the
+	// variables ClusterName, ClusterNamespace, ClusterLabels.<label> and
+	// ClusterValues.<path>, from the cluster's templateValues, unless they
+	// are set here. Vars take precedence over VarsFrom.
+	// +nullable
+	Vars map[string]string `json:"vars,omitempty"`
+	// VarsFrom reads variables from ConfigMaps and Secrets in the downstream
+	// cluster, each key is a variable. Later entries take precedence.
+	// +nullable
+	VarsFrom []SubstituteFrom `json:"varsFrom,omitempty"`
+}
+
+// SubstituteFrom references a ConfigMap or Secret containing variables.
+type SubstituteFrom struct {
+	// Kind of the resource, either ConfigMap or Secret.
+	// +kubebuilder:validation:Enum=ConfigMap;Secret
+	Kind string `json:"kind"`
+	// Name of the resource.
+	Name string `json:"name"`
+	// Namespace of the resource. Defaults to the namespace the bundle is
+	// deployed to.
+	// +nullable
+	Namespace string `json:"namespace,omitempty"`
+	// Optional ignores the resource if it does not exist.
+	Optional bool `json:"optional,omitempty"`
+}
+
 type BundleDeploymentStatus struct {
 	// +nullable
 	Conditions []genericcondition.GenericCondition `json:"conditions,omitempty"`
@@ -446,6 +652,18 @@ type BundleDeploymentStatus struct {
 	// It is incremented every time DownstreamResources are modified and reflects the value in the spec
 	// after it has been processed.
 	DownstreamResourcesGeneration int64 `json:"downstreamResourcesGeneration,omitempty"`
+	// ExternalValuesHash is the hash of the values read from external
+	// stores, e.g. Vault, which were used for the last deployment.
+	// +optional
+	ExternalValuesHash string `json:"externalValuesHash,omitempty"`
+	// ChartVersion is the version of the chart of the deployed Helm release.
+	// +optional
+	ChartVersion string `json:"chartVersion,omitempty"`
+	// Outputs contains the values of the outputs declared in the options,
+	// by name. Outputs which can't be read yet, e.g. because the resource
+	// doesn't exist, are missing.
+	// +nullable
+	Outputs map[string]string `json:"outputs,omitempty"`
 }
 
 type BundleDeploymentDisplay struct {
@@ -265,6 +265,11 @@ type ClusterStatus struct {
 	// AgentStatus contains information about the agent.
 	Agent AgentStatus `json:"agent,omitempty"`
 
+	// Facts about the downstream cluster, as collected by the agent.
+	// +nullable
+	// +optional
+	Facts *ClusterFacts `json:"facts,omitempty"`
+
 	// GarbageCollectionInterval determines how often agents clean up obsolete Helm releases.
 	GarbageCollectionInterval *metav1.Duration `json:"garbageCollectionInterval,omitempty"`
 
@@ -291,6 +296,37 @@ type ClusterDisplay struct {
 	State string `json:"state,omitempty"`
 }
 
+// ClusterFacts are collected by the agent on the downstream cluster and
+// reported on each check-in.
+type ClusterFacts struct {
+	// KubernetesVersion is the git version of the API server, e.g. "v1.30.2+k3s1".
+	// +optional
+	KubernetesVersion string `json:"kubernetesVersion,omitempty"`
+	// Distribution is the detected Kubernetes distribution, e.g. "k3s",
+	// "rke2", "eks", "gke", "aks" or "openshift". Empty if unknown.
+	// +optional
+	Distribution string `json:"distribution,omitempty"`
+	// CloudProvider is derived from the provider ID of the nodes, e.g.
+	// "aws", "gce" or "azure". Empty if unknown.
+	// +optional
+	CloudProvider string `json:"cloudProvider,omitempty"`
+	// Architectures are the CPU architectures of the nodes, e.g. "amd64".
+	// +optional
+	Architectures []string `json:"architectures,omitempty"`
+	// OperatingSystems are the operating systems of the nodes, e.g. "linux".
+	// +optional
+	OperatingSystems []string `json:"operatingSystems,omitempty"`
+	// NodeCount is the number of nodes.
+	// +optional
+	NodeCount int `json:"nodeCount,omitempty"`
+	// Allocatable is the sum of the allocatable resources of all nodes.
+	// +optional
+	Allocatable corev1.ResourceList `json:"allocatable,omitempty"`
+	// CRDGroups are the API groups of the installed custom resource definitions.
+	// +optional
+	CRDGroups []string `json:"crdGroups,omitempty"`
+}
+
 type AgentStatus struct {
 	// LastSeen is the last time the agent checked in to update the status
 	// of the cluster resource.
@@ -301,4 +337,37 @@ type AgentStatus struct {
 	// +nullable
 	// +optional
 	Namespace string `json:"namespace"`
+	// ContentBlobs is true if the agent reads bundle resources from the
+	// blobs of content resources. Otherwise the whole manifest is stored
+	// in the content resource for the agent.
+	// +optional
+	ContentBlobs bool `json:"contentBlobs,omitempty"`
+	// CredentialGeneration is the generation of the latest credential
+	// issued to the agent by credential rotation.
+	// +optional
+	CredentialGeneration int64 `json:"credentialGeneration,omitempty"`
+	// ActiveCredentialGeneration is reported by the agent, after it
+	// switched to a rotated credential.
+	// +optional
+	ActiveCredentialGeneration int64 `json:"activeCredentialGeneration,omitempty"`
+	// CredentialRotation is the state of the latest credential rotation:
+	// "Issued" until the agent uses the new credential, "Active" until the
+	// previous credentials are revoked and "Completed" afterwards.
+	// +optional
+	CredentialRotation string `json:"credentialRotation,omitempty"`
+	// LastCredentialRotation is the time the latest credential was issued.
+	// +nullable
+	// +optional
+	LastCredentialRotation *metav1.Time `json:"lastCredentialRotation,omitempty"`
 }
+
+const (
+	// CredentialRotationIssued means a new credential was issued, but the
+	// agent has not switched to it yet.
+	CredentialRotationIssued = "Issued"
+	// CredentialRotationActive means the agent uses the new credential,
+	// previous credentials are revoked after a grace period.
+	CredentialRotationActive = "Active"
+	// CredentialRotationCompleted means previous credentials were revoked.
+	CredentialRotationCompleted = "Completed"
+)
@@ -29,14 +29,34 @@ type Content struct {
 	// +nullable
 	Content []byte `json:"content,omitempty"`
 
+	// Blobs reference the bundle resources, which are stored once per
+	// content in separate Content resources, named after the SHA256 sum of
+	// the resource content. The Content field is only set if the agent of
+	// a targeted cluster does not read blobs.
+	// +nullable
+	// +optional
+	Blobs []ContentBlob `json:"blobs,omitempty"`
+
 	// SHA256Sum of the Content field
 	SHA256Sum string        `json:"sha256sum,omitempty"` // SHA256Sum of the Content field
 	Status    ContentStatus `json:"status,omitempty"`    // +optional
 }
 
+// ContentBlob references a bundle resource, which is stored as a blob.
+type ContentBlob struct {
+	// Name of the resource, can include the bundle's internal path.
+	Name string `json:"name,omitempty"`
+	// Encoding of the resource content, as in BundleResource.
+	// +optional
+	Encoding string `json:"encoding,omitempty"`
+	// SHA256Sum of the resource content, which addresses the blob.
+	SHA256Sum string `json:"sha256sum,omitempty"`
+}
+
 // ContentStatus defines the observed state of Content
 type ContentStatus struct {
 	// ReferenceCount is the number of BundleDeployments that currently reference this Content resource.
+	// For blobs, it is the number of Content resources referencing the blob.
 	// +optional
 	ReferenceCount int `json:"referenceCount,omitempty"`
 }
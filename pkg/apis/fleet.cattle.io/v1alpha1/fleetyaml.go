@@ -9,6 +9,21 @@ type FleetYAML struct {
 	// Labels are copied to the bundle and can be used in a
 	// dependsOn.selector.
 	Labels map[string]string `json:"labels,omitempty"`
+	// Extends lists fleet.yaml files, relative to this file, which are
+	// merged in order, before this file is merged on top of them. Maps are
+	// merged recursively, a null value removes an inherited key and lists
+	// replace inherited lists, except for targetCustomizations, dependsOn,
+	// imageScans, outputs, sources, downstreamResources,
+	// diff.comparePatches, helm.valuesFiles and helm.valuesFrom, which are
+	// appended. Entries of targetCustomizations, dependsOn, imageScans,
+	// outputs and sources replace inherited entries with the same name.
+	// Relative paths in helm.valuesFiles, helm.chart and kustomize.dir are
+	// relative to the file they are defined in. Overlays are always read
+	// from the bundle's "overlays/" directory.
+	// Extended files must be inside the repository. A directory containing
+	// a fleet.yaml is turned into a bundle, so shared files should use
+	// another name, e.g. "base/common.yaml".
+	Extends []string `json:"extends,omitempty"`
 	BundleSpec
 	// TargetCustomizations are used to determine how resources should be
 	// modified per target. Targets are evaluated in order. By default
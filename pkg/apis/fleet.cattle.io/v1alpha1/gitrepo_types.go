@@ -179,6 +179,9 @@ type GitTarget struct {
 	// ClusterGroupSelector is a label selector to select cluster groups.
 	// +nullable
 	ClusterGroupSelector *metav1.LabelSelector `json:"clusterGroupSelector,omitempty"`
+	// ClusterExpression is a CEL expression to select clusters, see BundleTarget.
+	// +nullable
+	ClusterExpression string `json:"clusterExpression,omitempty"`
 }
 
 type GitRepoStatus struct {
@@ -207,6 +210,19 @@ type GitRepoStatus struct {
 	LastSyncedImageScanTime metav1.Time `json:"lastSyncedImageScanTime,omitempty"`
 	// LastPollingTime is the last time the polling check was triggered
 	LastPollingTime metav1.Time `json:"lastPollingTriggered,omitempty"`
+	// CommitDetectedTime is the time at which the commit in Commit was
+	// detected, by polling or by a webhook.
+	// +optional
+	CommitDetectedTime metav1.Time `json:"commitDetectedTime,omitempty"`
+	// LastSyncTime is the time at which all bundle deployments were ready
+	// with the last detected commit.
+	// +optional
+	LastSyncTime metav1.Time `json:"lastSyncTime,omitempty"`
+	// LastSyncDuration is the time it took from detecting the last synced
+	// commit until all bundle deployments were ready.
+	// +optional
+	// +nullable
+	LastSyncDuration *metav1.Duration `json:"lastSyncDuration,omitempty"`
 }
 
 // CommitSpec specifies how to commit changes to the git repository
@@ -13,6 +13,11 @@ const (
 	// selectors - are trusted and must not be agent-assertable.
 	ManagementLabelPrefix = "management.cattle.io/"
 
+	// FactsLabelPrefix is the label namespace of cluster facts, which are
+	// reported by the agent and copied to the cluster labels by the
+	// fleet-controller. It must not be agent-assertable during registration.
+	FactsLabelPrefix = "facts.fleet.cattle.io/"
+
 	// CreatedByAgentPodLabel is set by the agent on its own registration to
 	// record the agent pod that created it, for debugging. Informational only;
 	// never used for targeting or authorization. It is the one FleetLabelPrefix
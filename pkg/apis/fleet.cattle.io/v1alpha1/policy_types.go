@@ -1,6 +1,9 @@
 package v1alpha1
 
-import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+import (
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
 
 func init() {
 	InternalSchemeBuilder.Register(&Policy{}, &PolicyList{})
@@ -9,6 +12,10 @@ func init() {
 // +genclient
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 // +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="GitRepos",type=integer,JSONPath=`.status.usage.gitRepos`
+// +kubebuilder:printcolumn:name="HelmOps",type=integer,JSONPath=`.status.usage.helmOps`
+// +kubebuilder:printcolumn:name="Bundles",type=integer,JSONPath=`.status.usage.bundles`
 
 // Policy restricts what GitRepo, HelmOp, and Bundle resources in the same
 // namespace may do. Enforced at three points in the controller stack:
@@ -51,6 +58,21 @@ type Policy struct {
 	// +optional
 	AllowNamespaceCreation bool `json:"allowNamespaceCreation,omitempty"`
 
+	// ResourceRules are evaluated by the agent against every rendered
+	// resource of a BundleDeployment, before it is applied. A resource which
+	// violates any rule blocks the BundleDeployment.
+	// Rules of all Policy objects in the namespace are combined.
+	// +optional
+	// +nullable
+	ResourceRules []ResourceRule `json:"resourceRules,omitempty"`
+
+	// AllowedDependencyNamespaces lists the namespaces of the bundles, which
+	// bundles in this namespace may depend on via dependsOn. Bundles can
+	// always depend on bundles in their own namespace.
+	// +optional
+	// +nullable
+	AllowedDependencyNamespaces []string `json:"allowedDependencyNamespaces,omitempty"`
+
 	// GitRepo contains restrictions and defaults applied only by the GitRepo reconciler.
 	// +optional
 	GitRepo *GitRepoPolicySpec `json:"gitRepo,omitempty"`
@@ -58,6 +80,103 @@ type Policy struct {
 	// HelmOp contains restrictions and defaults applied only by the HelmOp reconciler.
 	// +optional
 	HelmOp *HelmOpPolicySpec `json:"helmOp,omitempty"`
+
+	// Quota limits the number and size of resources in the namespace.
+	// +optional
+	Quota *PolicyQuota `json:"quota,omitempty"`
+
+	// Status reports the usage of the namespace, as counted against the quota.
+	// +optional
+	Status PolicyStatus `json:"status,omitempty"`
+}
+
+// PolicyQuota limits what can be created in a namespace. Unset fields are
+// not limited. When several Policy objects set the same limit, the lowest
+// one applies.
+//
+// GitRepos, HelmOps and Bundles are counted in creation order: the oldest
+// objects are allowed, the ones exceeding the quota are rejected with an error
+// in their status.
+type PolicyQuota struct {
+	// MaxGitRepos is the maximum number of GitRepos.
+	// +optional
+	// +nullable
+	// +kubebuilder:validation:Minimum=0
+	MaxGitRepos *int32 `json:"maxGitRepos,omitempty"`
+
+	// MaxHelmOps is the maximum number of HelmOps.
+	// +optional
+	// +nullable
+	// +kubebuilder:validation:Minimum=0
+	MaxHelmOps *int32 `json:"maxHelmOps,omitempty"`
+
+	// MaxBundles is the maximum number of Bundles, including the ones
+	// created by GitRepos and HelmOps.
+	// +optional
+	// +nullable
+	// +kubebuilder:validation:Minimum=0
+	MaxBundles *int32 `json:"maxBundles,omitempty"`
+
+	// MaxBundleSize is the maximum size of the resources stored for a
+	// single Bundle, in its Content resource or OCI artifact, e.g. "1Mi".
+	// HelmOps store no resources, their charts are downloaded by the agents
+	// and are not limited.
+	// +optional
+	// +nullable
+	MaxBundleSize *resource.Quantity `json:"maxBundleSize,omitempty"`
+
+	// MaxTargetClusters is the maximum number of clusters a single Bundle
+	// may be deployed to.
+	// +optional
+	// +nullable
+	// +kubebuilder:validation:Minimum=0
+	MaxTargetClusters *int32 `json:"maxTargetClusters,omitempty"`
+}
+
+// PolicyStatus reports the usage of a namespace.
+type PolicyStatus struct {
+	// Usage of the namespace.
+	// +optional
+	Usage PolicyUsage `json:"usage,omitempty"`
+}
+
+// PolicyUsage counts the resources in a namespace, which are limited by a
+// PolicyQuota.
+type PolicyUsage struct {
+	// GitRepos is the number of GitRepos in the namespace.
+	GitRepos int32 `json:"gitRepos"`
+	// HelmOps is the number of HelmOps in the namespace.
+	HelmOps int32 `json:"helmOps"`
+	// Bundles is the number of Bundles in the namespace.
+	Bundles int32 `json:"bundles"`
+	// LargestBundleSize is the size of the resources of the largest Bundle.
+	// +optional
+	// +nullable
+	LargestBundleSize *resource.Quantity `json:"largestBundleSize,omitempty"`
+	// MaxTargetClusters is the highest number of clusters targeted by a
+	// single Bundle.
+	MaxTargetClusters int32 `json:"maxTargetClusters"`
+}
+
+// ResourceRule is a CEL expression, which every rendered resource must
+// satisfy.
+type ResourceRule struct {
+	// Name identifies the rule in violation messages.
+	Name string `json:"name"`
+
+	// Expression is a CEL expression, which must evaluate to true for a
+	// resource to be allowed. The following variables are available:
+	//
+	//   - object: the rendered resource.
+	//   - podSpec: the pod spec of pods and workload pod templates, or an
+	//     empty map for other kinds.
+	//   - clusterScoped: true if the resource is not namespaced.
+	Expression string `json:"expression"`
+
+	// Message is reported for resources which violate the rule. Defaults to
+	// the expression.
+	// +optional
+	Message string `json:"message,omitempty"`
 }
 
 // GitRepoPolicySpec holds GitRepo-specific defaults and source restrictions.
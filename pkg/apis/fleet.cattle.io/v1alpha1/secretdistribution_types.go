@@ -0,0 +1,83 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func init() {
+	InternalSchemeBuilder.Register(&SecretDistribution{}, &SecretDistributionList{})
+}
+
+var (
+	// SecretDistributionLabel is set on the bundle created for a
+	// SecretDistribution and contains the name of the SecretDistribution.
+	SecretDistributionLabel = "fleet.cattle.io/secret-distribution-name"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:categories=fleet,path=secretdistributions
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Secret",type=string,JSONPath=`.spec.secretName`
+// +kubebuilder:printcolumn:name="Target-Namespace",type=string,JSONPath=`.spec.targetNamespace`
+// +kubebuilder:printcolumn:name="BundleDeployments-Ready",type=string,JSONPath=`.status.display.readyBundleDeployments`
+// +kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].message`
+
+// SecretDistribution copies a secret from its namespace on the management
+// cluster to the targeted downstream clusters.
+//
+// The secret is transported by a bundle without resources, which references
+// the secret as a downstream resource. Changes to the source secret are
+// propagated to all clusters. The copies are deleted from a cluster when it is
+// no longer targeted or when the SecretDistribution is deleted.
+type SecretDistribution struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SecretDistributionSpec   `json:"spec,omitempty"`
+	Status SecretDistributionStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// SecretDistributionList contains a list of SecretDistribution
+type SecretDistributionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SecretDistribution `json:"items"`
+}
+
+type SecretDistributionSpec struct {
+	// SecretName is the name of the secret to distribute. It must be in
+	// the namespace of the SecretDistribution.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	SecretName string `json:"secretName"`
+
+	// TargetNamespace is the namespace on the downstream clusters the
+	// secret is copied to. Defaults to the agent's default namespace.
+	// +nullable
+	TargetNamespace string `json:"targetNamespace,omitempty"`
+
+	// Templates overwrite the data of the copied secret per key, with a
+	// value rendered for each cluster. Templates use "${" and "}" as
+	// delimiters and have access to the same cluster context as helm
+	// templateValues, e.g. ${ .ClusterName } or ${ .ClusterValues.region },
+	// and to the data of the source secret as strings in .Secret, e.g.
+	// ${ .Secret.password }.
+	// +nullable
+	Templates map[string]string `json:"templates,omitempty"`
+
+	// Targets refer to the clusters which will receive the secret.
+	// +nullable
+	Targets []BundleTarget `json:"targets,omitempty"`
+}
+
+type SecretDistributionStatus struct {
+	StatusBase `json:",inline"`
+
+	// ObservedGeneration is the generation of the SecretDistribution,
+	// which was used to create the bundle.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
@@ -57,6 +57,10 @@ func (in *AgentSchedulingCustomization) DeepCopy() *AgentSchedulingCustomization
 func (in *AgentStatus) DeepCopyInto(out *AgentStatus) {
 	*out = *in
 	in.LastSeen.DeepCopyInto(&out.LastSeen)
+	if in.LastCredentialRotation != nil {
+		in, out := &in.LastCredentialRotation, &out.LastCredentialRotation
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AgentStatus.
@@ -233,6 +237,21 @@ func (in *BundleDeploymentOptions) DeepCopyInto(out *BundleDeploymentOptions) {
 		*out = make([]DownstreamResource, len(*in))
 		copy(*out, *in)
 	}
+	if in.Outputs != nil {
+		in, out := &in.Outputs, &out.Outputs
+		*out = make([]BundleOutput, len(*in))
+		copy(*out, *in)
+	}
+	if in.Patches != nil {
+		in, out := &in.Patches, &out.Patches
+		*out = make([]ResourcePatch, len(*in))
+		copy(*out, *in)
+	}
+	if in.Substitute != nil {
+		in, out := &in.Substitute, &out.Substitute
+		*out = new(SubstituteOptions)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Overwrites != nil {
 		in, out := &in.Overwrites, &out.Overwrites
 		*out = make([]OverwrittenResource, len(*in))
@@ -243,6 +262,29 @@ func (in *BundleDeploymentOptions) DeepCopyInto(out *BundleDeploymentOptions) {
 		*out = new(v1.LabelSelector)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.ResourceRules != nil {
+		in, out := &in.ResourceRules, &out.ResourceRules
+		*out = make([]ResourceRule, len(*in))
+		copy(*out, *in)
+	}
+	if in.DependencyOutputs != nil {
+		in, out := &in.DependencyOutputs, &out.DependencyOutputs
+		*out = make(map[string]map[string]string, len(*in))
+		for key, val := range *in {
+			var outVal map[string]string
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				inVal := (*in)[key]
+				in, out := &inVal, &outVal
+				*out = make(map[string]string, len(*in))
+				for key, val := range *in {
+					(*out)[key] = val
+				}
+			}
+			(*out)[key] = outVal
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BundleDeploymentOptions.
@@ -339,6 +381,13 @@ func (in *BundleDeploymentStatus) DeepCopyInto(out *BundleDeploymentStatus) {
 		}
 	}
 	out.ResourceCounts = in.ResourceCounts
+	if in.Outputs != nil {
+		in, out := &in.Outputs, &out.Outputs
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BundleDeploymentStatus.
@@ -485,6 +534,21 @@ func (in *BundleNamespaceMappingList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BundleOutput) DeepCopyInto(out *BundleOutput) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BundleOutput.
+func (in *BundleOutput) DeepCopy() *BundleOutput {
+	if in == nil {
+		return nil
+	}
+	out := new(BundleOutput)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BundlePath) DeepCopyInto(out *BundlePath) {
 	*out = *in
@@ -540,6 +604,52 @@ func (in *BundleResource) DeepCopy() *BundleResource {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BundleSource) DeepCopyInto(out *BundleSource) {
+	*out = *in
+	if in.Git != nil {
+		in, out := &in.Git, &out.Git
+		*out = new(GitSource)
+		**out = **in
+	}
+	if in.OCI != nil {
+		in, out := &in.OCI, &out.OCI
+		*out = new(OCISource)
+		**out = **in
+	}
+	if in.ConfigMap != nil {
+		in, out := &in.ConfigMap, &out.ConfigMap
+		*out = new(LocalObjectReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BundleSource.
+func (in *BundleSource) DeepCopy() *BundleSource {
+	if in == nil {
+		return nil
+	}
+	out := new(BundleSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BundleSourceStatus) DeepCopyInto(out *BundleSourceStatus) {
+	*out = *in
+	in.LastPollingTime.DeepCopyInto(&out.LastPollingTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BundleSourceStatus.
+func (in *BundleSourceStatus) DeepCopy() *BundleSourceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BundleSourceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BundleSpec) DeepCopyInto(out *BundleSpec) {
 	*out = *in
@@ -549,11 +659,23 @@ func (in *BundleSpec) DeepCopyInto(out *BundleSpec) {
 		*out = new(RolloutStrategy)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Placement != nil {
+		in, out := &in.Placement, &out.Placement
+		*out = new(PlacementStrategy)
+		**out = **in
+	}
 	if in.Resources != nil {
 		in, out := &in.Resources, &out.Resources
 		*out = make([]BundleResource, len(*in))
 		copy(*out, *in)
 	}
+	if in.Sources != nil {
+		in, out := &in.Sources, &out.Sources
+		*out = make([]BundleSource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.Targets != nil {
 		in, out := &in.Targets, &out.Targets
 		*out = make([]BundleTarget, len(*in))
@@ -608,12 +730,24 @@ func (in *BundleStatus) DeepCopyInto(out *BundleStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Placement != nil {
+		in, out := &in.Placement, &out.Placement
+		*out = new(PlacementStatus)
+		(*in).DeepCopyInto(*out)
+	}
 	out.Display = in.Display
 	if in.ResourceKey != nil {
 		in, out := &in.ResourceKey, &out.ResourceKey
 		*out = make([]ResourceKey, len(*in))
 		copy(*out, *in)
 	}
+	if in.Sources != nil {
+		in, out := &in.Sources, &out.Sources
+		*out = make([]BundleSourceStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BundleStatus.
@@ -755,6 +889,43 @@ func (in *ClusterDisplay) DeepCopy() *ClusterDisplay {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterFacts) DeepCopyInto(out *ClusterFacts) {
+	*out = *in
+	if in.Architectures != nil {
+		in, out := &in.Architectures, &out.Architectures
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.OperatingSystems != nil {
+		in, out := &in.OperatingSystems, &out.OperatingSystems
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Allocatable != nil {
+		in, out := &in.Allocatable, &out.Allocatable
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.CRDGroups != nil {
+		in, out := &in.CRDGroups, &out.CRDGroups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterFacts.
+func (in *ClusterFacts) DeepCopy() *ClusterFacts {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterFacts)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterGroup) DeepCopyInto(out *ClusterGroup) {
 	*out = *in
@@ -1182,6 +1353,11 @@ func (in *ClusterStatus) DeepCopyInto(out *ClusterStatus) {
 	}
 	out.Display = in.Display
 	in.Agent.DeepCopyInto(&out.Agent)
+	if in.Facts != nil {
+		in, out := &in.Facts, &out.Facts
+		*out = new(ClusterFacts)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.GarbageCollectionInterval != nil {
 		in, out := &in.GarbageCollectionInterval, &out.GarbageCollectionInterval
 		*out = new(v1.Duration)
@@ -1265,6 +1441,11 @@ func (in *Content) DeepCopyInto(out *Content) {
 		*out = make([]byte, len(*in))
 		copy(*out, *in)
 	}
+	if in.Blobs != nil {
+		in, out := &in.Blobs, &out.Blobs
+		*out = make([]ContentBlob, len(*in))
+		copy(*out, *in)
+	}
 	out.Status = in.Status
 }
 
@@ -1286,6 +1467,21 @@ func (in *Content) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ContentBlob) DeepCopyInto(out *ContentBlob) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ContentBlob.
+func (in *ContentBlob) DeepCopy() *ContentBlob {
+	if in == nil {
+		return nil
+	}
+	out := new(ContentBlob)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ContentList) DeepCopyInto(out *ContentList) {
 	*out = *in
@@ -1395,6 +1591,11 @@ func (in *FleetYAML) DeepCopyInto(out *FleetYAML) {
 			(*out)[key] = val
 		}
 	}
+	if in.Extends != nil {
+		in, out := &in.Extends, &out.Extends
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	in.BundleSpec.DeepCopyInto(&out.BundleSpec)
 	if in.TargetCustomizations != nil {
 		in, out := &in.TargetCustomizations, &out.TargetCustomizations
@@ -1714,6 +1915,13 @@ func (in *GitRepoStatus) DeepCopyInto(out *GitRepoStatus) {
 	in.LastWebhookTime.DeepCopyInto(&out.LastWebhookTime)
 	in.LastSyncedImageScanTime.DeepCopyInto(&out.LastSyncedImageScanTime)
 	in.LastPollingTime.DeepCopyInto(&out.LastPollingTime)
+	in.CommitDetectedTime.DeepCopyInto(&out.CommitDetectedTime)
+	in.LastSyncTime.DeepCopyInto(&out.LastSyncTime)
+	if in.LastSyncDuration != nil {
+		in, out := &in.LastSyncDuration, &out.LastSyncDuration
+		*out = new(v1.Duration)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitRepoStatus.
@@ -1726,6 +1934,21 @@ func (in *GitRepoStatus) DeepCopy() *GitRepoStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitSource) DeepCopyInto(out *GitSource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitSource.
+func (in *GitSource) DeepCopy() *GitSource {
+	if in == nil {
+		return nil
+	}
+	out := new(GitSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GitTarget) DeepCopyInto(out *GitTarget) {
 	*out = *in
@@ -2178,6 +2401,21 @@ func (in *NonReadyStatus) DeepCopy() *NonReadyStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OCISource) DeepCopyInto(out *OCISource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OCISource.
+func (in *OCISource) DeepCopy() *OCISource {
+	if in == nil {
+		return nil
+	}
+	out := new(OCISource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Operation) DeepCopyInto(out *Operation) {
 	*out = *in
@@ -2254,6 +2492,21 @@ func (in *PartitionStatus) DeepCopy() *PartitionStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PatchTarget) DeepCopyInto(out *PatchTarget) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PatchTarget.
+func (in *PatchTarget) DeepCopy() *PatchTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(PatchTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PerClusterState) DeepCopyInto(out *PerClusterState) {
 	*out = *in
@@ -2309,6 +2562,56 @@ func (in *PerClusterState) DeepCopy() *PerClusterState {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlacedCluster) DeepCopyInto(out *PlacedCluster) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlacedCluster.
+func (in *PlacedCluster) DeepCopy() *PlacedCluster {
+	if in == nil {
+		return nil
+	}
+	out := new(PlacedCluster)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlacementStatus) DeepCopyInto(out *PlacementStatus) {
+	*out = *in
+	if in.Clusters != nil {
+		in, out := &in.Clusters, &out.Clusters
+		*out = make([]PlacedCluster, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlacementStatus.
+func (in *PlacementStatus) DeepCopy() *PlacementStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PlacementStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlacementStrategy) DeepCopyInto(out *PlacementStrategy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlacementStrategy.
+func (in *PlacementStrategy) DeepCopy() *PlacementStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(PlacementStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PodDisruptionBudgetSpec) DeepCopyInto(out *PodDisruptionBudgetSpec) {
 	*out = *in
@@ -2334,6 +2637,16 @@ func (in *Policy) DeepCopyInto(out *Policy) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.ResourceRules != nil {
+		in, out := &in.ResourceRules, &out.ResourceRules
+		*out = make([]ResourceRule, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowedDependencyNamespaces != nil {
+		in, out := &in.AllowedDependencyNamespaces, &out.AllowedDependencyNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.GitRepo != nil {
 		in, out := &in.GitRepo, &out.GitRepo
 		*out = new(GitRepoPolicySpec)
@@ -2344,6 +2657,12 @@ func (in *Policy) DeepCopyInto(out *Policy) {
 		*out = new(HelmOpPolicySpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Quota != nil {
+		in, out := &in.Quota, &out.Quota
+		*out = new(PolicyQuota)
+		(*in).DeepCopyInto(*out)
+	}
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Policy.
@@ -2396,6 +2715,82 @@ func (in *PolicyList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolicyQuota) DeepCopyInto(out *PolicyQuota) {
+	*out = *in
+	if in.MaxGitRepos != nil {
+		in, out := &in.MaxGitRepos, &out.MaxGitRepos
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxHelmOps != nil {
+		in, out := &in.MaxHelmOps, &out.MaxHelmOps
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxBundles != nil {
+		in, out := &in.MaxBundles, &out.MaxBundles
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxBundleSize != nil {
+		in, out := &in.MaxBundleSize, &out.MaxBundleSize
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.MaxTargetClusters != nil {
+		in, out := &in.MaxTargetClusters, &out.MaxTargetClusters
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolicyQuota.
+func (in *PolicyQuota) DeepCopy() *PolicyQuota {
+	if in == nil {
+		return nil
+	}
+	out := new(PolicyQuota)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolicyStatus) DeepCopyInto(out *PolicyStatus) {
+	*out = *in
+	in.Usage.DeepCopyInto(&out.Usage)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolicyStatus.
+func (in *PolicyStatus) DeepCopy() *PolicyStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PolicyStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolicyUsage) DeepCopyInto(out *PolicyUsage) {
+	*out = *in
+	if in.LargestBundleSize != nil {
+		in, out := &in.LargestBundleSize, &out.LargestBundleSize
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolicyUsage.
+func (in *PolicyUsage) DeepCopy() *PolicyUsage {
+	if in == nil {
+		return nil
+	}
+	out := new(PolicyUsage)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PriorityClassSpec) DeepCopyInto(out *PriorityClassSpec) {
 	*out = *in
@@ -2462,6 +2857,37 @@ func (in *ResourceKey) DeepCopy() *ResourceKey {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourcePatch) DeepCopyInto(out *ResourcePatch) {
+	*out = *in
+	out.Target = in.Target
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourcePatch.
+func (in *ResourcePatch) DeepCopy() *ResourcePatch {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourcePatch)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceRule) DeepCopyInto(out *ResourceRule) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceRule.
+func (in *ResourceRule) DeepCopy() *ResourceRule {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RolloutStrategy) DeepCopyInto(out *RolloutStrategy) {
 	*out = *in
@@ -2658,6 +3084,110 @@ func (in *ScheduleTargets) DeepCopy() *ScheduleTargets {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretDistribution) DeepCopyInto(out *SecretDistribution) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretDistribution.
+func (in *SecretDistribution) DeepCopy() *SecretDistribution {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretDistribution)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SecretDistribution) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretDistributionList) DeepCopyInto(out *SecretDistributionList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]SecretDistribution, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretDistributionList.
+func (in *SecretDistributionList) DeepCopy() *SecretDistributionList {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretDistributionList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SecretDistributionList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretDistributionSpec) DeepCopyInto(out *SecretDistributionSpec) {
+	*out = *in
+	if in.Templates != nil {
+		in, out := &in.Templates, &out.Templates
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Targets != nil {
+		in, out := &in.Targets, &out.Targets
+		*out = make([]BundleTarget, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretDistributionSpec.
+func (in *SecretDistributionSpec) DeepCopy() *SecretDistributionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretDistributionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretDistributionStatus) DeepCopyInto(out *SecretDistributionStatus) {
+	*out = *in
+	in.StatusBase.DeepCopyInto(&out.StatusBase)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretDistributionStatus.
+func (in *SecretDistributionStatus) DeepCopy() *SecretDistributionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretDistributionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SecretKeySelector) DeepCopyInto(out *SecretKeySelector) {
 	*out = *in
@@ -2750,6 +3280,48 @@ func (in *StatusDisplay) DeepCopy() *StatusDisplay {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SubstituteFrom) DeepCopyInto(out *SubstituteFrom) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SubstituteFrom.
+func (in *SubstituteFrom) DeepCopy() *SubstituteFrom {
+	if in == nil {
+		return nil
+	}
+	out := new(SubstituteFrom)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SubstituteOptions) DeepCopyInto(out *SubstituteOptions) {
+	*out = *in
+	if in.Vars != nil {
+		in, out := &in.Vars, &out.Vars
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.VarsFrom != nil {
+		in, out := &in.VarsFrom, &out.VarsFrom
+		*out = make([]SubstituteFrom, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SubstituteOptions.
+func (in *SubstituteOptions) DeepCopy() *SubstituteOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(SubstituteOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ValuesFrom) DeepCopyInto(out *ValuesFrom) {
 	*out = *in
@@ -2763,6 +3335,11 @@ func (in *ValuesFrom) DeepCopyInto(out *ValuesFrom) {
 		*out = new(SecretKeySelector)
 		**out = **in
 	}
+	if in.VaultRef != nil {
+		in, out := &in.VaultRef, &out.VaultRef
+		*out = new(VaultValuesSource)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ValuesFrom.
@@ -2775,6 +3352,47 @@ func (in *ValuesFrom) DeepCopy() *ValuesFrom {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultAuth) DeepCopyInto(out *VaultAuth) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultAuth.
+func (in *VaultAuth) DeepCopy() *VaultAuth {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultAuth)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultValuesSource) DeepCopyInto(out *VaultValuesSource) {
+	*out = *in
+	out.Auth = in.Auth
+	if in.CABundle != nil {
+		in, out := &in.CABundle, &out.CABundle
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+	if in.RefreshInterval != nil {
+		in, out := &in.RefreshInterval, &out.RefreshInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultValuesSource.
+func (in *VaultValuesSource) DeepCopy() *VaultValuesSource {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultValuesSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *YAMLOptions) DeepCopyInto(out *YAMLOptions) {
 	*out = *in
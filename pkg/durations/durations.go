@@ -46,6 +46,39 @@ const (
 	// a reconcile on its own, so the agent requeues at this interval to
 	// converge once the permission is added.
 	NamespacePermissionRequeueInterval = time.Minute * 2
+	// ContentCacheResync is how often the agent reports status updates,
+	// which were queued while the upstream cluster was unreachable.
+	ContentCacheResync = time.Minute * 1
+	// OfflineModeDelay is how long the agent tries to register with the
+	// upstream cluster, before it deploys from its content cache.
+	OfflineModeDelay = time.Minute * 2
+	// OfflineResync is how often the agent checks cached bundle deployments
+	// for missing releases and drift, while in offline mode.
+	OfflineResync = time.Minute * 1
+	// ShardStatusInterval is how often the number of objects per shard is
+	// reported.
+	ShardStatusInterval = time.Minute * 1
+	// AgentCredentialCheckInterval is how often the agent checks for a
+	// rotated credential.
+	AgentCredentialCheckInterval = time.Minute * 5
+	// AgentCredentialGracePeriod is how long previous credentials remain
+	// valid, after the agent switched to a rotated credential.
+	AgentCredentialGracePeriod = time.Hour * 1
+	// PlacementRecheckInterval is how often bundles with a placement
+	// strategy are reconciled, to replace clusters whose agent went
+	// offline.
+	PlacementRecheckInterval = time.Minute * 5
+	// ContentBlobGracePeriod is how long a blob is kept after its last
+	// reference was removed, so manifests being stored can still reference
+	// it.
+	ContentBlobGracePeriod = time.Minute * 2
+	// QuotaRecheckInterval is how often objects rejected by a Policy quota
+	// are reconciled again, as deleting other objects or raising the quota
+	// does not trigger a reconcile on its own.
+	QuotaRecheckInterval = time.Minute * 1
+	// SourcesPollingInterval is how often bundles with git or OCI sources
+	// are reconciled, to pick up new revisions of their sources.
+	SourcesPollingInterval = time.Minute * 5
 )
 
 // Equal reports whether the duration t is equal to u.
@@ -45,6 +45,7 @@ type Interface interface {
 	ImageScan() ImageScanController
 	Policy() PolicyController
 	Schedule() ScheduleController
+	SecretDistribution() SecretDistributionController
 }
 
 func New(controllerFactory controller.SharedControllerFactory) Interface {
@@ -112,3 +113,7 @@ func (v *version) Policy() PolicyController {
 func (v *version) Schedule() ScheduleController {
 	return generic.NewController[*v1alpha1.Schedule, *v1alpha1.ScheduleList](schema.GroupVersionKind{Group: "fleet.cattle.io", Version: "v1alpha1", Kind: "Schedule"}, "schedules", true, v.controllerFactory)
 }
+
+func (v *version) SecretDistribution() SecretDistributionController {
+	return generic.NewController[*v1alpha1.SecretDistribution, *v1alpha1.SecretDistributionList](schema.GroupVersionKind{Group: "fleet.cattle.io", Version: "v1alpha1", Kind: "SecretDistribution"}, "secretdistributions", true, v.controllerFactory)
+}
@@ -19,8 +19,19 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"context"
+	"sync"
+	"time"
+
 	v1alpha1 "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/wrangler/v3/pkg/apply"
+	"github.com/rancher/wrangler/v3/pkg/condition"
 	"github.com/rancher/wrangler/v3/pkg/generic"
+	"github.com/rancher/wrangler/v3/pkg/kv"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 // PolicyController interface for managing Policy resources.
@@ -37,3 +48,161 @@ type PolicyClient interface {
 type PolicyCache interface {
 	generic.CacheInterface[*v1alpha1.Policy]
 }
+
+// PolicyStatusHandler is executed for every added or modified Policy. Should return the new status to be updated
+type PolicyStatusHandler func(obj *v1alpha1.Policy, status v1alpha1.PolicyStatus) (v1alpha1.PolicyStatus, error)
+
+// PolicyGeneratingHandler is the top-level handler that is executed for every Policy event. It extends PolicyStatusHandler by a returning a slice of child objects to be passed to apply.Apply
+type PolicyGeneratingHandler func(obj *v1alpha1.Policy, status v1alpha1.PolicyStatus) ([]runtime.Object, v1alpha1.PolicyStatus, error)
+
+// RegisterPolicyStatusHandler configures a PolicyController to execute a PolicyStatusHandler for every events observed.
+// If a non-empty condition is provided, it will be updated in the status conditions for every handler execution
+func RegisterPolicyStatusHandler(ctx context.Context, controller PolicyController, condition condition.Cond, name string, handler PolicyStatusHandler) {
+	statusHandler := &policyStatusHandler{
+		client:    controller,
+		condition: condition,
+		handler:   handler,
+	}
+	controller.AddGenericHandler(ctx, name, generic.FromObjectHandlerToHandler(statusHandler.sync))
+}
+
+// RegisterPolicyGeneratingHandler configures a PolicyController to execute a PolicyGeneratingHandler for every events observed, passing the returned objects to the provided apply.Apply.
+// If a non-empty condition is provided, it will be updated in the status conditions for every handler execution
+func RegisterPolicyGeneratingHandler(ctx context.Context, controller PolicyController, apply apply.Apply,
+	condition condition.Cond, name string, handler PolicyGeneratingHandler, opts *generic.GeneratingHandlerOptions) {
+	statusHandler := &policyGeneratingHandler{
+		PolicyGeneratingHandler: handler,
+		apply:                   apply,
+		name:                    name,
+		gvk:                     controller.GroupVersionKind(),
+	}
+	if opts != nil {
+		statusHandler.opts = *opts
+	}
+	controller.OnChange(ctx, name, statusHandler.Remove)
+	RegisterPolicyStatusHandler(ctx, controller, condition, name, statusHandler.Handle)
+}
+
+type policyStatusHandler struct {
+	client    PolicyClient
+	condition condition.Cond
+	handler   PolicyStatusHandler
+}
+
+// sync is executed on every resource addition or modification. Executes the configured handlers and sends the updated status to the Kubernetes API
+func (a *policyStatusHandler) sync(key string, obj *v1alpha1.Policy) (*v1alpha1.Policy, error) {
+	if obj == nil {
+		return obj, nil
+	}
+
+	origStatus := obj.Status.DeepCopy()
+	obj = obj.DeepCopy()
+	newStatus, err := a.handler(obj, obj.Status)
+	if err != nil {
+		// Revert to old status on error
+		newStatus = *origStatus.DeepCopy()
+	}
+
+	if a.condition != "" {
+		if errors.IsConflict(err) {
+			a.condition.SetError(&newStatus, "", nil)
+		} else {
+			a.condition.SetError(&newStatus, "", err)
+		}
+	}
+	if !equality.Semantic.DeepEqual(origStatus, &newStatus) {
+		if a.condition != "" {
+			// Since status has changed, update the lastUpdatedTime
+			a.condition.LastUpdated(&newStatus, time.Now().UTC().Format(time.RFC3339))
+		}
+
+		var newErr error
+		obj.Status = newStatus
+		newObj, newErr := a.client.UpdateStatus(obj)
+		if err == nil {
+			err = newErr
+		}
+		if newErr == nil {
+			obj = newObj
+		}
+	}
+	return obj, err
+}
+
+type policyGeneratingHandler struct {
+	PolicyGeneratingHandler
+	apply apply.Apply
+	opts  generic.GeneratingHandlerOptions
+	gvk   schema.GroupVersionKind
+	name  string
+	seen  sync.Map
+}
+
+// Remove handles the observed deletion of a resource, cascade deleting every associated resource previously applied
+func (a *policyGeneratingHandler) Remove(key string, obj *v1alpha1.Policy) (*v1alpha1.Policy, error) {
+	if obj != nil {
+		return obj, nil
+	}
+
+	obj = &v1alpha1.Policy{}
+	obj.Namespace, obj.Name = kv.RSplit(key, "/")
+	obj.SetGroupVersionKind(a.gvk)
+
+	if a.opts.UniqueApplyForResourceVersion {
+		a.seen.Delete(key)
+	}
+
+	return nil, generic.ConfigureApplyForObject(a.apply, obj, &a.opts).
+		WithOwner(obj).
+		WithSetID(a.name).
+		ApplyObjects()
+}
+
+// Handle executes the configured PolicyGeneratingHandler and pass the resulting objects to apply.Apply, finally returning the new status of the resource
+func (a *policyGeneratingHandler) Handle(obj *v1alpha1.Policy, status v1alpha1.PolicyStatus) (v1alpha1.PolicyStatus, error) {
+	if !obj.DeletionTimestamp.IsZero() {
+		return status, nil
+	}
+
+	objs, newStatus, err := a.PolicyGeneratingHandler(obj, status)
+	if err != nil {
+		return newStatus, err
+	}
+	if !a.isNewResourceVersion(obj) {
+		return newStatus, nil
+	}
+
+	err = generic.ConfigureApplyForObject(a.apply, obj, &a.opts).
+		WithOwner(obj).
+		WithSetID(a.name).
+		ApplyObjects(objs...)
+	if err != nil {
+		return newStatus, err
+	}
+	a.storeResourceVersion(obj)
+	return newStatus, nil
+}
+
+// isNewResourceVersion detects if a specific resource version was already successfully processed.
+// Only used if UniqueApplyForResourceVersion is set in generic.GeneratingHandlerOptions
+func (a *policyGeneratingHandler) isNewResourceVersion(obj *v1alpha1.Policy) bool {
+	if !a.opts.UniqueApplyForResourceVersion {
+		return true
+	}
+
+	// Apply once per resource version
+	key := obj.Namespace + "/" + obj.Name
+	previous, ok := a.seen.Load(key)
+	return !ok || previous != obj.ResourceVersion
+}
+
+// storeResourceVersion keeps track of the latest resource version of an object for which Apply was executed
+// Only used if UniqueApplyForResourceVersion is set in generic.GeneratingHandlerOptions
+func (a *policyGeneratingHandler) storeResourceVersion(obj *v1alpha1.Policy) {
+	if !a.opts.UniqueApplyForResourceVersion {
+		return
+	}
+
+	key := obj.Namespace + "/" + obj.Name
+	a.seen.Store(key, obj.ResourceVersion)
+}
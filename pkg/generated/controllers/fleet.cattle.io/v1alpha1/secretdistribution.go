@@ -0,0 +1,208 @@
+/*
+Copyright (c) 2020 - 2026 SUSE LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by main. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	v1alpha1 "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/wrangler/v3/pkg/apply"
+	"github.com/rancher/wrangler/v3/pkg/condition"
+	"github.com/rancher/wrangler/v3/pkg/generic"
+	"github.com/rancher/wrangler/v3/pkg/kv"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// SecretDistributionController interface for managing SecretDistribution resources.
+type SecretDistributionController interface {
+	generic.ControllerInterface[*v1alpha1.SecretDistribution, *v1alpha1.SecretDistributionList]
+}
+
+// SecretDistributionClient interface for managing SecretDistribution resources in Kubernetes.
+type SecretDistributionClient interface {
+	generic.ClientInterface[*v1alpha1.SecretDistribution, *v1alpha1.SecretDistributionList]
+}
+
+// SecretDistributionCache interface for retrieving SecretDistribution resources in memory.
+type SecretDistributionCache interface {
+	generic.CacheInterface[*v1alpha1.SecretDistribution]
+}
+
+// SecretDistributionStatusHandler is executed for every added or modified SecretDistribution. Should return the new status to be updated
+type SecretDistributionStatusHandler func(obj *v1alpha1.SecretDistribution, status v1alpha1.SecretDistributionStatus) (v1alpha1.SecretDistributionStatus, error)
+
+// SecretDistributionGeneratingHandler is the top-level handler that is executed for every SecretDistribution event. It extends SecretDistributionStatusHandler by a returning a slice of child objects to be passed to apply.Apply
+type SecretDistributionGeneratingHandler func(obj *v1alpha1.SecretDistribution, status v1alpha1.SecretDistributionStatus) ([]runtime.Object, v1alpha1.SecretDistributionStatus, error)
+
+// RegisterSecretDistributionStatusHandler configures a SecretDistributionController to execute a SecretDistributionStatusHandler for every events observed.
+// If a non-empty condition is provided, it will be updated in the status conditions for every handler execution
+func RegisterSecretDistributionStatusHandler(ctx context.Context, controller SecretDistributionController, condition condition.Cond, name string, handler SecretDistributionStatusHandler) {
+	statusHandler := &secretDistributionStatusHandler{
+		client:    controller,
+		condition: condition,
+		handler:   handler,
+	}
+	controller.AddGenericHandler(ctx, name, generic.FromObjectHandlerToHandler(statusHandler.sync))
+}
+
+// RegisterSecretDistributionGeneratingHandler configures a SecretDistributionController to execute a SecretDistributionGeneratingHandler for every events observed, passing the returned objects to the provided apply.Apply.
+// If a non-empty condition is provided, it will be updated in the status conditions for every handler execution
+func RegisterSecretDistributionGeneratingHandler(ctx context.Context, controller SecretDistributionController, apply apply.Apply,
+	condition condition.Cond, name string, handler SecretDistributionGeneratingHandler, opts *generic.GeneratingHandlerOptions) {
+	statusHandler := &secretDistributionGeneratingHandler{
+		SecretDistributionGeneratingHandler: handler,
+		apply:                               apply,
+		name:                                name,
+		gvk:                                 controller.GroupVersionKind(),
+	}
+	if opts != nil {
+		statusHandler.opts = *opts
+	}
+	controller.OnChange(ctx, name, statusHandler.Remove)
+	RegisterSecretDistributionStatusHandler(ctx, controller, condition, name, statusHandler.Handle)
+}
+
+type secretDistributionStatusHandler struct {
+	client    SecretDistributionClient
+	condition condition.Cond
+	handler   SecretDistributionStatusHandler
+}
+
+// sync is executed on every resource addition or modification. Executes the configured handlers and sends the updated status to the Kubernetes API
+func (a *secretDistributionStatusHandler) sync(key string, obj *v1alpha1.SecretDistribution) (*v1alpha1.SecretDistribution, error) {
+	if obj == nil {
+		return obj, nil
+	}
+
+	origStatus := obj.Status.DeepCopy()
+	obj = obj.DeepCopy()
+	newStatus, err := a.handler(obj, obj.Status)
+	if err != nil {
+		// Revert to old status on error
+		newStatus = *origStatus.DeepCopy()
+	}
+
+	if a.condition != "" {
+		if errors.IsConflict(err) {
+			a.condition.SetError(&newStatus, "", nil)
+		} else {
+			a.condition.SetError(&newStatus, "", err)
+		}
+	}
+	if !equality.Semantic.DeepEqual(origStatus, &newStatus) {
+		if a.condition != "" {
+			// Since status has changed, update the lastUpdatedTime
+			a.condition.LastUpdated(&newStatus, time.Now().UTC().Format(time.RFC3339))
+		}
+
+		var newErr error
+		obj.Status = newStatus
+		newObj, newErr := a.client.UpdateStatus(obj)
+		if err == nil {
+			err = newErr
+		}
+		if newErr == nil {
+			obj = newObj
+		}
+	}
+	return obj, err
+}
+
+type secretDistributionGeneratingHandler struct {
+	SecretDistributionGeneratingHandler
+	apply apply.Apply
+	opts  generic.GeneratingHandlerOptions
+	gvk   schema.GroupVersionKind
+	name  string
+	seen  sync.Map
+}
+
+// Remove handles the observed deletion of a resource, cascade deleting every associated resource previously applied
+func (a *secretDistributionGeneratingHandler) Remove(key string, obj *v1alpha1.SecretDistribution) (*v1alpha1.SecretDistribution, error) {
+	if obj != nil {
+		return obj, nil
+	}
+
+	obj = &v1alpha1.SecretDistribution{}
+	obj.Namespace, obj.Name = kv.RSplit(key, "/")
+	obj.SetGroupVersionKind(a.gvk)
+
+	if a.opts.UniqueApplyForResourceVersion {
+		a.seen.Delete(key)
+	}
+
+	return nil, generic.ConfigureApplyForObject(a.apply, obj, &a.opts).
+		WithOwner(obj).
+		WithSetID(a.name).
+		ApplyObjects()
+}
+
+// Handle executes the configured SecretDistributionGeneratingHandler and pass the resulting objects to apply.Apply, finally returning the new status of the resource
+func (a *secretDistributionGeneratingHandler) Handle(obj *v1alpha1.SecretDistribution, status v1alpha1.SecretDistributionStatus) (v1alpha1.SecretDistributionStatus, error) {
+	if !obj.DeletionTimestamp.IsZero() {
+		return status, nil
+	}
+
+	objs, newStatus, err := a.SecretDistributionGeneratingHandler(obj, status)
+	if err != nil {
+		return newStatus, err
+	}
+	if !a.isNewResourceVersion(obj) {
+		return newStatus, nil
+	}
+
+	err = generic.ConfigureApplyForObject(a.apply, obj, &a.opts).
+		WithOwner(obj).
+		WithSetID(a.name).
+		ApplyObjects(objs...)
+	if err != nil {
+		return newStatus, err
+	}
+	a.storeResourceVersion(obj)
+	return newStatus, nil
+}
+
+// isNewResourceVersion detects if a specific resource version was already successfully processed.
+// Only used if UniqueApplyForResourceVersion is set in generic.GeneratingHandlerOptions
+func (a *secretDistributionGeneratingHandler) isNewResourceVersion(obj *v1alpha1.SecretDistribution) bool {
+	if !a.opts.UniqueApplyForResourceVersion {
+		return true
+	}
+
+	// Apply once per resource version
+	key := obj.Namespace + "/" + obj.Name
+	previous, ok := a.seen.Load(key)
+	return !ok || previous != obj.ResourceVersion
+}
+
+// storeResourceVersion keeps track of the latest resource version of an object for which Apply was executed
+// Only used if UniqueApplyForResourceVersion is set in generic.GeneratingHandlerOptions
+func (a *secretDistributionGeneratingHandler) storeResourceVersion(obj *v1alpha1.SecretDistribution) {
+	if !a.opts.UniqueApplyForResourceVersion {
+		return
+	}
+
+	key := obj.Namespace + "/" + obj.Name
+	a.seen.Store(key, obj.ResourceVersion)
+}
@@ -3,6 +3,7 @@ package helmvalues
 import (
 	"errors"
 	"fmt"
+	"strings"
 
 	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
 )
@@ -96,17 +97,30 @@ func ExtractValues(bundle *fleet.Bundle) (string, map[string][]byte, error) {
 	return hash, data, nil
 }
 
-// ClearValues removes the values from a bundle. It mutates the bundle.
+// ClearValues removes the values from a bundle, except for values files of
+// the bundle's sources. It mutates the bundle.
 func ClearValues(bundle *fleet.Bundle) {
 	if bundle.Spec.Helm != nil {
 		bundle.Spec.Helm.Values = nil
-		bundle.Spec.Helm.ValuesFiles = nil
+		bundle.Spec.Helm.ValuesFiles = sourceValuesFiles(bundle.Spec.Helm.ValuesFiles)
 	}
 	for i := range bundle.Spec.Targets {
 		if bundle.Spec.Targets[i].Helm == nil {
 			continue
 		}
 		bundle.Spec.Targets[i].Helm.Values = nil
-		bundle.Spec.Targets[i].Helm.ValuesFiles = nil
+		bundle.Spec.Targets[i].Helm.ValuesFiles = sourceValuesFiles(bundle.Spec.Targets[i].Helm.ValuesFiles)
 	}
 }
+
+// sourceValuesFiles returns the values files of the bundle's sources. They
+// are read by the controller and must be kept.
+func sourceValuesFiles(files []string) []string {
+	var result []string
+	for _, f := range files {
+		if strings.HasPrefix(f, fleet.BundleSourcesDir+"/") {
+			result = append(result, f)
+		}
+	}
+	return result
+}
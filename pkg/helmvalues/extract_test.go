@@ -306,3 +306,30 @@ func TestExtractOptions(t *testing.T) {
 		}
 	}
 }
+
+func TestClearValues(t *testing.T) {
+	bundle := &fleet.Bundle{Spec: fleet.BundleSpec{
+		BundleDeploymentOptions: fleet.BundleDeploymentOptions{Helm: &fleet.HelmOptions{
+			Values:            &fleet.GenericMap{Data: map[string]any{"replicas": 2}},
+			GitOpsHelmOptions: fleet.GitOpsHelmOptions{ValuesFiles: []string{"values.yaml", ".sources/shared/values.yaml"}},
+		}},
+		Targets: []fleet.BundleTarget{{
+			Name: "prod",
+			BundleDeploymentOptions: fleet.BundleDeploymentOptions{Helm: &fleet.HelmOptions{
+				GitOpsHelmOptions: fleet.GitOpsHelmOptions{ValuesFiles: []string{"prod.yaml"}},
+			}},
+		}},
+	}}
+
+	helmvalues.ClearValues(bundle)
+
+	if bundle.Spec.Helm.Values != nil {
+		t.Errorf("expected values to be cleared, got %v", bundle.Spec.Helm.Values)
+	}
+	if got := bundle.Spec.Helm.ValuesFiles; len(got) != 1 || got[0] != ".sources/shared/values.yaml" {
+		t.Errorf("expected only the source values file to be kept, got %v", got)
+	}
+	if got := bundle.Spec.Targets[0].Helm.ValuesFiles; got != nil {
+		t.Errorf("expected target values files to be cleared, got %v", got)
+	}
+}
@@ -1,6 +1,9 @@
 package sharding
 
 import (
+	"crypto/sha256"
+	"encoding/binary"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/event"
@@ -14,8 +17,38 @@ const (
 	ShardingRefLabel string = "fleet.cattle.io/shard-ref"
 	// ShardingDefaultLabel is the label key which is set to true on the controller handling unlabeled resources
 	ShardingDefaultLabel string = "fleet.cattle.io/shard-default"
+	// ShardingAutoAnnotation records the shard ID, which was assigned automatically to a resource.
+	// Only those resources are reassigned when the configured shards change. Changing the
+	// ShardingRefLabel turns it into an explicit assignment.
+	ShardingAutoAnnotation string = "fleet.cattle.io/shard-auto"
 )
 
+// Assign returns the shard ID out of shardIDs, which key is assigned to. It
+// uses rendezvous hashing, so adding or removing a shard only moves the keys
+// of that shard. It returns "" if shardIDs is empty.
+func Assign(key string, shardIDs []string) string {
+	var (
+		assigned string
+		maxScore uint64
+	)
+	for _, id := range shardIDs {
+		sum := sha256.Sum256([]byte(id + "\x00" + key))
+		score := binary.BigEndian.Uint64(sum[:8])
+		if assigned == "" || score > maxScore || (score == maxScore && id < assigned) {
+			assigned = id
+			maxScore = score
+		}
+	}
+	return assigned
+}
+
+// IsAutoAssigned returns true if the shard of obj was assigned automatically
+// and has not been changed by the user since.
+func IsAutoAssigned(obj metav1.Object) bool {
+	label, hasLabel := obj.GetLabels()[ShardingRefLabel]
+	return hasLabel && obj.GetAnnotations()[ShardingAutoAnnotation] == label
+}
+
 // ShouldProcess returns true if the given object should be processed by the shard
 // identified by shardID.
 //
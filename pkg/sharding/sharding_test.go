@@ -0,0 +1,95 @@
+package sharding_test
+
+import (
+	"fmt"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/rancher/fleet/pkg/sharding"
+)
+
+func TestAssign(t *testing.T) {
+	if id := sharding.Assign("ns/name", nil); id != "" {
+		t.Errorf("expected no shard without shard IDs, got %q", id)
+	}
+
+	shards := []string{"shard0", "shard1", "shard2"}
+	counts := map[string]int{}
+	assigned := map[string]string{}
+	for i := range 3000 {
+		key := fmt.Sprintf("fleet-default/repo-%d", i)
+		id := sharding.Assign(key, shards)
+		if again := sharding.Assign(key, []string{"shard2", "shard0", "shard1"}); again != id {
+			t.Fatalf("expected the order of shard IDs not to matter, got %q and %q", id, again)
+		}
+		counts[id]++
+		assigned[key] = id
+	}
+	for _, id := range shards {
+		if counts[id] < 800 {
+			t.Errorf("expected keys to be spread evenly, got %v", counts)
+		}
+	}
+
+	// Adding a shard only moves keys to the new shard.
+	moved := 0
+	for key, id := range assigned {
+		newID := sharding.Assign(key, append(shards, "shard3"))
+		if newID == id {
+			continue
+		}
+		if newID != "shard3" {
+			t.Fatalf("expected %s to move to the new shard, got %q", key, newID)
+		}
+		moved++
+	}
+	if moved < 500 || moved > 1000 {
+		t.Errorf("expected about a quarter of the keys to move, got %d", moved)
+	}
+
+	// Removing a shard only moves the keys of that shard.
+	for key, id := range assigned {
+		if id == "shard1" {
+			continue
+		}
+		if newID := sharding.Assign(key, []string{"shard0", "shard2"}); newID != id {
+			t.Fatalf("expected %s to stay on %q, got %q", key, id, newID)
+		}
+	}
+}
+
+func TestIsAutoAssigned(t *testing.T) {
+	tests := map[string]struct {
+		meta metav1.ObjectMeta
+		want bool
+	}{
+		"unlabeled": {},
+		"labeled by user": {
+			meta: metav1.ObjectMeta{Labels: map[string]string{sharding.ShardingRefLabel: "shard0"}},
+		},
+		"assigned automatically": {
+			meta: metav1.ObjectMeta{
+				Labels:      map[string]string{sharding.ShardingRefLabel: "shard0"},
+				Annotations: map[string]string{sharding.ShardingAutoAnnotation: "shard0"},
+			},
+			want: true,
+		},
+		"label changed by user": {
+			meta: metav1.ObjectMeta{
+				Labels:      map[string]string{sharding.ShardingRefLabel: "shard1"},
+				Annotations: map[string]string{sharding.ShardingAutoAnnotation: "shard0"},
+			},
+		},
+		"label removed by user": {
+			meta: metav1.ObjectMeta{Annotations: map[string]string{sharding.ShardingAutoAnnotation: "shard0"}},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := sharding.IsAutoAssigned(&tt.meta); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
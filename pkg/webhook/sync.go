@@ -0,0 +1,476 @@
+package webhook
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	"github.com/rancher/fleet/internal/cmd/controller/summary"
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// SyncPath is the path of the sync trigger endpoint.
+	SyncPath = "/sync"
+	// SyncSecretName is the name of the secret holding the token, which
+	// authorizes sync requests. A secret in the controller namespace
+	// authorizes requests for all namespaces, a secret in a GitRepo or
+	// HelmOp namespace authorizes requests for that namespace only.
+	SyncSecretName = "fleet-sync-token" //nolint:gosec // this is a resource name
+	// SyncSecretTokenKey is the key of the token in the sync secret.
+	SyncSecretTokenKey = "token"
+
+	// SyncDefaultTimeout is used when waiting and no timeout is given.
+	SyncDefaultTimeout = 5 * time.Minute
+	// SyncMaxTimeout limits how long a request may wait.
+	SyncMaxTimeout = 30 * time.Minute
+
+	syncPollInterval = 2 * time.Second
+)
+
+// SyncRequest names the GitRepo or HelmOp to sync. Instead of a name, a
+// repository URL and branch can be given to sync all GitRepos using them.
+type SyncRequest struct {
+	// Kind is either GitRepo or HelmOp, defaults to GitRepo.
+	Kind      string `json:"kind,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name,omitempty"`
+	// Repo and Branch select GitRepos, if Name is empty.
+	Repo   string `json:"repo,omitempty"`
+	Branch string `json:"branch,omitempty"`
+	// Commit is the commit SHA to deploy. Only supported for GitRepos. If
+	// empty, the current commit is redeployed.
+	Commit string `json:"commit,omitempty"`
+	// Wait until all bundle deployments are ready or failed.
+	Wait bool `json:"wait,omitempty"`
+	// Timeout for waiting, as a Go duration.
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// SyncResponse holds the triggered GitRepos or HelmOps and, when waiting,
+// the results per cluster.
+type SyncResponse struct {
+	Triggered []string `json:"triggered"`
+	// Ready is true if all bundle deployments are ready.
+	Ready bool `json:"ready"`
+	// Failed is true if a bundle deployment or the git job failed.
+	Failed   bool            `json:"failed,omitempty"`
+	TimedOut bool            `json:"timedOut,omitempty"`
+	Message  string          `json:"message,omitempty"`
+	Clusters []ClusterResult `json:"clusters,omitempty"`
+}
+
+// ClusterResult is the state of a bundle deployment on a cluster.
+type ClusterResult struct {
+	Namespace string `json:"namespace"`
+	Cluster   string `json:"cluster"`
+	Bundle    string `json:"bundle"`
+	State     string `json:"state"`
+	Message   string `json:"message,omitempty"`
+}
+
+// syncTarget is a GitRepo or HelmOp, which has been triggered.
+type syncTarget struct {
+	obj client.Object
+	// commit is the commit to wait for, if any.
+	commit string
+	// previousCommit is the GitRepo's commit when the sync was triggered.
+	// Once the GitRepo moves to another commit than this one or commit,
+	// commit will not be deployed.
+	previousCommit string
+	// generation is the force sync generation to wait for, if no commit
+	// is given.
+	generation int64
+}
+
+// Sync handles sync requests from CI pipelines.
+type Sync struct {
+	client    client.Client
+	namespace string
+	log       logr.Logger
+}
+
+func NewSync(namespace string, client client.Client) *Sync {
+	return &Sync{
+		client:    client,
+		namespace: namespace,
+		log:       ctrl.Log.WithName("sync"),
+	}
+}
+
+func (s *Sync) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		http.Error(rw, "missing bearer token", http.StatusUnauthorized)
+		return
+	}
+
+	var req SyncRequest
+	if err := json.NewDecoder(http.MaxBytesReader(rw, r.Body, 1<<20)).Decode(&req); err != nil {
+		http.Error(rw, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+	timeout, err := validate(req)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	objs, err := s.lookup(ctx, req)
+	if err != nil {
+		s.error(rw, err)
+		return
+	}
+	objs, err = s.authorized(ctx, token, req.Namespace, objs)
+	if err != nil {
+		s.error(rw, err)
+		return
+	}
+	if len(objs) == 0 {
+		http.Error(rw, "no matching resource found", http.StatusNotFound)
+		return
+	}
+
+	resp := SyncResponse{}
+	targets := make([]syncTarget, 0, len(objs))
+	for _, obj := range objs {
+		t, err := s.trigger(ctx, obj, req.Commit)
+		if err != nil {
+			s.error(rw, err)
+			return
+		}
+		targets = append(targets, t)
+		resp.Triggered = append(resp.Triggered, obj.GetNamespace()+"/"+obj.GetName())
+	}
+
+	if req.Wait {
+		// The server's write timeout is shorter than the wait.
+		_ = http.NewResponseController(rw).SetWriteDeadline(time.Now().Add(timeout + 10*time.Second))
+		s.wait(ctx, targets, timeout, &resp)
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(rw).Encode(resp)
+}
+
+// validate checks the request and returns the timeout for waiting.
+func validate(req SyncRequest) (time.Duration, error) {
+	if req.Kind != "" && req.Kind != "GitRepo" && req.Kind != "HelmOp" {
+		return 0, fmt.Errorf("unsupported kind %q", req.Kind)
+	}
+	if req.Name == "" && req.Repo == "" {
+		return 0, errors.New("either name or repo is required")
+	}
+	if req.Name != "" && req.Namespace == "" {
+		return 0, errors.New("namespace is required")
+	}
+	if req.Kind == "HelmOp" && (req.Name == "" || req.Commit != "") {
+		return 0, errors.New("helmops must be selected by name and do not support a commit")
+	}
+	if req.Timeout == "" {
+		return SyncDefaultTimeout, nil
+	}
+	timeout, err := time.ParseDuration(req.Timeout)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timeout: %w", err)
+	}
+	if timeout <= 0 || timeout > SyncMaxTimeout {
+		return 0, fmt.Errorf("timeout must be positive and at most %s", SyncMaxTimeout)
+	}
+	return timeout, nil
+}
+
+// lookup returns the GitRepo or HelmOp named in the request, or the GitRepos
+// matching its repository URL and branch.
+func (s *Sync) lookup(ctx context.Context, req SyncRequest) ([]client.Object, error) {
+	key := types.NamespacedName{Namespace: req.Namespace, Name: req.Name}
+	if req.Kind == "HelmOp" {
+		helmop := &fleet.HelmOp{}
+		if err := s.client.Get(ctx, key, helmop); err != nil {
+			return nil, client.IgnoreNotFound(err)
+		}
+		return []client.Object{helmop}, nil
+	}
+
+	if req.Name != "" {
+		gitrepo := &fleet.GitRepo{}
+		if err := s.client.Get(ctx, key, gitrepo); err != nil {
+			return nil, client.IgnoreNotFound(err)
+		}
+		return []client.Object{gitrepo}, nil
+	}
+
+	u, err := url.Parse(sshURLToParsable(req.Repo))
+	if err != nil {
+		return nil, err
+	}
+	if u.EscapedPath() == "" {
+		return nil, nil
+	}
+	repoRegexp, err := repoURLRegexp(u)
+	if err != nil {
+		return nil, err
+	}
+	var list fleet.GitRepoList
+	if err := s.client.List(ctx, &list, client.InNamespace(req.Namespace)); err != nil {
+		return nil, err
+	}
+	var objs []client.Object
+	for i := range list.Items {
+		gitrepo := &list.Items[i]
+		if gitrepo.Spec.Revision != "" || !repoRegexp.MatchString(gitrepo.Spec.Repo) {
+			continue
+		}
+		if gitrepo.Spec.Branch != "" && req.Branch != "" && gitrepo.Spec.Branch != req.Branch {
+			continue
+		}
+		objs = append(objs, gitrepo)
+	}
+	return objs, nil
+}
+
+// authorized returns the objects, which the token grants access to. If no
+// objects were found, the token must be valid for the requested namespace, so
+// the response does not reveal which objects exist.
+func (s *Sync) authorized(ctx context.Context, token, namespace string, objs []client.Object) ([]client.Object, error) {
+	global, err := s.token(ctx, s.namespace)
+	if err != nil {
+		return nil, err
+	}
+	if tokenEqual(global, token) {
+		return objs, nil
+	}
+
+	if len(objs) == 0 {
+		if namespace == "" {
+			return nil, errUnauthorized
+		}
+		expected, err := s.token(ctx, namespace)
+		if err != nil {
+			return nil, err
+		}
+		if !tokenEqual(expected, token) {
+			return nil, errUnauthorized
+		}
+		return nil, nil
+	}
+
+	var result []client.Object
+	for _, obj := range objs {
+		expected, err := s.token(ctx, obj.GetNamespace())
+		if err != nil {
+			return nil, err
+		}
+		if tokenEqual(expected, token) {
+			result = append(result, obj)
+		}
+	}
+	if len(result) == 0 {
+		return nil, errUnauthorized
+	}
+	return result, nil
+}
+
+var errUnauthorized = errors.New("unauthorized")
+
+func (s *Sync) token(ctx context.Context, namespace string) (string, error) {
+	var secret corev1.Secret
+	err := s.client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: SyncSecretName}, &secret)
+	if apierrors.IsNotFound(err) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	return string(secret.Data[SyncSecretTokenKey]), nil
+}
+
+func tokenEqual(expected, token string) bool {
+	return expected != "" && subtle.ConstantTimeCompare([]byte(expected), []byte(token)) == 1
+}
+
+// trigger starts a sync of obj. For GitRepos with a commit, the commit is
+// announced like a webhook does, which makes the controller resolve the
+// remote HEAD immediately. Otherwise the force sync generation is increased
+// to redeploy the current state.
+func (s *Sync) trigger(ctx context.Context, obj client.Object, commit string) (syncTarget, error) {
+	s.log.Info("Triggering sync", "kind", kind(obj), "namespace", obj.GetNamespace(), "name", obj.GetName(), "commit", commit)
+
+	switch o := obj.(type) {
+	case *fleet.GitRepo:
+		if commit != "" {
+			orig := o.DeepCopy()
+			previous := o.Status.Commit
+			now := metav1.Now()
+			if commit != o.Status.WebhookCommit && commit != o.Status.Commit {
+				o.Status.CommitDetectedTime = now
+			}
+			o.Status.WebhookCommit = commit
+			o.Status.LastWebhookTime = now
+			if err := s.client.Status().Patch(ctx, o, client.MergeFrom(orig)); err != nil {
+				return syncTarget{}, err
+			}
+			return syncTarget{obj: o, commit: commit, previousCommit: previous}, nil
+		}
+		orig := o.DeepCopy()
+		o.Spec.ForceSyncGeneration++
+		if err := s.client.Patch(ctx, o, client.MergeFrom(orig)); err != nil {
+			return syncTarget{}, err
+		}
+		return syncTarget{obj: o, generation: o.Spec.ForceSyncGeneration}, nil
+	case *fleet.HelmOp:
+		orig := o.DeepCopy()
+		o.Spec.ForceSyncGeneration++
+		if err := s.client.Patch(ctx, o, client.MergeFrom(orig)); err != nil {
+			return syncTarget{}, err
+		}
+		return syncTarget{obj: o, generation: o.Spec.ForceSyncGeneration}, nil
+	}
+	return syncTarget{}, fmt.Errorf("unsupported object %T", obj)
+}
+
+// wait polls the targets until all their bundle deployments are ready or
+// failed, or the timeout expires.
+func (s *Sync) wait(ctx context.Context, targets []syncTarget, timeout time.Duration, resp *SyncResponse) {
+	err := wait.PollUntilContextTimeout(ctx, syncPollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+		resp.Clusters = nil
+		resp.Message = ""
+		done, failed := true, false
+		for _, t := range targets {
+			d, f, err := s.status(ctx, t, resp)
+			if err != nil {
+				s.log.Error(err, "Failed to read sync status")
+				return false, nil
+			}
+			done = done && d
+			failed = failed || f
+		}
+		if !done {
+			return false, nil
+		}
+		resp.Failed = failed
+		resp.Ready = !failed
+		return true, nil
+	})
+	if err != nil {
+		resp.TimedOut = true
+	}
+}
+
+// status adds the results of t's bundle deployments to resp. It returns
+// whether t is done, i.e. all its bundle deployments have picked up the
+// triggered sync and are ready or failed, and whether any of them failed.
+func (s *Sync) status(ctx context.Context, t syncTarget, resp *SyncResponse) (done bool, failed bool, err error) {
+	key := client.ObjectKeyFromObject(t.obj)
+	var label string
+	switch t.obj.(type) {
+	case *fleet.GitRepo:
+		label = fleet.RepoLabel
+		gitrepo := &fleet.GitRepo{}
+		if err := s.client.Get(ctx, key, gitrepo); err != nil {
+			return false, false, err
+		}
+		if gitrepo.Status.GitJobStatus == "Failed" {
+			resp.Message = summary.MessageFromCondition("Stalled", gitrepo.Status.Conditions)
+			return true, true, nil
+		}
+		// The controller deploys the HEAD of the branch. If it moved to
+		// another commit, or gave up waiting for the announced commit and
+		// realigned the webhook commit, the commit is not the HEAD and
+		// waiting for it would only run into the timeout.
+		if t.commit != "" && gitrepo.Status.Commit != t.commit &&
+			(gitrepo.Status.Commit != t.previousCommit || gitrepo.Status.WebhookCommit != t.commit) {
+			resp.Message = fmt.Sprintf("commit %s is not the HEAD of the branch, the GitRepo is at commit %s", t.commit, gitrepo.Status.Commit)
+			return true, true, nil
+		}
+		if t.commit != "" && gitrepo.Status.Commit != t.commit ||
+			t.generation != 0 && gitrepo.Status.UpdateGeneration < t.generation ||
+			gitrepo.Status.GitJobStatus != "Current" {
+			return false, false, nil
+		}
+	case *fleet.HelmOp:
+		label = fleet.HelmOpLabel
+		var bundles fleet.BundleList
+		if err := s.client.List(ctx, &bundles, client.InNamespace(key.Namespace), client.MatchingLabels{label: key.Name}); err != nil {
+			return false, false, err
+		}
+		for _, b := range bundles.Items {
+			if b.Spec.ForceSyncGeneration < t.generation || b.Status.ObservedGeneration != b.Generation {
+				return false, false, nil
+			}
+		}
+	}
+
+	var bds fleet.BundleDeploymentList
+	if err := s.client.List(ctx, &bds, client.MatchingLabels{label: key.Name, fleet.BundleNamespaceLabel: key.Namespace}); err != nil {
+		return false, false, err
+	}
+	done = true
+	for i := range bds.Items {
+		bd := &bds.Items[i]
+		state := summary.GetDeploymentState(bd)
+		current := bd.Spec.Options.ForceSyncGeneration >= t.generation
+		if t.commit != "" {
+			current = bd.Labels[fleet.CommitLabel] == t.commit
+		}
+		if !current {
+			state = fleet.Pending
+		}
+		switch state {
+		case fleet.Ready:
+		case fleet.ErrApplied:
+			failed = true
+		default:
+			done = false
+		}
+		resp.Clusters = append(resp.Clusters, ClusterResult{
+			Namespace: bd.Labels[fleet.ClusterNamespaceLabel],
+			Cluster:   bd.Labels[fleet.ClusterLabel],
+			Bundle:    bd.Labels[fleet.BundleLabel],
+			State:     string(state),
+			Message:   summary.MessageFromDeployment(bd),
+		})
+	}
+	slices.SortFunc(resp.Clusters, func(a, b ClusterResult) int {
+		return strings.Compare(a.Namespace+"/"+a.Cluster+"/"+a.Bundle, b.Namespace+"/"+b.Cluster+"/"+b.Bundle)
+	})
+	return done, failed, nil
+}
+
+func (s *Sync) error(rw http.ResponseWriter, err error) {
+	if errors.Is(err, errUnauthorized) {
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	s.log.Error(err, "Sync processing failed")
+	http.Error(rw, "Sync processing failed", http.StatusInternalServerError)
+}
+
+func kind(obj client.Object) string {
+	if _, ok := obj.(*fleet.HelmOp); ok {
+		return "HelmOp"
+	}
+	return "GitRepo"
+}
@@ -0,0 +1,217 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gotest.tools/assert"
+
+	v1alpha1 "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	cfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const syncCommit = "f00c3a181697bb3829a6462e931c7456bbed557b"
+
+func newSyncClient(objs ...client.Object) client.Client {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(corev1.AddToScheme(scheme))
+	utilruntime.Must(v1alpha1.AddToScheme(scheme))
+
+	objs = append(objs,
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "cattle-fleet-system", Name: SyncSecretName},
+			Data:       map[string][]byte{SyncSecretTokenKey: []byte("global")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: SyncSecretName},
+			Data:       map[string][]byte{SyncSecretTokenKey: []byte("team-a")},
+		},
+	)
+	return cfake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).
+		WithStatusSubresource(&v1alpha1.GitRepo{}).Build()
+}
+
+func newGitRepo(namespace, name, branch string) *v1alpha1.GitRepo {
+	return &v1alpha1.GitRepo{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec:       v1alpha1.GitRepoSpec{Repo: "https://github.com/rancher/fleet-examples.git", Branch: branch},
+	}
+}
+
+func doSync(t *testing.T, c client.Client, token string, req SyncRequest) (*httptest.ResponseRecorder, SyncResponse) {
+	t.Helper()
+	body, err := json.Marshal(req)
+	assert.NilError(t, err)
+	r := httptest.NewRequest(http.MethodPost, SyncPath, bytes.NewReader(body))
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	NewSync("cattle-fleet-system", c).ServeHTTP(w, r)
+
+	var resp SyncResponse
+	if w.Code == http.StatusOK {
+		assert.NilError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	}
+	return w, resp
+}
+
+func TestSyncUnauthorized(t *testing.T) {
+	c := newSyncClient(newGitRepo("team-a", "app", ""), newGitRepo("team-b", "app", ""))
+
+	for _, tc := range []struct {
+		name  string
+		token string
+		req   SyncRequest
+	}{
+		{name: "missing token", req: SyncRequest{Namespace: "team-a", Name: "app"}},
+		{name: "wrong token", token: "wrong", req: SyncRequest{Namespace: "team-a", Name: "app"}},
+		{name: "token of another namespace", token: "team-a", req: SyncRequest{Namespace: "team-b", Name: "app"}},
+		{name: "unknown gitrepo", token: "team-a", req: SyncRequest{Namespace: "team-b", Name: "missing"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w, _ := doSync(t, c, tc.token, tc.req)
+			assert.Equal(t, w.Code, http.StatusUnauthorized)
+		})
+	}
+
+	w, _ := doSync(t, c, "team-a", SyncRequest{Namespace: "team-a", Name: "missing"})
+	assert.Equal(t, w.Code, http.StatusNotFound)
+}
+
+func TestSyncGitRepoWithCommit(t *testing.T) {
+	c := newSyncClient(newGitRepo("team-a", "app", ""))
+
+	w, resp := doSync(t, c, "team-a", SyncRequest{Namespace: "team-a", Name: "app", Commit: syncCommit})
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.DeepEqual(t, resp.Triggered, []string{"team-a/app"})
+
+	gitrepo := &v1alpha1.GitRepo{}
+	assert.NilError(t, c.Get(context.Background(), types.NamespacedName{Namespace: "team-a", Name: "app"}, gitrepo))
+	assert.Equal(t, gitrepo.Status.WebhookCommit, syncCommit)
+	assert.Assert(t, !gitrepo.Status.CommitDetectedTime.IsZero())
+}
+
+func TestSyncGitReposByURL(t *testing.T) {
+	c := newSyncClient(
+		newGitRepo("team-a", "main", "main"),
+		newGitRepo("team-a", "any", ""),
+		newGitRepo("team-a", "dev", "dev"),
+		newGitRepo("team-b", "main", "main"),
+	)
+
+	w, resp := doSync(t, c, "global", SyncRequest{Repo: "git@github.com:rancher/fleet-examples.git", Branch: "main"})
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.DeepEqual(t, resp.Triggered, []string{"team-a/any", "team-a/main", "team-b/main"})
+
+	for _, key := range []types.NamespacedName{
+		{Namespace: "team-a", Name: "main"},
+		{Namespace: "team-a", Name: "dev"},
+	} {
+		gitrepo := &v1alpha1.GitRepo{}
+		assert.NilError(t, c.Get(context.Background(), key, gitrepo))
+		want := int64(1)
+		if key.Name == "dev" {
+			want = 0
+		}
+		assert.Equal(t, gitrepo.Spec.ForceSyncGeneration, want, key.String())
+	}
+
+	// A namespace token only triggers the gitrepos of its namespace.
+	w, resp = doSync(t, c, "team-a", SyncRequest{Repo: "https://github.com/rancher/fleet-examples", Branch: "main"})
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.DeepEqual(t, resp.Triggered, []string{"team-a/any", "team-a/main"})
+}
+
+func TestSyncWait(t *testing.T) {
+	gitrepo := newGitRepo("team-a", "app", "")
+	gitrepo.Status.Commit = syncCommit
+	gitrepo.Status.GitJobStatus = "Current"
+
+	bd := func(cluster, commit string, ready bool) *v1alpha1.BundleDeployment {
+		return &v1alpha1.BundleDeployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "cluster-" + cluster,
+				Name:      "app-bundle",
+				Labels: map[string]string{
+					v1alpha1.RepoLabel:             "app",
+					v1alpha1.BundleNamespaceLabel:  "team-a",
+					v1alpha1.BundleLabel:           "app-bundle",
+					v1alpha1.CommitLabel:           commit,
+					v1alpha1.ClusterNamespaceLabel: "team-a",
+					v1alpha1.ClusterLabel:          cluster,
+				},
+			},
+			Spec: v1alpha1.BundleDeploymentSpec{DeploymentID: "s-1", StagedDeploymentID: "s-1"},
+			Status: v1alpha1.BundleDeploymentStatus{
+				AppliedDeploymentID: "s-1",
+				Ready:               ready,
+				NonModified:         true,
+			},
+		}
+	}
+
+	c := newSyncClient(gitrepo, bd("one", syncCommit, true), bd("two", syncCommit, true))
+	w, resp := doSync(t, c, "team-a", SyncRequest{Namespace: "team-a", Name: "app", Commit: syncCommit, Wait: true, Timeout: "5s"})
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.Assert(t, resp.Ready)
+	assert.Assert(t, !resp.TimedOut)
+	assert.DeepEqual(t, resp.Clusters, []ClusterResult{
+		{Namespace: "team-a", Cluster: "one", Bundle: "app-bundle", State: string(v1alpha1.Ready)},
+		{Namespace: "team-a", Cluster: "two", Bundle: "app-bundle", State: string(v1alpha1.Ready)},
+	})
+
+	// A bundle deployment of an older commit is pending until the timeout.
+	c = newSyncClient(gitrepo, bd("one", syncCommit, true), bd("two", "old", true))
+	w, resp = doSync(t, c, "team-a", SyncRequest{Namespace: "team-a", Name: "app", Commit: syncCommit, Wait: true, Timeout: "1s"})
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.Assert(t, !resp.Ready)
+	assert.Assert(t, resp.TimedOut)
+	assert.Equal(t, resp.Clusters[1].State, string(v1alpha1.Pending))
+}
+
+func TestSyncStatusCommitNotHead(t *testing.T) {
+	gitrepo := newGitRepo("team-a", "app", "")
+	gitrepo.Status.Commit = "old"
+	gitrepo.Status.WebhookCommit = syncCommit
+	gitrepo.Status.GitJobStatus = "Current"
+	target := syncTarget{obj: gitrepo, commit: syncCommit, previousCommit: "old"}
+
+	for _, tc := range []struct {
+		name          string
+		commit        string
+		webhookCommit string
+		done          bool
+	}{
+		{name: "waiting for the controller", commit: "old", webhookCommit: syncCommit},
+		{name: "moved to another commit", commit: "new", webhookCommit: syncCommit, done: true},
+		{name: "webhook commit realigned", commit: "old", webhookCommit: "old", done: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			gr := gitrepo.DeepCopy()
+			gr.Status.Commit = tc.commit
+			gr.Status.WebhookCommit = tc.webhookCommit
+			s := NewSync("cattle-fleet-system", newSyncClient(gr))
+
+			var resp SyncResponse
+			done, failed, err := s.status(context.Background(), target, &resp)
+			assert.NilError(t, err)
+			assert.Equal(t, done, tc.done)
+			assert.Equal(t, failed, tc.done)
+			if tc.done {
+				assert.Assert(t, resp.Message != "")
+			}
+		})
+	}
+}
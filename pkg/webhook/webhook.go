@@ -114,10 +114,7 @@ func (w *Webhook) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
 		if u.EscapedPath() == "" {
 			continue
 		}
-		path := strings.Replace(regexp.QuoteMeta(u.EscapedPath()[1:]), `/_git/`, `(/_git)?/`, 1)
-		regexpStr := `(?i)(http://|https://|\w+@|ssh://(\w+@)?|git@(ssh\.)?)` + regexp.QuoteMeta(u.Hostname()) +
-			"(:[0-9]+|)[:/](v\\d/)?" + path + "(\\.git)?$"
-		repoRegexp, err := regexp.Compile(regexpStr)
+		repoRegexp, err := repoURLRegexp(u)
 		if err != nil {
 			w.logAndReturn(rw, err)
 			return
@@ -190,8 +187,12 @@ func (w *Webhook) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
 					return
 				}
 				orig := gitRepoFromCluster.DeepCopy()
+				now := metav1.Now()
+				if revision != orig.Status.WebhookCommit && revision != orig.Status.Commit {
+					gitRepoFromCluster.Status.CommitDetectedTime = now
+				}
 				gitRepoFromCluster.Status.WebhookCommit = revision
-				gitRepoFromCluster.Status.LastWebhookTime = metav1.Now()
+				gitRepoFromCluster.Status.LastWebhookTime = now
 				if err := w.client.Status().Patch(ctx, &gitRepoFromCluster, client.MergeFrom(orig)); err != nil {
 					w.logAndReturn(rw, err)
 					return
@@ -224,10 +225,20 @@ func HandleHooks(ctx context.Context, namespace string, client client.Client, cl
 	}
 	root := http.NewServeMux()
 	root.Handle("/", webhook)
+	root.Handle(SyncPath, NewSync(namespace, client))
 
 	return root, nil
 }
 
+// repoURLRegexp returns a regular expression matching the different URLs of
+// the repository u, e.g. its HTTPS and SSH URLs.
+func repoURLRegexp(u *url.URL) (*regexp.Regexp, error) {
+	path := strings.Replace(regexp.QuoteMeta(u.EscapedPath()[1:]), `/_git/`, `(/_git)?/`, 1)
+	regexpStr := `(?i)(http://|https://|\w+@|ssh://(\w+@)?|git@(ssh\.)?)` + regexp.QuoteMeta(u.Hostname()) +
+		"(:[0-9]+|)[:/](v\\d/)?" + path + "(\\.git)?$"
+	return regexp.Compile(regexpStr)
+}
+
 func (w *Webhook) logAndReturn(rw http.ResponseWriter, err error) {
 	w.log.Error(err, "Webhook processing failed")
 	http.Error(rw, "Webhook processing failed", getErrorCodeFromErr(err))